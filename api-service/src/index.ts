@@ -17,10 +17,13 @@ const fastify = Fastify({
   bodyLimit: 1024 * 1024 * 1024, // 1GB，支援大檔案串流上傳
 });
 
+/** 上傳大小上限：INPUT_MAX_BYTES（與 Gateway / Worker 共用），未設定時 1GB；超過時串流中止並返回 413 */
+const maxUploadBytes = Number(process.env.INPUT_MAX_BYTES) > 0 ? Number(process.env.INPUT_MAX_BYTES) : 1024 * 1024 * 1024;
+
 fastify.register(cors);
 fastify.register(multipart, {
   limits: {
-    fileSize: 1024 * 1024 * 1024 // 1GB
+    fileSize: maxUploadBytes
   }
 });
 
@@ -0,0 +1,15 @@
+/**
+ * Redis key、Pub/Sub channel 與佇列名稱，套用環境前綴（ENV_PREFIX）。
+ * 多個環境共用同一組 Redis 時以前綴隔離；命名需與 Worker / Gateway 的 keys 套件一致。
+ */
+const env = (process.env.ENV_PREFIX || '').replace(/:$/, '');
+const prefix = env ? `${env}:` : '';
+
+export const keys = {
+  task: (taskId: string) => `${prefix}task:${taskId}`,
+  taskOwner: (taskId: string) => `${prefix}task:owner:${taskId}`,
+  cancelChannel: () => `${prefix}cancel_channel`,
+  sttQueue: () => `${prefix}stt:queue`,
+  summaryQueue: () => `${prefix}summary:queue`,
+  highlightQueue: () => `${prefix}highlight:queue`,
+};
@@ -1,9 +1,31 @@
-import { Redis } from 'ioredis';
+import fs from 'fs';
+import { Redis, RedisOptions } from 'ioredis';
 
-/** Redis 單例連線，透過 docker bridge network 連接 */
-const redis = new Redis({
-  host: process.env.REDIS_HOST || 'localhost',
-  port: parseInt(process.env.REDIS_PORT || '6379'),
-});
+/**
+ * TLS 設定，環境變數與 Worker / Gateway 相同：
+ * rediss:// 或 REDIS_TLS=true 啟用，REDIS_TLS_CA_FILE / REDIS_TLS_CERT_FILE / REDIS_TLS_KEY_FILE 指定自訂 CA 與 client 憑證。
+ */
+function tlsOptions(): RedisOptions['tls'] {
+  const url = process.env.REDIS_URL;
+  if (!url?.startsWith('rediss://') && process.env.REDIS_TLS !== 'true') return undefined;
+  const { REDIS_TLS_CA_FILE: ca, REDIS_TLS_CERT_FILE: cert, REDIS_TLS_KEY_FILE: key } = process.env;
+  return {
+    minVersion: 'TLSv1.2',
+    ...(ca && { ca: fs.readFileSync(ca) }),
+    ...(cert && { cert: fs.readFileSync(cert) }),
+    ...(key && { key: fs.readFileSync(key) }),
+  };
+}
+
+const options: RedisOptions = { tls: tlsOptions() };
+
+/** Redis 單例連線，預設透過 docker bridge network 連接；REDIS_URL（可含帳密與 DB 編號）優先 */
+const redis = process.env.REDIS_URL
+  ? new Redis(process.env.REDIS_URL, options)
+  : new Redis({
+      host: process.env.REDIS_HOST || 'localhost',
+      port: parseInt(process.env.REDIS_PORT || '6379'),
+      ...options,
+    });
 
 export default redis;
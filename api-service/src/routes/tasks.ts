@@ -1,8 +1,14 @@
 // routes/tasks.ts — 任務路由薄層，解析 HTTP 邊界後委派至 service 層
+import fs from 'fs';
 import { FastifyInstance, FastifyPluginOptions, FastifyRequest, FastifyReply } from 'fastify';
 import * as taskService from '../services/task-service.js';
 import * as sttService from '../services/stt-service.js';
 import * as summaryService from '../services/summary-service.js';
+import * as noteService from '../services/note-service.js';
+import * as highlightService from '../services/highlight-service.js';
+import * as benchmarkService from '../services/benchmark-service.js';
+import * as glossaryService from '../services/glossary-service.js';
+import { AUDIO_CLEANUP_PRESETS, ChunkingOptions, GenerationParams, UploadOptions } from '../types/index.js';
 
 /**
  * 任務路由插件。
@@ -30,9 +36,16 @@ export default async function taskRoutes(fastify: FastifyInstance, options: Fast
   /**
    * PUT /tasks/:id/upload — 串流上傳音檔。
    * MIME 驗證後存檔，推送 STT 任務至 Redis queue。
+   * ?mode=benchmark&providers=a,b 建立 benchmark 任務；multipart 欄位 reference（需在檔案之前）為計算 WER 的人工逐字稿。
+   * ?notBefore=<ISO 8601> 排程於該時間之後才轉錄。
+   * ?chunkSec=&overlapSec=&noSplitBytes= 覆寫此任務的切片參數。
+   * ?loudnorm=true|false 切片前是否做響度正規化；?cleanup=off|highpass|denoise|phone 選擇音訊清理預設。
+   * ?stereoSplit=true|false 雙聲道通話錄音分聲道轉錄並標記講者。
+   * ?language=<ISO-639-1|auto|multi> 語言提示；?prompt= 初始 STT 提示（人名、專有名詞的寫法）。
+   * ?glossary=a,b,c 此任務的詞彙表，取代用戶的預設詞彙表（PUT /glossary）。
    */
   fastify.put('/tasks/:id/upload', async (
-    request: FastifyRequest<{ Params: { id: string } }>,
+    request: FastifyRequest<{ Params: { id: string }; Querystring: UploadQuery }>,
     reply: FastifyReply
   ) => {
     const { id: taskId } = request.params;
@@ -41,16 +54,51 @@ export default async function taskRoutes(fastify: FastifyInstance, options: Fast
     const data = await request.file();
     if (!data) return reply.code(400).send({ error: 'No file uploaded' });
 
+    const options = parseUploadQuery(request.query);
+    if (typeof options === 'string') return reply.code(400).send({ error: options });
+    if (options.mode === 'benchmark') options.reference = (data.fields.reference as any)?.value;
+
     try {
-      await sttService.handleUpload(taskId, userId, data);
+      await sttService.handleUpload(taskId, userId, data, options);
       return { status: 'upload_complete', taskId };
     } catch (err: any) {
       fastify.log.error(err);
       if (err.statusCode === 400) return reply.code(400).send({ error: err.message });
+      if (err.statusCode === 413) {
+        return reply.code(413).send({ error: 'ERR_FILE_TOO_LARGE: The file is larger than the upload size limit.' });
+      }
       return reply.code(500).send({ error: 'Upload streaming failed' });
     }
   });
 
+  /**
+   * POST /tasks/:id/source — 以遠端 URL（podcast、雲端硬碟分享連結等）取代上傳，body 為 { url, reference? }。
+   * Worker 負責下載（大小上限、Content-Type 檢查、斷線續傳），之後與上傳的音檔相同；query 參數同上傳。
+   */
+  fastify.post('/tasks/:id/source', async (
+    request: FastifyRequest<{ Params: { id: string }; Querystring: UploadQuery; Body: { url?: string; reference?: string } }>,
+    reply: FastifyReply
+  ) => {
+    const { id: taskId } = request.params;
+    const userId = (request as any).userId;
+
+    const sourceUrl = parseSourceUrl(request.body?.url);
+    if (!sourceUrl) return reply.code(400).send({ error: 'url must be an absolute http(s) URL' });
+    const options = parseUploadQuery(request.query);
+    if (typeof options === 'string') return reply.code(400).send({ error: options });
+    if (options.mode === 'benchmark') options.reference = request.body?.reference;
+
+    try {
+      await sttService.handleSource(taskId, userId, sourceUrl, options);
+      return { status: 'source_queued', taskId };
+    } catch (err: any) {
+      fastify.log.error(err);
+      if (err.statusCode === 404) return reply.code(404).send({ error: 'Task not found' });
+      if (err.statusCode === 409) return reply.code(409).send({ error: 'Task already uploaded' });
+      return reply.code(500).send({ error: 'Failed to queue source' });
+    }
+  });
+
   /**
    * GET /tasks/:id — 查詢單一任務詳情。
    * 合併 Redis live 狀態與 DB 持久欄位（transcript / summary / file_path）。
@@ -89,7 +137,11 @@ export default async function taskRoutes(fastify: FastifyInstance, options: Fast
 
   /**
    * POST /tasks/:id/summarize — 使用者手動觸發摘要。
-   * 僅限 stt_completed 狀態，推送 Summary 任務至 Redis queue。
+   * 僅限 stt_completed / completed_no_summary 狀態，推送 Summary 任務至 Redis queue。
+   * body.persona 選用 Worker SUMMARY_PERSONAS 中預先核准的人設。
+   * body.generation 覆寫 LLM 取樣參數（temperature、topP、maxTokens、presencePenalty、frequencyPenalty、stop）。
+   * body.structured 另外產生結構化摘要（title、tl_dr、key_points、action_items、participants）。
+   * body.summaryLanguage 指定摘要語言（例如 zh-TW、en），與轉錄稿語言無關。
    */
   fastify.post('/tasks/:id/summarize', async (
     request: FastifyRequest<{ Params: { id: string } }>,
@@ -97,9 +149,28 @@ export default async function taskRoutes(fastify: FastifyInstance, options: Fast
   ) => {
     const { id: taskId } = request.params;
     const body = (request.body as any) ?? {};
+    let notBefore: string | undefined;
+    if (body.notBefore !== undefined) {
+      notBefore = parseNotBefore(body.notBefore) ?? undefined;
+      if (!notBefore) return reply.code(400).send({ error: 'Invalid notBefore timestamp' });
+    }
+    if (body.persona !== undefined && (typeof body.persona !== 'string' || !/^[\w-]{1,64}$/.test(body.persona))) {
+      return reply.code(400).send({ error: 'Invalid persona name' });
+    }
+    let generation: GenerationParams | undefined;
+    if (body.generation !== undefined) {
+      generation = parseGeneration(body.generation) ?? undefined;
+      if (!generation) return reply.code(400).send({ error: 'Invalid generation parameters' });
+    }
+    if (body.structured !== undefined && typeof body.structured !== 'boolean') {
+      return reply.code(400).send({ error: 'structured must be a boolean' });
+    }
+    if (body.summaryLanguage !== undefined && (typeof body.summaryLanguage !== 'string' || !SUMMARY_LANGUAGE_PATTERN.test(body.summaryLanguage))) {
+      return reply.code(400).send({ error: 'Invalid summaryLanguage' });
+    }
 
     try {
-      await summaryService.triggerSummary(taskId, (request as any).userId, body.prompt);
+      await summaryService.triggerSummary(taskId, (request as any).userId, body.prompt, notBefore, body.persona, generation, body.structured, body.summaryLanguage);
       return { status: 'summary_requested' };
     } catch (err: any) {
       fastify.log.error(err);
@@ -108,4 +179,230 @@ export default async function taskRoutes(fastify: FastifyInstance, options: Fast
       return reply.code(500).send({ error: 'Failed to request summary' });
     }
   });
+
+  /** GET /glossary — 用戶的預設詞彙表（任務未指定 glossary 時套用） */
+  fastify.get('/glossary', async (request: FastifyRequest) => {
+    return { terms: await glossaryService.getGlossary((request as any).userId) };
+  });
+
+  /**
+   * PUT /glossary — 取代用戶的預設詞彙表。
+   * body: { terms: string[] }，產品名稱、術語、人名等，注入 STT prompt 與摘要的 system prompt。
+   */
+  fastify.put('/glossary', async (request: FastifyRequest, reply: FastifyReply) => {
+    try {
+      const terms = await glossaryService.saveGlossary((request as any).userId, (request.body as any)?.terms);
+      return { terms };
+    } catch (err: any) {
+      if (err.statusCode === 400) return reply.code(400).send({ error: err.message });
+      fastify.log.error(err);
+      return reply.code(500).send({ error: 'Failed to save glossary' });
+    }
+  });
+
+  /** GET /tasks/:id/notes — 依時間軸列出任務標註 */
+  fastify.get('/tasks/:id/notes', async (
+    request: FastifyRequest<{ Params: { id: string } }>,
+    reply: FastifyReply
+  ) => {
+    const notes = await noteService.listNotes(request.params.id, (request as any).userId);
+    if (!notes) return reply.code(404).send({ error: 'Task not found' });
+    return notes;
+  });
+
+  /**
+   * POST /tasks/:id/notes — 新增標註。
+   * body: { kind?: 'note' | 'highlight', startSec, endSec?, body? }
+   */
+  fastify.post('/tasks/:id/notes', async (
+    request: FastifyRequest<{ Params: { id: string } }>,
+    reply: FastifyReply
+  ) => {
+    try {
+      const note = await noteService.createNote(request.params.id, (request as any).userId, (request.body as any) ?? {});
+      if (!note) return reply.code(404).send({ error: 'Task not found' });
+      return reply.code(201).send(note);
+    } catch (err: any) {
+      if (err.statusCode === 400) return reply.code(400).send({ error: err.message });
+      fastify.log.error(err);
+      return reply.code(500).send({ error: 'Failed to create note' });
+    }
+  });
+
+  /** DELETE /tasks/:id/notes/:noteId — 刪除標註 */
+  fastify.delete('/tasks/:id/notes/:noteId', async (
+    request: FastifyRequest<{ Params: { id: string; noteId: string } }>,
+    reply: FastifyReply
+  ) => {
+    const deleted = await noteService.deleteNote(request.params.id, request.params.noteId, (request as any).userId);
+    if (!deleted) return reply.code(404).send({ error: 'Note not found' });
+    return reply.code(204).send();
+  });
+
+  /**
+   * POST /tasks/:id/highlights — 以 highlight 標註剪輯 highlight reel。
+   * 非同步處理，完成時 SSE 推送 highlights_ready / highlights_failed。
+   */
+  fastify.post('/tasks/:id/highlights', async (
+    request: FastifyRequest<{ Params: { id: string } }>,
+    reply: FastifyReply
+  ) => {
+    try {
+      await highlightService.requestHighlights(request.params.id, (request as any).userId);
+      return reply.code(202).send({ status: 'highlights_requested' });
+    } catch (err: any) {
+      if (err.statusCode === 404 || err.statusCode === 409) return reply.code(err.statusCode).send({ error: err.message });
+      fastify.log.error(err);
+      return reply.code(500).send({ error: 'Failed to request highlights' });
+    }
+  });
+
+  /** GET /tasks/:id/benchmark — benchmark 任務各 provider 的轉錄結果與 WER */
+  fastify.get('/tasks/:id/benchmark', async (
+    request: FastifyRequest<{ Params: { id: string } }>,
+    reply: FastifyReply
+  ) => {
+    const results = await benchmarkService.getBenchmarkResults(request.params.id, (request as any).userId);
+    if (!results) return reply.code(404).send({ error: 'Task not found' });
+    return results;
+  });
+
+  /** GET /tasks/:id/highlights — 下載 highlight reel（audio/mp4） */
+  fastify.get('/tasks/:id/highlights', async (
+    request: FastifyRequest<{ Params: { id: string } }>,
+    reply: FastifyReply
+  ) => {
+    const filePath = await highlightService.getHighlightsPath(request.params.id, (request as any).userId);
+    if (!filePath) return reply.code(404).send({ error: 'Highlights not found' });
+    return reply
+      .type('audio/mp4')
+      .header('Content-Disposition', `attachment; filename="highlights-${request.params.id}.m4a"`)
+      .send(fs.createReadStream(filePath));
+  });
+}
+
+/** 上傳與遠端來源共用的 query 參數 */
+type UploadQuery = {
+  mode?: string; providers?: string; notBefore?: string;
+  chunkSec?: string; overlapSec?: string; noSplitBytes?: string; loudnorm?: string; cleanup?: string;
+  stereoSplit?: string; language?: string; prompt?: string; glossary?: string;
+};
+
+/** 語言提示：ISO-639-1（可帶地區，如 zh-TW），或 auto / multi 由 provider 逐段偵測 */
+const LANGUAGE_PATTERN = /^(auto|multi|[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})?)$/;
+/** 摘要語言：必須明確指定，不接受 auto / multi */
+const SUMMARY_LANGUAGE_PATTERN = /^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})?$/;
+/** Whisper 的 prompt 約 224 token，過長的部分會被 provider 截掉 */
+const MAX_STT_PROMPT_CHARS = 500;
+
+/** 解析任務選項；格式錯誤時返回錯誤訊息。benchmark 的 reference 由呼叫端自 multipart / body 取得 */
+function parseUploadQuery(query: UploadQuery): UploadOptions | string {
+  const options: UploadOptions = {};
+  if (query.mode === 'benchmark') {
+    options.mode = 'benchmark';
+    options.benchmarkProviders = query.providers?.split(',').map((p) => p.trim()).filter(Boolean);
+  } else if (query.mode) {
+    return `Unknown mode: ${query.mode}`;
+  }
+  if (query.notBefore !== undefined) {
+    const notBefore = parseNotBefore(query.notBefore);
+    if (!notBefore) return 'Invalid notBefore timestamp';
+    options.notBefore = notBefore;
+  }
+  const chunking = parseChunking(query);
+  if (chunking === null) return 'chunkSec, overlapSec and noSplitBytes must be positive numbers';
+  if (chunking) options.chunking = chunking;
+  if (query.loudnorm !== undefined) {
+    if (query.loudnorm !== 'true' && query.loudnorm !== 'false') return 'loudnorm must be true or false';
+    options.loudnorm = query.loudnorm === 'true';
+  }
+  if (query.stereoSplit !== undefined) {
+    if (query.stereoSplit !== 'true' && query.stereoSplit !== 'false') return 'stereoSplit must be true or false';
+    options.stereoSplit = query.stereoSplit === 'true';
+  }
+  if (query.language !== undefined) {
+    if (!LANGUAGE_PATTERN.test(query.language)) return 'language must be an ISO-639-1 code (optionally with region), auto or multi';
+    options.language = query.language;
+  }
+  if (query.prompt !== undefined) {
+    const prompt = query.prompt.trim();
+    if (prompt.length > MAX_STT_PROMPT_CHARS) return `prompt must be at most ${MAX_STT_PROMPT_CHARS} characters`;
+    if (prompt) options.prompt = prompt;
+  }
+  if (query.glossary !== undefined) {
+    try {
+      options.glossary = glossaryService.normalizeGlossary(query.glossary.split(','));
+    } catch (err: any) {
+      return err.message;
+    }
+  }
+  if (query.cleanup !== undefined) {
+    const preset = AUDIO_CLEANUP_PRESETS.find((p) => p === query.cleanup);
+    if (!preset) return `cleanup must be one of ${AUDIO_CLEANUP_PRESETS.join(', ')}`;
+    options.cleanup = preset;
+  }
+  return options;
+}
+
+/** 解析遠端來源 URL，僅接受 http(s) 絕對網址；是否為內網位址由 Worker 連線時檢查 */
+function parseSourceUrl(value: unknown): string | null {
+  if (typeof value !== 'string') return null;
+  try {
+    const url = new URL(value);
+    return url.protocol === 'http:' || url.protocol === 'https:' ? url.toString() : null;
+  } catch {
+    return null;
+  }
+}
+
+/** 解析切片覆寫參數；未指定任何參數返回 undefined，格式錯誤返回 null */
+function parseChunking(query: { chunkSec?: string; overlapSec?: string; noSplitBytes?: string }): ChunkingOptions | undefined | null {
+  const fields = { maxChunkSec: query.chunkSec, overlapSec: query.overlapSec, noSplitBytes: query.noSplitBytes };
+  const chunking: ChunkingOptions = {};
+  for (const [key, raw] of Object.entries(fields)) {
+    if (raw === undefined) continue;
+    const value = Number(raw);
+    if (!Number.isFinite(value) || value <= 0) return null;
+    chunking[key as keyof ChunkingOptions] = key === 'noSplitBytes' ? Math.floor(value) : value;
+  }
+  return Object.keys(chunking).length > 0 ? chunking : undefined;
+}
+
+/** 解析排程時間（ISO 8601），無效時回傳 null；統一轉為 UTC ISO 字串供 Worker 解析 */
+function parseNotBefore(value: unknown): string | null {
+  if (typeof value !== 'string') return null;
+  const ms = Date.parse(value);
+  return Number.isNaN(ms) ? null : new Date(ms).toISOString();
+}
+
+/** 取樣參數範圍，與 Worker ai.GenerationParams.Validate 一致 */
+const GENERATION_RANGES: Record<string, [number, number]> = {
+  temperature: [0, 2],
+  topP: [0, 1],
+  presencePenalty: [-2, 2],
+  frequencyPenalty: [-2, 2],
+};
+const MAX_STOP_SEQUENCES = 4;
+
+/** 解析摘要的 LLM 取樣參數覆寫；未知欄位或超出範圍返回 null */
+function parseGeneration(value: unknown): GenerationParams | null {
+  if (typeof value !== 'object' || value === null || Array.isArray(value)) return null;
+  const params: GenerationParams = {};
+  for (const [key, raw] of Object.entries(value)) {
+    if (key in GENERATION_RANGES) {
+      const [lo, hi] = GENERATION_RANGES[key];
+      if (typeof raw !== 'number' || !(raw >= lo && raw <= hi)) return null;
+      params[key as 'temperature' | 'topP' | 'presencePenalty' | 'frequencyPenalty'] = raw;
+    } else if (key === 'maxTokens') {
+      if (!Number.isInteger(raw) || (raw as number) <= 0) return null;
+      params.maxTokens = raw as number;
+    } else if (key === 'stop') {
+      if (!Array.isArray(raw) || raw.length > MAX_STOP_SEQUENCES
+        || raw.some((s) => typeof s !== 'string' || s === '')) return null;
+      params.stop = raw as string[];
+    } else {
+      return null;
+    }
+  }
+  return params;
 }
@@ -0,0 +1,19 @@
+import { db } from '../lib/db.js';
+
+/**
+ * 取得 benchmark 任務各 provider 的結果，依 WER（未提供 reference 時為 null）與耗時排序。
+ * 任務不存在或不屬於該用戶時回傳 null。
+ */
+export async function getBenchmarkResults(taskId: string, userId: string): Promise<unknown[] | null> {
+  const task = await db.query('SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2', [taskId, userId]);
+  if (task.rows.length === 0) return null;
+
+  const res = await db.query(
+    `SELECT provider, model, transcript, wer, duration_ms AS "durationMs", error_message AS "errorMessage"
+     FROM benchmark_results
+     WHERE task_id = $1
+     ORDER BY error_message IS NOT NULL, wer NULLS LAST, duration_ms`,
+    [taskId]
+  );
+  return res.rows;
+}
@@ -0,0 +1,46 @@
+import { db } from '../lib/db.js';
+
+/** 詞彙表上限：Whisper 的 prompt 約 224 token，過多的詞會被 provider 截掉 */
+export const MAX_GLOSSARY_TERMS = 100;
+const MAX_TERM_LENGTH = 100;
+
+function badRequest(message: string): Error {
+  const err = new Error(message);
+  (err as any).statusCode = 400;
+  return err;
+}
+
+/** 驗證並正規化詞彙：去除前後空白與空字串，忽略大小寫去重，保留第一次出現的寫法與順序 */
+export function normalizeGlossary(terms: unknown): string[] {
+  if (!Array.isArray(terms) || terms.some((t) => typeof t !== 'string')) {
+    throw badRequest('terms must be an array of strings');
+  }
+  const seen = new Set<string>();
+  const result: string[] = [];
+  for (const raw of terms as string[]) {
+    const term = raw.trim();
+    if (!term || seen.has(term.toLowerCase())) continue;
+    if (term.length > MAX_TERM_LENGTH) throw badRequest(`each term must be at most ${MAX_TERM_LENGTH} characters`);
+    seen.add(term.toLowerCase());
+    result.push(term);
+  }
+  if (result.length > MAX_GLOSSARY_TERMS) throw badRequest(`glossary must have at most ${MAX_GLOSSARY_TERMS} terms`);
+  return result;
+}
+
+/** 取得用戶的預設詞彙表，未設定時為空陣列 */
+export async function getGlossary(userId: string): Promise<string[]> {
+  const res = await db.query('SELECT terms FROM user_glossaries WHERE user_id = $1', [userId]);
+  return res.rows[0]?.terms ?? [];
+}
+
+/** 取代用戶的預設詞彙表；輸入不合法時拋出 400 */
+export async function saveGlossary(userId: string, terms: unknown): Promise<string[]> {
+  const glossary = normalizeGlossary(terms);
+  await db.query(
+    `INSERT INTO user_glossaries (user_id, terms, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+     ON CONFLICT (user_id) DO UPDATE SET terms = EXCLUDED.terms, updated_at = EXCLUDED.updated_at`,
+    [userId, JSON.stringify(glossary)]
+  );
+  return glossary;
+}
@@ -0,0 +1,37 @@
+import fs from 'fs';
+import { db } from '../lib/db.js';
+import { pushHighlightTask } from '../lib/redis-queue.js';
+import { listNotes } from './note-service.js';
+
+function httpError(statusCode: number, message: string): Error {
+  const err = new Error(message);
+  (err as any).statusCode = statusCode;
+  return err;
+}
+
+/**
+ * 觸發 highlight reel 剪輯：以任務的 highlight 標註為片段，推送至 highlight:queue。
+ * 任務不存在回傳 404；沒有 highlight 標註或原始音檔已刪除（未啟用 KEEP_SOURCE_AUDIO）回傳 409。
+ */
+export async function requestHighlights(taskId: string, userId: string): Promise<void> {
+  const res = await db.query('SELECT file_path FROM tasks WHERE id = $1 AND user_id = $2', [taskId, userId]);
+  if (res.rows.length === 0) throw httpError(404, 'Task not found');
+
+  const notes = (await listNotes(taskId, userId)) ?? [];
+  const clips = notes
+    .filter((n) => n.kind === 'highlight' && n.endSec !== undefined)
+    .map((n) => ({ startSec: n.startSec, endSec: n.endSec as number }));
+  if (clips.length === 0) throw httpError(409, 'Task has no highlights');
+
+  const filePath: string | null = res.rows[0].file_path;
+  if (!filePath || !fs.existsSync(filePath)) throw httpError(409, 'Source audio is no longer available');
+
+  await pushHighlightTask({ taskId, userId, filePath, clips });
+}
+
+/** 取得已完成的 highlight reel 路徑，尚未產生時回傳 null */
+export async function getHighlightsPath(taskId: string, userId: string): Promise<string | null> {
+  const res = await db.query('SELECT highlights_path FROM tasks WHERE id = $1 AND user_id = $2', [taskId, userId]);
+  const p: string | null = res.rows[0]?.highlights_path ?? null;
+  return p && fs.existsSync(p) ? p : null;
+}
@@ -0,0 +1,83 @@
+import { db } from '../lib/db.js';
+import { NoteKind, TaskNote } from '../types/index.js';
+
+const NOTE_KINDS: NoteKind[] = ['note', 'highlight'];
+const MAX_BODY_LENGTH = 2000;
+
+/** 建立標註的輸入，startSec / endSec 為音檔時間軸（秒） */
+export interface NoteInput {
+  kind?: NoteKind;
+  startSec: number;
+  endSec?: number;
+  body?: string;
+}
+
+function badRequest(message: string): Error {
+  const err = new Error(message);
+  (err as any).statusCode = 400;
+  return err;
+}
+
+/** 驗證輸入；highlight 必須帶 endSec 標出片段範圍 */
+function validate(input: NoteInput): void {
+  const kind = input.kind ?? 'note';
+  if (!NOTE_KINDS.includes(kind)) throw badRequest(`kind must be one of ${NOTE_KINDS.join(', ')}`);
+  if (typeof input.startSec !== 'number' || !Number.isFinite(input.startSec) || input.startSec < 0) {
+    throw badRequest('startSec must be a non-negative number');
+  }
+  if (input.endSec !== undefined && (typeof input.endSec !== 'number' || input.endSec < input.startSec)) {
+    throw badRequest('endSec must be a number not before startSec');
+  }
+  if (kind === 'highlight' && input.endSec === undefined) throw badRequest('highlight requires endSec');
+  if ((input.body ?? '').length > MAX_BODY_LENGTH) throw badRequest(`body exceeds ${MAX_BODY_LENGTH} characters`);
+}
+
+/** 確認任務屬於該用戶 */
+async function ownsTask(taskId: string, userId: string): Promise<boolean> {
+  const res = await db.query('SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2', [taskId, userId]);
+  return res.rows.length > 0;
+}
+
+/** 依時間軸列出任務的標註，任務不存在或不屬於該用戶時回傳 null */
+export async function listNotes(taskId: string, userId: string): Promise<TaskNote[] | null> {
+  if (!(await ownsTask(taskId, userId))) return null;
+  const res = await db.query(
+    `SELECT id, kind, start_sec, end_sec, body, created_at
+     FROM task_notes WHERE task_id = $1 ORDER BY start_sec, created_at`,
+    [taskId]
+  );
+  return res.rows.map(toNote);
+}
+
+/** 新增標註，任務不存在或不屬於該用戶時回傳 null；輸入不合法時拋出 400 */
+export async function createNote(taskId: string, userId: string, input: NoteInput): Promise<TaskNote | null> {
+  validate(input);
+  if (!(await ownsTask(taskId, userId))) return null;
+  const res = await db.query(
+    `INSERT INTO task_notes (task_id, user_id, kind, start_sec, end_sec, body)
+     VALUES ($1, $2, $3, $4, $5, $6)
+     RETURNING id, kind, start_sec, end_sec, body, created_at`,
+    [taskId, userId, input.kind ?? 'note', input.startSec, input.endSec ?? null, input.body ?? '']
+  );
+  return toNote(res.rows[0]);
+}
+
+/** 刪除標註，回傳 false 代表不存在或不屬於該用戶 */
+export async function deleteNote(taskId: string, noteId: string, userId: string): Promise<boolean> {
+  const res = await db.query(
+    'DELETE FROM task_notes WHERE id = $1 AND task_id = $2 AND user_id = $3',
+    [noteId, taskId, userId]
+  );
+  return (res.rowCount ?? 0) > 0;
+}
+
+function toNote(row: any): TaskNote {
+  return {
+    id: row.id,
+    kind: row.kind,
+    startSec: row.start_sec,
+    endSec: row.end_sec ?? undefined,
+    body: row.body,
+    createdAt: row.created_at,
+  };
+}
@@ -1,3 +1,4 @@
+import crypto from 'crypto';
 import fs from 'fs';
 import path from 'path';
 import { pipeline } from 'stream/promises';
@@ -5,19 +6,23 @@ import { Readable } from 'stream';
 import { fileTypeFromBuffer } from 'file-type';
 import { db } from '../lib/db.js';
 import redis from '../lib/redis.js';
+import { keys } from '../lib/keys.js';
 import { pushSTTTask } from '../lib/redis-queue.js';
-import { STTPayload, TaskStatus } from '../types/index.js';
+import { STTPayload, TaskStatus, UploadOptions } from '../types/index.js';
 
 const UPLOAD_BASE = '/app/uploads';
 
+/** 正式環境也接受的會議錄影容器，其餘 video/* 僅限開發環境 */
+const MEETING_VIDEO_MIMES = new Set(['video/mp4', 'video/x-matroska', 'video/webm', 'video/quicktime']);
+
 /**
- * 音檔上傳處理：MIME 驗證 → 寫檔 → DB UPDATE file_path → Redis HSET stt_queued → LPUSH stt:queue。
+ * 音檔上傳處理：MIME 驗證 → 寫檔（同時計算 SHA-256）→ DB UPDATE file_path / upload_sha256 → Redis HSET stt_queued → LPUSH stt:queue。
  * 失敗時自動清理已寫入的檔案並更新 DB status=failed，再 rethrow。
  */
 export async function handleUpload(taskId: string, userId: string, fileData: {
   filename: string;
   file: any;
-}): Promise<void> {
+}, options: UploadOptions = {}): Promise<void> {
   const userDir = path.join(UPLOAD_BASE, userId);
   const taskDir = path.join(userDir, taskId);
 
@@ -36,48 +41,48 @@ export async function handleUpload(taskId: string, userId: string, fileData: {
       const type = await fileTypeFromBuffer(buffer);
       const isDev = process.env.APP_ENV === 'dev';
       const isAudio = type?.mime.startsWith('audio/');
+      // 會議錄影：Worker 切片前會抽出音軌
+      const isMeetingVideo = type !== undefined && MEETING_VIDEO_MIMES.has(type.mime);
       const isVideo = type?.mime.startsWith('video/');
-      const isValid = isDev ? (isAudio || isVideo) : isAudio;
+      const isValid = isAudio || isMeetingVideo || (isDev && isVideo);
 
       if (!type || !isValid) {
         const err = new Error(
-          `Invalid file type: ${type?.mime ?? 'unknown'}. ${isDev ? 'Audio and Video' : 'Only audio'} files are allowed.`
+          `Invalid file type: ${type?.mime ?? 'unknown'}. Only audio and mp4 / mkv / webm / mov video files are allowed.`
         );
         (err as any).statusCode = 400;
         throw err;
       }
     }
 
-    // 把剛才讀出的 buffer 補回 stream，確保檔案完整性
+    // 把剛才讀出的 buffer 補回 stream，確保檔案完整性；寫檔同時計算 SHA-256，Worker 切片前比對
+    const hash = crypto.createHash('sha256');
     const combinedStream = Readable.from((async function* () {
-      if (buffer) yield buffer;
-      for await (const chunk of fileData.file) yield chunk;
+      if (buffer) {
+        hash.update(buffer);
+        yield buffer;
+      }
+      for await (const chunk of fileData.file) {
+        hash.update(chunk);
+        yield chunk;
+      }
     })());
 
     await pipeline(combinedStream, fs.createWriteStream(filePath));
+    const checksum = hash.digest('hex');
 
     await db.query(
-      'UPDATE tasks SET file_path = $1 WHERE id = $2 AND user_id = $3',
-      [filePath, taskId, userId]
+      'UPDATE tasks SET file_path = $1, upload_sha256 = $2 WHERE id = $3 AND user_id = $4',
+      [filePath, checksum, taskId, userId]
     );
 
-    const payload: STTPayload = {
-      taskId,
-      userId,
-      filePath,
-      config: {
-        language: process.env.STT_LANGUAGE ?? 'zh-TW',
-        sttModel: process.env.AI_STT_MODEL ?? '',
-      },
-    };
-
-    await redis.hset(`task:${taskId}`, { status: TaskStatus.SttQueued, filePath });
-    await pushSTTTask(payload);
+    await enqueueSTT(taskId, userId, filePath, options, { checksum });
   } catch (err) {
     // 清理殘留檔案
     if (fs.existsSync(filePath)) fs.unlinkSync(filePath);
-    // 若非 MIME 錯誤（400），更新 DB status
-    if ((err as any).statusCode !== 400) {
+    // 若非 MIME 錯誤（400）或超過大小上限（413，串流已中止），更新 DB status
+    const status = (err as any).statusCode;
+    if (status !== 400 && status !== 413) {
       await db.query(
         'UPDATE tasks SET status = $1, error_message = $2 WHERE id = $3',
         [TaskStatus.Failed, 'Upload failed', taskId]
@@ -86,3 +91,62 @@ export async function handleUpload(taskId: string, userId: string, fileData: {
     throw err;
   }
 }
+
+/**
+ * 以遠端 URL 建立 STT 任務：不下載，只記錄 source_url / file_path 並推送帶 sourceUrl 的 payload，
+ * 由 Worker 下載（大小上限、Content-Type 檢查、斷線續傳）後照常處理。任務不存在拋 404、已上傳拋 409。
+ */
+export async function handleSource(taskId: string, userId: string, sourceUrl: string, options: UploadOptions = {}): Promise<void> {
+  const filePath = path.join(UPLOAD_BASE, userId, taskId, sourceFilename(sourceUrl));
+  const { rowCount } = await db.query(
+    "UPDATE tasks SET file_path = $1, source_url = $2 WHERE id = $3 AND user_id = $4 AND status = 'pending' AND file_path IS NULL",
+    [filePath, sourceUrl, taskId, userId]
+  );
+  if (rowCount === 0) {
+    const { rows } = await db.query('SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2', [taskId, userId]);
+    const err = new Error(rows.length === 0 ? 'Task not found' : 'Task already uploaded');
+    (err as any).statusCode = rows.length === 0 ? 404 : 409;
+    throw err;
+  }
+  await enqueueSTT(taskId, userId, filePath, options, { sourceUrl });
+}
+
+/** 由 URL 路徑取檔名（保留副檔名供 ffprobe 與輸入政策判斷），無法取得時為 source */
+function sourceFilename(sourceUrl: string): string {
+  const base = path.posix.basename(new URL(sourceUrl).pathname).replace(/[^\w.-]/g, '_');
+  return base && base !== '.' && base !== '..' ? base : 'source';
+}
+
+/** 組成 STT payload → Redis HSET stt_queued → LPUSH stt:queue */
+async function enqueueSTT(
+  taskId: string,
+  userId: string,
+  filePath: string,
+  options: UploadOptions,
+  source: { checksum?: string; sourceUrl?: string }
+): Promise<void> {
+  const payload: STTPayload = {
+    taskId,
+    userId,
+    filePath,
+    ...source,
+    config: {
+      language: options.language ?? process.env.STT_LANGUAGE ?? 'zh-TW',
+      languageExplicit: options.language ? true : undefined,
+      sttModel: process.env.AI_STT_MODEL ?? '',
+      prompt: options.prompt,
+      glossary: options.glossary,
+      benchmarkProviders: options.benchmarkProviders,
+      reference: options.reference,
+      chunking: options.chunking,
+      loudnorm: options.loudnorm,
+      cleanup: options.cleanup,
+      stereoSplit: options.stereoSplit,
+    },
+    mode: options.mode,
+    notBefore: options.notBefore,
+  };
+
+  await redis.hset(keys.task(taskId), { status: TaskStatus.SttQueued, filePath });
+  await pushSTTTask(payload);
+}
@@ -1,18 +1,26 @@
 import { db } from '../lib/db.js';
 import redis from '../lib/redis.js';
+import { keys } from '../lib/keys.js';
 import { pushSummaryTask } from '../lib/redis-queue.js';
-import { SummaryPayload, TaskStatus } from '../types/index.js';
+import { listNotes } from './note-service.js';
+import { GenerationParams, SummaryPayload, TaskStatus } from '../types/index.js';
 
 /**
- * 觸發摘要：驗證任務處於 stt_completed → 取 DB transcript → Redis HSET summary_queued → LPUSH summary:queue。
- * 非 stt_completed 狀態回傳 409，任務不存在回傳 404。
+ * 觸發摘要：驗證任務處於 stt_completed（或降級的 completed_no_summary）→ 取 DB transcript
+ * → Redis HSET summary_queued → LPUSH summary:queue。notBefore 指定時 Worker 延後至該時間才處理。
+ * persona 為人設名稱，是否在核准清單內由 Worker 判斷；generation 覆寫 Worker 的 LLM 取樣參數；
+ * structured 要求另外產生結構化摘要（task_results.structured_summary）；summaryLanguage 指定摘要語言。
+ * 其他狀態回傳 409，任務不存在回傳 404。
  */
-export async function triggerSummary(taskId: string, userId: string, prompt?: string): Promise<void> {
+export async function triggerSummary(
+  taskId: string, userId: string, prompt?: string, notBefore?: string, persona?: string,
+  generation?: GenerationParams, structured?: boolean, summaryLanguage?: string,
+): Promise<void> {
   // 以 Redis live 狀態為主（response 時間短），fallback DB
-  const liveStatus = await redis.hget(`task:${taskId}`, 'status');
+  const liveStatus = await redis.hget(keys.task(taskId), 'status');
   const effectiveStatus = liveStatus || await fetchDbStatus(taskId, userId);
 
-  if (effectiveStatus !== TaskStatus.SttCompleted) {
+  if (effectiveStatus !== TaskStatus.SttCompleted && effectiveStatus !== TaskStatus.CompletedNoSummary) {
     const err = new Error('Task is not in stt_completed state');
     (err as any).statusCode = 409;
     throw err;
@@ -34,10 +42,12 @@ export async function triggerSummary(taskId: string, userId: string, prompt?: st
     taskId,
     userId,
     transcript: res.rows[0].transcript,
-    config: { summaryPrompt: prompt ?? '' },
+    config: { summaryPrompt: prompt ?? '', persona, generation, structured, summaryLanguage },
+    notes: (await listNotes(taskId, userId)) ?? [],
+    notBefore,
   };
 
-  await redis.hset(`task:${taskId}`, 'status', TaskStatus.SummaryQueued);
+  await redis.hset(keys.task(taskId), 'status', TaskStatus.SummaryQueued);
   await pushSummaryTask(payload);
 }
 
@@ -1,6 +1,8 @@
 import { v4 as uuidv4 } from 'uuid';
 import { db } from '../lib/db.js';
 import redis from '../lib/redis.js';
+import { keys } from '../lib/keys.js';
+import { listNotes } from './note-service.js';
 
 /** 建立任務：DB INSERT + Redis HSET task owner */
 export async function createTask(userId: string): Promise<string> {
@@ -9,8 +11,8 @@ export async function createTask(userId: string): Promise<string> {
     'INSERT INTO tasks (id, user_id, status) VALUES ($1, $2, $3)',
     [taskId, userId, 'pending']
   );
-  await redis.set(`task:owner:${taskId}`, userId);
-  await redis.hset(`task:${taskId}`, { status: 'pending', userId });
+  await redis.set(keys.taskOwner(taskId), userId);
+  await redis.hset(keys.task(taskId), { status: 'pending', userId });
   return taskId;
 }
 
@@ -19,10 +21,10 @@ export async function createTask(userId: string): Promise<string> {
  * 任務不存在或不屬於該用戶時回傳 null。
  */
 export async function getTask(taskId: string, userId: string): Promise<Record<string, unknown> | null> {
-  const liveData = await redis.hgetall(`task:${taskId}`);
+  const liveData = await redis.hgetall(keys.task(taskId));
 
   const res = await db.query(
-    `SELECT t.*, r.transcript, r.summary
+    `SELECT t.*, r.transcript, r.summary, r.segments, r.timings, r.structured_summary, r.waveform, r.review_reasons, r.partial, r.source_sha256
      FROM tasks t
      LEFT JOIN task_results r ON t.id = r.task_id
      WHERE t.id = $1 AND t.user_id = $2`,
@@ -33,13 +35,14 @@ export async function getTask(taskId: string, userId: string): Promise<Record<st
   const row = res.rows[0];
   const status = liveData?.status || row.status;
   const progress = liveData?.progress ? parseInt(liveData.progress, 10) : (row.progress ?? 0);
-  return { ...row, status, progress };
+  const notes = await listNotes(taskId, userId);
+  return { ...row, status, progress, notes };
 }
 
 /** 列出用戶所有任務（支援分頁） */
 export async function listTasks(userId: string, limit: number, offset: number): Promise<unknown[]> {
   const res = await db.query(
-    'SELECT id, status, created_at FROM tasks WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3',
+    'SELECT id, title, status, created_at FROM tasks WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3',
     [userId, limit, offset]
   );
   return res.rows;
@@ -51,10 +54,10 @@ export async function listTasks(userId: string, limit: number, offset: number):
  */
 export async function cancelTask(taskId: string, userId: string): Promise<boolean> {
   const result = await db.query(
-    "UPDATE tasks SET status = 'cancelled', updated_at = NOW() WHERE id = $1 AND user_id = $2 AND status NOT IN ('completed', 'failed', 'cancelled')",
+    "UPDATE tasks SET status = 'cancelled', updated_at = NOW() WHERE id = $1 AND user_id = $2 AND status NOT IN ('completed', 'completed_no_summary', 'failed', 'cancelled', 'flagged')",
     [taskId, userId]
   );
   if (result.rowCount === 0) return false;
-  await redis.publish('cancel_channel', JSON.stringify({ taskId }));
+  await redis.publish(keys.cancelChannel(), JSON.stringify({ taskId }));
   return true;
 }
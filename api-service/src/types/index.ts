@@ -7,8 +7,37 @@ export enum TaskStatus {
   SummaryQueued     = 'summary_queued',
   SummaryProcessing = 'summary_processing',
   Completed         = 'completed',
+  /** LLM 重試用盡後的降級終態：transcript 已交付，可重新觸發摘要 */
+  CompletedNoSummary = 'completed_no_summary',
   Failed            = 'failed',
   Cancelled         = 'cancelled',
+  /** 轉錄稿或摘要未通過內容審核，審核類別見 tasks.moderation */
+  Flagged           = 'flagged',
+}
+
+/** 任務層級的階段 deadline 覆寫（秒），僅能縮短 Worker 設定的上限 */
+export interface StageTimeouts {
+  chunkingSec?: number;
+  sttSec?: number;
+  sttChunkSec?: number;
+  summarySec?: number;
+}
+
+/** 摘要的 LLM 取樣參數覆寫（Worker 會再次驗證範圍） */
+export interface GenerationParams {
+  temperature?: number;
+  topP?: number;
+  maxTokens?: number;
+  presencePenalty?: number;
+  frequencyPenalty?: number;
+  stop?: string[];
+}
+
+/** 任務層級的切片參數覆寫，Worker 會限制在部署設定的上限內 */
+export interface ChunkingOptions {
+  maxChunkSec?: number;
+  overlapSec?: number;
+  noSplitBytes?: number;
 }
 
 /** STT 任務訊息，推送至 stt:queue */
@@ -16,12 +45,62 @@ export interface STTPayload {
   taskId: string;
   userId: string;
   filePath: string;
+  /** 遠端音檔 URL：Worker 先下載至 filePath（或暫存目錄）再處理，此時沒有 checksum */
+  sourceUrl?: string;
+  /** 上傳檔案的 SHA-256（hex），Worker 切片前驗證共享 volume 上的檔案未損毀或截斷 */
+  checksum?: string;
   config: {
     language: string;
+    /** language 由使用者指定（而非 STT_LANGUAGE 預設），Worker 以此為確定的轉錄稿語言，不再偵測 */
+    languageExplicit?: boolean;
     sttModel: string;
+    /** 初始 STT 提示（人名、專有名詞的寫法等），送往支援 prompt 的 provider */
+    prompt?: string;
+    /** 此任務的詞彙表，省略時 Worker 套用用戶的預設詞彙表 */
+    glossary?: string[];
+    timeouts?: StageTimeouts;
+    chunking?: ChunkingOptions;
+    /** 切片前做 EBU R128 響度正規化，省略時沿用 Worker 的 LOUDNORM 設定 */
+    loudnorm?: boolean;
+    /** 切片前的音訊清理預設，省略時沿用 Worker 的 AUDIO_CLEANUP 設定 */
+    cleanup?: AudioCleanup;
+    /** 雙聲道通話錄音分聲道轉錄並標記講者，省略時沿用 Worker 的 STT_STEREO_SPLIT 設定 */
+    stereoSplit?: boolean;
+    /** 任務終態時接收 HMAC 簽章通知的 callback URL */
+    webhookUrl?: string;
+    /** benchmark 任務比較的 provider 名稱，省略表示全部 */
+    benchmarkProviders?: string[];
+    /** benchmark 任務計算 WER 用的人工逐字稿 */
+    reference?: string;
   };
+  /** 'benchmark'：以多個 STT provider 轉錄同一音檔並產生比較報告 */
+  mode?: TaskMode;
+  /** 排程任務：ISO 8601 時間，Worker 在此之前不處理 */
+  notBefore?: string;
 }
 
+export type TaskMode = 'benchmark';
+
+/** 上傳時指定的任務選項 */
+export interface UploadOptions {
+  mode?: TaskMode;
+  benchmarkProviders?: string[];
+  reference?: string;
+  notBefore?: string;
+  chunking?: ChunkingOptions;
+  loudnorm?: boolean;
+  cleanup?: AudioCleanup;
+  stereoSplit?: boolean;
+  /** 語言提示（ISO-639-1 / BCP-47，auto 或 multi 逐段偵測），省略時沿用 STT_LANGUAGE */
+  language?: string;
+  prompt?: string;
+  glossary?: string[];
+}
+
+/** 音訊清理預設：highpass 去低頻、denoise 再 FFT 降噪、phone 限制電話頻段並降噪 */
+export const AUDIO_CLEANUP_PRESETS = ['off', 'highpass', 'denoise', 'phone'] as const;
+export type AudioCleanup = typeof AUDIO_CLEANUP_PRESETS[number];
+
 /** Summary 任務訊息，推送至 summary:queue */
 export interface SummaryPayload {
   taskId: string;
@@ -29,7 +108,40 @@ export interface SummaryPayload {
   transcript: string;
   config: {
     summaryPrompt: string;
+    timeouts?: StageTimeouts;
+    /** Worker SUMMARY_PERSONAS 中的人設名稱 */
+    persona?: string;
+    /** 覆寫 Worker AI_LLM_* 的取樣參數，未指定的欄位沿用部署設定 */
+    generation?: GenerationParams;
+    /** 另外產生結構化摘要，省略時沿用 Worker SUMMARY_STRUCTURED */
+    structured?: boolean;
+    /** 摘要語言（BCP 47，例如 zh-TW、en），省略時沿用 Worker SUMMARY_LANGUAGE */
+    summaryLanguage?: string;
   };
+  /** 使用者標註，Worker 會附在 transcript 後供 LLM 參考 */
+  notes?: TaskNote[];
+  /** 排程任務：ISO 8601 時間，Worker 在此之前不處理 */
+  notBefore?: string;
+}
+
+/** Highlight reel 剪輯任務訊息，推送至 highlight:queue */
+export interface HighlightPayload {
+  taskId: string;
+  userId: string;
+  filePath: string;
+  clips: { startSec: number; endSec: number }[];
+}
+
+export type NoteKind = 'note' | 'highlight';
+
+/** 任務標註，對應 DB task_notes 表；highlight 以 startSec~endSec 標出片段 */
+export interface TaskNote {
+  id: string;
+  kind: NoteKind;
+  startSec: number;
+  endSec?: number;
+  body: string;
+  createdAt: string;
 }
 
 /** 任務記錄，對應 DB tasks 表結構 */
@@ -39,6 +151,18 @@ export interface TaskRecord {
   file_path: string;
   status: TaskStatus;
   error_message?: string;
+  /** 上傳驗證失敗的錯誤代碼（例如 ERR_UNSUPPORTED_FORMAT） */
+  error_code?: string;
+  /** 錄音資訊：Worker 驗證時以 ffprobe 讀取（第一條音軌） */
+  audio_format?: string;
+  audio_codec?: string;
+  audio_duration_sec?: number;
+  audio_sample_rate?: number;
+  audio_channels?: number;
+  audio_size_bytes?: string; // BIGINT 以字串返回
+  /** Worker 自第一個 chunk 偵測到的語言（ISO-639-1） */
+  detected_language?: string;
+  source_url?: string; // 以遠端 URL 建立的任務
   created_at: Date;
   updated_at: Date;
 }
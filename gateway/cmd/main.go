@@ -2,16 +2,26 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"stt-gateway/internal/admin"
+	"stt-gateway/internal/audit"
+	"stt-gateway/internal/intake"
+	"stt-gateway/internal/keys"
 	"stt-gateway/internal/middleware"
 	"stt-gateway/internal/proxy"
 	"stt-gateway/internal/sse"
 
+	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -23,10 +33,21 @@ func main() {
 	redisPort := getEnv("REDIS_PORT", "6379")
 	apiServiceURL := getEnv("API_SERVICE_URL", "http://api-service:3000")
 	port := getEnv("GATEWAY_PORT", "8081")
+	adminToken := os.Getenv("ADMIN_TOKEN")
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr: fmt.Sprintf("%s:%s", redisHost, redisPort),
-	})
+	// 環境前綴需與 Worker / API Service 相同
+	keys.SetPrefix(os.Getenv("ENV_PREFIX"))
+
+	// 安全稽核事件匯出至 SIEM（syslog / HTTP collector），未設定 AUDIT_SINK 時停用
+	if err := audit.Configure("gateway", os.Getenv("AUDIT_SINK"), os.Getenv("AUDIT_SINK_TOKEN")); err != nil {
+		log.Fatalf("Failed to configure audit sink: %v", err)
+	}
+
+	redisOpts, err := redisOptions(fmt.Sprintf("%s:%s", redisHost, redisPort))
+	if err != nil {
+		log.Fatalf("Failed to configure Redis: %v", err)
+	}
+	rdb := redis.NewClient(redisOpts)
 
 	// 啟動時驗證 Redis 連線，失敗則終止進程
 	if err := verifyRedisConnection(rdb); err != nil {
@@ -38,13 +59,74 @@ func main() {
 	go broadcaster.Run(context.Background())
 
 	sseHandler := sse.NewHandler(rdb, broadcaster)
+	sseHandler.AdminToken = adminToken
+	sseHandler.Dedup = os.Getenv("SSE_DEDUP") == "true"
 	apiProxy := proxy.NewAPIProxy(apiServiceURL)
 
+	// 輸入政策（INPUT_*，與 Worker 共用）：公開給前端上傳前檢查，並限制上傳大小
+	inputPolicy, err := intake.LoadInputPolicy()
+	if err != nil {
+		log.Fatalf("Invalid input policy: %v", err)
+	}
+
 	mux := http.NewServeMux()
+	mux.Handle("GET /api/input-policy", inputPolicy)
 
 	// SSE 端點由 Gateway 直接處理，不經過反向代理
 	mux.Handle("GET /api/tasks/{id}/events", sseHandler)
 
+	// Admin：開關任務的 Worker live log tail、查詢 Worker registry（需 X-Admin-Token）
+	if adminToken != "" {
+		debugHandler := admin.NewDebugHandler(rdb, adminToken)
+		mux.Handle("POST /api/admin/tasks/{id}/debug", debugHandler)
+		mux.Handle("DELETE /api/admin/tasks/{id}/debug", debugHandler)
+		mux.Handle("GET /api/admin/workers", admin.NewWorkersHandler(rdb, adminToken))
+		mux.Handle("POST /api/admin/workers/{id}/drain", admin.NewDrainHandler(rdb, adminToken))
+	}
+
+	// 精簡部署：Gateway 直接受理建立任務 / 上傳 / 查詢，不需 API Service
+	var postgres *sql.DB
+	database := func() *sql.DB {
+		if postgres == nil {
+			db, err := connectDB()
+			if err != nil {
+				log.Fatalf("Failed to connect to database: %v", err)
+			}
+			postgres = db
+		}
+		return postgres
+	}
+	if os.Getenv("INTAKE_ENABLED") == "true" {
+		intake.NewHandler(database(), rdb, intake.Config{
+			UploadDir:  getEnv("UPLOAD_DIR", "/app/uploads"),
+			Language:   getEnv("STT_LANGUAGE", "zh-TW"),
+			STTModel:   os.Getenv("AI_STT_MODEL"),
+			AllowVideo: os.Getenv("APP_ENV") == "dev",
+			Policy:     inputPolicy,
+		}).Register(mux)
+		log.Println("Task intake enabled (API Service bypass)")
+	} else {
+		mux.Handle("PUT /api/tasks/{id}/upload", inputPolicy.LimitUpload(apiProxy))
+	}
+
+	// Admin：編輯摘要提示模板（prompt_templates，需 DB_* 連線與 X-Admin-Token）
+	if adminToken != "" && os.Getenv("PROMPT_TEMPLATE_ADMIN") == "true" {
+		promptHandler := admin.NewPromptTemplateHandler(database(), adminToken)
+		mux.Handle("GET /api/admin/prompt-templates/{name}", promptHandler)
+		mux.Handle("PUT /api/admin/prompt-templates/{name}", promptHandler)
+		mux.Handle("DELETE /api/admin/prompt-templates/{name}", promptHandler)
+	}
+
+	// 重新摘要：沿用已儲存的轉錄稿與摘要設定，每個任務最多 SUMMARY_RETRY_MAX 次
+	if os.Getenv("SUMMARY_RETRY_ENABLED") == "true" {
+		maxRetries, err := strconv.Atoi(getEnv("SUMMARY_RETRY_MAX", "3"))
+		if err != nil || maxRetries < 1 {
+			log.Fatalf("Invalid SUMMARY_RETRY_MAX: %q", os.Getenv("SUMMARY_RETRY_MAX"))
+		}
+		intake.NewRetryHandler(database(), rdb, maxRetries).Register(mux)
+		log.Printf("Summary retry enabled (max %d per task)", maxRetries)
+	}
+
 	// 其餘 /api/* 請求代理至 API Service
 	mux.Handle("/api/", apiProxy)
 
@@ -77,6 +159,75 @@ func main() {
 	}
 }
 
+// redisOptions 組合 Redis 連線設定，環境變數與 Worker 相同：
+// REDIS_URL（redis:// 或 rediss://）優先於 addr；rediss:// 或 REDIS_TLS=true 啟用 TLS，
+// REDIS_TLS_CA_FILE / REDIS_TLS_CERT_FILE / REDIS_TLS_KEY_FILE 指定自訂 CA 與 client 憑證。
+func redisOptions(addr string) (*redis.Options, error) {
+	opts := &redis.Options{Addr: addr}
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		parsed, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("parse REDIS_URL: %w", err)
+		}
+		opts = parsed
+	}
+
+	if opts.TLSConfig == nil && os.Getenv("REDIS_TLS") == "true" {
+		host, _, _ := net.SplitHostPort(opts.Addr)
+		opts.TLSConfig = &tls.Config{ServerName: host}
+	}
+	if opts.TLSConfig != nil {
+		opts.TLSConfig.MinVersion = tls.VersionTLS12
+		if caFile := os.Getenv("REDIS_TLS_CA_FILE"); caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", caFile)
+			}
+			opts.TLSConfig.RootCAs = pool
+		}
+		certFile, keyFile := os.Getenv("REDIS_TLS_CERT_FILE"), os.Getenv("REDIS_TLS_KEY_FILE")
+		if certFile != "" || keyFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("load client certificate: %w", err)
+			}
+			opts.TLSConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	if d, err := time.ParseDuration(os.Getenv("REDIS_DIAL_TIMEOUT")); err == nil {
+		opts.DialTimeout = d
+	}
+	return opts, nil
+}
+
+// connectDB 以 DB_* 環境變數連線 PostgreSQL（與 Worker 相同）並驗證連線。
+func connectDB() (*sql.DB, error) {
+	connStr := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+	)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // verifyRedisConnection 以 5 秒 timeout 執行 PING 驗證 Redis 連線。
 func verifyRedisConnection(rdb *redis.Client) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
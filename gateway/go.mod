@@ -4,6 +4,7 @@ go 1.22.2
 
 require (
 	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.11.2
 	github.com/redis/go-redis/v9 v9.17.3
 )
 
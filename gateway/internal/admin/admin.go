@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"stt-gateway/internal/audit"
+	"stt-gateway/internal/keys"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const headerAdminToken = "X-Admin-Token"
+
+// debugTTL debug tail 的有效時間，避免忘記關閉而持續推送 log。
+const debugTTL = time.Hour
+
+// IsAdmin 比對請求的 X-Admin-Token 與設定的 token；token 未設定時一律為 false。
+func IsAdmin(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	got := r.Header.Get(headerAdminToken)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// forbid 回應 403 並記錄 admin 驗證失敗的稽核事件。
+func forbid(w http.ResponseWriter, r *http.Request, action, target string) {
+	audit.Record(audit.Event{
+		Action:   action,
+		Outcome:  audit.OutcomeDenied,
+		Target:   target,
+		RemoteIP: audit.RemoteIP(r),
+		Details:  map[string]string{"reason": "invalid admin token"},
+	})
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
+
+// recordAdmin 記錄已通過驗證的 admin 操作。
+func recordAdmin(r *http.Request, action, target string, err error) {
+	e := audit.Event{Action: action, Outcome: audit.OutcomeSuccess, Actor: "admin", Target: target, RemoteIP: audit.RemoteIP(r)}
+	if err != nil {
+		e.Outcome = audit.OutcomeFailure
+		e.Details = map[string]string{"error": err.Error()}
+	}
+	audit.Record(e)
+}
+
+// NewDebugHandler 處理 POST / DELETE /api/admin/tasks/{id}/debug，
+// 開關指定任務的 Worker live log tail（Worker 需啟用 DEBUG_LOG_STREAMING）。
+func NewDebugHandler(rdb *redis.Client, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		taskID := r.PathValue("id")
+		if !IsAdmin(r, token) {
+			forbid(w, r, "admin.task_debug", taskID)
+			return
+		}
+		key := keys.DebugTask(taskID)
+
+		var err error
+		switch r.Method {
+		case http.MethodPost:
+			err = rdb.Set(r.Context(), key, "1", debugTTL).Err()
+		case http.MethodDelete:
+			err = rdb.Del(r.Context(), key).Err()
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		recordAdmin(r, "admin.task_debug."+strings.ToLower(r.Method), taskID, err)
+		if err != nil {
+			log.Printf("Admin: toggle debug for task %s: %v", taskID, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Admin: debug tail %s for task %s", r.Method, taskID)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
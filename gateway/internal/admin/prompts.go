@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// maxPromptTemplateBytes 單一模板的大小上限。
+const maxPromptTemplateBytes = 32 << 10
+
+// promptTemplate prompt_templates 的一列；空字串表示未設定，Worker 沿用 SUMMARY_SYSTEM_PROMPT / SUMMARY_USER_TEMPLATE。
+type promptTemplate struct {
+	Name           string `json:"name"`
+	SystemTemplate string `json:"systemTemplate"`
+	UserTemplate   string `json:"userTemplate"`
+}
+
+// NewPromptTemplateHandler 處理 GET / PUT / DELETE /api/admin/prompt-templates/{name}，
+// 讓營運人員調整摘要的提示模板（Worker 每次摘要時讀取，不需重新部署）。
+func NewPromptTemplateHandler(db *sql.DB, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !IsAdmin(r, token) {
+			forbid(w, r, "admin.prompt_template", name)
+			return
+		}
+		if len(name) > 64 {
+			http.Error(w, "Template name too long", http.StatusBadRequest)
+			return
+		}
+		ctx := r.Context()
+
+		switch r.Method {
+		case http.MethodGet:
+			t := promptTemplate{Name: name}
+			var system, user sql.NullString
+			err := db.QueryRowContext(ctx,
+				`SELECT system_template, user_template FROM prompt_templates WHERE name = $1`, name).Scan(&system, &user)
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				log.Printf("Admin: get prompt template %s: %v", name, err)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			t.SystemTemplate, t.UserTemplate = system.String, user.String
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(t)
+
+		case http.MethodPut:
+			var t promptTemplate
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxPromptTemplateBytes)).Decode(&t); err != nil {
+				http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+				return
+			}
+			if len(t.SystemTemplate) > maxPromptTemplateBytes || len(t.UserTemplate) > maxPromptTemplateBytes {
+				http.Error(w, "Template too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			_, err := db.ExecContext(ctx, `
+				INSERT INTO prompt_templates (name, system_template, user_template, updated_at)
+				VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NOW())
+				ON CONFLICT (name) DO UPDATE SET
+					system_template = EXCLUDED.system_template, user_template = EXCLUDED.user_template, updated_at = NOW()`,
+				name, t.SystemTemplate, t.UserTemplate)
+			recordAdmin(r, "admin.prompt_template.put", name, err)
+			if err != nil {
+				log.Printf("Admin: put prompt template %s: %v", name, err)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Admin: prompt template %s updated", name)
+			w.WriteHeader(http.StatusNoContent)
+
+		case http.MethodDelete:
+			_, err := db.ExecContext(ctx, `DELETE FROM prompt_templates WHERE name = $1`, name)
+			recordAdmin(r, "admin.prompt_template.delete", name, err)
+			if err != nil {
+				log.Printf("Admin: delete prompt template %s: %v", name, err)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Admin: prompt template %s deleted", name)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"stt-gateway/internal/keys"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// workerStatus Worker registry 中單一 instance 的狀態。
+type workerStatus struct {
+	ID          string   `json:"id"`
+	Hostname    string   `json:"hostname"`
+	StartedAt   int64    `json:"startedAt"`
+	HeartbeatAt int64    `json:"heartbeatAt"`
+	InFlight    []string `json:"inFlight"`
+	Draining    bool     `json:"draining"`
+}
+
+// NewWorkersHandler 處理 GET /api/admin/workers，列出存活的 Worker instance 與其進行中的任務。
+// instance HASH 已過期（Worker 未正常關閉）的項目會順便從 registry 移除。
+func NewWorkersHandler(rdb *redis.Client, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdmin(r, token) {
+			forbid(w, r, "admin.workers.list", "")
+			return
+		}
+		ctx := r.Context()
+		ids, err := rdb.ZRange(ctx, keys.Workers(), 0, -1).Result()
+		recordAdmin(r, "admin.workers.list", "", err)
+		if err != nil {
+			log.Printf("Admin: list workers: %v", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		workers := []workerStatus{}
+		for _, id := range ids {
+			fields, err := rdb.HGetAll(ctx, keys.WorkerInstance(id)).Result()
+			if err != nil {
+				continue
+			}
+			if len(fields) == 0 {
+				rdb.ZRem(ctx, keys.Workers(), id)
+				continue
+			}
+			ws := workerStatus{ID: id, Hostname: fields["hostname"], InFlight: []string{}}
+			ws.StartedAt, _ = strconv.ParseInt(fields["startedAt"], 10, 64)
+			ws.HeartbeatAt, _ = strconv.ParseInt(fields["heartbeatAt"], 10, 64)
+			_ = json.Unmarshal([]byte(fields["inFlight"]), &ws.InFlight)
+			ws.Draining = fields["draining"] == "1"
+			workers = append(workers, ws)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(workers)
+	})
+}
+
+// NewDrainHandler 處理 POST /api/admin/workers/{id}/drain，通知指定 instance（"*" 為全部）停止消費、
+// 完成進行中任務後結束，供 rolling deploy 交接。
+func NewDrainHandler(rdb *redis.Client, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("id")
+		if !IsAdmin(r, token) {
+			forbid(w, r, "admin.worker_drain", workerID)
+			return
+		}
+		msg, _ := json.Marshal(map[string]string{"workerId": workerID})
+		receivers, err := rdb.Publish(r.Context(), keys.DrainChannel(), msg).Result()
+		recordAdmin(r, "admin.worker_drain", workerID, err)
+		if err != nil {
+			log.Printf("Admin: drain worker %s: %v", workerID, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Admin: drain requested for worker %s (%d subscribers)", workerID, receivers)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,490 @@
+// Package intake 精簡部署（Gateway + Worker，不含 API Service）時由 Gateway 直接受理任務：
+// 建立任務、串流上傳音檔、推送 STT 任務，以及查詢任務結果。
+// 任務佇列僅支援 Redis LIST（Worker BROKER=redis），payload 格式與 API Service 相同。
+package intake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"stt-gateway/internal/keys"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config intake 設定。
+type Config struct {
+	UploadDir string // 與 Worker 共用的上傳目錄（{UploadDir}/{userId}/{taskId}/{filename}）
+	Language  string // STT 語言提示
+	STTModel  string
+	// AllowVideo 允許 video/* 容器（開發環境）；正式環境僅接受音訊。
+	AllowVideo bool
+	// Policy 部署共用的輸入限制（副檔名、大小），完整驗證由 Worker 執行。
+	Policy InputPolicy
+}
+
+// Handler 處理任務受理相關的 /api/tasks 路由。
+type Handler struct {
+	db  *sql.DB
+	rdb *redis.Client
+	cfg Config
+}
+
+// NewHandler 建立 intake Handler。
+func NewHandler(db *sql.DB, rdb *redis.Client, cfg Config) *Handler {
+	return &Handler{db: db, rdb: rdb, cfg: cfg}
+}
+
+// Register 掛載路由；比 "/api/" 反向代理更精確，其餘 /api/* 仍交給 API Service（若有部署）。
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/tasks", h.createTask)
+	mux.HandleFunc("PUT /api/tasks/{id}/upload", h.upload)
+	mux.HandleFunc("POST /api/tasks/{id}/source", h.source)
+	mux.HandleFunc("GET /api/tasks/{id}", h.getTask)
+}
+
+// sttPayload 與 Worker models.STTPayload / API Service STTPayload 對齊。
+type sttPayload struct {
+	TaskID    string    `json:"taskId"`
+	UserID    string    `json:"userId"`
+	FilePath  string    `json:"filePath"`
+	SourceURL string    `json:"sourceUrl,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Config    sttConfig `json:"config"`
+}
+
+type sttConfig struct {
+	Language string `json:"language"`
+	// LanguageExplicit language 由使用者指定（?language=），Worker 以此為確定的轉錄稿語言，不再偵測
+	LanguageExplicit bool     `json:"languageExplicit,omitempty"`
+	STTModel         string   `json:"sttModel"`
+	Prompt           string   `json:"prompt,omitempty"`
+	Glossary         []string `json:"glossary,omitempty"`
+}
+
+// languagePattern 語言提示：ISO-639-1（可帶地區，如 zh-TW），或 auto / multi 由 provider 逐段偵測。
+var languagePattern = regexp.MustCompile(`^(auto|multi|[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})?)$`)
+
+// maxSTTPromptChars 初始 STT 提示的長度上限（Whisper 的 prompt 約 224 token）。
+const maxSTTPromptChars = 500
+
+// maxGlossaryTerms / maxGlossaryTermChars 任務詞彙表的詞數與單詞長度上限，與 API Service 一致。
+const (
+	maxGlossaryTerms     = 100
+	maxGlossaryTermChars = 100
+)
+
+// taskConfig 解析 ?language=&prompt=&glossary= 任務覆寫；language 省略時由 enqueue 套用部署預設，
+// glossary（逗號分隔）省略時由 Worker 套用用戶的預設詞彙表。
+func taskConfig(r *http.Request) (sttConfig, error) {
+	q := r.URL.Query()
+	cfg := sttConfig{Language: q.Get("language"), Prompt: strings.TrimSpace(q.Get("prompt"))}
+	if cfg.Language != "" && !languagePattern.MatchString(cfg.Language) {
+		return cfg, errors.New("language must be an ISO-639-1 code (optionally with region), auto or multi")
+	}
+	if n := utf8.RuneCountInString(cfg.Prompt); n > maxSTTPromptChars {
+		return cfg, fmt.Errorf("prompt must be at most %d characters", maxSTTPromptChars)
+	}
+	if q.Has("glossary") {
+		for _, term := range strings.Split(q.Get("glossary"), ",") {
+			if term = strings.TrimSpace(term); term == "" {
+				continue
+			}
+			if utf8.RuneCountInString(term) > maxGlossaryTermChars {
+				return cfg, fmt.Errorf("each glossary term must be at most %d characters", maxGlossaryTermChars)
+			}
+			cfg.Glossary = append(cfg.Glossary, term)
+		}
+		if len(cfg.Glossary) > maxGlossaryTerms {
+			return cfg, fmt.Errorf("glossary must have at most %d terms", maxGlossaryTerms)
+		}
+	}
+	return cfg, nil
+}
+
+// createTask POST /api/tasks：DB INSERT + Redis owner key 與 live 狀態。
+func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-Id")
+	taskID := uuid.New().String()
+	ctx := r.Context()
+
+	if _, err := h.db.ExecContext(ctx, `INSERT INTO tasks (id, user_id, status) VALUES ($1, $2, 'pending')`, taskID, userID); err != nil {
+		log.Printf("intake: create task: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create task")
+		return
+	}
+	h.rdb.Set(ctx, keys.TaskOwner(taskID), userID, 0)
+	h.rdb.HSet(ctx, keys.Task(taskID), "status", "pending", "userId", userID)
+
+	writeJSON(w, http.StatusOK, map[string]string{"taskId": taskID, "status": "pending"})
+}
+
+// upload PUT /api/tasks/{id}/upload：驗證擁有者 → 以 magic bytes 驗證格式 → 串流寫檔 → 推送 STT 任務。
+// ?language= 覆寫語言提示，?prompt= 為初始 STT 提示（人名、專有名詞的寫法）。
+func (h *Handler) upload(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	userID := r.Header.Get("X-User-Id")
+	ctx := r.Context()
+	if !validPathID(userID) || !validPathID(taskID) {
+		writeError(w, http.StatusBadRequest, "Invalid user or task id")
+		return
+	}
+
+	var status string
+	err := h.db.QueryRowContext(ctx, `SELECT status FROM tasks WHERE id = $1 AND user_id = $2`, taskID, userID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	if err != nil {
+		log.Printf("intake: load task %s: %v", taskID, err)
+		writeError(w, http.StatusInternalServerError, "Upload streaming failed")
+		return
+	}
+	if status != "pending" {
+		writeError(w, http.StatusConflict, "Task already uploaded")
+		return
+	}
+	sttCfg, err := taskConfig(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "No file uploaded")
+		return
+	}
+	var part io.ReadCloser
+	var filename string
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "No file uploaded")
+			return
+		}
+		if p.FileName() != "" {
+			part, filename = p, filepath.Base(p.FileName())
+			break
+		}
+		p.Close()
+	}
+	defer part.Close()
+	if !h.cfg.Policy.allowsFilename(filename) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("ERR_UNSUPPORTED_FORMAT: This deployment does not accept %s files.", filepath.Ext(filename)))
+		return
+	}
+
+	filePath, checksum, err := h.store(userID, taskID, filename, part)
+	if err != nil {
+		var invalid invalidTypeError
+		if errors.As(err, &invalid) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var tooLarge fileTooLargeError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		if errors.Is(err, errUnsafePath) {
+			writeError(w, http.StatusBadRequest, "Invalid file name")
+			return
+		}
+		log.Printf("intake: store upload %s: %v", taskID, err)
+		h.db.ExecContext(ctx, `UPDATE tasks SET status = 'failed', error_message = 'Upload failed' WHERE id = $1`, taskID)
+		writeError(w, http.StatusInternalServerError, "Upload streaming failed")
+		return
+	}
+
+	if err := h.enqueue(r, sttPayload{TaskID: taskID, UserID: userID, FilePath: filePath, Checksum: checksum, Config: sttCfg}); err != nil {
+		log.Printf("intake: enqueue %s: %v", taskID, err)
+		os.Remove(filePath)
+		h.db.ExecContext(ctx, `UPDATE tasks SET status = 'failed', error_message = 'Upload failed' WHERE id = $1`, taskID)
+		writeError(w, http.StatusInternalServerError, "Upload streaming failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "upload_complete", "taskId": taskID})
+}
+
+// invalidTypeError 上傳的檔案不是允許的音訊格式。
+type invalidTypeError struct{ mime string }
+
+func (e invalidTypeError) Error() string {
+	return fmt.Sprintf("Invalid file type: %s. Only audio files are allowed.", e.mime)
+}
+
+// store 讀取前 512 bytes 偵測格式後串流寫入上傳目錄，同時計算 SHA-256（hex）；失敗時清理已寫入的檔案。
+// 超過 Policy.MaxBytes 時中止並返回 fileTooLargeError。
+func (h *Handler) store(userID, taskID, filename string, src io.Reader) (string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", "", invalidTypeError{mime: "unknown"}
+	}
+	head = head[:n]
+	if mime := http.DetectContentType(head); !h.allowed(mime) {
+		return "", "", invalidTypeError{mime: mime}
+	}
+
+	filePath, err := h.uploadPath(userID, taskID, filename)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", "", err
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	hash := sha256.New()
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), src)
+	if limit := h.cfg.Policy.MaxBytes; limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	written, err := io.Copy(io.MultiWriter(f, hash), body)
+	if err == nil && h.cfg.Policy.MaxBytes > 0 && written > h.cfg.Policy.MaxBytes {
+		err = fileTooLargeError{h.cfg.Policy.MaxBytes}
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(filePath)
+		return "", "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(filePath)
+		return "", "", err
+	}
+	return filePath, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// pathID 可作為上傳目錄中一層路徑的 ID。使用者 ID 來自 client 可控制的 cookie，不得含路徑字元。
+var pathID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// validPathID 判斷使用者 / 任務 ID 能否安全地用於檔案路徑。
+func validPathID(id string) bool {
+	return pathID.MatchString(id)
+}
+
+// errUnsafePath 上傳路徑不在 UploadDir 之下。
+var errUnsafePath = errors.New("upload path escapes the upload directory")
+
+// uploadPath 返回 {UploadDir}/{userID}/{taskID}/{filename}；ID 不合法或清理後的路徑不在 UploadDir 之下時返回 errUnsafePath。
+func (h *Handler) uploadPath(userID, taskID, filename string) (string, error) {
+	if !validPathID(userID) || !validPathID(taskID) {
+		return "", errUnsafePath
+	}
+	dir := filepath.Join(h.cfg.UploadDir, userID, taskID)
+	p := filepath.Join(dir, filename)
+	if filepath.Dir(p) != dir {
+		return "", errUnsafePath
+	}
+	return p, nil
+}
+
+// allowed 判斷偵測到的 MIME 是否可接受：音訊，以及會議錄影常用的 mp4 / mkv / webm / mov 容器（Worker 會抽出音軌）。
+func (h *Handler) allowed(mime string) bool {
+	switch {
+	case strings.HasPrefix(mime, "audio/"), mime == "application/ogg",
+		mime == "video/mp4", mime == "video/webm", mime == "video/x-matroska", mime == "video/quicktime":
+		return true
+	case strings.HasPrefix(mime, "video/"):
+		return h.cfg.AllowVideo
+	}
+	return false
+}
+
+// source POST /api/tasks/{id}/source：以遠端 URL（body {"url": "..."}）取代上傳，由 Worker 下載後處理。
+// 只檢查網址格式與副檔名；大小、Content-Type 與內網位址由 Worker 下載時檢查。
+func (h *Handler) source(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	userID := r.Header.Get("X-User-Id")
+	ctx := r.Context()
+	if !validPathID(userID) || !validPathID(taskID) {
+		writeError(w, http.StatusBadRequest, "Invalid user or task id")
+		return
+	}
+
+	sttCfg, err := taskConfig(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	u, err := url.Parse(body.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		writeError(w, http.StatusBadRequest, "url must be an absolute http(s) URL")
+		return
+	}
+	filename := sourceFilename(u)
+	if !h.cfg.Policy.allowsFilename(filename) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("ERR_UNSUPPORTED_FORMAT: This deployment does not accept %s files.", filepath.Ext(filename)))
+		return
+	}
+
+	filePath, err := h.uploadPath(userID, taskID, filename)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user or task id")
+		return
+	}
+	res, err := h.db.ExecContext(ctx, `UPDATE tasks SET source_url = $1 WHERE id = $2 AND user_id = $3 AND status = 'pending' AND file_path IS NULL`,
+		u.String(), taskID, userID)
+	if err != nil {
+		log.Printf("intake: source %s: %v", taskID, err)
+		writeError(w, http.StatusInternalServerError, "Failed to queue source")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		h.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2)`, taskID, userID).Scan(&exists)
+		if !exists {
+			writeError(w, http.StatusNotFound, "Task not found")
+			return
+		}
+		writeError(w, http.StatusConflict, "Task already uploaded")
+		return
+	}
+
+	if err := h.enqueue(r, sttPayload{TaskID: taskID, UserID: userID, FilePath: filePath, SourceURL: u.String(), Config: sttCfg}); err != nil {
+		log.Printf("intake: enqueue %s: %v", taskID, err)
+		h.db.ExecContext(ctx, `UPDATE tasks SET status = 'failed', error_message = 'Upload failed' WHERE id = $1`, taskID)
+		writeError(w, http.StatusInternalServerError, "Failed to queue source")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "source_queued", "taskId": taskID})
+}
+
+// sourceFilename 由 URL 路徑取檔名（保留副檔名供輸入政策與 ffprobe 判斷），無法取得時為 source。
+func sourceFilename(u *url.URL) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, path.Base(u.Path))
+	if name == "" || name == "." || name == ".." || name == "_" {
+		return "source"
+	}
+	return name
+}
+
+// enqueue 更新 file_path / upload_sha256、Redis live 狀態並 LPUSH 至 STT 佇列。
+func (h *Handler) enqueue(r *http.Request, payload sttPayload) error {
+	ctx := r.Context()
+	if _, err := h.db.ExecContext(ctx, `UPDATE tasks SET file_path = $1, upload_sha256 = NULLIF($2, '') WHERE id = $3 AND user_id = $4`,
+		payload.FilePath, payload.Checksum, payload.TaskID, payload.UserID); err != nil {
+		return fmt.Errorf("update file_path: %w", err)
+	}
+
+	if payload.Config.Language == "" {
+		payload.Config.Language = h.cfg.Language
+	} else {
+		payload.Config.LanguageExplicit = true
+	}
+	payload.Config.STTModel = h.cfg.STTModel
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := h.rdb.HSet(ctx, keys.Task(payload.TaskID), "status", "stt_queued", "filePath", payload.FilePath).Err(); err != nil {
+		return fmt.Errorf("set live status: %w", err)
+	}
+	if err := h.rdb.LPush(ctx, keys.STTQueue(), body).Err(); err != nil {
+		return fmt.Errorf("push stt queue: %w", err)
+	}
+	return nil
+}
+
+// getTask GET /api/tasks/{id}：Redis live 狀態優先，合併 DB 中的 transcript / summary。
+func (h *Handler) getTask(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	userID := r.Header.Get("X-User-Id")
+	ctx := r.Context()
+
+	var task struct {
+		ID           string  `json:"id"`
+		Status       string  `json:"status"`
+		Title        *string `json:"title"`
+		ErrorMessage *string `json:"error_message"`
+		ErrorCode    *string `json:"error_code"`
+		Transcript   *string `json:"transcript"`
+		Summary      *string `json:"summary"`
+		// Trace 使用者可見的處理時間分解（tasks.trace）
+		Trace *json.RawMessage `json:"trace"`
+		// Waveform 切片時計算的波形（task_results.waveform）
+		Waveform *json.RawMessage `json:"waveform"`
+		// Timings 逐段 / 逐字時間戳（task_results.timings）
+		Timings *json.RawMessage `json:"timings"`
+		// StructuredSummary 結構化摘要（task_results.structured_summary）
+		StructuredSummary *json.RawMessage `json:"structured_summary"`
+		// Moderation 未通過內容審核時的類別（tasks.moderation），status 為 flagged
+		Moderation *json.RawMessage `json:"moderation"`
+		// 錄音資訊（Worker 驗證時以 ffprobe 讀取）
+		AudioFormat      *string  `json:"audio_format"`
+		AudioCodec       *string  `json:"audio_codec"`
+		AudioDurationSec *float64 `json:"audio_duration_sec"`
+		AudioSampleRate  *int     `json:"audio_sample_rate"`
+		AudioChannels    *int     `json:"audio_channels"`
+		AudioSizeBytes   *int64   `json:"audio_size_bytes"`
+		// DetectedLanguage Worker 自第一個 chunk 偵測到的語言（ISO-639-1）
+		DetectedLanguage *string `json:"detected_language"`
+	}
+	err := h.db.QueryRowContext(ctx, `
+		SELECT t.id, t.status, t.title, t.error_message, t.error_code, r.transcript, r.summary, t.trace, r.waveform, r.timings, r.structured_summary, t.moderation,
+		       t.audio_format, t.audio_codec, t.audio_duration_sec, t.audio_sample_rate, t.audio_channels, t.audio_size_bytes,
+		       t.detected_language
+		FROM tasks t
+		LEFT JOIN task_results r ON t.id = r.task_id
+		WHERE t.id = $1 AND t.user_id = $2`, taskID, userID).
+		Scan(&task.ID, &task.Status, &task.Title, &task.ErrorMessage, &task.ErrorCode, &task.Transcript, &task.Summary, &task.Trace, &task.Waveform, &task.Timings, &task.StructuredSummary, &task.Moderation,
+			&task.AudioFormat, &task.AudioCodec, &task.AudioDurationSec, &task.AudioSampleRate, &task.AudioChannels, &task.AudioSizeBytes,
+			&task.DetectedLanguage)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	if err != nil {
+		log.Printf("intake: get task %s: %v", taskID, err)
+		writeError(w, http.StatusInternalServerError, "Failed to load task")
+		return
+	}
+	if live, err := h.rdb.HGet(ctx, keys.Task(taskID), "status").Result(); err == nil && live != "" {
+		task.Status = live
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
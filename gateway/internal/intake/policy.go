@@ -0,0 +1,125 @@
+package intake
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// multipartSlack 代理上傳時 multipart 邊界與欄位的額外位元組，Content-Length 預檢時予以寬容。
+const multipartSlack = 1 << 20
+
+// InputPolicy 整個部署共用的輸入限制，與 Worker audio.InputPolicy 讀取相同的 INPUT_* 環境變數：
+// Gateway 上傳時先以副檔名與大小快速拒絕，Worker 切片前再以 ffprobe 完整驗證。零值欄位表示不限制。
+type InputPolicy struct {
+	Formats        []string `json:"formats"`
+	Codecs         []string `json:"codecs"`
+	MaxDurationSec float64  `json:"maxDurationSec,omitempty"`
+	MaxBytes       int64    `json:"maxBytes,omitempty"`
+	MaxChunks      int      `json:"maxChunks,omitempty"`
+}
+
+// formatAliases 副檔名 → ffprobe format_name，與 Worker 相同。
+var formatAliases = map[string]string{
+	"mkv":  "matroska",
+	"mka":  "matroska",
+	"m4a":  "mp4",
+	"mov":  "mp4",
+	"oga":  "ogg",
+	"opus": "ogg",
+}
+
+// LoadInputPolicy 讀取 INPUT_FORMATS / INPUT_CODECS / INPUT_MAX_DURATION / INPUT_MAX_BYTES / INPUT_MAX_CHUNKS。
+func LoadInputPolicy() (InputPolicy, error) {
+	p := InputPolicy{
+		Formats: parseList(os.Getenv("INPUT_FORMATS")),
+		Codecs:  parseList(os.Getenv("INPUT_CODECS")),
+	}
+	if v := os.Getenv("INPUT_MAX_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return p, fmt.Errorf("INPUT_MAX_DURATION: %w", err)
+		}
+		p.MaxDurationSec = d.Seconds()
+	}
+	if v := os.Getenv("INPUT_MAX_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("INPUT_MAX_BYTES: %w", err)
+		}
+		p.MaxBytes = n
+	}
+	if v := os.Getenv("INPUT_MAX_CHUNKS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("INPUT_MAX_CHUNKS: %w", err)
+		}
+		p.MaxChunks = n
+	}
+	return p, nil
+}
+
+// parseList 解析逗號分隔清單（轉小寫、去除空項目），空清單以 [] 輸出。
+func parseList(spec string) []string {
+	out := []string{}
+	for _, s := range strings.Split(spec, ",") {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// allowsFilename 以副檔名判斷容器是否在允許清單內；無副檔名時交由 Worker 驗證。
+func (p InputPolicy) allowsFilename(name string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if len(p.Formats) == 0 || ext == "" {
+		return true
+	}
+	if alias, ok := formatAliases[ext]; ok {
+		ext = alias
+	}
+	for _, f := range p.Formats {
+		if alias, ok := formatAliases[f]; ok {
+			f = alias
+		}
+		if f == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP GET /api/input-policy：返回目前的輸入限制，讓前端在上傳前先行檢查。
+func (p InputPolicy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, p)
+}
+
+// LimitUpload 包裝代理至 API Service 的上傳請求：Content-Length 明顯超過 MaxBytes 時直接返回 413，
+// 其餘以 MaxBytesReader 限制實際讀取量。
+func (p InputPolicy) LimitUpload(next http.Handler) http.Handler {
+	if p.MaxBytes <= 0 {
+		return next
+	}
+	limit := p.MaxBytes + multipartSlack
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			writeError(w, http.StatusRequestEntityTooLarge, fileTooLargeError{p.MaxBytes}.Error())
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fileTooLargeError 上傳超過 InputPolicy.MaxBytes。
+type fileTooLargeError struct{ max int64 }
+
+func (e fileTooLargeError) Error() string {
+	return fmt.Sprintf("ERR_FILE_TOO_LARGE: The file is larger than the %d MB limit.", e.max/(1024*1024))
+}
@@ -0,0 +1,236 @@
+package intake
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"stt-gateway/internal/keys"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// idempotencyTTL Idempotency-Key 紀錄的保存時間。
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyPending 請求處理中的佔位值，處理完成後改存回應內容。
+const idempotencyPending = "pending"
+
+// RetryHandler 處理 POST /api/tasks/{id}/summary/retry：沿用已儲存的轉錄稿與上一次的摘要設定重新摘要，
+// 不需重新上傳或轉錄。每個任務最多重試 MaxRetries 次；帶 Idempotency-Key 的重送不會重複排入佇列。
+type RetryHandler struct {
+	db         *sql.DB
+	rdb        *redis.Client
+	maxRetries int
+}
+
+// NewRetryHandler 建立重新摘要 Handler，maxRetries 為每個任務的重試上限。
+func NewRetryHandler(db *sql.DB, rdb *redis.Client, maxRetries int) *RetryHandler {
+	return &RetryHandler{db: db, rdb: rdb, maxRetries: maxRetries}
+}
+
+// Register 掛載路由。
+func (h *RetryHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/tasks/{id}/summary/retry", h.retry)
+}
+
+// summaryNote 與 Worker models.TaskNote 對齊。
+type summaryNote struct {
+	Kind     string   `json:"kind"`
+	StartSec float64  `json:"startSec"`
+	EndSec   *float64 `json:"endSec,omitempty"`
+	Body     string   `json:"body"`
+}
+
+// summaryPayload 與 Worker models.SummaryPayload 對齊；Config 原樣沿用 tasks.summary_config。
+type summaryPayload struct {
+	TaskID     string          `json:"taskId"`
+	UserID     string          `json:"userId"`
+	Transcript string          `json:"transcript"`
+	Config     json.RawMessage `json:"config"`
+	Notes      []summaryNote   `json:"notes,omitempty"`
+	Mode       string          `json:"mode"`
+}
+
+// retryResponse 202 回應內容，同一 Idempotency-Key 重送時原樣返回。
+type retryResponse struct {
+	Status     string `json:"status"`
+	TaskID     string `json:"taskId"`
+	Retry      int    `json:"retry"`
+	MaxRetries int    `json:"maxRetries"`
+}
+
+// retryError 可直接回應給使用者的錯誤。
+type retryError struct {
+	code int
+	msg  string
+}
+
+func (e retryError) Error() string { return e.msg }
+
+// retry 驗證 Idempotency-Key → 排入重新摘要 → 記錄回應供重送使用；失敗時釋放 Idempotency-Key 讓使用者可再試。
+func (h *RetryHandler) retry(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	userID := r.Header.Get("X-User-Id")
+	ctx := r.Context()
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Missing X-User-Id header")
+		return
+	}
+
+	idemKey := ""
+	if k := r.Header.Get("Idempotency-Key"); k != "" {
+		if len(k) > 128 {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key too long")
+			return
+		}
+		idemKey = keys.SummaryRetryIdempotency(taskID, userID+":"+k)
+		ok, err := h.rdb.SetNX(ctx, idemKey, idempotencyPending, idempotencyTTL).Result()
+		if err != nil {
+			log.Printf("intake: summary retry %s: idempotency: %v", taskID, err)
+			writeError(w, http.StatusInternalServerError, "Failed to retry summary")
+			return
+		}
+		if !ok {
+			h.replay(w, r, idemKey)
+			return
+		}
+	}
+
+	resp, err := h.enqueue(ctx, taskID, userID)
+	if err != nil {
+		if idemKey != "" {
+			h.rdb.Del(context.WithoutCancel(ctx), idemKey)
+		}
+		var re retryError
+		if errors.As(err, &re) {
+			writeError(w, re.code, re.msg)
+			return
+		}
+		log.Printf("intake: summary retry %s: %v", taskID, err)
+		writeError(w, http.StatusInternalServerError, "Failed to retry summary")
+		return
+	}
+	if idemKey != "" {
+		if body, err := json.Marshal(resp); err == nil {
+			h.rdb.Set(context.WithoutCancel(ctx), idemKey, body, idempotencyTTL)
+		}
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// replay 返回同一 Idempotency-Key 第一次請求的結果；第一次請求仍在處理中時返回 409。
+func (h *RetryHandler) replay(w http.ResponseWriter, r *http.Request, idemKey string) {
+	stored, err := h.rdb.Get(r.Context(), idemKey).Result()
+	if err != nil || stored == idempotencyPending {
+		writeError(w, http.StatusConflict, "A request with this Idempotency-Key is in progress")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(stored))
+}
+
+// enqueue 在交易內鎖定任務列，檢查狀態與重試上限後累加 summary_retries，
+// 再設定 Redis live 狀態並 LPUSH 至 Summary 佇列；推送失敗時交易回滾，不消耗重試次數。
+func (h *RetryHandler) enqueue(ctx context.Context, taskID, userID string) (*retryResponse, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		status     string
+		retries    int
+		config     []byte
+		transcript sql.NullString
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.status, t.summary_retries, t.summary_config, r.transcript
+		FROM tasks t
+		LEFT JOIN task_results r ON t.id = r.task_id
+		WHERE t.id = $1 AND t.user_id = $2
+		FOR UPDATE OF t`, taskID, userID).Scan(&status, &retries, &config, &transcript)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, retryError{http.StatusNotFound, "Task not found"}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load task: %w", err)
+	}
+	switch status {
+	case "completed", "completed_no_summary", "failed":
+	default:
+		return nil, retryError{http.StatusConflict, "Task summary cannot be retried in its current state"}
+	}
+	if !transcript.Valid || transcript.String == "" {
+		return nil, retryError{http.StatusConflict, "Task has no transcript to summarize"}
+	}
+	if live, _ := h.rdb.HGet(ctx, keys.Task(taskID), "status").Result(); live == "summary_queued" || live == "summary_processing" {
+		return nil, retryError{http.StatusConflict, "Summary is already in progress"}
+	}
+	if retries >= h.maxRetries {
+		return nil, retryError{http.StatusTooManyRequests, "Summary retry limit reached"}
+	}
+
+	notes, err := loadNotes(ctx, tx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(config) == 0 {
+		config = []byte(`{"summaryPrompt":""}`)
+	}
+	body, err := json.Marshal(summaryPayload{
+		TaskID:     taskID,
+		UserID:     userID,
+		Transcript: transcript.String,
+		Config:     config,
+		Notes:      notes,
+		Mode:       "summary_retry",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET summary_retries = summary_retries + 1 WHERE id = $1`, taskID); err != nil {
+		return nil, fmt.Errorf("count retry: %w", err)
+	}
+	if err := h.rdb.HSet(ctx, keys.Task(taskID), "status", "summary_queued").Err(); err != nil {
+		return nil, fmt.Errorf("set live status: %w", err)
+	}
+	if err := h.rdb.LPush(ctx, keys.SummaryQueue(), body).Err(); err != nil {
+		h.rdb.HSet(context.WithoutCancel(ctx), keys.Task(taskID), "status", status)
+		return nil, fmt.Errorf("push summary queue: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		// 已推送至佇列，任務仍會執行；僅重試次數未記錄
+		log.Printf("intake: summary retry %s: commit: %v", taskID, err)
+	}
+	return &retryResponse{Status: "summary_retry_queued", TaskID: taskID, Retry: retries + 1, MaxRetries: h.maxRetries}, nil
+}
+
+// loadNotes 依時間軸讀取任務標註，附在摘要 payload 供 LLM 參考。
+func loadNotes(ctx context.Context, tx *sql.Tx, taskID string) ([]summaryNote, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT kind, start_sec, end_sec, body FROM task_notes
+		WHERE task_id = $1 ORDER BY start_sec, created_at`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("load notes: %w", err)
+	}
+	defer rows.Close()
+	var notes []summaryNote
+	for rows.Next() {
+		var n summaryNote
+		if err := rows.Scan(&n.Kind, &n.StartSec, &n.EndSec, &n.Body); err != nil {
+			return nil, fmt.Errorf("load notes: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
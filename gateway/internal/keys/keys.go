@@ -0,0 +1,90 @@
+// Package keys 集中定義 Gateway 使用的 Redis key 與 Pub/Sub channel，並套用環境前綴（ENV_PREFIX）。
+// 命名需與 Worker（tts-worker/internal/keys）及 API Service（src/lib/keys.ts）一致。
+package keys
+
+import (
+	"fmt"
+	"strings"
+)
+
+var prefix string
+
+// SetPrefix 設定環境前綴，例如 "staging" → 所有 key 以 "staging:" 開頭。空字串表示不加前綴。
+func SetPrefix(env string) {
+	env = strings.TrimSuffix(env, ":")
+	if env == "" {
+		prefix = ""
+		return
+	}
+	prefix = env + ":"
+}
+
+// TaskOwner 任務擁有者，SSE 驗證用。
+func TaskOwner(taskID string) string {
+	return fmt.Sprintf("%stask:owner:%s", prefix, taskID)
+}
+
+// TranscriptBuffer 進行中的累積轉錄稿。
+func TranscriptBuffer(taskID string) string {
+	return fmt.Sprintf("%stranscript:buffer:%s", prefix, taskID)
+}
+
+// SummaryBuffer 進行中的累積摘要。
+func SummaryBuffer(taskID string) string {
+	return fmt.Sprintf("%ssummary:buffer:%s", prefix, taskID)
+}
+
+// ProgressPattern 訂閱所有任務進度 channel 的 PSUBSCRIBE pattern。
+func ProgressPattern() string {
+	return prefix + "progress:*"
+}
+
+// ProgressTaskID 由進度 channel 名稱取出 taskId，非本環境的 channel 返回 false。
+func ProgressTaskID(channel string) (string, bool) {
+	return strings.CutPrefix(channel, prefix+"progress:")
+}
+
+// Events 任務事件 Stream。
+func Events(taskID string) string {
+	return fmt.Sprintf("%sevents:%s", prefix, taskID)
+}
+
+// DebugTask Worker live log tail 開關。
+func DebugTask(taskID string) string {
+	return fmt.Sprintf("%sdebug:task:%s", prefix, taskID)
+}
+
+// Workers 存活 Worker instance 的 ZSET，與 Worker 端 keys.Workers 一致。
+func Workers() string {
+	return prefix + "workers"
+}
+
+// WorkerInstance 單一 Worker instance 的狀態 HASH。
+func WorkerInstance(id string) string {
+	return fmt.Sprintf("%sworker:instance:%s", prefix, id)
+}
+
+// DrainChannel Worker drain 信號的 Pub/Sub channel，與 Worker 端 keys.DrainChannel 一致。
+func DrainChannel() string {
+	return prefix + "worker:drain"
+}
+
+// Task 任務 live 狀態 HASH。
+func Task(taskID string) string {
+	return fmt.Sprintf("%stask:%s", prefix, taskID)
+}
+
+// STTQueue STT 任務佇列（Redis LIST）。
+func STTQueue() string {
+	return prefix + "stt:queue"
+}
+
+// SummaryQueue Summary 任務佇列（Redis LIST）。
+func SummaryQueue() string {
+	return prefix + "summary:queue"
+}
+
+// SummaryRetryIdempotency 重新摘要請求的 Idempotency-Key 紀錄，重送時返回第一次的結果。
+func SummaryRetryIdempotency(taskID, key string) string {
+	return fmt.Sprintf("%ssummary:retry:%s:%s", prefix, taskID, key)
+}
@@ -3,9 +3,10 @@ package sse
 import (
 	"context"
 	"log"
-	"strings"
 	"sync"
 
+	"stt-gateway/internal/keys"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -14,25 +15,34 @@ import (
 type Broadcaster struct {
 	rdb         *redis.Client
 	mu          sync.RWMutex
-	clientChans map[string]map[chan string]bool
+	clientChans map[string]map[chan string]*subscription
+	// synced sync key（使用者 + 任務 + 客戶端）→ 最新的 channel，用於收斂重複連線
+	synced map[string]chan string
+}
+
+// subscription 單一 SSE 監聽者的註冊資訊。
+type subscription struct {
+	syncKey    string
+	superseded chan struct{}
 }
 
 // NewBroadcaster 初始化 Multiplexer。
 func NewBroadcaster(rdb *redis.Client) *Broadcaster {
 	return &Broadcaster{
 		rdb:         rdb,
-		clientChans: make(map[string]map[chan string]bool),
+		clientChans: make(map[string]map[chan string]*subscription),
+		synced:      make(map[string]chan string),
 	}
 }
 
 // Run 啟動背景監聽服務，此方法應設計為常駐 Goroutine。
 func (b *Broadcaster) Run(ctx context.Context) {
 	// 透過 PSUBSCRIBE 訂閱所有任務的進度頻道
-	pubsub := b.rdb.PSubscribe(ctx, "progress:*")
+	pubsub := b.rdb.PSubscribe(ctx, keys.ProgressPattern())
 	defer pubsub.Close()
 
 	ch := pubsub.Channel()
-	log.Println("Broadcaster started, listening to progress:*")
+	log.Printf("Broadcaster started, listening to %s", keys.ProgressPattern())
 
 	for {
 		select {
@@ -45,9 +55,11 @@ func (b *Broadcaster) Run(ctx context.Context) {
 				return
 			}
 
-			// 頻道名稱通常為 "progress:{taskId}"
-			// 剝離前綴取得 taskId
-			taskID := strings.TrimPrefix(msg.Channel, "progress:")
+			// 頻道名稱為 "{prefix}progress:{taskId}"，剝離前綴取得 taskId
+			taskID, ok := keys.ProgressTaskID(msg.Channel)
+			if !ok {
+				continue
+			}
 
 			b.mu.RLock()
 			listeners := b.clientChans[taskID]
@@ -67,17 +79,37 @@ func (b *Broadcaster) Run(ctx context.Context) {
 
 // Subscribe 讓 SSE Handler 向 Broadcaster 註冊，取得專屬的接受 Channel。
 func (b *Broadcaster) Subscribe(taskID string) chan string {
+	ch, _ := b.SubscribeSynced(taskID, "")
+	return ch
+}
+
+// SubscribeSynced 以 sync key 註冊：同一 key（同一使用者於同一客戶端重複開啟的連線，例如複製分頁）
+// 已有監聽者時，較舊的連線會收到 superseded 通知並應結束，避免熱門任務重複 fan-out。
+// syncKey 為空時等同 Subscribe，返回的 superseded 永遠不會關閉。
+func (b *Broadcaster) SubscribeSynced(taskID, syncKey string) (chan string, <-chan struct{}) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if b.clientChans[taskID] == nil {
-		b.clientChans[taskID] = make(map[chan string]bool)
+		b.clientChans[taskID] = make(map[chan string]*subscription)
 	}
 
 	// channel 帶有適量 Buffer，抵抗瞬發流量
 	ch := make(chan string, 16)
-	b.clientChans[taskID][ch] = true
-	return ch
+	sub := &subscription{syncKey: syncKey, superseded: make(chan struct{})}
+	b.clientChans[taskID][ch] = sub
+
+	if syncKey != "" {
+		if older, ok := b.synced[syncKey]; ok {
+			if prev := b.clientChans[taskID][older]; prev != nil {
+				// 舊連線不再接收事件，由其 Handler 送出 superseded 後斷線並 Unsubscribe
+				delete(b.clientChans[taskID], older)
+				close(prev.superseded)
+			}
+		}
+		b.synced[syncKey] = ch
+	}
+	return ch, sub.superseded
 }
 
 // Unsubscribe 從記憶體中註銷並關閉 Channel，確保資源回收。
@@ -86,6 +118,9 @@ func (b *Broadcaster) Unsubscribe(taskID string, ch chan string) {
 	defer b.mu.Unlock()
 
 	if listeners, ok := b.clientChans[taskID]; ok {
+		if sub := listeners[ch]; sub != nil && sub.syncKey != "" && b.synced[sub.syncKey] == ch {
+			delete(b.synced, sub.syncKey)
+		}
 		delete(listeners, ch)
 		if len(listeners) == 0 {
 			delete(b.clientChans, taskID) // 避免 memory leak
@@ -7,6 +7,10 @@ import (
 	"log"
 	"net/http"
 
+	"stt-gateway/internal/admin"
+	"stt-gateway/internal/audit"
+	"stt-gateway/internal/keys"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -14,12 +18,16 @@ import (
 //
 // 連線流程（加入 Multiplexer 防止連接數飆高）：
 //  1. 註冊 Gateway 在記憶體內的 Broadcaster，不再對 Redis 開實體連線
-//  2. 讀取 summary buffer，恢復已產生的摘要內容
-//  3. 持續讀取 Broadcaster 派發的事件並寫入 SSE
+//  2. 帶 Last-Event-ID 重連時從 Redis Stream 補送遺失事件；否則讀取 buffer 恢復已產生的內容
+//  3. 持續讀取 Broadcaster 派發的事件並寫入 SSE（以 eventId 作為 SSE id，略過已補送的事件）
 //  4. 客戶端斷線時向 Broadcaster 註銷，釋放 Channel
 type Handler struct {
 	Redis       *redis.Client
 	Broadcaster *Broadcaster
+	// AdminToken 非空時，帶相同 X-Admin-Token 的請求略過 ownership 檢查並接收 debug 事件。
+	AdminToken string
+	// Dedup 啟用時，同一使用者以相同 ?syncKey= 重複開啟同一任務的連線，較舊者收到 superseded 事件後關閉。
+	Dedup bool
 }
 
 // NewHandler 建立 SSE Handler 實例。
@@ -39,25 +47,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 驗證 task ownership：比對 Redis 中的 task:owner:{taskId} 與 X-User-Id
-	userID := r.Header.Get("X-User-Id")
-	if userID == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	ownerKey := fmt.Sprintf("task:owner:%s", taskID)
-	owner, err := h.Redis.Get(r.Context(), ownerKey).Result()
-	if err == redis.Nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
-		return
-	} else if err != nil {
-		log.Printf("SSE: failed to verify task ownership: %v", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
-		return
-	}
-	if owner != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+	// Admin（支援人員）可觀看任何任務並接收 debug 事件；一般用戶僅能觀看自己的任務
+	isAdmin := admin.IsAdmin(r, h.AdminToken)
+	if !isAdmin && !h.authorizeOwner(w, r, taskID) {
 		return
 	}
 
@@ -76,30 +68,32 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Step 1: 註冊記憶體 Channel，防止 buffer 讀取與新事件之間的 race condition
-	msgCh := h.Broadcaster.Subscribe(taskID)
+	msgCh, superseded := h.Broadcaster.SubscribeSynced(taskID, h.syncKey(r, taskID, isAdmin))
 	defer h.Broadcaster.Unsubscribe(taskID, msgCh)
 
-	// Step 2: 讀取 buffer，恢復 SSE 重連時遺失的內容
-	// 2a. 轉譯內容恢復
-	transBufferKey := fmt.Sprintf("transcript:buffer:%s", taskID)
-	if tBuf, err := h.Redis.Get(ctx, transBufferKey).Result(); err == nil && tBuf != "" {
-		event := map[string]string{
-			"type":    "transcript_update",
-			"content": tBuf,
+	// Step 2: 恢復 SSE 重連時遺失的內容
+	// 2a. 瀏覽器自動重連會帶 Last-Event-ID，從 Stream 精確補送之後的事件
+	lastSent := ""
+	replayed := false
+	if lastID := r.Header.Get("Last-Event-ID"); validStreamID(lastID) {
+		if events, ok := replayEvents(ctx, h.Redis, taskID, lastID); ok {
+			for _, ev := range events {
+				if !isAdmin && eventTypeOf(ev.Data) == "debug" {
+					lastSent = ev.ID
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\ndata: %s\n\n", ev.ID, ev.Data)
+				lastSent = ev.ID
+			}
+			replayed = true
+			if lastSent == "" {
+				lastSent = lastID
+			}
 		}
-		data, _ := json.Marshal(event)
-		fmt.Fprintf(w, "data: %s\n\n", data)
 	}
 
-	// 2b. 摘要內容恢復
-	summaryBufferKey := fmt.Sprintf("summary:buffer:%s", taskID)
-	if sBuf, err := h.Redis.Get(ctx, summaryBufferKey).Result(); err == nil && sBuf != "" {
-		event := map[string]string{
-			"type":    "summary_chunk",
-			"content": sBuf,
-		}
-		data, _ := json.Marshal(event)
-		fmt.Fprintf(w, "data: %s\n\n", data)
+	if !replayed {
+		h.writeBuffers(ctx, w, taskID)
 	}
 	flusher.Flush()
 
@@ -110,12 +104,103 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			fmt.Fprintf(w, "data: %s\n\n", msgPayload)
+			// debug 事件含 Worker 內部 log，僅轉送給 admin
+			if !isAdmin && eventTypeOf(msgPayload) == "debug" {
+				continue
+			}
+			id := eventIDOf(msgPayload)
+			if id == "" {
+				fmt.Fprintf(w, "data: %s\n\n", msgPayload)
+			} else {
+				// 補送期間同時抵達的即時事件可能已送過
+				if lastSent != "" && compareStreamID(id, lastSent) <= 0 {
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\ndata: %s\n\n", id, msgPayload)
+				lastSent = id
+			}
 			flusher.Flush()
 
+		case <-superseded:
+			// 同一客戶端已開啟較新的連線；客戶端收到後應關閉 EventSource，不再自動重連
+			fmt.Fprintf(w, "data: {\"type\":\"superseded\",\"message\":\"此任務已於其他分頁開啟\"}\n\n")
+			flusher.Flush()
+			log.Printf("SSE: stream for task %s superseded by a newer connection", taskID)
+			return
+
 		case <-ctx.Done():
 			log.Printf("SSE: client disconnected for task %s", taskID)
 			return
 		}
 	}
 }
+
+// syncKey 組合重複連線收斂用的 key；未啟用 Dedup 或客戶端未帶 ?syncKey= 時返回空字串。
+func (h *Handler) syncKey(r *http.Request, taskID string, isAdmin bool) string {
+	clientKey := r.URL.Query().Get("syncKey")
+	if !h.Dedup || clientKey == "" || len(clientKey) > 128 {
+		return ""
+	}
+	user := r.Header.Get("X-User-Id")
+	if isAdmin {
+		user = "admin:" + user
+	}
+	return user + "\x00" + taskID + "\x00" + clientKey
+}
+
+// authorizeOwner 比對 Redis 中的 keys.TaskOwner 與 X-User-Id，失敗時寫入錯誤回應並返回 false。
+func (h *Handler) authorizeOwner(w http.ResponseWriter, r *http.Request, taskID string) bool {
+	userID := r.Header.Get("X-User-Id")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	ownerKey := keys.TaskOwner(taskID)
+	owner, err := h.Redis.Get(r.Context(), ownerKey).Result()
+	if err == redis.Nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return false
+	} else if err != nil {
+		log.Printf("SSE: failed to verify task ownership: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return false
+	}
+	if owner != userID {
+		audit.Record(audit.Event{
+			Action:   "task.ownership_check",
+			Outcome:  audit.OutcomeDenied,
+			Actor:    userID,
+			Target:   taskID,
+			RemoteIP: audit.RemoteIP(r),
+		})
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// writeBuffers 讀取 transcript / summary buffer，恢復首次連線或無法補送時已產生的內容。
+func (h *Handler) writeBuffers(ctx context.Context, w http.ResponseWriter, taskID string) {
+	// 轉譯內容恢復
+	transBufferKey := keys.TranscriptBuffer(taskID)
+	if tBuf, err := h.Redis.Get(ctx, transBufferKey).Result(); err == nil && tBuf != "" {
+		event := map[string]string{
+			"type":    "transcript_update",
+			"content": tBuf,
+		}
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+
+	// 摘要內容恢復
+	summaryBufferKey := keys.SummaryBuffer(taskID)
+	if sBuf, err := h.Redis.Get(ctx, summaryBufferKey).Result(); err == nil && sBuf != "" {
+		event := map[string]string{
+			"type":    "summary_chunk",
+			"content": sBuf,
+		}
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+}
@@ -0,0 +1,109 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"stt-gateway/internal/keys"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streamEvent 從 Worker 事件 Stream（keys.Events）讀出的單筆事件。
+type streamEvent struct {
+	ID   string
+	Data string
+}
+
+// replayEvents 讀取 lastID 之後的所有事件。
+// 第二個回傳值為 false 表示無法保證完整補送（Stream 不存在，或 lastID 之後的事件已被 MAXLEN 裁掉），
+// 呼叫端應退回 buffer 恢復機制。
+func replayEvents(ctx context.Context, rdb *redis.Client, taskID, lastID string) ([]streamEvent, bool) {
+	key := keys.Events(taskID)
+
+	first, err := rdb.XRangeN(ctx, key, "-", "+", 1).Result()
+	if err != nil || len(first) == 0 {
+		return nil, false
+	}
+	// Stream 最舊的事件比 lastID 新，中間有事件已被裁掉
+	if compareStreamID(first[0].ID, lastID) > 0 {
+		return nil, false
+	}
+
+	msgs, err := rdb.XRange(ctx, key, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, false
+	}
+	events := make([]streamEvent, 0, len(msgs))
+	for _, m := range msgs {
+		data, _ := m.Values["data"].(string)
+		events = append(events, streamEvent{ID: m.ID, Data: withEventID(data, m.ID)})
+	}
+	return events, true
+}
+
+// withEventID 將 Stream entry ID 寫回事件 JSON 的 eventId 欄位，與即時推送的格式一致。
+func withEventID(data, id string) string {
+	var event map[string]any
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return data
+	}
+	event["eventId"] = id
+	b, _ := json.Marshal(event)
+	return string(b)
+}
+
+// eventIDOf 取出 Pub/Sub 訊息中的 eventId，沒有時返回空字串。
+func eventIDOf(payload string) string {
+	var event struct {
+		EventID string `json:"eventId"`
+	}
+	_ = json.Unmarshal([]byte(payload), &event)
+	return event.EventID
+}
+
+// eventTypeOf 取出事件 JSON 的 type 欄位。
+func eventTypeOf(payload string) string {
+	var event struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal([]byte(payload), &event)
+	return event.Type
+}
+
+// validStreamID 檢查字串是否為 "<ms>-<seq>" 格式的 Stream ID。
+func validStreamID(id string) bool {
+	_, _, ok := parseStreamID(id)
+	return ok
+}
+
+func parseStreamID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err1 := strconv.ParseUint(msPart, 10, 64)
+	seq, err2 := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq, err1 == nil && err2 == nil
+}
+
+// compareStreamID 比較兩個 Stream ID，a<b 返回 -1，相等 0，a>b 返回 1。
+func compareStreamID(a, b string) int {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
@@ -2,30 +2,62 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 	"tts-worker/internal/ai"
+	"tts-worker/internal/audio"
+	"tts-worker/internal/audit"
+	"tts-worker/internal/config"
 	"tts-worker/internal/db"
+	"tts-worker/internal/diagnostics"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/queue"
 	rdb_lib "tts-worker/internal/redis"
+	"tts-worker/internal/repair"
+	"tts-worker/internal/webhook"
 	"tts-worker/internal/worker"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 // main 啟動 Worker 服務。
 // 啟動順序：PostgreSQL → Redis → AI Service → STT consumer / Summary consumer / Reaper goroutines。
-// DB/Redis 不可達時以 Fatal 終止（由 Docker restart 策略重啟）。
+// DB/Redis 尚未就緒時以指數退避重試（STARTUP_MAX_ATTEMPTS），超過上限才以 Fatal 終止（由 Docker restart 策略重啟）。
+//
+// 子指令：
+//   - `worker repair [-dry-run] [-uploads DIR]` 掃描並修復不一致狀態後結束。
+//   - `worker redrive-dlq` 將 SQS DLQ 訊息移回原佇列（BROKER=sqs）。
 func main() {
 	godotenv.Load(".env")
 
+	// 環境前綴：多個環境共用 Redis / broker 時隔離 key、channel 與佇列名稱
+	keys.SetPrefix(config.String("ENV_PREFIX", ""))
+
+	// Worker instance ID：每行 log 以此為前綴，並記錄於 tasks.worker_id 與 Redis registry
+	instanceID := config.String("WORKER_ID", worker.NewInstanceID())
+	log.SetPrefix("[" + instanceID + "] ")
+
+	// 安全稽核事件匯出至 SIEM（syslog / HTTP collector），未設定 AUDIT_SINK 時停用
+	if err := audit.Configure("worker", os.Getenv("AUDIT_SINK"), os.Getenv("AUDIT_SINK_TOKEN")); err != nil {
+		log.Fatal(err)
+	}
+
 	// 建立 PostgreSQL 連線並驗證
 	postgres, err := db.Connect()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := postgres.Ping(); err != nil {
+	if err := waitForDependency("database", postgres.Ping); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	log.Println("Database connected")
@@ -36,68 +68,589 @@ func main() {
 	}
 
 	// 建立 Redis 連線
-	rdb := rdb_lib.Connect()
+	rdb, err := rdb_lib.Connect()
+	if err != nil {
+		log.Fatal("Failed to configure Redis:", err)
+	}
+	if err := waitForDependency("redis", func() error { return rdb.Ping(context.Background()).Err() }); err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+	log.Println("Redis connected")
+	rdb_lib.ConfigureEventStream(int64(config.Int("EVENT_STREAM_MAXLEN", 1000)), config.Duration("EVENT_STREAM_TTL", time.Hour))
 
-	// 根據環境變數選擇 AI 服務實作（Mock / Standard）
-	var sttSvc ai.STTService
-	var llmSvc ai.Summarizer
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "repair":
+			runRepair(postgres, rdb, os.Args[2:])
+			return
+		case "redrive-dlq":
+			runRedriveDLQ(rdb)
+			return
+		}
+	}
+
+	// AI provider 共用的 HTTP client：連線池與各階段 timeout，代理伺服器取自 HTTPS_PROXY / NO_PROXY
+	defHTTP := ai.DefaultHTTPClientConfig()
+	httpCfg := ai.HTTPClientConfig{
+		DialTimeout:           config.Duration("AI_HTTP_DIAL_TIMEOUT", defHTTP.DialTimeout),
+		TLSHandshakeTimeout:   config.Duration("AI_HTTP_TLS_TIMEOUT", defHTTP.TLSHandshakeTimeout),
+		ResponseHeaderTimeout: config.Duration("AI_HTTP_RESPONSE_HEADER_TIMEOUT", defHTTP.ResponseHeaderTimeout),
+		IdleConnTimeout:       config.Duration("AI_HTTP_IDLE_CONN_TIMEOUT", defHTTP.IdleConnTimeout),
+		MaxIdleConnsPerHost:   config.Int("AI_HTTP_MAX_IDLE_CONNS_PER_HOST", defHTTP.MaxIdleConnsPerHost),
+		MaxConnsPerHost:       config.Int("AI_HTTP_MAX_CONNS_PER_HOST", 0),
+	}
+	if httpCfg.MaxIdleConnsPerHost < 0 || httpCfg.MaxConnsPerHost < 0 {
+		log.Fatalf("AI_HTTP_MAX_IDLE_CONNS_PER_HOST and AI_HTTP_MAX_CONNS_PER_HOST must be >= 0")
+	}
+	// VCR：record 將 provider 的 HTTP 互動錄至 AI_VCR_DIR，replay 只重播錄製內容（不需 API key 與費用）
+	httpClient, err := ai.WrapVCR(ai.NewHTTPClient(httpCfg), os.Getenv("AI_VCR_MODE"), config.String("AI_VCR_DIR", "testdata/vcr"))
+	if err != nil {
+		log.Fatalf("Invalid AI_VCR_MODE configuration: %v", err)
+	}
+	ai.SetHTTPClient(httpClient)
+	// 多把 API key（逗號分隔）輪流使用，遇到 429 / 402 的 key 暫停此時間（回應帶 Retry-After 時以其為準）
+	ai.SetKeyCooldown(config.Duration("AI_KEY_COOLDOWN", ai.DefaultKeyCooldown))
 
+	// 依 AI_STT_PROVIDER / AI_LLM_PROVIDER 由 ai.Registry 建立 provider（MOCK=true 時兩者皆為 mock）；
+	// 各 provider 讀取自己的環境變數，新增 provider 不需修改此處
+	sttProvider := config.String("AI_STT_PROVIDER", "openai")
+	llmProvider := config.String("AI_LLM_PROVIDER", "openai")
 	if os.Getenv("MOCK") == "true" {
+		sttProvider, llmProvider = "mock", "mock"
+	}
+	sttSvc, err := ai.NewSTT(context.Background(), sttProvider, os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid AI_STT_PROVIDER configuration: %v", err)
+	}
+	llmSvc, err := ai.NewLLM(context.Background(), llmProvider, os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid AI_LLM_PROVIDER configuration: %v", err)
+	}
+	log.Printf("AI Services enabled (STT: %s, LLM: %s)", sttProvider, llmProvider)
+
+	broker := newBroker(rdb)
+	defer broker.Close()
+
+	w := worker.NewWorker(postgres, rdb, broker, sttSvc, llmSvc)
+	w.SetInstanceID(instanceID)
+
+	// Benchmark 任務：同一音檔交給多個 STT provider 轉錄並比較 WER / 耗時
+	w.SetBenchmarkProviders(benchmarkProviders(sttSvc))
+
+	// 各階段 deadline（任務 payload 的 config.timeouts 只能縮短，不能超過此上限）
+	defaults := worker.DefaultDeadlines()
+	w.SetDeadlines(worker.Deadlines{
+		Chunking: config.Duration("DEADLINE_CHUNKING", defaults.Chunking),
+		STT:      config.Duration("DEADLINE_STT", defaults.STT),
+		STTChunk: config.Duration("DEADLINE_STT_CHUNK", defaults.STTChunk),
+		Summary:  config.Duration("DEADLINE_SUMMARY", defaults.Summary),
+	})
+
+	// 摘要重試與降級：SUMMARY_TRANSCRIPT_ONLY=true 時 LLM 失敗不再讓整個任務 failed
+	w.SetSummaryPolicy(worker.SummaryPolicy{
+		MaxAttempts:    config.Int("SUMMARY_MAX_ATTEMPTS", 3),
+		Backoff:        config.Duration("SUMMARY_RETRY_BACKOFF", 2*time.Second),
+		TranscriptOnly: config.Bool("SUMMARY_TRANSCRIPT_ONLY", false),
+	})
+
+	// 多語言錄音：逐段偵測語言，並可依語言改用專用 STT 模型
+	w.SetLanguageRouting(worker.LanguageRouting{
+		Detect: config.Bool("STT_DETECT_LANGUAGE", false),
+		Models: worker.ParseLanguageModels(os.Getenv("STT_LANGUAGE_MODELS")),
+	})
+
+	// 逐段 / 逐字時間戳（字幕、章節、點擊跳轉）
+	w.SetTimestamps(config.Bool("STT_TIMESTAMPS", false))
+
+	// chunk 接縫前文提示：以前一段轉錄稿結尾作為下一段的 STT prompt
+	w.SetContextPriming(worker.ContextPriming{
+		Enabled:   config.Bool("STT_CONTEXT_PRIMING", false),
+		TailChars: config.Int("STT_PRIMING_CHARS", worker.DefaultContextPriming().TailChars),
+		MaxWait:   config.Duration("STT_PRIMING_MAX_WAIT", 0),
+	})
+
+	// 摘要驗證：拒答 / transcript 未提及的內容 → 重新生成，仍不通過則標記待審核
+	w.SetGuardrailPolicy(worker.GuardrailPolicy{
+		Enabled:        config.Bool("SUMMARY_GUARDRAILS", true),
+		MaxUnsupported: config.Int("SUMMARY_MAX_UNSUPPORTED", 3),
+		SelfCheck:      config.Bool("SUMMARY_SELF_CHECK", false),
+		MaxRetries:     config.Int("SUMMARY_GUARDRAIL_RETRIES", 1),
+	})
+
+	// 使用者公平性：每位使用者同時處理中的任務上限，超過時延後重新投遞
+	w.SetFairnessPolicy(worker.FairnessPolicy{
+		MaxPerUser: config.Int("USER_MAX_CONCURRENT_TASKS", 0),
+		Lease:      config.Duration("USER_SLOT_LEASE", 5*time.Minute),
+		Defer:      config.Duration("USER_SLOT_DEFER", 15*time.Second),
+	})
+
+	// STT 完成後以 LLM 產生任務標題
+	w.SetAutoTitle(config.Bool("AUTO_TITLE", true))
+
+	// 保留原始音檔供 highlight reel 剪輯（需額外磁碟空間）
+	w.SetKeepSourceAudio(config.Bool("KEEP_SOURCE_AUDIO", false))
+
+	// 暫時性錯誤的延遲重試（需 Redis 或 SQS backend）
+	w.SetRetryPolicy(worker.RetryPolicy{
+		MaxRetries: config.Int("RETRY_MAX", 2),
+		BaseDelay:  config.Duration("RETRY_BASE_DELAY", 30*time.Second),
+		MaxDelay:   config.Duration("RETRY_MAX_DELAY", 10*time.Minute),
+	})
+
+	// chunk 重疊區段合併：模糊比對容忍 "we'll" / "we will" 等轉錄差異
+	defMerge := worker.DefaultMergeStrategy()
+	w.SetMergeStrategy(worker.MergeStrategy{
+		MaxWindow: config.Int("TRANSCRIPT_MERGE_WINDOW", defMerge.MaxWindow),
+		Fuzzy:     config.Bool("TRANSCRIPT_MERGE_FUZZY", defMerge.Fuzzy),
+		Threshold: config.Float("TRANSCRIPT_MERGE_THRESHOLD", defMerge.Threshold),
+	})
+
+	// 音檔切片參數；任務可於 payload config.chunking 覆寫，但不超過 *_LIMIT。
+	// 分片長度另依 CHUNK_MAX_BYTES（provider 單檔上限）與分片編碼的位元率縮短
+	defChunking := worker.DefaultChunkingPolicy()
+	encoding, err := audio.ParseChunkEncoding(config.String("CHUNK_ENCODING", "wav"))
+	if err != nil {
+		log.Fatalf("Invalid CHUNK_ENCODING: %v", err)
+	}
+	w.SetChunkingPolicy(worker.ChunkingPolicy{
+		Default: audio.SplitOptions{
+			MaxChunkDuration: config.Float("CHUNK_MAX_SEC", defChunking.Default.MaxChunkDuration),
+			Overlap:          config.Float("CHUNK_OVERLAP_SEC", defChunking.Default.Overlap),
+			NoSplitBytes:     int64(config.Int("CHUNK_NO_SPLIT_BYTES", int(defChunking.Default.NoSplitBytes))),
+			MaxChunkBytes:    int64(config.Int("CHUNK_MAX_BYTES", int(defChunking.Default.MaxChunkBytes))),
+			Encoding:         encoding,
+			VAD:              splitVAD(),
+		},
+		MaxChunkDuration: config.Float("CHUNK_MAX_SEC_LIMIT", defChunking.MaxChunkDuration),
+		MaxNoSplitBytes:  int64(config.Int("CHUNK_NO_SPLIT_BYTES_LIMIT", int(defChunking.MaxNoSplitBytes))),
+	})
+
+	// 切片前響度正規化（EBU R128），任務可於 config.loudnorm 覆寫
+	w.SetLoudnorm(config.Bool("LOUDNORM", false))
+	// 切片前音訊清理（highpass / afftdn），任務可於 config.cleanup 覆寫
+	cleanupPreset := config.String("AUDIO_CLEANUP", "off")
+	if _, ok := audio.CleanupPresets[cleanupPreset]; !ok && cleanupPreset != "off" {
+		log.Fatalf("Unknown AUDIO_CLEANUP %q (off, highpass, denoise, phone)", cleanupPreset)
+	}
+	w.SetAudioCleanup(cleanupPreset)
+	// 切片前以 atempo 加速（保留音高），降低按音訊分鐘計費的 STT 成本；1 表示不加速
+	speed := config.Float("STT_SPEED_FACTOR", 1)
+	if speed < audio.MinSpeedFactor || speed > audio.MaxSpeedFactor {
+		log.Fatalf("STT_SPEED_FACTOR must be between %.1f and %.1f, got %g", audio.MinSpeedFactor, audio.MaxSpeedFactor, speed)
+	}
+	w.SetSpeedUp(speed)
+
+	// 切片前裁掉頭尾靜音；語音比例低於 MIN_SPEECH_RATIO（0~1）的錄音不送 STT，以 ERR_NO_SPEECH 失敗
+	minSpeech := config.Float("MIN_SPEECH_RATIO", 0)
+	if minSpeech < 0 || minSpeech > 1 {
+		log.Fatalf("MIN_SPEECH_RATIO must be between 0 and 1, got %g", minSpeech)
+	}
+	w.SetSpeechPolicy(worker.SpeechPolicy{Trim: config.Bool("SILENCE_TRIM", false), MinCoverage: minSpeech})
+
+	// 相同音訊（解碼後內容 hash）重複上傳時沿用既有轉錄稿與摘要：off / user / global
+	dedup := config.String("STT_DEDUP", worker.DedupOff)
+	switch dedup {
+	case worker.DedupOff, worker.DedupUser, worker.DedupGlobal:
+	default:
+		log.Fatalf("Unknown STT_DEDUP %q (off, user, global)", dedup)
+	}
+	w.SetDedupScope(dedup)
+
+	// 分片與前處理暫存檔的根目錄（例如 tmpfs）；未設定時寫在上傳音檔旁
+	w.SetScratchDir(config.String("SCRATCH_DIR", ""))
+	// 遠端音檔（payload sourceUrl）的下載：逾時、續傳次數，以及是否允許內網位址
+	defSource := worker.DefaultSourceDownload()
+	w.SetSourceDownload(worker.SourceDownload{
+		Timeout:      config.Duration("SOURCE_DOWNLOAD_TIMEOUT", defSource.Timeout),
+		MaxAttempts:  config.Int("SOURCE_DOWNLOAD_ATTEMPTS", defSource.MaxAttempts),
+		AllowPrivate: config.Bool("SOURCE_ALLOW_PRIVATE", false),
+	})
+	// 單次 ffmpeg / ffprobe 執行的硬性上限，卡住的行程連同子行程一併終止
+	audio.SetCommandTimeout(config.Duration("FFMPEG_TIMEOUT", 30*time.Minute))
+
+	// 雙聲道通話錄音分聲道轉錄並標記講者，任務可於 config.stereoSplit 覆寫
+	stereo := worker.StereoSplit{Enabled: config.Bool("STT_STEREO_SPLIT", false)}
+	if labels := strings.Split(config.String("STT_CHANNEL_LABELS", ""), ","); len(labels) == 2 {
+		stereo.Labels = [2]string{strings.TrimSpace(labels[0]), strings.TrimSpace(labels[1])}
+	}
+	w.SetStereoSplit(stereo)
+
+	// 輸入政策：與 Gateway 共用 INPUT_* 設定，切片前驗證、切片後檢查分片數
+	w.SetInputPolicy(audio.InputPolicy{
+		Formats:     audio.ParseList(os.Getenv("INPUT_FORMATS")),
+		Codecs:      audio.ParseList(os.Getenv("INPUT_CODECS")),
+		MaxDuration: config.Duration("INPUT_MAX_DURATION", 0),
+		MaxBytes:    int64(config.Int("INPUT_MAX_BYTES", 0)),
+		MaxChunks:   config.Int("INPUT_MAX_CHUNKS", 0),
+	})
+
+	// 摘要提示模板：部署基礎 system 指示與 user message 模板（@ 開頭為檔案，DB prompt_templates 優先）
+	w.SetPromptTemplates(worker.PromptTemplates{
+		System: os.Getenv("SUMMARY_SYSTEM_PROMPT"),
+		User:   os.Getenv("SUMMARY_USER_TEMPLATE"),
+	})
+
+	// 摘要 system message 附加任務可選用的人設（僅限清單內）
+	personas, err := worker.ParsePersonas(os.Getenv("SUMMARY_PERSONAS"))
+	if err != nil {
+		log.Fatalf("Invalid SUMMARY_PERSONAS: %v", err)
+	}
+	w.SetPersonaPolicy(worker.PersonaPolicy{Personas: personas})
+
+	// 摘要輸出語言（與轉錄稿語言無關）；任務可以 config.summaryLanguage 覆寫
+	w.SetSummaryLanguage(os.Getenv("SUMMARY_LANGUAGE"))
+
+	// 摘要取樣參數（temperature、top_p 等）；任務可以 config.generation 逐項覆寫
+	generation, err := ai.ParseGenerationParams(os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid AI_LLM_* generation parameters: %v", err)
+	}
+	w.SetGenerationParams(generation)
+
+	// 內容審核：轉錄稿與摘要交付前送審，未通過的任務改為 flagged
+	moderator, err := ai.NewModerator(os.Getenv("MODERATION_PROVIDER"), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid MODERATION_PROVIDER configuration: %v", err)
+	}
+	w.SetModerationPolicy(worker.ModerationPolicy{
+		Moderator:  moderator,
+		FailClosed: config.Bool("MODERATION_FAIL_CLOSED", false),
+	})
+
+	// 轉錄稿 embeddings：STT 完成後切段產生向量寫入 transcript_embeddings（需 pgvector），供語意搜尋與問答
+	embedder, err := ai.NewEmbedder(os.Getenv("EMBEDDING_PROVIDER"), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid EMBEDDING_PROVIDER configuration: %v", err)
+	}
+	embeddings := worker.DefaultEmbeddingPolicy()
+	embeddings.Embedder = embedder
+	embeddings.ChunkRunes = config.Int("EMBEDDING_CHUNK_CHARS", embeddings.ChunkRunes)
+	embeddings.BatchSize = config.Int("EMBEDDING_BATCH_SIZE", embeddings.BatchSize)
+	if embeddings.ChunkRunes < 1 || embeddings.BatchSize < 1 {
+		log.Fatalf("Invalid EMBEDDING_CHUNK_CHARS / EMBEDDING_BATCH_SIZE: %d / %d (must be >= 1)", embeddings.ChunkRunes, embeddings.BatchSize)
+	}
+	w.SetEmbeddingPolicy(embeddings)
+
+	// 結構化摘要：串流摘要完成後另以 JSON schema 生成 {title, tl_dr, key_points, action_items, participants}
+	w.SetStructuredSummary(config.Bool("SUMMARY_STRUCTURED", false))
+
+	// 轉錄稿數字 / 日期正規化（口語數字轉阿拉伯數字）
+	w.SetTranscriptNormalization(config.Bool("TRANSCRIPT_NORMALIZE", true))
+
+	// 取消後等待任務停止的上限，逾時強制標記 failed
+	w.SetCancelDeadline(config.Duration("CANCEL_DEADLINE", time.Minute))
+
+	// Debug log tail：開啟後，被 admin 標記（debug:task:{id}）的任務 log 會以 debug 事件推送至 SSE
+	w.SetDebugLogStreaming(config.Bool("DEBUG_LOG_STREAMING", false))
+
+	// Webhook：任務終態以 HMAC 簽章通知 callback URL
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		w.SetWebhooks(webhook.NewDispatcher(postgres, webhook.Config{
+			Secret:      secret,
+			MaxAttempts: config.Int("WEBHOOK_MAX_ATTEMPTS", 5),
+			BaseBackoff: config.Duration("WEBHOOK_BACKOFF_BASE", 2*time.Second),
+			MaxBackoff:  config.Duration("WEBHOOK_BACKOFF_MAX", 5*time.Minute),
+			Timeout:     config.Duration("WEBHOOK_TIMEOUT", 10*time.Second),
+			// 預設拒絕投遞至 loopback / 內網位址（SSRF）；內部整合可設定 WEBHOOK_ALLOW_PRIVATE=true
+			AllowPrivate: config.Bool("WEBHOOK_ALLOW_PRIVATE", false),
+		}))
+		log.Println("Webhook notifications enabled")
+	}
+
+	// Canary 任務可指定使用 Mock provider，只驗證 pipeline 本身而不產生 AI 費用
+	if config.String("CANARY_PROVIDER", "mock") == "mock" {
 		mock := &ai.MockAIService{}
-		sttSvc = mock
-		llmSvc = mock
-		log.Println("Mock AI Services enabled")
-	} else {
-		sttURL := os.Getenv("AI_STT_URL")
-		llmURL := os.Getenv("AI_LLM_URL")
-		sttModel := os.Getenv("AI_STT_MODEL")
-		llmModel := os.Getenv("AI_LLM_MODEL")
-		sttKey := os.Getenv("AI_STT_KEY")
-		llmKey := os.Getenv("AI_LLM_KEY")
-		llmPrompt := os.Getenv("AI_LLM_PROMPT")
-
-		if sttURL == "" || llmURL == "" || sttModel == "" || llmModel == "" {
-			log.Fatal("Necessary AI configurations missing: AI_STT_URL/MODEL and AI_LLM_URL/MODEL must be provided")
-		}
+		w.SetCanaryProviders(mock, mock)
+	}
+
+	// 收到 SIGINT / SIGTERM 先 drain，背景服務（ctx）待進行中任務結束後才停止
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Prometheus 指標：/metrics 端點 + 滾動失敗率計算
+	metrics.Failures.Configure(metrics.FailureAlertConfig{
+		Threshold5m: config.Float("ALERT_FAILURE_RATE_5M", 0.25),
+		Threshold1h: config.Float("ALERT_FAILURE_RATE_1H", 0.10),
+		MinSamples:  config.Int("ALERT_FAILURE_MIN_SAMPLES", 5),
+	})
+	go metrics.Failures.Run(ctx)
+	go metrics.Serve(config.String("METRICS_ADDR", ":9091"))
 
-		provider := &ai.StandardAIProvider{
-			STTApiKey: sttKey,
-			STTURL:    sttURL,
-			STTModel:  sttModel,
-			LLMApiKey: llmKey,
-			LLMURL:    llmURL,
-			LLMModel:  llmModel,
-			LLMPrompt: llmPrompt,
+	// pprof、執行期快照與 POST /drain：只綁定內部位址，供排查 goroutine 洩漏 / ffmpeg 殭屍行程與 preStop hook
+	diagnostics.Handle("POST /drain", w.DrainHandler())
+	go diagnostics.Serve(config.String("DIAGNOSTICS_ADDR", ""), w.InFlightTasks)
+
+	// Provider 設定熱更新：輪詢設定檔或 Redis key，輪換 API key / 切換模型不需重啟
+	var reloadable []*ai.ReloadableProvider
+	for _, svc := range []any{sttSvc, llmSvc} {
+		if r, ok := svc.(*ai.ReloadableProvider); ok {
+			reloadable = append(reloadable, r)
+		}
+	}
+	if len(reloadable) > 0 {
+		if source := providerConfigSource(rdb); source != nil {
+			for _, r := range reloadable {
+				go r.WatchConfig(ctx, source, config.Duration("AI_CONFIG_POLL_INTERVAL", 30*time.Second))
+			}
 		}
-		sttSvc = provider
-		llmSvc = provider
-		log.Println("Standard AI Services enabled (STT + LLM)")
 	}
 
-	w := worker.NewWorker(postgres, rdb, sttSvc, llmSvc)
+	// 佇列積壓量：Prometheus gauge + Redis key，供 autoscaler 依 backlog 擴縮
+	go w.PublishQueueDepth(ctx, config.Duration("QUEUE_DEPTH_INTERVAL", 15*time.Second),
+		keys.Queue(queue.STT), keys.Queue(queue.Summary), keys.Queue(queue.Highlight))
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	// 自適應 STT chunk 並發：依記憶體使用率與 provider 延遲 / 錯誤率調整（false 時每任務固定 2）
+	if config.Bool("STT_ADAPTIVE_CONCURRENCY", true) {
+		def := worker.DefaultConcurrencyConfig()
+		limiter := worker.NewConcurrencyLimiter(worker.ConcurrencyConfig{
+			Min:             config.Int("STT_CONCURRENCY_MIN", def.Min),
+			Initial:         config.Int("STT_CONCURRENCY_INITIAL", def.Initial),
+			Max:             config.Int("STT_CONCURRENCY_MAX", def.Max),
+			Interval:        config.Duration("STT_CONCURRENCY_INTERVAL", def.Interval),
+			MemoryHighWater: config.Float("STT_MEMORY_HIGH_WATER", def.MemoryHighWater),
+			MemoryLimit:     uint64(config.Int("STT_MEMORY_LIMIT_MB", 0)) << 20,
+			MaxErrorRate:    config.Float("STT_MAX_ERROR_RATE", def.MaxErrorRate),
+			LatencyFactor:   config.Float("STT_LATENCY_FACTOR", def.LatencyFactor),
+		})
+		w.SetConcurrencyLimiter(limiter)
+		go limiter.Run(ctx)
+	}
+
+	// Worker registry：heartbeat 與進行中任務寫入 Redis，供 Gateway admin API 查詢
+	go w.RunRegistry(ctx, config.Duration("WORKER_HEARTBEAT_INTERVAL", 10*time.Second))
 
 	// 取消信號監聽（自帶重訂閱機制）
 	go w.StartCancellationListener(ctx)
 
+	// Drain 信號：Redis channel 或 POST /drain（診斷埠），停止消費並交接給其他 instance
+	consumeCtx := w.ConsumeContext(ctx)
+	go w.ListenDrain(ctx)
+
 	// STT queue consumer
-	go w.ConsumeSTTQueue(ctx)
+	go w.ConsumeSTTQueue(consumeCtx)
 
 	// Summary queue consumer
-	go w.ConsumeSummaryQueue(ctx)
+	go w.ConsumeSummaryQueue(consumeCtx)
+
+	// Highlight reel consumer
+	go w.ConsumeHighlightQueue(consumeCtx)
 
-	// Reaper：回收 stt:processing 超時任務
-	sttReaper := worker.NewReaper(rdb)
-	go sttReaper.Start(ctx, "stt:processing", "stt:queue")
+	// Reaper 僅適用 Redis backend（Kafka 由未 commit 的 offset、SQS 由 visibility timeout 重新投遞）
+	if rb, ok := broker.(*queue.RedisBroker); ok {
+		// 延遲重試佇列：到期訊息移回 stt:queue / summary:queue
+		go rb.RunDelayed(ctx, keys.Queue(queue.STT))
+		go rb.RunDelayed(ctx, keys.Queue(queue.Summary))
 
-	// Reaper：回收 summary:processing 超時任務
-	summaryReaper := worker.NewReaper(rdb)
-	go summaryReaper.Start(ctx, "summary:processing", "summary:queue")
+		// Reaper：回收 stt:processing 超時任務；超時依 DEADLINE_* 計算，長錄音不會在轉錄途中被重新入列
+		sttReaper := worker.NewReaper(rdb, w.STTReaperTimeout())
+		go sttReaper.Start(ctx, queue.ProcessingKey(keys.Queue(queue.STT)), keys.Queue(queue.STT))
+
+		// Reaper：回收 summary:processing 超時任務
+		summaryReaper := worker.NewReaper(rdb, w.SummaryReaperTimeout())
+		go summaryReaper.Start(ctx, queue.ProcessingKey(keys.Queue(queue.Summary)), keys.Queue(queue.Summary))
+
+		// Reaper：回收 highlight:processing 超時任務
+		highlightReaper := worker.NewReaper(rdb, 0)
+		go highlightReaper.Start(ctx, queue.ProcessingKey(keys.Queue(queue.Highlight)), keys.Queue(queue.Highlight))
+	}
+
+	// Canary：定期投遞已知音檔任務，端到端驗證 pipeline
+	if config.Bool("CANARY_ENABLED", false) {
+		canary := worker.NewCanary(w, worker.CanaryConfig{
+			Interval:         config.Duration("CANARY_INTERVAL", 15*time.Minute),
+			Timeout:          config.Duration("CANARY_TIMEOUT", 10*time.Minute),
+			AudioPath:        config.String("CANARY_AUDIO_PATH", "./canary/canary.wav"),
+			UploadDir:        config.String("CANARY_UPLOAD_DIR", "/app/uploads/canary"),
+			ExpectTranscript: os.Getenv("CANARY_EXPECT_TRANSCRIPT"),
+			ExpectSummary:    os.Getenv("CANARY_EXPECT_SUMMARY"),
+		})
+		go canary.Start(ctx)
+	}
 
 	log.Println("Worker ready (STT + Summary consumers, Reaper active)")
 
-	<-ctx.Done()
-	log.Println("Received shutdown signal, exiting...")
+	select {
+	case <-sigCtx.Done():
+		// 再次收到信號時直接結束
+		stop()
+		log.Println("Received shutdown signal, draining...")
+		w.Drain("shutdown signal")
+	case <-consumeCtx.Done():
+	}
+
+	timeout := config.Duration("SHUTDOWN_TIMEOUT", 10*time.Minute)
+	if w.WaitInFlight(timeout) {
+		log.Println("All in-flight tasks finished, exiting...")
+	} else {
+		log.Printf("Shutdown timeout (%s), exiting with in-flight tasks %v; they will be redelivered", timeout, w.InFlightTasks())
+	}
+}
+
+// benchmarkProviders 解析 BENCHMARK_STT_PROVIDERS（"name=model[@url]"，逗號分隔），URL 與 key 沿用 AI_STT_*；
+// Azure 模式下未指定 URL 時 model 為 deployment 名稱。
+// 未設定或 MOCK=true 時只包含目前的 STT provider。
+func benchmarkProviders(current ai.STTService) []worker.BenchmarkProvider {
+	spec := os.Getenv("BENCHMARK_STT_PROVIDERS")
+	if spec == "" || os.Getenv("MOCK") == "true" {
+		return []worker.BenchmarkProvider{{Name: "default", Model: os.Getenv("AI_STT_MODEL"), STT: current}}
+	}
+	var providers []worker.BenchmarkProvider
+	for _, entry := range strings.Split(spec, ",") {
+		name, target, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || target == "" {
+			log.Printf("BENCHMARK_STT_PROVIDERS: ignoring invalid entry %q", entry)
+			continue
+		}
+		model, url, _ := strings.Cut(target, "@")
+		if url == "" {
+			url = os.Getenv("AI_STT_URL")
+		}
+		if endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT"); url == "" && endpoint != "" {
+			url = ai.AzureDeploymentURL(endpoint, model, "audio/transcriptions")
+		}
+		providers = append(providers, worker.BenchmarkProvider{
+			Name:  name,
+			Model: model,
+			STT:   &ai.StandardAIProvider{STTApiKey: os.Getenv("AI_STT_KEY"), STTURL: url, STTModel: model, APIVersion: ai.AzureAPIVersion(os.Getenv)},
+		})
+	}
+	return providers
+}
+
+// providerConfigSource 依 AI_CONFIG_FILE（JSON 檔）或 AI_CONFIG_REDIS=true（keys.ProviderConfig）建立設定來源，皆未設定時返回 nil。
+// 檔案或 key 不存在視為未設定，沿用環境變數。
+func providerConfigSource(rdb *redis.Client) ai.ConfigSource {
+	if path := os.Getenv("AI_CONFIG_FILE"); path != "" {
+		log.Printf("Watching provider config file %s", path)
+		return func(ctx context.Context) ([]byte, error) {
+			b, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return b, err
+		}
+	}
+	if config.Bool("AI_CONFIG_REDIS", false) {
+		log.Printf("Watching provider config Redis key %s", keys.ProviderConfig())
+		return func(ctx context.Context) ([]byte, error) {
+			b, err := rdb.Get(ctx, keys.ProviderConfig()).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return nil, nil
+			}
+			return b, err
+		}
+	}
+	return nil
+}
+
+// splitVAD 依 VAD_BACKEND 選擇切割點偵測：silencedetect（預設，nil）或 silero（外部 ONNX runtime 程式）。
+func splitVAD() audio.VAD {
+	switch backend := config.String("VAD_BACKEND", "silencedetect"); backend {
+	case "silencedetect":
+		return nil
+	case "silero":
+		vad := audio.CommandVAD{
+			Command:    strings.Fields(config.String("SILERO_VAD_COMMAND", "python3 vad/silero_vad.py")),
+			MinSilence: config.Float("VAD_MIN_SILENCE", 0.3),
+		}
+		log.Printf("Silero VAD enabled: %v", vad.Command)
+		return vad
+	default:
+		log.Fatalf("Unknown VAD_BACKEND %q (silencedetect, silero)", backend)
+		return nil
+	}
+}
+
+// waitForDependency 以指數退避（1s 起、上限 STARTUP_BACKOFF_MAX）重試 check，
+// 避免 compose 冷啟動時 DB/Redis 尚未就緒造成 crash loop。嘗試 STARTUP_MAX_ATTEMPTS 次後返回最後一次錯誤。
+func waitForDependency(name string, check func() error) error {
+	attempts := config.Int("STARTUP_MAX_ATTEMPTS", 10)
+	maxBackoff := config.Duration("STARTUP_BACKOFF_MAX", 30*time.Second)
+	backoff := time.Second
+
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = check(); err == nil {
+			return nil
+		}
+		if i == attempts {
+			break
+		}
+		log.Printf("%s not ready (attempt %d/%d): %v, retrying in %s...", name, i, attempts, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+	return err
+}
+
+// runRepair 執行 repair 子指令，發現錯誤時以非零狀態碼結束。
+func runRepair(postgres *sql.DB, rdb *redis.Client, args []string) {
+	defer audit.Close(10 * time.Second)
+
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report inconsistencies without fixing them")
+	uploadDir := fs.String("uploads", config.String("UPLOAD_DIR", "/app/uploads"), "upload root to scan for leftover chunk dirs")
+	scratchDir := fs.String("scratch", config.String("SCRATCH_DIR", ""), "scratch root to scan for leftover task dirs")
+	fs.Parse(args)
+
+	report, err := repair.Run(context.Background(), postgres, rdb, repair.Options{
+		DryRun:     *dryRun,
+		UploadDir:  *uploadDir,
+		ScratchDir: *scratchDir,
+	})
+	report.Print(*dryRun)
+	if err != nil {
+		audit.Close(10 * time.Second)
+		log.Fatal("Repair finished with errors: ", err)
+	}
+}
+
+// newBroker 依 BROKER 環境變數建立任務佇列 backend：預設 Redis LIST，可選 kafka / sqs。
+func newBroker(rdb *redis.Client) queue.Broker {
+	switch config.String("BROKER", "redis") {
+	case "kafka":
+		broker := queue.NewKafkaBroker(queue.KafkaConfig{
+			Brokers:           strings.Split(config.String("KAFKA_BROKERS", "kafka:9092"), ","),
+			GroupID:           config.String("KAFKA_GROUP_ID", "stt-worker"),
+			Partitions:        config.Int("KAFKA_TOPIC_PARTITIONS", 0),
+			ReplicationFactor: config.Int("KAFKA_TOPIC_REPLICATION_FACTOR", 0),
+			MinInSyncReplicas: config.Int("KAFKA_TOPIC_MIN_INSYNC_REPLICAS", 0),
+			Retention:         config.Duration("KAFKA_TOPIC_RETENTION", 0),
+		})
+		if err := broker.EnsureTopics(context.Background(), keys.Queue(queue.STT), keys.Queue(queue.Summary), keys.Queue(queue.Highlight)); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Kafka broker enabled")
+		return broker
+	case "sqs":
+		broker, err := queue.NewSQSBroker(context.Background(), queue.SQSConfig{
+			Region:            config.String("AWS_REGION", "us-east-1"),
+			Endpoint:          os.Getenv("SQS_ENDPOINT"),
+			VisibilityTimeout: config.Duration("SQS_VISIBILITY_TIMEOUT", 5*time.Minute),
+			DLQArn:            os.Getenv("SQS_DLQ_ARN"),
+			MaxReceiveCount:   config.Int("SQS_MAX_RECEIVE_COUNT", 3),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("SQS broker enabled")
+		return broker
+	default:
+		return queue.NewRedisBroker(rdb)
+	}
+}
+
+// runRedriveDLQ 執行 redrive-dlq 子指令。
+func runRedriveDLQ(rdb *redis.Client) {
+	broker, ok := newBroker(rdb).(*queue.SQSBroker)
+	if !ok {
+		log.Fatal("redrive-dlq requires BROKER=sqs")
+	}
+	handle, err := broker.RedriveDLQ(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("DLQ redrive started (task handle %s)", handle)
 }
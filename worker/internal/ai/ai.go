@@ -5,13 +5,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math/rand"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -27,20 +31,84 @@ type Summarizer interface {
 	SummarizeStream(ctx context.Context, text string, prompt string, onChunk func(chunk string)) error
 }
 
+// STTModelNamer 可選介面：返回目前使用的 STT 模型名稱，供任務執行紀錄顯示。
+type STTModelNamer interface {
+	STTModelName() string
+}
+
+// LLMModelNamer 可選介面：返回目前使用的 LLM 模型名稱。
+type LLMModelNamer interface {
+	LLMModelName() string
+}
+
+// STTModelName 返回 s 的模型名稱，未實作 STTModelNamer 時為空字串。
+func STTModelName(s STTService) string {
+	if n, ok := s.(STTModelNamer); ok {
+		return n.STTModelName()
+	}
+	return ""
+}
+
+// LLMModelName 返回 s 的模型名稱，未實作 LLMModelNamer 時為空字串。
+func LLMModelName(s Summarizer) string {
+	if n, ok := s.(LLMModelNamer); ok {
+		return n.LLMModelName()
+	}
+	return ""
+}
+
 // AIService 組合介面（保留相容性，或作為聯合介面使用）。
 type AIService interface {
 	STTService
 	Summarizer
 }
 
+// ErrPayloadTooLarge provider 因檔案大小拒絕請求（HTTP 413 或等效的錯誤訊息）。
+// Worker 收到時會將 chunk 對半切割後重試。
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+// sttStatusError 將非 200 的 STT 回應轉為錯誤，大小超限時包裝 ErrPayloadTooLarge。
+func sttStatusError(provider string, status int, body []byte) error {
+	lower := strings.ToLower(string(body))
+	if status == http.StatusRequestEntityTooLarge || strings.Contains(lower, "too large") || strings.Contains(lower, "maximum content size") {
+		return fmt.Errorf("%s stt failed: %w: %s", provider, ErrPayloadTooLarge, body)
+	}
+	return fmt.Errorf("%s stt failed: %s", provider, body)
+}
+
+func init() {
+	RegisterSTT("mock", func(_ context.Context, opts Options) (STTService, error) { return newMock(opts) })
+	RegisterLLM("mock", func(_ context.Context, opts Options) (Summarizer, error) { return newMock(opts) })
+	RegisterSTT("openai", newOpenAISTT)
+	RegisterLLM("openai", newOpenAILLM)
+}
+
 // --- Mock 實作 ---
 
+// newMock 建立 Mock 服務，MOCK_CHAOS 設定故障注入。
+func newMock(opts Options) (*MockAIService, error) {
+	chaos, err := ParseChaosConfig(opts("MOCK_CHAOS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MOCK_CHAOS: %w", err)
+	}
+	if chaos != nil {
+		log.Printf("Mock chaos enabled: %+v", *chaos)
+	}
+	return &MockAIService{Chaos: chaos}, nil
+}
+
 // MockAIService 模擬 AI 服務，用於開發測試環境。
 // 模擬真實的延遲與串流行為，確保前後端整合測試的穩定性。
-type MockAIService struct{}
+// Chaos 非 nil 時依設定注入錯誤、延遲、中斷與異常輸出。
+type MockAIService struct {
+	Chaos *ChaosConfig
+}
 
 // STT 模擬語音轉錄，隨機延遲 2~4 秒後返回固定文字。
 // 它會先檢查檔案是否存在，以確保 Worker 傳入的路徑是正確的。
+func (m *MockAIService) STTModelName() string { return "mock" }
+func (m *MockAIService) LLMModelName() string { return "mock" }
+
 func (m *MockAIService) STT(ctx context.Context, filePath string) (string, error) {
 	if filePath == "" {
 		return "", fmt.Errorf("mock stt: file path is empty")
@@ -49,22 +117,40 @@ func (m *MockAIService) STT(ctx context.Context, filePath string) (string, error
 		return "", fmt.Errorf("mock stt: file not found at %s", filePath)
 	}
 
+	if err := m.Chaos.before(ctx); err != nil {
+		return "", err
+	}
+
 	select {
 	case <-time.After(time.Duration(2+rand.Intn(3)) * time.Second):
-		return "這是一段模擬的語音轉錄內容。內容包含了一些關於系統架構與設計模式的討論。", nil
+		return m.Chaos.mangle("這是一段模擬的語音轉錄內容。內容包含了一些關於系統架構與設計模式的討論。"), nil
 	case <-ctx.Done():
 		return "", ctx.Err()
 	}
 }
 
+// TranscribeWithOptions 模擬語言感知轉錄，未指定語言時回報 zh。
+func (m *MockAIService) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	text, err := m.STT(ctx, filePath)
+	lang := NormalizeLanguage(opts.Language)
+	if lang == "" {
+		lang = "zh"
+	}
+	return STTResult{Text: text, Language: lang}, err
+}
+
 // Summarize 模擬一次性摘要生成，會檢查輸入文字是否為空。
 func (m *MockAIService) Summarize(ctx context.Context, text string, prompt string) (string, error) {
 	if text == "" {
 		return "", fmt.Errorf("mock llm: input text is empty")
 	}
+	if err := m.Chaos.before(ctx); err != nil {
+		return "", err
+	}
 	select {
 	case <-time.After(time.Duration(2+rand.Intn(2)) * time.Second):
-		return "摘要：討論了系統的微服務架構，包含 API Gateway、RabbitMQ 與 Worker 的協作模式。", nil
+		reportUsage(ctx, TokenUsage{PromptTokens: len([]rune(text)), CompletionTokens: 40})
+		return m.Chaos.mangle("摘要：討論了系統的微服務架構，包含 API Gateway、RabbitMQ 與 Worker 的協作模式。"), nil
 	case <-ctx.Done():
 		return "", ctx.Err()
 	}
@@ -87,17 +173,42 @@ func (m *MockAIService) SummarizeStream(ctx context.Context, text string, prompt
 		"二、串流上傳設計；\n",
 		"三、原子狀態管理。",
 	}
+	if m.Chaos.failed() {
+		return ErrChaos
+	}
 	for _, chunk := range chunks {
+		// 串流中途注入延遲或中斷，此時前面的片段已送出
+		if err := m.Chaos.disrupt(ctx); err != nil {
+			return err
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(time.Duration(200+rand.Intn(300)) * time.Millisecond):
-			onChunk(chunk)
+			onChunk(m.Chaos.mangle(chunk))
 		}
 	}
+	reportUsage(ctx, TokenUsage{PromptTokens: len([]rune(text)), CompletionTokens: len(chunks) * 8})
 	return nil
 }
 
+// SummarizeStructured 模擬結構化摘要。
+func (m *MockAIService) SummarizeStructured(ctx context.Context, text string, prompt string) (StructuredSummary, error) {
+	if text == "" {
+		return StructuredSummary{}, fmt.Errorf("mock llm: input text is empty")
+	}
+	if err := m.Chaos.before(ctx); err != nil {
+		return StructuredSummary{}, err
+	}
+	return StructuredSummary{
+		Title:        "微服務架構討論",
+		TLDR:         "討論了 API Gateway、RabbitMQ 與 Worker 的協作模式。",
+		KeyPoints:    []string{"非同步任務處理", "串流上傳設計", "原子狀態管理"},
+		ActionItems:  []ActionItem{{Task: "整理架構文件", Owner: ""}},
+		Participants: []string{},
+	}, nil
+}
+
 // --- OpenAI 實作 ---
 
 // StandardAIProvider 提供符合 OpenAI 規範的 STT 與 LLM 服務。
@@ -110,55 +221,211 @@ type StandardAIProvider struct {
 	LLMURL    string
 	LLMModel  string
 	LLMPrompt string
+	// StreamUsage 串流摘要時送出 stream_options.include_usage，讓最後一個 chunk 帶回 token 用量；
+	// 不接受此欄位的相容 API 需關閉。
+	StreamUsage bool
+	// RealtimeURL / RealtimeModel 即時串流轉錄（StartStream）的 Realtime API 端點與模型，
+	// 空值為 DefaultRealtimeURL / DefaultRealtimeModel。
+	RealtimeURL   string
+	RealtimeModel string
+	// STTTemperature 轉錄的取樣溫度（0~1），大於 0 時才送出，否則沿用 provider 預設（Whisper 為 0）。
+	STTTemperature float64
+	// APIVersion 非空時為 Azure OpenAI 模式：URL 為 deployment 端點（見 AzureDeploymentURL），
+	// 以 api-key header 取代 Bearer 認證，並附加 api-version 查詢參數。
+	APIVersion string
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+// newOpenAISTT 依 AI_STT_* 建立 OpenAI 相容 STT，包裝為可熱更新的 provider。
+func newOpenAISTT(_ context.Context, opts Options) (STTService, error) {
+	p := StandardAIProvider{
+		STTApiKey:      opts("AI_STT_KEY"),
+		STTURL:         opts("AI_STT_URL"),
+		STTModel:       opts("AI_STT_MODEL"),
+		STTTemperature: opts.Float("AI_STT_TEMPERATURE", 0),
+		RealtimeURL:    opts("AI_STT_REALTIME_URL"),
+		RealtimeModel:  opts("AI_STT_REALTIME_MODEL"),
+		APIVersion:     AzureAPIVersion(opts),
+	}
+	if p.STTTemperature < 0 || p.STTTemperature > 1 {
+		return nil, fmt.Errorf("invalid AI_STT_TEMPERATURE: %g (must be between 0 and 1)", p.STTTemperature)
+	}
+	if endpoint := opts("AZURE_OPENAI_ENDPOINT"); p.STTURL == "" && endpoint != "" && p.STTModel != "" {
+		p.STTURL = AzureDeploymentURL(endpoint, p.STTModel, "audio/transcriptions")
+	}
+	if p.STTURL == "" || p.STTModel == "" {
+		return nil, fmt.Errorf("AI_STT_URL/MODEL must be provided")
+	}
+	return NewReloadableProvider(p), nil
+}
+
+// newOpenAILLM 依 AI_LLM_* 建立 OpenAI 相容摘要，包裝為可熱更新的 provider。
+func newOpenAILLM(_ context.Context, opts Options) (Summarizer, error) {
+	p := StandardAIProvider{
+		LLMApiKey:   opts("AI_LLM_KEY"),
+		LLMURL:      opts("AI_LLM_URL"),
+		LLMModel:    opts("AI_LLM_MODEL"),
+		LLMPrompt:   opts("AI_LLM_PROMPT"),
+		StreamUsage: opts.Bool("AI_LLM_STREAM_USAGE", true),
+		APIVersion:  AzureAPIVersion(opts),
+	}
+	if endpoint := opts("AZURE_OPENAI_ENDPOINT"); p.LLMURL == "" && endpoint != "" && p.LLMModel != "" {
+		p.LLMURL = AzureDeploymentURL(endpoint, p.LLMModel, "chat/completions")
+	}
+	if p.LLMURL == "" || p.LLMModel == "" {
+		return nil, fmt.Errorf("AI_LLM_URL/MODEL must be provided")
+	}
+	return NewReloadableProvider(p), nil
+}
+
+// AzureAPIVersion 設定 AZURE_OPENAI_ENDPOINT 時返回 AZURE_OPENAI_API_VERSION（啟用 Azure 模式），否則為空字串。
+func AzureAPIVersion(opts Options) string {
+	if opts("AZURE_OPENAI_ENDPOINT") == "" {
+		return ""
+	}
+	return opts.String("AZURE_OPENAI_API_VERSION", "2024-10-21")
+}
+
+// AzureDeploymentURL 組合 Azure OpenAI deployment 端點，operation 例如 audio/transcriptions、chat/completions。
+func AzureDeploymentURL(endpoint, deployment, operation string) string {
+	return strings.TrimSuffix(endpoint, "/") + "/openai/deployments/" + url.PathEscape(deployment) + "/" + operation
+}
+
+// newRequest 建立帶認證的 POST 請求；Azure 模式改用 api-key header 並附加 api-version。
+func (o *StandardAIProvider) newRequest(ctx context.Context, endpoint string, body io.Reader) (*http.Request, error) {
+	if o.APIVersion != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		q.Set("api-version", o.APIVersion)
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+	}
+	return http.NewRequestWithContext(ctx, "POST", endpoint, body)
 }
 
+// do 以 keys（AI_STT_KEY / AI_LLM_KEY，可為逗號分隔的多把 key）輪流認證並送出 req。
+func (o *StandardAIProvider) do(req *http.Request, keys string) (*http.Response, error) {
+	return keyPool("openai", keys).do(httpClient(o.HTTPClient), req, func(r *http.Request, key string) {
+		if o.APIVersion != "" {
+			r.Header.Set("api-key", key)
+		} else if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+	})
+}
+
+func (o *StandardAIProvider) STTModelName() string { return o.STTModel }
+func (o *StandardAIProvider) LLMModelName() string { return o.LLMModel }
+
 // STT 呼叫 OpenAI 規範的語音轉錄 API。
 // 使用 multipart/form-data 格式上傳音檔。
 func (o *StandardAIProvider) STT(ctx context.Context, filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
+	res, err := o.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+// TranscribeWithOptions 轉錄並套用語言提示 / 模型覆寫。
+// DetectLanguage 時改用 verbose_json 回應格式以取得 provider 偵測到的語言；Timestamps 時另要求逐段與逐字時間戳。
+func (o *StandardAIProvider) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	model := o.STTModel
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	body, contentType, err := multipartFile(filePath, func(writer *multipart.Writer) {
+		_ = writer.WriteField("model", model)
+		if opts.Language != "" {
+			_ = writer.WriteField("language", opts.Language)
+		}
+		if opts.DetectLanguage || opts.Timestamps {
+			_ = writer.WriteField("response_format", "verbose_json")
+		}
+		if opts.Timestamps {
+			_ = writer.WriteField("timestamp_granularities[]", "segment")
+			_ = writer.WriteField("timestamp_granularities[]", "word")
+		}
+		if opts.Prompt != "" {
+			_ = writer.WriteField("prompt", opts.Prompt)
+		}
+		if o.STTTemperature > 0 {
+			_ = writer.WriteField("temperature", strconv.FormatFloat(o.STTTemperature, 'f', -1, 64))
+		}
+	})
 	if err != nil {
-		return "", err
+		return STTResult{}, err
 	}
-	if _, err := io.Copy(part, file); err != nil {
-		return "", err
-	}
-	_ = writer.WriteField("model", o.STTModel)
-	writer.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", o.STTURL, body)
+	req, err := o.newRequest(ctx, o.STTURL, body)
 	if err != nil {
-		return "", err
+		body.Close()
+		return STTResult{}, err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+o.STTApiKey)
+	req.Header.Set("Content-Type", contentType)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.do(req, o.STTApiKey)
 	if err != nil {
-		return "", err
+		return STTResult{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openai stt failed: %s", string(b))
+		return STTResult{}, sttStatusError("openai", resp.StatusCode, b)
 	}
 
 	var result struct {
-		Text string `json:"text"`
+		Text     string    `json:"text"`
+		Language string    `json:"language"`
+		Segments []Segment `json:"segments"`
+		Words    []struct {
+			Word  string  `json:"word"`
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+		} `json:"words"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+		return STTResult{}, err
+	}
+	lang := NormalizeLanguage(result.Language)
+	if lang == "" {
+		lang = NormalizeLanguage(opts.Language)
 	}
-	return result.Text, nil
+	res := STTResult{Text: result.Text, Language: lang}
+	if opts.Timestamps {
+		res.Segments = trimSegments(result.Segments)
+		for _, w := range result.Words {
+			res.Words = append(res.Words, Word{Text: w.Word, Start: w.Start, End: w.End})
+		}
+	}
+	return res, nil
+}
+
+// multipartFile 以 io.Pipe 串流產生 multipart/form-data：音檔欄位（file）邊讀邊送，之後由 fields 寫入其餘欄位，
+// 記憶體用量與分片大小無關。返回的 body 交給 http.Client 後由其關閉；未送出請求時呼叫端需自行關閉。
+// 讀檔或寫入失敗時，錯誤經由 body 的 Read 回報給 http.Client。
+func multipartFile(filePath string, fields func(*multipart.Writer)) (io.ReadCloser, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		defer file.Close()
+		part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			fields(writer)
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, writer.FormDataContentType(), nil
 }
 
 // Summarize 呼叫 OpenAI 規範的 ChatCompletion API 一次性生成摘要。
@@ -167,27 +434,57 @@ func (o *StandardAIProvider) Summarize(ctx context.Context, text string, userPro
 		userPrompt = o.LLMPrompt
 	}
 	if userPrompt == "" {
-		userPrompt = "請摘要以下內容："
+		userPrompt = defaultSummaryPrompt(ctx)
+	}
+
+	payload := map[string]interface{}{
+		"model": o.LLMModel,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt(ctx)},
+			{"role": "user", "content": userMessage(ctx, userPrompt, text)},
+		},
 	}
+	generationParams(ctx).applyOpenAI(payload, "max_tokens")
+	return o.complete(ctx, payload)
+}
 
+// SummarizeStructured 以 response_format=json_schema（strict）要求模型輸出 StructuredSummary。
+// 取樣參數只有 temperature 生效，避免 max_tokens / stop 截斷 JSON。
+func (o *StandardAIProvider) SummarizeStructured(ctx context.Context, text string, userPrompt string) (StructuredSummary, error) {
 	payload := map[string]interface{}{
 		"model": o.LLMModel,
 		"messages": []map[string]string{
-			{"role": "system", "content": "You are a helpful assistant that summarizes audio transcripts."},
-			{"role": "user", "content": fmt.Sprintf("%s\n\n%s", userPrompt, text)},
+			{"role": "system", "content": systemPrompt(ctx)},
+			{"role": "user", "content": userMessage(ctx, structuredPrompt(userPrompt), text)},
 		},
+		"response_format": map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "summary",
+				"strict": true,
+				"schema": SummarySchema(),
+			},
+		},
+	}
+	GenerationParams{Temperature: generationParams(ctx).Temperature}.applyOpenAI(payload, "max_tokens")
+	raw, err := o.complete(ctx, payload)
+	if err != nil {
+		return StructuredSummary{}, err
 	}
+	return ParseStructuredSummary(raw)
+}
+
+// complete 送出非串流的 ChatCompletion 請求，返回第一個 choice 的內容。
+func (o *StandardAIProvider) complete(ctx context.Context, payload map[string]interface{}) (string, error) {
 	body, _ := json.Marshal(payload)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", o.LLMURL, bytes.NewBuffer(body))
+	req, err := o.newRequest(ctx, o.LLMURL, bytes.NewBuffer(body))
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.LLMApiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.do(req, o.LLMApiKey)
 	if err != nil {
 		return "", err
 	}
@@ -204,10 +501,12 @@ func (o *StandardAIProvider) Summarize(ctx context.Context, text string, userPro
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", err
 	}
+	reportUsage(ctx, result.Usage.tokens())
 
 	if len(result.Choices) > 0 {
 		return result.Choices[0].Message.Content, nil
@@ -223,7 +522,7 @@ func (o *StandardAIProvider) SummarizeStream(ctx context.Context, text string, u
 		userPrompt = o.LLMPrompt
 	}
 	if userPrompt == "" {
-		userPrompt = "請摘要以下內容："
+		userPrompt = defaultSummaryPrompt(ctx)
 	}
 
 	// 建立payload
@@ -232,21 +531,23 @@ func (o *StandardAIProvider) SummarizeStream(ctx context.Context, text string, u
 		// 串流設定
 		"stream": true,
 		"messages": []map[string]string{
-			{"role": "system", "content": "You are a helpful assistant that summarizes audio transcripts."},
-			{"role": "user", "content": fmt.Sprintf("%s\n\n%s", userPrompt, text)},
+			{"role": "system", "content": systemPrompt(ctx)},
+			{"role": "user", "content": userMessage(ctx, userPrompt, text)},
 		},
 	}
+	if o.StreamUsage {
+		payload["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	generationParams(ctx).applyOpenAI(payload, "max_tokens")
 	body, _ := json.Marshal(payload)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", o.LLMURL, bytes.NewBuffer(body))
+	req, err := o.newRequest(ctx, o.LLMURL, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.LLMApiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.do(req, o.LLMApiKey)
 	if err != nil {
 		return err
 	}
@@ -279,6 +580,8 @@ func (o *StandardAIProvider) SummarizeStream(ctx context.Context, text string, u
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
+			// Usage 只出現在最後一個 chunk（choices 為空）
+			Usage *openAIUsage `json:"usage"`
 		}
 
 		// 將 "data: " 後面的 JSON 字串解析進臨時結構中
@@ -290,6 +593,7 @@ func (o *StandardAIProvider) SummarizeStream(ctx context.Context, text string, u
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
 			onChunk(chunk.Choices[0].Delta.Content)
 		}
+		reportUsage(ctx, chunk.Usage.tokens())
 	}
 	return nil
 }
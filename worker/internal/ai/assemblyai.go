@@ -0,0 +1,216 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() { RegisterSTT("assemblyai", newAssemblyAISTT) }
+
+// newAssemblyAISTT 依 ASSEMBLYAI_* 建立 AssemblyAI 非同步轉錄。
+func newAssemblyAISTT(_ context.Context, opts Options) (STTService, error) {
+	stt := &AssemblyAISTT{
+		APIKey:          opts("ASSEMBLYAI_KEY"),
+		URL:             opts.String("ASSEMBLYAI_URL", DefaultAssemblyAIURL),
+		SpeechModel:     opts("ASSEMBLYAI_SPEECH_MODEL"),
+		Language:        opts("ASSEMBLYAI_LANGUAGE"),
+		PollInterval:    opts.Duration("ASSEMBLYAI_POLL_INTERVAL", time.Second),
+		MaxPollInterval: opts.Duration("ASSEMBLYAI_POLL_MAX_INTERVAL", 10*time.Second),
+	}
+	if stt.APIKey == "" {
+		return nil, fmt.Errorf("ASSEMBLYAI_KEY must be provided")
+	}
+	if stt.PollInterval <= 0 || stt.MaxPollInterval < stt.PollInterval {
+		return nil, fmt.Errorf("invalid ASSEMBLYAI_POLL_INTERVAL / ASSEMBLYAI_POLL_MAX_INTERVAL: %s / %s", stt.PollInterval, stt.MaxPollInterval)
+	}
+	log.Printf("AssemblyAI STT enabled: poll %s..%s", stt.PollInterval, stt.MaxPollInterval)
+	return stt, nil
+}
+
+// DefaultAssemblyAIURL AssemblyAI API 根路徑。
+const DefaultAssemblyAIURL = "https://api.assemblyai.com/v2"
+
+// AssemblyAISTT 以 AssemblyAI 非同步 API 轉錄：上傳 chunk 取得 upload_url、建立 transcript，
+// 再輪詢至 completed / error。輪詢間隔自 PollInterval 起每次加倍至 MaxPollInterval，ctx 取消時立即返回。
+type AssemblyAISTT struct {
+	APIKey          string
+	URL             string // 空值為 DefaultAssemblyAIURL
+	SpeechModel     string // 例如 universal；空值沿用帳號預設
+	Language        string // 預設語言，任務的語言提示優先；空值時由 AssemblyAI 偵測
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (a *AssemblyAISTT) STTModelName() string {
+	if a.SpeechModel == "" {
+		return "assemblyai"
+	}
+	return a.SpeechModel
+}
+
+func (a *AssemblyAISTT) STT(ctx context.Context, filePath string) (string, error) {
+	res, err := a.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
+
+// TranscribeWithOptions 轉錄並套用語言提示 / 模型覆寫；未指定語言時啟用 language_detection。
+// AssemblyAI 不支援前文提示，opts.Prompt 忽略。
+func (a *AssemblyAISTT) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	// upload_url 與 transcript 只能由同一帳號存取，整個轉錄流程固定使用同一把 key
+	key := keyPool("assemblyai", a.APIKey).Pick()
+	uploadURL, err := a.upload(ctx, key, filePath)
+	if err != nil {
+		return STTResult{}, err
+	}
+
+	lang := NormalizeLanguage(opts.Language)
+	if lang == "" {
+		lang = NormalizeLanguage(a.Language)
+	}
+	model := a.SpeechModel
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	payload := map[string]interface{}{"audio_url": uploadURL, "punctuate": true, "format_text": true}
+	if model != "" {
+		payload["speech_model"] = model
+	}
+	if lang != "" {
+		payload["language_code"] = lang
+	} else {
+		payload["language_detection"] = true
+	}
+	var job assemblyAITranscript
+	if err := a.call(ctx, key, "POST", "/transcript", payload, &job); err != nil {
+		return STTResult{}, err
+	}
+
+	interval := a.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		switch job.Status {
+		case "completed":
+			detected := NormalizeLanguage(job.LanguageCode)
+			if detected == "" {
+				detected = lang
+			}
+			res := STTResult{Text: strings.TrimSpace(job.Text), Language: detected}
+			if opts.Timestamps {
+				for _, w := range job.Words {
+					res.Words = append(res.Words, Word{Text: w.Text, Start: float64(w.Start) / 1000, End: float64(w.End) / 1000})
+				}
+			}
+			return res, nil
+		case "error":
+			return STTResult{}, sttStatusError("assemblyai", http.StatusOK, []byte(job.Error))
+		}
+		select {
+		case <-ctx.Done():
+			return STTResult{}, ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval *= 2; a.MaxPollInterval > 0 && interval > a.MaxPollInterval {
+			interval = a.MaxPollInterval
+		}
+		if err := a.call(ctx, key, "GET", "/transcript/"+job.ID, nil, &job); err != nil {
+			return STTResult{}, err
+		}
+	}
+}
+
+// assemblyAITranscript transcript 資源（建立與輪詢回應相同）。
+type assemblyAITranscript struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"` // queued / processing / completed / error
+	Text         string `json:"text"`
+	LanguageCode string `json:"language_code"`
+	Error        string `json:"error"`
+	// Words 逐字時間（毫秒）
+	Words []struct {
+		Text  string `json:"text"`
+		Start int64  `json:"start"`
+		End   int64  `json:"end"`
+	} `json:"words"`
+}
+
+// upload 以原始位元組上傳音檔，返回僅供此帳號使用的 upload_url。
+func (a *AssemblyAISTT) upload(ctx context.Context, key, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	st, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint("/upload"), file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = st.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	var result struct {
+		UploadURL string `json:"upload_url"`
+	}
+	if err := a.do(req, key, &result); err != nil {
+		return "", err
+	}
+	return result.UploadURL, nil
+}
+
+// call 送出 JSON 請求（payload 為 nil 時不帶 body）並解析回應至 out。
+func (a *AssemblyAISTT) call(ctx context.Context, key, method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		b, _ := json.Marshal(payload)
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.endpoint(path), body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return a.do(req, key, out)
+}
+
+// do 以 key 認證送出 req；429 / 402 時暫停該 key，之後的轉錄改用其他 key。
+func (a *AssemblyAISTT) do(req *http.Request, key string, out interface{}) error {
+	req.Header.Set("Authorization", key)
+	resp, err := httpClient(a.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	keyPool("assemblyai", a.APIKey).Observe(key, resp)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return sttStatusError("assemblyai", resp.StatusCode, b)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("assemblyai stt failed: %w", err)
+	}
+	return nil
+}
+
+func (a *AssemblyAISTT) endpoint(path string) string {
+	base := a.URL
+	if base == "" {
+		base = DefaultAssemblyAIURL
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
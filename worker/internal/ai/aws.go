@@ -0,0 +1,276 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/google/uuid"
+)
+
+func init() { RegisterSTT("aws", newAWSTranscribe) }
+
+// newAWSTranscribe 依 AWS_TRANSCRIBE_* 建立 AWS Transcribe 轉錄（chunk 經 S3 暫存），憑證取自 AWS 預設憑證鏈。
+func newAWSTranscribe(ctx context.Context, opts Options) (STTService, error) {
+	stt, err := NewAWSTranscribeSTT(ctx, AWSTranscribeConfig{
+		Region:             opts.String("AWS_REGION", "us-east-1"),
+		Bucket:             opts("AWS_TRANSCRIBE_BUCKET"),
+		Prefix:             opts.String("AWS_TRANSCRIBE_PREFIX", "stt/"),
+		Language:           opts("AWS_TRANSCRIBE_LANGUAGE"),
+		S3Endpoint:         opts("AWS_TRANSCRIBE_S3_ENDPOINT"),
+		TranscribeEndpoint: opts("AWS_TRANSCRIBE_ENDPOINT"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("AWS Transcribe STT enabled: bucket %s", stt.cfg.Bucket)
+	return stt, nil
+}
+
+// AWSTranscribeConfig AWS Transcribe provider 設定。
+type AWSTranscribeConfig struct {
+	Region string
+	Bucket string // chunk 與轉錄結果的暫存 bucket（建議設定 lifecycle 規則自動清除殘留物件）
+	Prefix string // 物件 key 前綴，例如 stt/
+	// Language Transcribe 語言代碼（例如 zh-TW），任務的語言提示含地區時優先；皆未設定時啟用 IdentifyLanguage。
+	Language string
+	// S3Endpoint / TranscribeEndpoint 覆寫端點（LocalStack 等），S3 改用 path-style 位址。
+	S3Endpoint         string
+	TranscribeEndpoint string
+	PollInterval       time.Duration
+	MaxPollInterval    time.Duration
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+// AWSTranscribeSTT 以 AWS Transcribe 批次任務轉錄：chunk 上傳至 S3、啟動 transcription job 並輪詢至完成，
+// 讀取 S3 上的輸出 JSON 後刪除 job 與暫存物件。請求以 SigV4 簽署，憑證取自 AWS 預設憑證鏈。
+type AWSTranscribeSTT struct {
+	cfg   AWSTranscribeConfig
+	creds aws.CredentialsProvider
+}
+
+// NewAWSTranscribeSTT 以 AWS 預設憑證鏈（環境變數 / IAM role）建立 Transcribe provider。
+func NewAWSTranscribeSTT(ctx context.Context, cfg AWSTranscribeConfig) (*AWSTranscribeSTT, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("NewAWSTranscribeSTT: bucket is required")
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("NewAWSTranscribeSTT: load aws config: %w", err)
+	}
+	cfg.Region = awsCfg.Region
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.MaxPollInterval < cfg.PollInterval {
+		cfg.MaxPollInterval = 15 * time.Second
+	}
+	return &AWSTranscribeSTT{cfg: cfg, creds: awsCfg.Credentials}, nil
+}
+
+func (t *AWSTranscribeSTT) STTModelName() string { return "aws-transcribe" }
+
+func (t *AWSTranscribeSTT) STT(ctx context.Context, filePath string) (string, error) {
+	res, err := t.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
+
+// TranscribeWithOptions 轉錄一個 chunk。Transcribe 不支援前文提示與模型覆寫，opts.Prompt / opts.Model 忽略。
+func (t *AWSTranscribeSTT) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	job := "stt-" + uuid.NewString()
+	inputKey := path.Join(t.cfg.Prefix, job+filepath.Ext(filePath))
+	outputKey := path.Join(t.cfg.Prefix, job+".json")
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return STTResult{}, err
+	}
+	if _, err := t.s3(ctx, "PUT", inputKey, data); err != nil {
+		return STTResult{}, err
+	}
+	// 不論成功與否都清除暫存物件與 job；ctx 可能已取消，另開短時限 context
+	defer func() {
+		cleanup, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		t.s3(cleanup, "DELETE", inputKey, nil)
+		t.s3(cleanup, "DELETE", outputKey, nil)
+		t.transcribe(cleanup, "DeleteTranscriptionJob", map[string]string{"TranscriptionJobName": job}, nil)
+	}()
+
+	input := map[string]interface{}{
+		"TranscriptionJobName": job,
+		"Media":                map[string]string{"MediaFileUri": "s3://" + t.cfg.Bucket + "/" + inputKey},
+		"MediaFormat":          strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), "."),
+		"OutputBucketName":     t.cfg.Bucket,
+		"OutputKey":            outputKey,
+	}
+	// Transcribe 需要含地區的語言代碼（zh-TW）；只有 ISO-639-1 的提示（zh）改用設定值或自動識別
+	lang := opts.Language
+	if !strings.Contains(lang, "-") {
+		lang = t.cfg.Language
+	}
+	if lang != "" {
+		input["LanguageCode"] = lang
+	} else {
+		input["IdentifyLanguage"] = true
+	}
+
+	var status transcriptionJob
+	if err := t.transcribe(ctx, "StartTranscriptionJob", input, &status); err != nil {
+		return STTResult{}, err
+	}
+	interval := t.cfg.PollInterval
+	for {
+		switch status.TranscriptionJob.TranscriptionJobStatus {
+		case "COMPLETED":
+			out, err := t.s3(ctx, "GET", outputKey, nil)
+			if err != nil {
+				return STTResult{}, err
+			}
+			res, err := parseTranscribeOutput(out)
+			if err != nil {
+				return STTResult{}, err
+			}
+			res.Language = NormalizeLanguage(status.TranscriptionJob.LanguageCode)
+			return res, nil
+		case "FAILED":
+			return STTResult{}, sttStatusError("aws", http.StatusOK, []byte(status.TranscriptionJob.FailureReason))
+		}
+		select {
+		case <-ctx.Done():
+			return STTResult{}, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, t.cfg.MaxPollInterval)
+		if err := t.transcribe(ctx, "GetTranscriptionJob", map[string]string{"TranscriptionJobName": job}, &status); err != nil {
+			return STTResult{}, err
+		}
+	}
+}
+
+// transcriptionJob Start / GetTranscriptionJob 回應中用到的欄位。
+type transcriptionJob struct {
+	TranscriptionJob struct {
+		TranscriptionJobStatus string // QUEUED / IN_PROGRESS / COMPLETED / FAILED
+		FailureReason          string
+		LanguageCode           string
+	}
+}
+
+// parseTranscribeOutput 將 Transcribe 輸出 JSON 轉為轉錄稿與逐字時間戳（標點項目沒有時間，不列入 Words）。
+func parseTranscribeOutput(b []byte) (STTResult, error) {
+	var out struct {
+		Results struct {
+			Transcripts []struct {
+				Transcript string `json:"transcript"`
+			} `json:"transcripts"`
+			Items []struct {
+				Type         string `json:"type"` // pronunciation / punctuation
+				StartTime    string `json:"start_time"`
+				EndTime      string `json:"end_time"`
+				Alternatives []struct {
+					Content string `json:"content"`
+				} `json:"alternatives"`
+			} `json:"items"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return STTResult{}, fmt.Errorf("aws stt failed: %w", err)
+	}
+	var res STTResult
+	var parts []string
+	for _, tr := range out.Results.Transcripts {
+		parts = append(parts, tr.Transcript)
+	}
+	res.Text = strings.TrimSpace(strings.Join(parts, " "))
+	for _, item := range out.Results.Items {
+		if item.Type != "pronunciation" || len(item.Alternatives) == 0 {
+			continue
+		}
+		res.Words = append(res.Words, Word{Text: item.Alternatives[0].Content, Start: parseSeconds(item.StartTime), End: parseSeconds(item.EndTime)})
+	}
+	return res, nil
+}
+
+// transcribe 呼叫 Transcribe JSON API（X-Amz-Target: Transcribe.{action}）。out 為 nil 時忽略回應內容。
+func (t *AWSTranscribeSTT) transcribe(ctx context.Context, action string, input interface{}, out interface{}) error {
+	body, _ := json.Marshal(input)
+	endpoint := t.cfg.TranscribeEndpoint
+	if endpoint == "" {
+		endpoint = "https://transcribe." + t.cfg.Region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Transcribe."+action)
+	resp, err := t.send(ctx, req, body, "transcribe")
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp, out)
+}
+
+// s3 對暫存 bucket 的單一物件執行 PUT / GET / DELETE，GET 返回物件內容。
+func (t *AWSTranscribeSTT) s3(ctx context.Context, method, key string, body []byte) ([]byte, error) {
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	target := "https://" + t.cfg.Bucket + ".s3." + t.cfg.Region + ".amazonaws.com/" + escaped
+	if t.cfg.S3Endpoint != "" {
+		target = strings.TrimSuffix(t.cfg.S3Endpoint, "/") + "/" + t.cfg.Bucket + "/" + escaped
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	return t.send(ctx, req, body, "s3")
+}
+
+// send 以 SigV4 簽署並送出請求；非 2xx 回應轉為錯誤。
+func (t *AWSTranscribeSTT) send(ctx context.Context, req *http.Request, body []byte, service string) ([]byte, error) {
+	creds, err := t.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("aws stt failed: retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hash, service, t.cfg.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("aws stt failed: sign request: %w", err)
+	}
+	resp, err := httpClient(t.cfg.HTTPClient).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, sttStatusError("aws "+service, resp.StatusCode, b)
+	}
+	return b, nil
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// ChaosConfig MockAIService 的故障注入設定，用於端到端演練 retry、DLQ 與部分結果等路徑。
+// 各 Rate 為每次呼叫（STT 為每個 chunk、串流為每個片段）觸發的機率（0~1），零值表示停用。
+type ChaosConfig struct {
+	// ErrorRate 直接返回錯誤。
+	ErrorRate float64 `json:"errorRate"`
+	// SlowRate 額外延遲 SlowDelay（預設 2 分鐘），用於觸發 chunk / 階段 deadline。
+	SlowRate  float64  `json:"slowRate"`
+	SlowDelay Duration `json:"slowDelay"`
+	// CancelRate 處理到一半時中斷（io.ErrUnexpectedEOF），模擬 provider 連線被切斷；
+	// 不使用 context.Canceled，以免被視為使用者取消。
+	CancelRate float64 `json:"cancelRate"`
+	// MalformedRate 返回損毀內容（無效 UTF-8、截斷的文字）。
+	MalformedRate float64 `json:"malformedRate"`
+	// HugeRate 返回約 HugeBytes（預設 5 MB）的超長輸出。
+	HugeRate  float64 `json:"hugeRate"`
+	HugeBytes int     `json:"hugeBytes"`
+}
+
+// Duration 可由 JSON 字串（"30s"）或毫秒數解析的時間長度。
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = Duration(v)
+		return nil
+	}
+	var ms int64
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\" or milliseconds: %s", b)
+	}
+	*d = Duration(time.Duration(ms) * time.Millisecond)
+	return nil
+}
+
+// ErrChaos 故障注入產生的錯誤。
+var ErrChaos = errors.New("mock chaos: injected failure")
+
+// ParseChaosConfig 解析 JSON 設定；spec 以 @ 開頭時視為檔案路徑。空字串返回 nil（停用）。
+func ParseChaosConfig(spec string) (*ChaosConfig, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	raw := []byte(spec)
+	if path, ok := strings.CutPrefix(spec, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ParseChaosConfig(%s): %w", path, err)
+		}
+		raw = b
+	}
+	var c ChaosConfig
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("ParseChaosConfig: %w", err)
+	}
+	if c.SlowDelay <= 0 {
+		c.SlowDelay = Duration(2 * time.Minute)
+	}
+	if c.HugeBytes <= 0 {
+		c.HugeBytes = 5 << 20
+	}
+	return &c, nil
+}
+
+func roll(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// failed 是否注入錯誤；c 為 nil（未啟用）時一律 false。
+func (c *ChaosConfig) failed() bool {
+	return c != nil && roll(c.ErrorRate)
+}
+
+// before 呼叫 provider 前注入錯誤、延遲與中斷。
+func (c *ChaosConfig) before(ctx context.Context) error {
+	if c.failed() {
+		return ErrChaos
+	}
+	return c.disrupt(ctx)
+}
+
+// disrupt 注入延遲與中斷，串流時於每個片段前呼叫。
+func (c *ChaosConfig) disrupt(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	if roll(c.SlowRate) {
+		select {
+		case <-time.After(time.Duration(c.SlowDelay)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if roll(c.CancelRate) {
+		return fmt.Errorf("mock chaos: stream interrupted: %w", io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// mangle 依設定將正常輸出替換為損毀或超長內容。
+func (c *ChaosConfig) mangle(text string) string {
+	if c == nil {
+		return text
+	}
+	if roll(c.MalformedRate) {
+		runes := []rune(text)
+		return string(runes[:len(runes)/2]) + "\xff\xfe\x00"
+	}
+	if roll(c.HugeRate) && text != "" {
+		return strings.Repeat(text, c.HugeBytes/len(text)+1)[:c.HugeBytes]
+	}
+	return text
+}
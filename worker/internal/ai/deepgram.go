@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+func init() { RegisterSTT("deepgram", newDeepgramSTT) }
+
+// newDeepgramSTT 依 DEEPGRAM_* 建立 Deepgram 預錄音檔轉錄。
+func newDeepgramSTT(_ context.Context, opts Options) (STTService, error) {
+	stt := &DeepgramSTT{
+		APIKey:      opts("DEEPGRAM_KEY"),
+		URL:         opts.String("DEEPGRAM_URL", DefaultDeepgramURL),
+		Model:       opts.String("DEEPGRAM_MODEL", "nova-3"),
+		SmartFormat: opts.Bool("DEEPGRAM_SMART_FORMAT", true),
+		Diarize:     opts.Bool("DEEPGRAM_DIARIZE", false),
+		Language:    opts("DEEPGRAM_LANGUAGE"),
+	}
+	if stt.APIKey == "" {
+		return nil, fmt.Errorf("DEEPGRAM_KEY must be provided")
+	}
+	log.Printf("Deepgram STT enabled: model=%s smart_format=%t diarize=%t", stt.Model, stt.SmartFormat, stt.Diarize)
+	return stt, nil
+}
+
+// DefaultDeepgramURL Deepgram 預錄音檔轉錄 API。
+const DefaultDeepgramURL = "https://api.deepgram.com/v1/listen"
+
+// DeepgramSTT 以 Deepgram 預錄音檔 API（/v1/listen）轉錄；音檔以原始位元組上傳，參數放在 query string。
+type DeepgramSTT struct {
+	APIKey      string
+	URL         string // 空值為 DefaultDeepgramURL
+	Model       string // 例如 nova-3
+	SmartFormat bool   // 標點、數字、日期等格式化
+	Diarize     bool   // 區分說話者，轉錄稿以 "Speaker N:" 段落輸出
+	Language    string // 預設語言，任務的語言提示優先；空值時由 Deepgram 偵測
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (d *DeepgramSTT) STTModelName() string { return d.Model }
+
+func (d *DeepgramSTT) STT(ctx context.Context, filePath string) (string, error) {
+	res, err := d.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
+
+// TranscribeWithOptions 轉錄並套用語言提示 / 模型覆寫；未指定語言時啟用 detect_language 回報偵測結果。
+// Deepgram 不支援前文提示，opts.Prompt 忽略；回應一律含逐字時間，opts.Timestamps 時填入 Words。
+func (d *DeepgramSTT) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return STTResult{}, err
+	}
+	defer file.Close()
+	st, err := file.Stat()
+	if err != nil {
+		return STTResult{}, err
+	}
+
+	model := d.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	lang := NormalizeLanguage(opts.Language)
+	if lang == "" {
+		lang = NormalizeLanguage(d.Language)
+	}
+	query := url.Values{}
+	if model != "" {
+		query.Set("model", model)
+	}
+	query.Set("smart_format", strconv.FormatBool(d.SmartFormat))
+	query.Set("punctuate", "true")
+	if d.Diarize {
+		query.Set("diarize", "true")
+		query.Set("paragraphs", "true")
+	}
+	if lang != "" {
+		query.Set("language", lang)
+	} else {
+		query.Set("detect_language", "true")
+	}
+	endpoint := d.URL
+	if endpoint == "" {
+		endpoint = DefaultDeepgramURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint+"?"+query.Encode(), file)
+	if err != nil {
+		return STTResult{}, err
+	}
+	req.ContentLength = st.Size()
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := keyPool("deepgram", d.APIKey).do(httpClient(d.HTTPClient), req, func(r *http.Request, key string) {
+		r.Header.Set("Authorization", "Token "+key)
+	})
+	if err != nil {
+		return STTResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return STTResult{}, sttStatusError("deepgram", resp.StatusCode, b)
+	}
+
+	var result struct {
+		Results struct {
+			Channels []struct {
+				DetectedLanguage string `json:"detected_language"`
+				Alternatives     []struct {
+					Transcript string `json:"transcript"`
+					Paragraphs struct {
+						Transcript string `json:"transcript"`
+					} `json:"paragraphs"`
+					Words []struct {
+						Word           string  `json:"word"`
+						PunctuatedWord string  `json:"punctuated_word"`
+						Start          float64 `json:"start"`
+						End            float64 `json:"end"`
+					} `json:"words"`
+				} `json:"alternatives"`
+			} `json:"channels"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return STTResult{}, err
+	}
+	if len(result.Results.Channels) == 0 || len(result.Results.Channels[0].Alternatives) == 0 {
+		return STTResult{}, fmt.Errorf("deepgram stt failed: response has no transcript")
+	}
+	channel := result.Results.Channels[0]
+	alt := channel.Alternatives[0]
+	text := alt.Transcript
+	if d.Diarize && alt.Paragraphs.Transcript != "" {
+		text = alt.Paragraphs.Transcript
+	}
+	detected := NormalizeLanguage(channel.DetectedLanguage)
+	if detected == "" {
+		detected = lang
+	}
+	res := STTResult{Text: strings.TrimSpace(text), Language: detected}
+	if opts.Timestamps {
+		for _, w := range alt.Words {
+			word := w.PunctuatedWord
+			if word == "" {
+				word = w.Word
+			}
+			res.Words = append(res.Words, Word{Text: word, Start: w.Start, End: w.End})
+		}
+	}
+	return res, nil
+}
+
+// StartStream 以 Deepgram live streaming（wss 的 /v1/listen）即時轉錄 PCM 音訊，回傳 interim 與最終結果。
+// CloseSend 送出 CloseStream，Deepgram 送完剩餘結果後關閉連線。超過約 10 秒未送音訊時 Deepgram 會中斷連線。
+func (d *DeepgramSTT) StartStream(ctx context.Context, opts StreamOptions) (STTStream, error) {
+	model := d.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	lang := NormalizeLanguage(opts.Language)
+	if lang == "" {
+		lang = NormalizeLanguage(d.Language)
+	}
+	rate := opts.SampleRate
+	if rate == 0 {
+		rate = 16000
+	}
+	query := url.Values{}
+	if model != "" {
+		query.Set("model", model)
+	}
+	query.Set("encoding", "linear16")
+	query.Set("sample_rate", strconv.Itoa(rate))
+	query.Set("channels", strconv.Itoa(max(opts.Channels, 1)))
+	query.Set("interim_results", "true")
+	query.Set("smart_format", strconv.FormatBool(d.SmartFormat))
+	query.Set("punctuate", "true")
+	if lang != "" {
+		query.Set("language", lang)
+	}
+	endpoint := d.URL
+	if endpoint == "" {
+		endpoint = DefaultDeepgramURL
+	}
+
+	conn, err := dialStream(ctx, wsURL(endpoint)+"?"+query.Encode(), http.Header{
+		"Authorization": {"Token " + keyPool("deepgram", d.APIKey).Pick()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deepgram stream: %w", err)
+	}
+	s := &wsStream{
+		sendAudio: func(conn *websocket.Conn, audio []byte) error {
+			return websocket.Message.Send(conn, audio)
+		},
+		closeSend: func(conn *websocket.Conn) error {
+			return websocket.Message.Send(conn, `{"type":"CloseStream"}`)
+		},
+	}
+	return startWSStream(ctx, conn, s, func(msg []byte) ([]StreamResult, error) {
+		var m struct {
+			Type    string `json:"type"`
+			Channel struct {
+				Alternatives []struct {
+					Transcript string `json:"transcript"`
+				} `json:"alternatives"`
+			} `json:"channel"`
+			IsFinal  bool    `json:"is_final"`
+			Start    float64 `json:"start"`
+			Duration float64 `json:"duration"`
+			// 錯誤訊息
+			Description string `json:"description"`
+		}
+		if err := json.Unmarshal(msg, &m); err != nil {
+			return nil, fmt.Errorf("deepgram stream: %w", err)
+		}
+		switch m.Type {
+		case "Results":
+			if len(m.Channel.Alternatives) == 0 || m.Channel.Alternatives[0].Transcript == "" {
+				return nil, nil
+			}
+			return []StreamResult{{
+				Text:    m.Channel.Alternatives[0].Transcript,
+				IsFinal: m.IsFinal,
+				Start:   m.Start,
+				End:     m.Start + m.Duration,
+			}}, nil
+		case "Error":
+			return nil, fmt.Errorf("deepgram stream: %s", m.Description)
+		}
+		return nil, nil
+	}), nil
+}
@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// Embedder 將文字轉為向量，供語意搜尋與問答使用。返回的向量與 texts 順序一致。
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbeddingModelNamer 可選介面：返回 embedding 模型名稱，與向量一起保存以便日後換模型時重建。
+type EmbeddingModelNamer interface {
+	EmbeddingModelName() string
+}
+
+// EmbeddingModelName 返回 e 的模型名稱，未實作 EmbeddingModelNamer 時為空字串。
+func EmbeddingModelName(e Embedder) string {
+	if n, ok := e.(EmbeddingModelNamer); ok {
+		return n.EmbeddingModelName()
+	}
+	return ""
+}
+
+// DefaultEmbeddingURL OpenAI embeddings 端點。
+const DefaultEmbeddingURL = "https://api.openai.com/v1/embeddings"
+
+// NewEmbedder 依 EMBEDDING_PROVIDER 建立 Embedder："openai"（OpenAI 相容 /v1/embeddings）、"ollama" 或 "mock"；
+// 空字串表示停用，返回 nil。
+func NewEmbedder(name string, opts Options) (Embedder, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "openai":
+		e := &OpenAIEmbedder{
+			URL:        opts.String("EMBEDDING_URL", DefaultEmbeddingURL),
+			APIKey:     opts("EMBEDDING_KEY"),
+			Model:      opts.String("EMBEDDING_MODEL", "text-embedding-3-small"),
+			Dimensions: opts.Int("EMBEDDING_DIMENSIONS", 0),
+		}
+		if e.Dimensions < 0 {
+			return nil, fmt.Errorf("invalid EMBEDDING_DIMENSIONS: %d (must be >= 0)", e.Dimensions)
+		}
+		log.Printf("Embeddings enabled: %s model=%s", e.URL, e.Model)
+		return e, nil
+	case "ollama":
+		e := &OllamaEmbedder{
+			URL:   opts.String("EMBEDDING_URL", opts.String("OLLAMA_URL", DefaultOllamaURL)),
+			Model: opts.String("EMBEDDING_MODEL", "nomic-embed-text"),
+		}
+		log.Printf("Embeddings enabled: ollama %s model=%s", e.URL, e.Model)
+		return e, nil
+	case "mock":
+		return MockEmbedder{}, nil
+	}
+	return nil, fmt.Errorf("unknown embedding provider %q (available: openai, ollama, mock)", name)
+}
+
+// OpenAIEmbedder 呼叫 OpenAI 相容的 /v1/embeddings。
+type OpenAIEmbedder struct {
+	URL        string // 空值為 DefaultEmbeddingURL
+	APIKey     string // 可為逗號分隔的多把 key
+	Model      string
+	Dimensions int // 大於 0 時要求縮減維度（text-embedding-3 系列支援）
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (e *OpenAIEmbedder) EmbeddingModelName() string { return e.Model }
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	endpoint := e.URL
+	if endpoint == "" {
+		endpoint = DefaultEmbeddingURL
+	}
+	payload := map[string]interface{}{"model": e.Model, "input": texts}
+	if e.Dimensions > 0 {
+		payload["dimensions"] = e.Dimensions
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := keyPool("embedding", e.APIKey).do(httpClient(e.HTTPClient), req, func(r *http.Request, key string) {
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai embeddings failed: %s", string(b))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("openai embeddings: unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, checkEmbeddings(vectors)
+}
+
+// OllamaEmbedder 呼叫 Ollama /api/embed。
+type OllamaEmbedder struct {
+	URL   string // 空值為 DefaultOllamaURL
+	Model string // 例如 nomic-embed-text
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (e *OllamaEmbedder) EmbeddingModelName() string { return e.Model }
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	base := e.URL
+	if base == "" {
+		base = DefaultOllamaURL
+	}
+	body, _ := json.Marshal(map[string]interface{}{"model": e.Model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(base, "/")+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient(e.HTTPClient).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama embeddings failed: %s", string(b))
+	}
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama embeddings: got %d vectors for %d inputs", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, checkEmbeddings(result.Embeddings)
+}
+
+// MockEmbedder 以文字雜湊產生固定的 8 維單位向量，用於開發測試環境。
+type MockEmbedder struct{}
+
+func (MockEmbedder) EmbeddingModelName() string { return "mock" }
+
+func (MockEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, 8)
+		var norm float64
+		for j := range v {
+			h := fnv.New32a()
+			fmt.Fprintf(h, "%d:%s", j, t)
+			v[j] = float32(h.Sum32())/math.MaxUint32*2 - 1
+			norm += float64(v[j]) * float64(v[j])
+		}
+		for j := range v {
+			v[j] /= float32(math.Sqrt(norm))
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// checkEmbeddings 確認每個輸入都有向量且維度一致。
+func checkEmbeddings(vectors [][]float32) error {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return fmt.Errorf("embeddings: missing vector for input %d", i)
+		}
+		if len(v) != len(vectors[0]) {
+			return fmt.Errorf("embeddings: inconsistent dimensions (%d vs %d)", len(v), len(vectors[0]))
+		}
+	}
+	return nil
+}
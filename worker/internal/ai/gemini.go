@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() { RegisterLLM("gemini", newGeminiSummarizer) }
+
+// newGeminiSummarizer 依 GEMINI_* 建立 Gemini 摘要，預設 Prompt 沿用 AI_LLM_PROMPT。
+func newGeminiSummarizer(_ context.Context, opts Options) (Summarizer, error) {
+	llm := &GeminiSummarizer{
+		APIKey: opts("GEMINI_KEY"),
+		URL:    opts.String("GEMINI_URL", DefaultGeminiURL),
+		Model:  opts.String("GEMINI_MODEL", "gemini-2.5-flash-lite"),
+		Prompt: opts("AI_LLM_PROMPT"),
+	}
+	if llm.APIKey == "" {
+		return nil, fmt.Errorf("GEMINI_KEY must be provided")
+	}
+	log.Printf("Gemini summarizer enabled: model=%s", llm.Model)
+	return llm, nil
+}
+
+// DefaultGeminiURL Gemini（generativelanguage）API 根路徑。
+const DefaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiSummarizer 以 Gemini generateContent / streamGenerateContent API 生成摘要，
+// 不需經過 OpenAI 相容層。系統提示放在 systemInstruction，逐字稿與摘要指示為單一 user 訊息。
+type GeminiSummarizer struct {
+	APIKey string
+	URL    string // 空值為 DefaultGeminiURL
+	Model  string // 例如 gemini-2.5-flash-lite
+	Prompt string // 預設摘要 Prompt
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (g *GeminiSummarizer) LLMModelName() string { return g.Model }
+
+// geminiResponse generateContent 回應（串流時每個 SSE 事件為相同結構）。
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	// UsageMetadata 串流時每個事件皆為累計值，以最後一個為準。
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// usage 返回回應的 token 用量。
+func (r geminiResponse) usage() TokenUsage {
+	return TokenUsage{PromptTokens: r.UsageMetadata.PromptTokenCount, CompletionTokens: r.UsageMetadata.CandidatesTokenCount}
+}
+
+// text 串接第一個候選的所有 part。
+func (r geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range r.Candidates[0].Content.Parts {
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+// Summarize 呼叫 generateContent 一次性生成摘要。
+func (g *GeminiSummarizer) Summarize(ctx context.Context, text string, userPrompt string) (string, error) {
+	resp, err := g.post(ctx, "generateContent", text, userPrompt, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	reportUsage(ctx, result.usage())
+	if result.PromptFeedback.BlockReason != "" {
+		return "", fmt.Errorf("gemini llm failed: prompt blocked (%s)", result.PromptFeedback.BlockReason)
+	}
+	if summary := result.text(); summary != "" {
+		return summary, nil
+	}
+	return "", fmt.Errorf("no summary generated")
+}
+
+// SummarizeStream 呼叫 streamGenerateContent（alt=sse），逐事件解析並透過 onChunk 回傳摘要片段。
+func (g *GeminiSummarizer) SummarizeStream(ctx context.Context, text string, userPrompt string, onChunk func(chunk string)) error {
+	resp, err := g.post(ctx, "streamGenerateContent", text, userPrompt, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var usage TokenUsage
+	defer func() { reportUsage(ctx, usage) }()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if u := chunk.usage(); u != (TokenUsage{}) {
+			usage = u
+		}
+		if chunk.PromptFeedback.BlockReason != "" {
+			return fmt.Errorf("gemini stream failed: prompt blocked (%s)", chunk.PromptFeedback.BlockReason)
+		}
+		if t := chunk.text(); t != "" {
+			onChunk(t)
+		}
+	}
+	return scanner.Err()
+}
+
+// SummarizeStructured 以 responseMimeType=application/json 與 responseJsonSchema 要求模型輸出 StructuredSummary。
+func (g *GeminiSummarizer) SummarizeStructured(ctx context.Context, text string, userPrompt string) (StructuredSummary, error) {
+	resp, err := g.post(ctx, "generateContent", text, structuredPrompt(userPrompt), SummarySchema())
+	if err != nil {
+		return StructuredSummary{}, err
+	}
+	defer resp.Body.Close()
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return StructuredSummary{}, err
+	}
+	reportUsage(ctx, result.usage())
+	if result.PromptFeedback.BlockReason != "" {
+		return StructuredSummary{}, fmt.Errorf("gemini llm failed: prompt blocked (%s)", result.PromptFeedback.BlockReason)
+	}
+	return ParseStructuredSummary(result.text())
+}
+
+// post 送出 models/{model}:{method} 請求；串流方法附加 alt=sse。非 200 回應轉為錯誤。
+// schema 非 nil 時要求 JSON 輸出，取樣參數只套用 temperature。
+func (g *GeminiSummarizer) post(ctx context.Context, method, text, userPrompt string, schema map[string]interface{}) (*http.Response, error) {
+	if userPrompt == "" {
+		userPrompt = g.Prompt
+	}
+	if userPrompt == "" {
+		userPrompt = defaultSummaryPrompt(ctx)
+	}
+	payload := map[string]interface{}{
+		"systemInstruction": map[string]interface{}{
+			"parts": []map[string]string{{"text": systemPrompt(ctx)}},
+		},
+		"contents": []map[string]interface{}{{
+			"role":  "user",
+			"parts": []map[string]string{{"text": userMessage(ctx, userPrompt, text)}},
+		}},
+	}
+	params := generationParams(ctx)
+	if schema != nil {
+		params = GenerationParams{Temperature: params.Temperature}
+	}
+	cfg := params.geminiConfig()
+	if schema != nil {
+		if cfg == nil {
+			cfg = map[string]interface{}{}
+		}
+		cfg["responseMimeType"] = "application/json"
+		cfg["responseJsonSchema"] = schema
+	}
+	if cfg != nil {
+		payload["generationConfig"] = cfg
+	}
+	body, _ := json.Marshal(payload)
+
+	base := g.URL
+	if base == "" {
+		base = DefaultGeminiURL
+	}
+	endpoint := strings.TrimSuffix(base, "/") + "/models/" + url.PathEscape(g.Model) + ":" + method
+	if method == "streamGenerateContent" {
+		endpoint += "?alt=sse"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := keyPool("gemini", g.APIKey).do(httpClient(g.HTTPClient), req, func(r *http.Request, key string) {
+		r.Header.Set("x-goog-api-key", key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("gemini llm failed: %s", string(b))
+	}
+	return resp, nil
+}
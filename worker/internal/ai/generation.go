@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// GenerationParams LLM 取樣參數；nil / 零值的欄位不送出，沿用 provider 預設。
+type GenerationParams struct {
+	Temperature      *float64 `json:"temperature,omitempty"`      // 0–2
+	TopP             *float64 `json:"topP,omitempty"`             // 0–1
+	MaxTokens        int      `json:"maxTokens,omitempty"`        // 輸出上限
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`  // -2–2
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"` // -2–2
+	Stop             []string `json:"stop,omitempty"`             // 最多 MaxStopSequences 個
+}
+
+// MaxStopSequences stop sequences 數量上限（OpenAI 的限制，Gemini 為 5）。
+const MaxStopSequences = 4
+
+// ParseGenerationParams 讀取部署預設：AI_LLM_TEMPERATURE、AI_LLM_TOP_P、AI_LLM_MAX_TOKENS、
+// AI_LLM_PRESENCE_PENALTY、AI_LLM_FREQUENCY_PENALTY 與 AI_LLM_STOP（JSON 字串陣列）。
+func ParseGenerationParams(opts Options) (GenerationParams, error) {
+	var p GenerationParams
+	floats := []struct {
+		key string
+		dst **float64
+	}{
+		{"AI_LLM_TEMPERATURE", &p.Temperature},
+		{"AI_LLM_TOP_P", &p.TopP},
+		{"AI_LLM_PRESENCE_PENALTY", &p.PresencePenalty},
+		{"AI_LLM_FREQUENCY_PENALTY", &p.FrequencyPenalty},
+	}
+	for _, f := range floats {
+		v := opts(f.key)
+		if v == "" {
+			continue
+		}
+		x, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return p, fmt.Errorf("ParseGenerationParams(%s): %w", f.key, err)
+		}
+		*f.dst = &x
+	}
+	if v := opts("AI_LLM_MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("ParseGenerationParams(AI_LLM_MAX_TOKENS): %w", err)
+		}
+		p.MaxTokens = n
+	}
+	if v := opts("AI_LLM_STOP"); v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Stop); err != nil {
+			return p, fmt.Errorf("ParseGenerationParams(AI_LLM_STOP): %w", err)
+		}
+	}
+	return p, p.Validate()
+}
+
+// Validate 檢查各參數是否在 provider 共同接受的範圍內。
+func (p GenerationParams) Validate() error {
+	inRange := func(name string, v *float64, lo, hi float64) error {
+		if v != nil && (*v < lo || *v > hi) {
+			return fmt.Errorf("%s %g out of range [%g, %g]", name, *v, lo, hi)
+		}
+		return nil
+	}
+	for _, err := range []error{
+		inRange("temperature", p.Temperature, 0, 2),
+		inRange("topP", p.TopP, 0, 1),
+		inRange("presencePenalty", p.PresencePenalty, -2, 2),
+		inRange("frequencyPenalty", p.FrequencyPenalty, -2, 2),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	if p.MaxTokens < 0 {
+		return fmt.Errorf("maxTokens %d must be >= 0", p.MaxTokens)
+	}
+	if len(p.Stop) > MaxStopSequences {
+		return fmt.Errorf("at most %d stop sequences, got %d", MaxStopSequences, len(p.Stop))
+	}
+	for _, s := range p.Stop {
+		if s == "" {
+			return fmt.Errorf("stop sequences must not be empty")
+		}
+	}
+	return nil
+}
+
+// Merge 返回以 o 中有設定的欄位覆寫 p 的結果。
+func (p GenerationParams) Merge(o GenerationParams) GenerationParams {
+	if o.Temperature != nil {
+		p.Temperature = o.Temperature
+	}
+	if o.TopP != nil {
+		p.TopP = o.TopP
+	}
+	if o.MaxTokens > 0 {
+		p.MaxTokens = o.MaxTokens
+	}
+	if o.PresencePenalty != nil {
+		p.PresencePenalty = o.PresencePenalty
+	}
+	if o.FrequencyPenalty != nil {
+		p.FrequencyPenalty = o.FrequencyPenalty
+	}
+	if len(o.Stop) > 0 {
+		p.Stop = o.Stop
+	}
+	return p
+}
+
+type generationParamsKey struct{}
+
+// WithGenerationParams 返回帶有取樣參數的 context，讓單次摘要套用部署或任務指定的設定。
+func WithGenerationParams(ctx context.Context, p GenerationParams) context.Context {
+	return context.WithValue(ctx, generationParamsKey{}, p)
+}
+
+// generationParams 返回 ctx 上的取樣參數，未設定時為零值（全部沿用 provider 預設）。
+func generationParams(ctx context.Context) GenerationParams {
+	p, _ := ctx.Value(generationParamsKey{}).(GenerationParams)
+	return p
+}
+
+// applyOpenAI 將取樣參數寫入 Chat Completions payload（Ollama 的 options 使用相同鍵名，num_predict 除外）。
+func (p GenerationParams) applyOpenAI(payload map[string]interface{}, maxTokensKey string) {
+	if p.Temperature != nil {
+		payload["temperature"] = *p.Temperature
+	}
+	if p.TopP != nil {
+		payload["top_p"] = *p.TopP
+	}
+	if p.MaxTokens > 0 {
+		payload[maxTokensKey] = p.MaxTokens
+	}
+	if p.PresencePenalty != nil {
+		payload["presence_penalty"] = *p.PresencePenalty
+	}
+	if p.FrequencyPenalty != nil {
+		payload["frequency_penalty"] = *p.FrequencyPenalty
+	}
+	if len(p.Stop) > 0 {
+		payload["stop"] = p.Stop
+	}
+}
+
+// geminiConfig 返回 Gemini 的 generationConfig，未設定任何參數時為 nil。
+func (p GenerationParams) geminiConfig() map[string]interface{} {
+	cfg := map[string]interface{}{}
+	if p.Temperature != nil {
+		cfg["temperature"] = *p.Temperature
+	}
+	if p.TopP != nil {
+		cfg["topP"] = *p.TopP
+	}
+	if p.MaxTokens > 0 {
+		cfg["maxOutputTokens"] = p.MaxTokens
+	}
+	if p.PresencePenalty != nil {
+		cfg["presencePenalty"] = *p.PresencePenalty
+	}
+	if p.FrequencyPenalty != nil {
+		cfg["frequencyPenalty"] = *p.FrequencyPenalty
+	}
+	if len(p.Stop) > 0 {
+		cfg["stopSequences"] = p.Stop
+	}
+	if len(cfg) == 0 {
+		return nil
+	}
+	return cfg
+}
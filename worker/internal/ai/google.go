@@ -0,0 +1,367 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+func init() { RegisterSTT("google", newGoogleSTT) }
+
+// newGoogleSTT 依 GOOGLE_STT_* 建立 Google Cloud Speech-to-Text 轉錄，以 GOOGLE_APPLICATION_CREDENTIALS 的 service account 認證。
+func newGoogleSTT(_ context.Context, opts Options) (STTService, error) {
+	path := opts("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS must be provided")
+	}
+	creds, err := LoadGoogleServiceAccount(path)
+	if err != nil {
+		return nil, err
+	}
+	stt := &GoogleSTT{
+		URL:             opts.String("GOOGLE_STT_URL", DefaultGoogleSpeechURL),
+		Credentials:     creds,
+		Model:           opts("GOOGLE_STT_MODEL"),
+		Language:        opts.String("GOOGLE_STT_LANGUAGE", "zh-TW"),
+		Punctuation:     opts.Bool("GOOGLE_STT_PUNCTUATION", true),
+		WordTimestamps:  opts.Bool("GOOGLE_STT_WORD_TIMESTAMPS", true),
+		PollInterval:    time.Second,
+		MaxPollInterval: 10 * time.Second,
+	}
+	log.Printf("Google STT enabled: %s language=%s model=%s", creds.ClientEmail, stt.Language, stt.Model)
+	return stt, nil
+}
+
+// DefaultGoogleSpeechURL Google Cloud Speech-to-Text v1 API 根路徑。
+const DefaultGoogleSpeechURL = "https://speech.googleapis.com/v1"
+
+// googleInlineLimit 以 content 內嵌音訊時的請求大小上限；超過時返回 ErrPayloadTooLarge 讓 Worker 對半切割。
+const googleInlineLimit = 10 * 1024 * 1024
+
+// GoogleSTT 以 Google Cloud Speech-to-Text longrunningrecognize 轉錄：音訊以 base64 內嵌，
+// 建立 operation 後輪詢至 done。認證使用 service account 金鑰（JSON）交換的 access token。
+type GoogleSTT struct {
+	URL             string // 空值為 DefaultGoogleSpeechURL
+	Credentials     *GoogleServiceAccount
+	Model           string // 例如 latest_long；空值沿用 API 預設
+	Language        string // BCP-47 語言代碼（例如 zh-TW），任務的語言提示優先；Google 不支援自動偵測，必填
+	Punctuation     bool   // enableAutomaticPunctuation
+	WordTimestamps  bool   // enableWordTimeOffsets，結果填入 STTResult.Words；opts.Timestamps 時一律啟用
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (g *GoogleSTT) STTModelName() string {
+	if g.Model == "" {
+		return "google"
+	}
+	return g.Model
+}
+
+func (g *GoogleSTT) STT(ctx context.Context, filePath string) (string, error) {
+	res, err := g.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
+
+// TranscribeWithOptions 轉錄並套用語言提示 / 模型覆寫。詞彙表以 speechContexts 片語傳入；
+// speechContexts 只接受短片語，初始提示與前文提示不適用於此 provider。
+func (g *GoogleSTT) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	audio, err := os.ReadFile(filePath)
+	if err != nil {
+		return STTResult{}, err
+	}
+	// base64 膨脹 4/3
+	if len(audio)/3*4 > googleInlineLimit {
+		return STTResult{}, fmt.Errorf("google stt failed: %w: %d bytes exceeds the inline limit", ErrPayloadTooLarge, len(audio))
+	}
+
+	lang := strings.TrimSpace(opts.Language)
+	if lang == "" {
+		lang = g.Language
+	}
+	model := g.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	cfg := map[string]interface{}{
+		"languageCode":               lang,
+		"enableAutomaticPunctuation": g.Punctuation,
+		"enableWordTimeOffsets":      g.WordTimestamps || opts.Timestamps,
+	}
+	if model != "" {
+		cfg["model"] = model
+	}
+	// WAV / FLAC 由檔頭判斷編碼；Ogg Opus 需明確指定（chunk 為 16kHz mono）
+	if strings.EqualFold(filepath.Ext(filePath), ".ogg") {
+		cfg["encoding"] = "OGG_OPUS"
+		cfg["sampleRateHertz"] = 16000
+	}
+	if phrases := googlePhrases(opts.Phrases); len(phrases) > 0 {
+		cfg["speechContexts"] = []map[string]interface{}{{"phrases": phrases}}
+	}
+	payload := map[string]interface{}{
+		"config": cfg,
+		"audio":  map[string]string{"content": base64.StdEncoding.EncodeToString(audio)},
+	}
+
+	var op googleOperation
+	if err := g.call(ctx, "POST", "/speech:longrunningrecognize", payload, &op); err != nil {
+		return STTResult{}, err
+	}
+	interval := g.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return STTResult{}, ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval *= 2; g.MaxPollInterval > 0 && interval > g.MaxPollInterval {
+			interval = g.MaxPollInterval
+		}
+		if err := g.call(ctx, "GET", "/operations/"+op.Name, nil, &op); err != nil {
+			return STTResult{}, err
+		}
+	}
+	if op.Error != nil {
+		return STTResult{}, sttStatusError("google", http.StatusOK, []byte(op.Error.Message))
+	}
+	return op.Response.result(NormalizeLanguage(lang)), nil
+}
+
+// googleOperation longrunningrecognize 的 operation 資源。
+type googleOperation struct {
+	Name  string `json:"name"`
+	Done  bool   `json:"done"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Response googleRecognizeResponse `json:"response"`
+}
+
+type googleRecognizeResponse struct {
+	Results []struct {
+		Alternatives []struct {
+			Transcript string `json:"transcript"`
+			Words      []struct {
+				StartTime string `json:"startTime"` // 例如 "1.500s"
+				EndTime   string `json:"endTime"`
+				Word      string `json:"word"`
+			} `json:"words"`
+		} `json:"alternatives"`
+		LanguageCode string `json:"languageCode"`
+	} `json:"results"`
+}
+
+// result 串接各段的第一候選；段落之間以空白分隔（中文等不分詞語言 Google 本身不會加入空白）。
+func (r googleRecognizeResponse) result(lang string) STTResult {
+	var res STTResult
+	var parts []string
+	for _, seg := range r.Results {
+		if len(seg.Alternatives) == 0 {
+			continue
+		}
+		alt := seg.Alternatives[0]
+		parts = append(parts, strings.TrimSpace(alt.Transcript))
+		first := len(res.Words)
+		for _, w := range alt.Words {
+			res.Words = append(res.Words, Word{Text: w.Word, Start: parseSeconds(w.StartTime), End: parseSeconds(w.EndTime)})
+		}
+		// 每個 result 為一段：起訖取自其第一個與最後一個詞
+		if words := res.Words[first:]; len(words) > 0 {
+			res.Segments = append(res.Segments, Segment{Text: strings.TrimSpace(alt.Transcript), Start: words[0].Start, End: words[len(words)-1].End})
+		}
+		if res.Language == "" {
+			res.Language = NormalizeLanguage(seg.LanguageCode)
+		}
+	}
+	res.Text = strings.Join(parts, " ")
+	if res.Language == "" {
+		res.Language = lang
+	}
+	return res
+}
+
+// parseSeconds 解析秒數字串：protobuf Duration 的 JSON 表示（"1.500s"）或純數字（"1.500"）。
+func parseSeconds(s string) float64 {
+	sec, _ := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+	return sec
+}
+
+func (g *GoogleSTT) call(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		b, _ := json.Marshal(payload)
+		body = bytes.NewReader(b)
+	}
+	base := g.URL
+	if base == "" {
+		base = DefaultGoogleSpeechURL
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, err := g.Credentials.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("google stt failed: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient(g.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return sttStatusError("google", resp.StatusCode, b)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// googleMaxPhrase speechContexts 單一片語的字元上限，超過時整個請求會被拒絕。
+const googleMaxPhrase = 100
+
+// googlePhrases 捨棄超過 googleMaxPhrase 字元的片語。
+func googlePhrases(terms []string) []string {
+	var phrases []string
+	for _, t := range terms {
+		if utf8.RuneCountInString(t) <= googleMaxPhrase {
+			phrases = append(phrases, t)
+		}
+	}
+	return phrases
+}
+
+// googleScope access token 的授權範圍。
+const googleScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// GoogleServiceAccount 以 service account 金鑰簽署 JWT 並交換 OAuth access token，token 到期前 1 分鐘重新取得。
+type GoogleServiceAccount struct {
+	ClientEmail string
+	TokenURI    string
+	key         *rsa.PrivateKey
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// LoadGoogleServiceAccount 讀取 service account 金鑰 JSON（GCP console 下載的格式）。
+func LoadGoogleServiceAccount(path string) (*GoogleServiceAccount, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): %w", path, err)
+	}
+	var f struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): %w", path, err)
+	}
+	if f.Type != "service_account" || f.ClientEmail == "" {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): not a service account key", path)
+	}
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): private_key is not PEM", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("LoadGoogleServiceAccount(%s): private_key is not RSA", path)
+	}
+	if f.TokenURI == "" {
+		f.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &GoogleServiceAccount{ClientEmail: f.ClientEmail, TokenURI: f.TokenURI, key: key}, nil
+}
+
+// Token 返回有效的 access token，必要時以 JWT bearer grant 重新取得。
+func (s *GoogleServiceAccount) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Until(s.expires) > time.Minute {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.ClientEmail,
+		"scope": googleScope,
+		"aud":   s.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("Token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + enc.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("Token: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient(nil).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Token: %s: %s", resp.Status, b)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("Token: %w", err)
+	}
+	s.token = result.AccessToken
+	s.expires = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.token, nil
+}
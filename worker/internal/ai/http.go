@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPClientConfig AI provider 共用 HTTP client 的連線設定。
+// 不設定整體 timeout：轉錄與串流摘要的總時間由呼叫端的 context deadline 控制。
+type HTTPClientConfig struct {
+	DialTimeout           time.Duration // 建立 TCP 連線
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // 送出請求後等待回應 header（非串流轉錄需涵蓋整個處理時間），0 表示不限
+	IdleConnTimeout       time.Duration // keep-alive 閒置連線保留時間
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int // 0 表示不限
+}
+
+// DefaultHTTPClientConfig 連線 10 秒、TLS 10 秒、回應 header 10 分鐘，每個 host 保留 16 條閒置連線 90 秒。
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		DialTimeout:           10 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   16,
+	}
+}
+
+// NewHTTPClient 依設定建立 HTTP client；代理伺服器取自 HTTPS_PROXY / HTTP_PROXY / NO_PROXY。
+func NewHTTPClient(cfg HTTPClientConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+			IdleConnTimeout:       cfg.IdleConnTimeout,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       cfg.MaxConnsPerHost,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// sharedClient 各 provider 未指定 HTTPClient 時使用的 client。
+var sharedClient atomic.Pointer[http.Client]
+
+func init() { sharedClient.Store(NewHTTPClient(DefaultHTTPClientConfig())) }
+
+// SetHTTPClient 替換各 provider 共用的 HTTP client，需在建立 provider 前呼叫。
+func SetHTTPClient(c *http.Client) { sharedClient.Store(c) }
+
+// httpClient 返回 provider 指定的 client，nil 時為共用 client。
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return sharedClient.Load()
+}
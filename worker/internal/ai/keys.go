@@ -0,0 +1,166 @@
+package ai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tts-worker/internal/metrics"
+)
+
+// DefaultKeyCooldown key 遇到 429 / 402 且回應未帶 Retry-After 時的暫停時間。
+const DefaultKeyCooldown = time.Minute
+
+var keyCooldown atomic.Int64
+
+func init() { keyCooldown.Store(int64(DefaultKeyCooldown)) }
+
+// SetKeyCooldown 設定 key 遇到限流 / 額度錯誤時的預設暫停時間，需在建立 provider 前呼叫。
+func SetKeyCooldown(d time.Duration) {
+	if d > 0 {
+		keyCooldown.Store(int64(d))
+	}
+}
+
+// KeyPool 同一 provider 的多把 API key（設定值以逗號分隔）：請求輪流使用各 key，
+// 回應 429（限流）或 402（額度不足）的 key 暫停一段時間，期間改用其他 key。
+type KeyPool struct {
+	provider string
+	keys     []*poolKey
+	next     atomic.Uint64
+}
+
+type poolKey struct {
+	key         string
+	label       string // metrics 標籤：序號與末四碼，不含完整 key
+	parkedUntil atomic.Int64
+}
+
+// ParseKeys 解析逗號分隔的 key 清單，忽略空白項目。
+func ParseKeys(spec string) []string {
+	var keys []string
+	for _, k := range strings.Split(spec, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// pools 相同 provider 與設定值共用同一個 pool，provider 重建（例如熱更新）後暫停狀態仍保留。
+var pools sync.Map
+
+// keyPool 返回 provider 與 spec 對應的 pool。
+func keyPool(provider, spec string) *KeyPool {
+	id := provider + "\x00" + spec
+	if p, ok := pools.Load(id); ok {
+		return p.(*KeyPool)
+	}
+	p := &KeyPool{provider: provider}
+	for i, k := range ParseKeys(spec) {
+		p.keys = append(p.keys, &poolKey{key: k, label: keyLabel(i, k)})
+	}
+	actual, _ := pools.LoadOrStore(id, p)
+	return actual.(*KeyPool)
+}
+
+func keyLabel(i int, key string) string {
+	if len(key) > 4 {
+		key = key[len(key)-4:]
+	}
+	return fmt.Sprintf("#%d…%s", i+1, key)
+}
+
+// Pick 輪流返回下一把未暫停的 key；全部暫停時返回最早恢復的 key，沒有 key 時返回空字串。
+func (p *KeyPool) Pick() string {
+	if len(p.keys) == 0 {
+		return ""
+	}
+	now := time.Now().UnixNano()
+	start := p.next.Add(1) - 1
+	var soonest *poolKey
+	for i := range p.keys {
+		k := p.keys[(start+uint64(i))%uint64(len(p.keys))]
+		until := k.parkedUntil.Load()
+		if until <= now {
+			return k.key
+		}
+		if soonest == nil || until < soonest.parkedUntil.Load() {
+			soonest = k
+		}
+	}
+	return soonest.key
+}
+
+// Observe 記錄 key 的一次請求；回應為 429 / 402 時依 Retry-After（或預設 cooldown）暫停該 key 並返回 true。
+func (p *KeyPool) Observe(key string, resp *http.Response) bool {
+	k := p.find(key)
+	if k == nil {
+		return false
+	}
+	metrics.APIKeyRequests.WithLabelValues(p.provider, k.label).Inc()
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusPaymentRequired {
+		return false
+	}
+	cooldown := time.Duration(keyCooldown.Load())
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		cooldown = time.Duration(secs) * time.Second
+	}
+	until := time.Now().Add(cooldown)
+	k.parkedUntil.Store(until.UnixNano())
+	metrics.APIKeyThrottled.WithLabelValues(p.provider, k.label).Inc()
+	metrics.APIKeyParkedUntil.WithLabelValues(p.provider, k.label).Set(float64(until.Unix()))
+	return true
+}
+
+// available 是否仍有未暫停的 key。
+func (p *KeyPool) available() bool {
+	now := time.Now().UnixNano()
+	for _, k := range p.keys {
+		if k.parkedUntil.Load() <= now {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *KeyPool) find(key string) *poolKey {
+	for _, k := range p.keys {
+		if k.key == key {
+			return k
+		}
+	}
+	return nil
+}
+
+// do 以 pool 的 key 送出 req，auth 負責設定認證 header。key 被暫停且 req 可重送（GetBody 非 nil）時，
+// 改用下一把可用的 key 重試；串流上傳等無法重送的請求直接返回該回應，由呼叫端的重試機制處理。
+func (p *KeyPool) do(c *http.Client, req *http.Request, auth func(r *http.Request, key string)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		key := p.Pick()
+		auth(r, key)
+		resp, err := c.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		if !p.Observe(key, resp) || req.GetBody == nil || attempt >= len(p.keys) || !p.available() {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
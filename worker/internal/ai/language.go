@@ -0,0 +1,215 @@
+package ai
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// STTOptions 單次轉錄的語言提示與模型覆寫，空值沿用 provider 設定。
+type STTOptions struct {
+	Language       string // ISO-639-1 語言提示，空字串表示由 provider 自動偵測
+	Model          string // 覆寫 STT 模型（例如特定語言專用模型）
+	DetectLanguage bool   // 要求 provider 回報偵測到的語言
+	Prompt         string // 前文提示（前一個 chunk 轉錄稿的結尾），延續拼寫與語境
+	// Phrases 詞彙表的個別詞，供只接受短片語清單的 provider（Google speechContexts）使用
+	Phrases    []string
+	Timestamps bool // 要求逐段 / 逐字時間戳（STTResult.Segments / Words）
+}
+
+// STTResult 轉錄結果。Language 為 ISO-639-1 代碼，provider 未回報時為空字串。
+// Segments / Words 為逐段 / 逐字時間戳（相對於本次轉錄的音檔），provider 未提供時為 nil。
+type STTResult struct {
+	Text     string
+	Language string
+	Segments []Segment
+	Words    []Word
+}
+
+// Segment 一段轉錄（通常為一句）的起訖時間（秒）。
+type Segment struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Word 單一詞的起訖時間（秒）。
+type Word struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// trimSegments 去除 Whisper 段落文字前後的空白，略過空段落。
+func trimSegments(segments []Segment) []Segment {
+	var out []Segment
+	for _, seg := range segments {
+		if seg.Text = strings.TrimSpace(seg.Text); seg.Text != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+// LanguageAwareSTT 支援語言提示並可回報偵測語言的 STT provider。
+type LanguageAwareSTT interface {
+	TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error)
+}
+
+// languageNames Whisper verbose_json 以英文全名回報語言，轉換為 ISO-639-1。
+var languageNames = map[string]string{
+	"chinese":    "zh",
+	"mandarin":   "zh",
+	"cantonese":  "yue",
+	"english":    "en",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"spanish":    "es",
+	"french":     "fr",
+	"german":     "de",
+	"italian":    "it",
+	"portuguese": "pt",
+	"russian":    "ru",
+	"vietnamese": "vi",
+	"thai":       "th",
+	"indonesian": "id",
+	"malay":      "ms",
+	"hindi":      "hi",
+	"arabic":     "ar",
+}
+
+// NormalizeLanguage 將語言名稱或代碼（"English"、"zh-TW"、"en_us"）統一為小寫 ISO-639-1 代碼；"auto" 與空值返回空字串。
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" || lang == "auto" {
+		return ""
+	}
+	if code, ok := languageNames[lang]; ok {
+		return code
+	}
+	// 地區後綴：zh-TW、AssemblyAI 的 en_us
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+// scriptCounts 文字中各文字系統的字元數；拉丁字母以單字計。
+type scriptCounts struct {
+	han, kana, hangul, cyrillic, arabic, thai, devanagari, latinWords int
+	// traditional / simplified 常見繁簡異體字的出現次數
+	traditional, simplified int
+}
+
+// 常見繁簡異體字，用於判斷中文使用的字形。
+const (
+	traditionalOnly = "這們說個時對會來為發過還進問題經動實現關於業務項開與見議應將從後總體當無區點據產"
+	simplifiedOnly  = "这们说个时对会来为发过还进问题经动实现关于业务项开与见议应将从后总体当无区点据产"
+)
+
+// countScripts 統計 text 中各文字系統的字元數。
+func countScripts(text string) scriptCounts {
+	var c scriptCounts
+	inWord := false
+	for _, r := range text {
+		isLatin := unicode.In(r, unicode.Latin)
+		if isLatin && !inWord {
+			c.latinWords++
+		}
+		inWord = isLatin
+		switch {
+		case unicode.In(r, unicode.Han):
+			c.han++
+			if strings.ContainsRune(traditionalOnly, r) {
+				c.traditional++
+			} else if strings.ContainsRune(simplifiedOnly, r) {
+				c.simplified++
+			}
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			c.kana++
+		case unicode.In(r, unicode.Hangul):
+			c.hangul++
+		case unicode.In(r, unicode.Cyrillic):
+			c.cyrillic++
+		case unicode.In(r, unicode.Arabic):
+			c.arabic++
+		case unicode.In(r, unicode.Thai):
+			c.thai++
+		case unicode.In(r, unicode.Devanagari):
+			c.devanagari++
+		}
+	}
+	return c
+}
+
+// latin 拉丁字母的權重：一個單字約當兩個 CJK 字元，避免夾雜的英文專有名詞主導比例。
+func (c scriptCounts) latin() int {
+	return c.latinWords * 2
+}
+
+// total 返回加權後的文字總數。
+func (c scriptCounts) total() int {
+	return c.han + c.kana + c.hangul + c.cyrillic + c.arabic + c.thai + c.devanagari + c.latin()
+}
+
+// share 返回 n 佔所有文字的比例；沒有任何文字時為 0。
+func (c scriptCounts) share(n int) float64 {
+	if t := c.total(); t > 0 {
+		return float64(n) / float64(t)
+	}
+	return 0
+}
+
+// latinStopwords 拉丁字母語言的高頻功能詞，用於區分以拉丁字母書寫的語言。
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "to", "of", "that", "it", "we", "you", "this", "have"},
+	"es": {"el", "la", "los", "las", "que", "y", "es", "en", "una", "por", "para", "con"},
+	"fr": {"le", "la", "les", "et", "est", "que", "une", "des", "pour", "dans", "pas", "nous"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "wir", "mit", "ein", "eine", "zu"},
+	"pt": {"o", "os", "que", "e", "é", "não", "uma", "para", "com", "do", "da", "em"},
+	"it": {"il", "che", "e", "è", "non", "di", "una", "per", "con", "gli", "sono", "della"},
+	"id": {"yang", "dan", "di", "ini", "itu", "tidak", "dengan", "untuk", "saya", "kita", "ada", "akan"},
+	"vi": {"và", "của", "là", "không", "có", "được", "những", "cho", "này", "một", "các", "chúng"},
+}
+
+// GuessLanguage 以文字系統（拉丁字母語言另比對高頻功能詞）粗略判斷 text 的語言，返回 ISO-639-1 代碼；
+// 無法判斷時返回空字串。用於 provider 未回報語言時的偵測。
+func GuessLanguage(text string) string {
+	c := countScripts(text)
+	switch {
+	case c.total() == 0:
+		return ""
+	case c.share(c.hangul) >= 0.3:
+		return "ko"
+	case c.kana > 0 && c.kana*10 >= c.han && c.share(c.han+c.kana) >= 0.5:
+		return "ja"
+	case c.share(c.han) >= 0.5:
+		return "zh"
+	case c.share(c.cyrillic) >= 0.5:
+		return "ru"
+	case c.share(c.arabic) >= 0.5:
+		return "ar"
+	case c.share(c.thai) >= 0.5:
+		return "th"
+	case c.share(c.devanagari) >= 0.5:
+		return "hi"
+	case c.share(c.latin()) < 0.5:
+		return ""
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	best, bestHits := "", 0
+	for lang, stopwords := range latinStopwords {
+		hits := 0
+		for _, w := range words {
+			if slices.Contains(stopwords, w) {
+				hits++
+			}
+		}
+		if hits > bestHits || (hits == bestHits && hits > 0 && lang < best) {
+			best, bestHits = lang, hits
+		}
+	}
+	return best
+}
@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// Moderator 內容審核服務，於交付轉錄稿與摘要前檢查。
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (ModerationResult, error)
+}
+
+// ModerationResult 審核結果；Categories 為被標記的類別（已排序），Scores 為其分數。
+type ModerationResult struct {
+	Flagged    bool
+	Categories []string
+	Scores     map[string]float64
+}
+
+// DefaultModerationURL OpenAI moderation 端點。
+const DefaultModerationURL = "https://api.openai.com/v1/moderations"
+
+// moderationChunkRunes 單一 input 的字元上限，較長的文字切成多段一次送出。
+const moderationChunkRunes = 8000
+
+// NewModerator 依 MODERATION_PROVIDER 建立審核服務："openai"（moderation API）或 "http"（自訂分類器）；
+// 空字串表示停用，返回 nil。
+func NewModerator(name string, opts Options) (Moderator, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "openai":
+		m := &OpenAIModerator{
+			URL:       opts.String("MODERATION_URL", DefaultModerationURL),
+			APIKey:    opts("MODERATION_KEY"),
+			Model:     opts.String("MODERATION_MODEL", "omni-moderation-latest"),
+			Threshold: opts.Float("MODERATION_THRESHOLD", 0),
+		}
+		if m.APIKey == "" {
+			return nil, fmt.Errorf("MODERATION_KEY must be provided")
+		}
+		log.Printf("Moderation enabled: openai model=%s", m.Model)
+		return m, nil
+	case "http":
+		m := &HTTPModerator{URL: opts("MODERATION_URL"), APIKey: opts("MODERATION_KEY")}
+		if m.URL == "" {
+			return nil, fmt.Errorf("MODERATION_URL must be provided")
+		}
+		log.Printf("Moderation enabled: classifier %s", m.URL)
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown moderation provider %q (available: openai, http)", name)
+}
+
+// OpenAIModerator 以 OpenAI moderation API 審核。Threshold 大於 0 時，分數達門檻的類別也視為標記，
+// 否則只採用 API 的判定。
+type OpenAIModerator struct {
+	URL       string // 空值為 DefaultModerationURL
+	APIKey    string // 可為逗號分隔的多把 key
+	Model     string
+	Threshold float64
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+// Moderate 將 text 切段後一次送出，任一段被標記即視為標記；類別取聯集、分數取最大值。
+func (m *OpenAIModerator) Moderate(ctx context.Context, text string) (ModerationResult, error) {
+	endpoint := m.URL
+	if endpoint == "" {
+		endpoint = DefaultModerationURL
+	}
+	body, _ := json.Marshal(map[string]interface{}{"model": m.Model, "input": splitRunes(text, moderationChunkRunes)})
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := keyPool("moderation", m.APIKey).do(httpClient(m.HTTPClient), req, func(r *http.Request, key string) {
+		r.Header.Set("Authorization", "Bearer "+key)
+	})
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return ModerationResult{}, fmt.Errorf("openai moderation failed: %s", string(b))
+	}
+
+	var result struct {
+		Results []struct {
+			Flagged        bool               `json:"flagged"`
+			Categories     map[string]bool    `json:"categories"`
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ModerationResult{}, err
+	}
+
+	flagged := map[string]float64{}
+	var out ModerationResult
+	for _, r := range result.Results {
+		out.Flagged = out.Flagged || r.Flagged
+		for category, score := range r.CategoryScores {
+			if r.Categories[category] || (m.Threshold > 0 && score >= m.Threshold) {
+				flagged[category] = max(flagged[category], score)
+			}
+		}
+		for category, hit := range r.Categories {
+			if _, ok := flagged[category]; hit && !ok {
+				flagged[category] = r.CategoryScores[category]
+			}
+		}
+	}
+	return out.withCategories(flagged), nil
+}
+
+// HTTPModerator 自訂分類器：POST {"text": ...}，回應 {"flagged": bool, "categories": [...], "scores": {...}}。
+type HTTPModerator struct {
+	URL    string
+	APIKey string // 非空時以 Bearer 送出
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (m *HTTPModerator) Moderate(ctx context.Context, text string) (ModerationResult, error) {
+	body, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequestWithContext(ctx, "POST", m.URL, bytes.NewReader(body))
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+	resp, err := httpClient(m.HTTPClient).Do(req)
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return ModerationResult{}, fmt.Errorf("moderation classifier failed: %s", string(b))
+	}
+
+	var result struct {
+		Flagged    bool               `json:"flagged"`
+		Categories []string           `json:"categories"`
+		Scores     map[string]float64 `json:"scores"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ModerationResult{}, err
+	}
+	flagged := map[string]float64{}
+	for _, c := range result.Categories {
+		flagged[c] = result.Scores[c]
+	}
+	return ModerationResult{Flagged: result.Flagged}.withCategories(flagged), nil
+}
+
+// withCategories 填入排序後的類別與分數；有任何類別時一律視為標記。
+func (r ModerationResult) withCategories(flagged map[string]float64) ModerationResult {
+	for c := range flagged {
+		r.Categories = append(r.Categories, c)
+	}
+	sort.Strings(r.Categories)
+	if len(flagged) > 0 {
+		r.Flagged = true
+		r.Scores = flagged
+	}
+	return r
+}
+
+// splitRunes 將 s 切成每段最多 n 個字元。
+func splitRunes(s string, n int) []string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return []string{s}
+	}
+	var parts []string
+	for len(runes) > n {
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
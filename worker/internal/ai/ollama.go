@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func init() { RegisterLLM("ollama", newOllamaSummarizer) }
+
+// newOllamaSummarizer 依 OLLAMA_* 建立本機 Ollama 摘要，預設 Prompt 沿用 AI_LLM_PROMPT。
+func newOllamaSummarizer(_ context.Context, opts Options) (Summarizer, error) {
+	llm := &OllamaSummarizer{
+		URL:    opts.String("OLLAMA_URL", DefaultOllamaURL),
+		Model:  opts.String("OLLAMA_MODEL", "llama3.1:8b"),
+		Prompt: opts("AI_LLM_PROMPT"),
+		NumCtx: opts.Int("OLLAMA_NUM_CTX", 32768),
+	}
+	if llm.NumCtx < 0 {
+		return nil, fmt.Errorf("invalid OLLAMA_NUM_CTX: %d (must be >= 0)", llm.NumCtx)
+	}
+	log.Printf("Ollama summarizer enabled: %s model=%s", llm.URL, llm.Model)
+	return llm, nil
+}
+
+// DefaultOllamaURL 本機 Ollama server。
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaSummarizer 以 Ollama 原生 /api/chat 生成摘要，搭配 whisper.cpp 即可完全離線部署。
+// 串流回應為 NDJSON：每行一個 message 片段，最後一行 done=true。
+type OllamaSummarizer struct {
+	URL    string // 空值為 DefaultOllamaURL
+	Model  string // 例如 llama3.1:8b
+	Prompt string // 預設摘要 Prompt
+	// NumCtx 覆寫模型的 context 長度（tokens），0 沿用模型預設；Ollama 預設值通常容不下長篇逐字稿。
+	NumCtx int
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+func (o *OllamaSummarizer) LLMModelName() string { return o.Model }
+
+// ollamaChunk /api/chat 回應（非串流時為單一物件）。
+type ollamaChunk struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+	// PromptEvalCount / EvalCount 只出現在 done=true 的回應
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// usage 返回回應的 token 用量。
+func (c ollamaChunk) usage() TokenUsage {
+	return TokenUsage{PromptTokens: c.PromptEvalCount, CompletionTokens: c.EvalCount}
+}
+
+// Summarize 呼叫 /api/chat（stream=false）一次性生成摘要。
+func (o *OllamaSummarizer) Summarize(ctx context.Context, text string, userPrompt string) (string, error) {
+	resp, err := o.chat(ctx, text, userPrompt, false, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChunk
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	reportUsage(ctx, result.usage())
+	if result.Error != "" {
+		return "", fmt.Errorf("ollama llm failed: %s", result.Error)
+	}
+	if result.Message.Content == "" {
+		return "", fmt.Errorf("no summary generated")
+	}
+	return result.Message.Content, nil
+}
+
+// SummarizeStream 呼叫 /api/chat（stream=true），逐行解析 NDJSON 並透過 onChunk 回傳摘要片段。
+func (o *OllamaSummarizer) SummarizeStream(ctx context.Context, text string, userPrompt string, onChunk func(chunk string)) error {
+	resp, err := o.chat(ctx, text, userPrompt, true, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var chunk ollamaChunk
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("ollama stream failed: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			onChunk(chunk.Message.Content)
+		}
+		if chunk.Done {
+			reportUsage(ctx, chunk.usage())
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("ollama stream failed: response ended before done")
+}
+
+// SummarizeStructured 以 format（JSON schema）要求模型輸出 StructuredSummary。
+func (o *OllamaSummarizer) SummarizeStructured(ctx context.Context, text string, userPrompt string) (StructuredSummary, error) {
+	resp, err := o.chat(ctx, text, structuredPrompt(userPrompt), false, SummarySchema())
+	if err != nil {
+		return StructuredSummary{}, err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChunk
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return StructuredSummary{}, err
+	}
+	reportUsage(ctx, result.usage())
+	if result.Error != "" {
+		return StructuredSummary{}, fmt.Errorf("ollama llm failed: %s", result.Error)
+	}
+	return ParseStructuredSummary(result.Message.Content)
+}
+
+// chat 送出 /api/chat 請求。format 非 nil 時要求符合該 JSON schema 的輸出，取樣參數只套用 temperature。
+func (o *OllamaSummarizer) chat(ctx context.Context, text, userPrompt string, stream bool, format map[string]interface{}) (*http.Response, error) {
+	if userPrompt == "" {
+		userPrompt = o.Prompt
+	}
+	if userPrompt == "" {
+		userPrompt = defaultSummaryPrompt(ctx)
+	}
+	payload := map[string]interface{}{
+		"model":  o.Model,
+		"stream": stream,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt(ctx)},
+			{"role": "user", "content": userMessage(ctx, userPrompt, text)},
+		},
+	}
+	options := map[string]interface{}{}
+	if o.NumCtx > 0 {
+		options["num_ctx"] = o.NumCtx
+	}
+	params := generationParams(ctx)
+	if format != nil {
+		payload["format"] = format
+		params = GenerationParams{Temperature: params.Temperature}
+	}
+	params.applyOpenAI(options, "num_predict")
+	if len(options) > 0 {
+		payload["options"] = options
+	}
+	body, _ := json.Marshal(payload)
+
+	base := o.URL
+	if base == "" {
+		base = DefaultOllamaURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(base, "/")+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient(o.HTTPClient).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama llm failed: %s", string(b))
+	}
+	return resp, nil
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/net/websocket"
+)
+
+// DefaultRealtimeURL OpenAI Realtime API 的轉錄工作階段端點。
+const DefaultRealtimeURL = "wss://api.openai.com/v1/realtime?intent=transcription"
+
+// DefaultRealtimeModel Realtime 轉錄預設模型。
+const DefaultRealtimeModel = "gpt-4o-mini-transcribe"
+
+// realtimeSampleRate OpenAI Realtime 的 pcm16 音訊固定為 24 kHz 單聲道。
+const realtimeSampleRate = 24000
+
+// StartStream 以 OpenAI Realtime API（轉錄工作階段）即時轉錄 24 kHz 單聲道 PCM 音訊。
+// 由伺服器端 VAD 切段，每段先回傳 delta 累積的部分結果，段落結束後回傳最終結果。
+// CloseSend 提交剩餘音訊，所有已提交段落的最終結果送達後關閉 Results。Azure 模式不支援。
+func (o *StandardAIProvider) StartStream(ctx context.Context, opts StreamOptions) (STTStream, error) {
+	if o.APIVersion != "" {
+		return nil, fmt.Errorf("openai realtime: not supported in Azure mode")
+	}
+	if opts.SampleRate != 0 && opts.SampleRate != realtimeSampleRate {
+		return nil, fmt.Errorf("openai realtime: sample rate must be %d, got %d", realtimeSampleRate, opts.SampleRate)
+	}
+	if opts.Channels > 1 {
+		return nil, fmt.Errorf("openai realtime: only mono audio is supported")
+	}
+	endpoint := o.RealtimeURL
+	if endpoint == "" {
+		endpoint = DefaultRealtimeURL
+	}
+	model := o.RealtimeModel
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if model == "" {
+		model = DefaultRealtimeModel
+	}
+
+	conn, err := dialStream(ctx, endpoint, http.Header{
+		"Authorization": {"Bearer " + keyPool("openai", o.STTApiKey).Pick()},
+		"OpenAI-Beta":   {"realtime=v1"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai realtime: %w", err)
+	}
+	transcription := map[string]interface{}{"model": model}
+	if lang := NormalizeLanguage(opts.Language); lang != "" {
+		transcription["language"] = lang
+	}
+	if err := websocket.JSON.Send(conn, map[string]interface{}{
+		"type": "transcription_session.update",
+		"session": map[string]interface{}{
+			"input_audio_format":        "pcm16",
+			"input_audio_transcription": transcription,
+			"turn_detection":            map[string]interface{}{"type": "server_vad"},
+		},
+	}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("openai realtime: %w", err)
+	}
+
+	// partial / pending 只在讀取 goroutine 存取：pending 為已提交但尚未收到最終結果的段落，closing 後全部完成即結束
+	var (
+		partial = map[string]string{}
+		pending = map[string]bool{}
+		closing atomic.Bool
+	)
+	s := &wsStream{
+		sendAudio: func(conn *websocket.Conn, audio []byte) error {
+			return websocket.JSON.Send(conn, map[string]string{
+				"type":  "input_audio_buffer.append",
+				"audio": base64.StdEncoding.EncodeToString(audio),
+			})
+		},
+		closeSend: func(conn *websocket.Conn) error {
+			closing.Store(true)
+			return websocket.JSON.Send(conn, map[string]string{"type": "input_audio_buffer.commit"})
+		},
+	}
+	return startWSStream(ctx, conn, s, func(msg []byte) ([]StreamResult, error) {
+		var ev struct {
+			Type       string `json:"type"`
+			ItemID     string `json:"item_id"`
+			Delta      string `json:"delta"`
+			Transcript string `json:"transcript"`
+			Error      struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			return nil, fmt.Errorf("openai realtime: %w", err)
+		}
+		switch ev.Type {
+		case "input_audio_buffer.committed":
+			pending[ev.ItemID] = true
+		case "conversation.item.input_audio_transcription.delta":
+			partial[ev.ItemID] += ev.Delta
+			return []StreamResult{{Text: partial[ev.ItemID]}}, nil
+		case "conversation.item.input_audio_transcription.completed":
+			delete(partial, ev.ItemID)
+			delete(pending, ev.ItemID)
+			var results []StreamResult
+			if ev.Transcript != "" {
+				results = append(results, StreamResult{Text: ev.Transcript, IsFinal: true})
+			}
+			if closing.Load() && len(pending) == 0 {
+				return results, errStreamDone
+			}
+			return results, nil
+		case "conversation.item.input_audio_transcription.failed":
+			return nil, fmt.Errorf("openai realtime: transcription failed: %s", ev.Error.Message)
+		case "error":
+			// CloseSend 時緩衝區已被 VAD 提交完畢：等待剩餘段落完成即可
+			if closing.Load() && ev.Error.Code == "input_audio_buffer_commit_empty" {
+				if len(pending) == 0 {
+					return nil, errStreamDone
+				}
+				return nil, nil
+			}
+			return nil, fmt.Errorf("openai realtime: %s", ev.Error.Message)
+		}
+		return nil, nil
+	}), nil
+}
@@ -0,0 +1,208 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options provider 設定的來源，鍵為環境變數名稱（例如 DEEPGRAM_KEY）。main 以 os.Getenv 提供；
+// 以 Override 疊加覆寫值即可為個別任務建立不同設定的 provider。
+type Options func(key string) string
+
+// String 取得字串設定，未設定時返回 fallback。
+func (o Options) String(key, fallback string) string {
+	if v := o(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Int 取得整數設定，未設定或格式錯誤時返回 fallback。
+func (o Options) Int(key string, fallback int) int {
+	v := o(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("config: invalid int for %s=%q, using default %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// Bool 取得布林設定，未設定或格式錯誤時返回 fallback。
+func (o Options) Bool(key string, fallback bool) bool {
+	v := o(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("config: invalid bool for %s=%q, using default %t", key, v, fallback)
+		return fallback
+	}
+	return b
+}
+
+// Float 取得浮點數設定，未設定或格式錯誤時返回 fallback。
+func (o Options) Float(key string, fallback float64) float64 {
+	v := o(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("config: invalid float for %s=%q, using default %g", key, v, fallback)
+		return fallback
+	}
+	return f
+}
+
+// Duration 取得 time.Duration 設定，未設定或格式錯誤時返回 fallback。
+func (o Options) Duration(key string, fallback time.Duration) time.Duration {
+	v := o(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("config: invalid duration for %s=%q, using default %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// Override 返回以 values 優先、其餘沿用 o 的設定。
+func (o Options) Override(values map[string]string) Options {
+	return func(key string) string {
+		if v, ok := values[key]; ok {
+			return v
+		}
+		return o(key)
+	}
+}
+
+// STTFactory 依設定建立 STT provider，設定缺漏或無效時返回錯誤。
+type STTFactory func(ctx context.Context, opts Options) (STTService, error)
+
+// LLMFactory 依設定建立摘要 provider。
+type LLMFactory func(ctx context.Context, opts Options) (Summarizer, error)
+
+// Registry 以名稱（AI_STT_PROVIDER / AI_LLM_PROVIDER 的值）登記 provider factory。
+// 各 provider 於 init 登記至預設 Registry，新增 provider 不需修改 main。
+type Registry struct {
+	mu  sync.RWMutex
+	stt map[string]STTFactory
+	llm map[string]LLMFactory
+}
+
+// NewRegistry 建立空的 Registry。
+func NewRegistry() *Registry {
+	return &Registry{stt: make(map[string]STTFactory), llm: make(map[string]LLMFactory)}
+}
+
+// defaultRegistry 各 provider 於 init 登記的 Registry。
+var defaultRegistry = NewRegistry()
+
+// RegisterSTT 登記 STT factory；名稱重複時 panic（程式錯誤）。
+func (r *Registry) RegisterSTT(name string, f STTFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.stt[name]; dup {
+		panic("ai: duplicate STT provider " + name)
+	}
+	r.stt[name] = f
+}
+
+// RegisterLLM 登記摘要 factory；名稱重複時 panic（程式錯誤）。
+func (r *Registry) RegisterLLM(name string, f LLMFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.llm[name]; dup {
+		panic("ai: duplicate LLM provider " + name)
+	}
+	r.llm[name] = f
+}
+
+// NewSTT 以名稱對應的 factory 建立 STT provider。
+func (r *Registry) NewSTT(ctx context.Context, name string, opts Options) (STTService, error) {
+	r.mu.RLock()
+	f, ok := r.stt[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("NewSTT(%s): unknown provider (available: %s)", name, strings.Join(r.STTProviders(), ", "))
+	}
+	svc, err := f(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("NewSTT(%s): %w", name, err)
+	}
+	return svc, nil
+}
+
+// NewLLM 以名稱對應的 factory 建立摘要 provider。
+func (r *Registry) NewLLM(ctx context.Context, name string, opts Options) (Summarizer, error) {
+	r.mu.RLock()
+	f, ok := r.llm[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("NewLLM(%s): unknown provider (available: %s)", name, strings.Join(r.LLMProviders(), ", "))
+	}
+	svc, err := f(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("NewLLM(%s): %w", name, err)
+	}
+	return svc, nil
+}
+
+// STTProviders 已登記的 STT provider 名稱（排序）。
+func (r *Registry) STTProviders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.stt))
+	for name := range r.stt {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LLMProviders 已登記的摘要 provider 名稱（排序）。
+func (r *Registry) LLMProviders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.llm))
+	for name := range r.llm {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterSTT 登記至預設 Registry。
+func RegisterSTT(name string, f STTFactory) { defaultRegistry.RegisterSTT(name, f) }
+
+// RegisterLLM 登記至預設 Registry。
+func RegisterLLM(name string, f LLMFactory) { defaultRegistry.RegisterLLM(name, f) }
+
+// NewSTT 以預設 Registry 建立 STT provider。
+func NewSTT(ctx context.Context, name string, opts Options) (STTService, error) {
+	return defaultRegistry.NewSTT(ctx, name, opts)
+}
+
+// NewLLM 以預設 Registry 建立摘要 provider。
+func NewLLM(ctx context.Context, name string, opts Options) (Summarizer, error) {
+	return defaultRegistry.NewLLM(ctx, name, opts)
+}
+
+// STTProviders 預設 Registry 中的 STT provider 名稱。
+func STTProviders() []string { return defaultRegistry.STTProviders() }
+
+// LLMProviders 預設 Registry 中的摘要 provider 名稱。
+func LLMProviders() []string { return defaultRegistry.LLMProviders() }
@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+	"tts-worker/internal/audit"
+)
+
+// ProviderConfig 可熱更新的 provider 設定（JSON），空欄位沿用啟動時的環境變數值。
+type ProviderConfig struct {
+	STTURL    string `json:"sttUrl"`
+	STTModel  string `json:"sttModel"`
+	STTKey    string `json:"sttKey"`
+	LLMURL    string `json:"llmUrl"`
+	LLMModel  string `json:"llmModel"`
+	LLMKey    string `json:"llmKey"`
+	LLMPrompt string `json:"llmPrompt"`
+}
+
+// ReloadableProvider 包裝 StandardAIProvider，可在執行期間原子替換。
+// 每次呼叫在開始時取得當下的 provider，進行中的請求不受替換影響。
+type ReloadableProvider struct {
+	base    StandardAIProvider
+	current atomic.Pointer[StandardAIProvider]
+}
+
+// NewReloadableProvider 以 base（來自環境變數）作為初始設定與熱更新的預設值。
+func NewReloadableProvider(base StandardAIProvider) *ReloadableProvider {
+	r := &ReloadableProvider{base: base}
+	p := base
+	r.current.Store(&p)
+	return r
+}
+
+// Apply 以 cfg 覆寫 base 中的非空欄位後替換 provider；必要欄位缺漏時返回錯誤且不替換。
+func (r *ReloadableProvider) Apply(cfg ProviderConfig) error {
+	next := r.base
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	override(&next.STTURL, cfg.STTURL)
+	override(&next.STTModel, cfg.STTModel)
+	override(&next.STTApiKey, cfg.STTKey)
+	override(&next.LLMURL, cfg.LLMURL)
+	override(&next.LLMModel, cfg.LLMModel)
+	override(&next.LLMApiKey, cfg.LLMKey)
+	override(&next.LLMPrompt, cfg.LLMPrompt)
+	// 轉錄 / 摘要改由其他 provider 負責（base 未設定對應 URL）時不檢查該部分
+	if (r.base.STTURL != "" && (next.STTURL == "" || next.STTModel == "")) ||
+		(r.base.LLMURL != "" && (next.LLMURL == "" || next.LLMModel == "")) {
+		return fmt.Errorf("Apply: STT / LLM URL and model are required")
+	}
+	r.current.Store(&next)
+	return nil
+}
+
+// Current 返回目前使用中的 provider。
+func (r *ReloadableProvider) Current() *StandardAIProvider {
+	return r.current.Load()
+}
+
+// STT 以下方法皆委派給呼叫當下的 provider。
+func (r *ReloadableProvider) STT(ctx context.Context, filePath string) (string, error) {
+	return r.Current().STT(ctx, filePath)
+}
+
+func (r *ReloadableProvider) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	return r.Current().TranscribeWithOptions(ctx, filePath, opts)
+}
+
+func (r *ReloadableProvider) StartStream(ctx context.Context, opts StreamOptions) (STTStream, error) {
+	return r.Current().StartStream(ctx, opts)
+}
+
+func (r *ReloadableProvider) STTModelName() string { return r.Current().STTModel }
+func (r *ReloadableProvider) LLMModelName() string { return r.Current().LLMModel }
+
+func (r *ReloadableProvider) Summarize(ctx context.Context, text string, prompt string) (string, error) {
+	return r.Current().Summarize(ctx, text, prompt)
+}
+
+func (r *ReloadableProvider) SummarizeStructured(ctx context.Context, text string, prompt string) (StructuredSummary, error) {
+	return r.Current().SummarizeStructured(ctx, text, prompt)
+}
+
+func (r *ReloadableProvider) SummarizeStream(ctx context.Context, text string, prompt string, onChunk func(chunk string)) error {
+	return r.Current().SummarizeStream(ctx, text, prompt, onChunk)
+}
+
+// ConfigSource 讀取 provider 設定原始內容（檔案或 Redis key）；內容為空表示未設定，沿用環境變數。
+type ConfigSource func(ctx context.Context) ([]byte, error)
+
+// WatchConfig 每 interval 讀取 source，內容變更時套用至 r，直到 ctx 結束。
+// 讀取或解析失敗時保留目前的 provider，只記錄 log。
+func (r *ReloadableProvider) WatchConfig(ctx context.Context, source ConfigSource, interval time.Duration) {
+	var last []byte
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		raw, err := source(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Printf("Provider config: read failed: %v", err)
+			}
+		case !bytes.Equal(raw, last):
+			last = raw
+			var cfg ProviderConfig
+			if len(bytes.TrimSpace(raw)) > 0 {
+				if err := json.Unmarshal(raw, &cfg); err != nil {
+					log.Printf("Provider config: invalid JSON, keeping current providers: %v", err)
+					break
+				}
+			}
+			if err := r.Apply(cfg); err != nil {
+				log.Printf("Provider config: %v, keeping current providers", err)
+				audit.Record(audit.Event{Action: "provider_config.reload", Outcome: audit.OutcomeFailure, Details: map[string]string{"error": err.Error()}})
+				break
+			}
+			p := r.Current()
+			log.Printf("Provider config reloaded: stt=%s (%s) llm=%s (%s)", p.STTModel, p.STTURL, p.LLMModel, p.LLMURL)
+			// 只記錄模型與端點，不含 API key
+			audit.Record(audit.Event{
+				Action:  "provider_config.reload",
+				Outcome: audit.OutcomeSuccess,
+				Details: map[string]string{"sttModel": p.STTModel, "sttUrl": p.STTURL, "llmModel": p.LLMModel, "llmUrl": p.LLMURL},
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// StreamingSTT 可選介面：以 WebSocket 即時串流音訊並取得部分辨識結果，供即時聽寫等不經過檔案的場景使用。
+type StreamingSTT interface {
+	StartStream(ctx context.Context, opts StreamOptions) (STTStream, error)
+}
+
+// StreamOptions 串流音訊的格式。音訊一律為 PCM signed 16-bit little-endian。
+type StreamOptions struct {
+	// SampleRate 取樣率，0 為 provider 預設（Deepgram 16000；OpenAI Realtime 只接受 24000）。
+	SampleRate int
+	// Channels 聲道數，0 為單聲道。
+	Channels int
+	// Language 語言提示，空值沿用 provider 設定。
+	Language string
+	// Model 覆寫 provider 設定的串流模型。
+	Model string
+}
+
+// StreamResult 一次辨識結果。IsFinal 為 false 時是部分結果（partial hypothesis），
+// 之後會被同一段的新結果取代；IsFinal 為 true 的結果不再變動，依序串接即為完整轉錄稿。
+type StreamResult struct {
+	Text    string
+	IsFinal bool
+	Start   float64 // 秒，provider 未提供時為 0
+	End     float64
+}
+
+// STTStream 一條串流辨識連線。Send 與 CloseSend 可與讀取 Results 在不同 goroutine 進行。
+type STTStream interface {
+	// Send 送出一段 PCM 音訊。
+	Send(audio []byte) error
+	// CloseSend 告知音訊已送完；provider 送出剩餘的最終結果後關閉 Results。
+	CloseSend() error
+	// Results 辨識結果，連線結束時關閉。
+	Results() <-chan StreamResult
+	// Err Results 關閉後返回結束原因，正常結束為 nil。
+	Err() error
+	// Close 立即中斷連線。
+	Close() error
+}
+
+// errStreamDone 由 handler 返回，表示 provider 已送完所有結果，連線可正常結束。
+var errStreamDone = errors.New("stream done")
+
+// wsStream 以 WebSocket 實作 STTStream；各 provider 提供送出音訊 / 結束訊息的方式與訊息解析。
+type wsStream struct {
+	conn      *websocket.Conn
+	results   chan StreamResult
+	sendAudio func(conn *websocket.Conn, audio []byte) error
+	closeSend func(conn *websocket.Conn) error
+
+	sendMu    sync.Mutex
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error
+}
+
+// dialStream 建立 WebSocket 連線。不經由共用 HTTP client，代理伺服器設定不適用。
+func dialStream(ctx context.Context, rawURL string, header http.Header) (*websocket.Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	origin := "https://" + u.Host
+	cfg, err := websocket.NewConfig(rawURL, origin)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Header = header
+	conn, err := cfg.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s://%s%s: %w", u.Scheme, u.Host, u.Path, err)
+	}
+	return conn, nil
+}
+
+// startWSStream 開始讀取 conn 的訊息，handle 將每則訊息轉為辨識結果；ctx 結束時中斷連線。
+func startWSStream(ctx context.Context, conn *websocket.Conn, s *wsStream, handle func(msg []byte) ([]StreamResult, error)) *wsStream {
+	s.conn = conn
+	s.results = make(chan StreamResult, 64)
+	s.closed = make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.finish(ctx.Err())
+		case <-s.closed:
+		}
+	}()
+	go func() {
+		defer close(s.results)
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(conn, &msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				s.finish(err)
+				return
+			}
+			results, err := handle(msg)
+			for _, r := range results {
+				select {
+				case s.results <- r:
+				case <-s.closed:
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, errStreamDone) {
+					err = nil
+				}
+				s.finish(err)
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// finish 記錄第一個結束原因並關閉連線。
+func (s *wsStream) finish(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.closed)
+		s.conn.Close()
+	})
+}
+
+func (s *wsStream) Send(audio []byte) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.sendAudio(s.conn, audio)
+}
+
+func (s *wsStream) CloseSend() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.closeSend(s.conn)
+}
+
+func (s *wsStream) Results() <-chan StreamResult { return s.results }
+
+// Err 需在 Results 關閉後呼叫；連線被 Close 中斷時讀取錯誤會被忽略。
+func (s *wsStream) Err() error { return s.err }
+
+func (s *wsStream) Close() error {
+	s.finish(nil)
+	return nil
+}
+
+// wsURL 將 http(s) URL 轉為 ws(s)。
+func wsURL(u string) string {
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		return "wss://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "http://"); ok {
+		return "ws://" + rest
+	}
+	return u
+}
+
+// StartStream 模擬即時轉錄：每次 Send 回傳一個部分結果，每 5 段音訊與 CloseSend 時回傳最終結果。
+func (m *MockAIService) StartStream(ctx context.Context, _ StreamOptions) (STTStream, error) {
+	if err := m.Chaos.before(ctx); err != nil {
+		return nil, err
+	}
+	return &mockStream{results: make(chan StreamResult, 64)}, nil
+}
+
+type mockStream struct {
+	mu      sync.Mutex
+	results chan StreamResult
+	sent    int
+	closed  bool
+}
+
+func (s *mockStream) Send(audio []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("mock stream: send after close")
+	}
+	s.sent++
+	s.emit(StreamResult{Text: fmt.Sprintf("模擬即時轉錄（第 %d 段音訊）", s.sent)})
+	if s.sent%5 == 0 {
+		s.emit(StreamResult{Text: fmt.Sprintf("這是第 %d 句模擬的即時轉錄內容。", s.sent/5), IsFinal: true})
+	}
+	return nil
+}
+
+func (s *mockStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if s.sent%5 != 0 {
+		s.emit(StreamResult{Text: "模擬即時轉錄結束。", IsFinal: true})
+	}
+	s.closed = true
+	close(s.results)
+	return nil
+}
+
+// emit 呼叫端未讀取導致緩衝區滿時丟棄結果，不阻塞 Send。
+func (s *mockStream) emit(r StreamResult) {
+	select {
+	case s.results <- r:
+	default:
+	}
+}
+
+func (s *mockStream) Results() <-chan StreamResult { return s.results }
+func (s *mockStream) Err() error                   { return nil }
+func (s *mockStream) Close() error                 { return s.CloseSend() }
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredSummary 結構化摘要，欄位對應 SummarySchema；下游不需再解析自由格式文字。
+type StructuredSummary struct {
+	Title        string       `json:"title"`
+	TLDR         string       `json:"tl_dr"`
+	KeyPoints    []string     `json:"key_points"`
+	ActionItems  []ActionItem `json:"action_items"`
+	Participants []string     `json:"participants"`
+}
+
+// ActionItem 待辦事項；Owner 未提及負責人時為空字串。
+type ActionItem struct {
+	Task  string `json:"task"`
+	Owner string `json:"owner"`
+}
+
+// StructuredSummarizer 可選介面：以 provider 的結構化輸出（JSON schema）生成摘要。
+type StructuredSummarizer interface {
+	SummarizeStructured(ctx context.Context, text string, prompt string) (StructuredSummary, error)
+}
+
+// DefaultStructuredPrompt 結構化摘要的預設指示；輸出格式由 schema 約束。
+const DefaultStructuredPrompt = "請將以下內容整理為結構化摘要：標題、一句話重點（tl_dr）、重點條列、待辦事項（含負責人，未提及則留空）與參與者。"
+
+// SummarySchema 返回 StructuredSummary 的 JSON Schema（strict：所有欄位必填、不允許額外欄位）。
+func SummarySchema() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	strs := map[string]interface{}{"type": "array", "items": str}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":      str,
+			"tl_dr":      str,
+			"key_points": strs,
+			"action_items": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":                 "object",
+					"properties":           map[string]interface{}{"task": str, "owner": str},
+					"required":             []string{"task", "owner"},
+					"additionalProperties": false,
+				},
+			},
+			"participants": strs,
+		},
+		"required":             []string{"title", "tl_dr", "key_points", "action_items", "participants"},
+		"additionalProperties": false,
+	}
+}
+
+// ParseStructuredSummary 解析並驗證模型輸出：必須包含 schema 的所有欄位且不含其他欄位，
+// title 與 tl_dr 不可為空。容許模型以 ``` 程式碼區塊包住 JSON。
+func ParseStructuredSummary(raw string) (StructuredSummary, error) {
+	raw = strings.TrimSpace(raw)
+	if body, ok := strings.CutPrefix(raw, "```"); ok {
+		body = strings.TrimPrefix(body, "json")
+		raw = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(body), "```"))
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return StructuredSummary{}, fmt.Errorf("ParseStructuredSummary: %w", err)
+	}
+	for _, key := range []string{"title", "tl_dr", "key_points", "action_items", "participants"} {
+		if v, ok := fields[key]; !ok || string(v) == "null" {
+			return StructuredSummary{}, fmt.Errorf("ParseStructuredSummary: missing field %q", key)
+		}
+	}
+
+	var s StructuredSummary
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return StructuredSummary{}, fmt.Errorf("ParseStructuredSummary: %w", err)
+	}
+	if strings.TrimSpace(s.Title) == "" || strings.TrimSpace(s.TLDR) == "" {
+		return StructuredSummary{}, fmt.Errorf("ParseStructuredSummary: title and tl_dr must not be empty")
+	}
+	for _, item := range s.ActionItems {
+		if strings.TrimSpace(item.Task) == "" {
+			return StructuredSummary{}, fmt.Errorf("ParseStructuredSummary: action item without task")
+		}
+	}
+	return s, nil
+}
+
+// structuredPrompt 任務未指定 prompt 時使用 DefaultStructuredPrompt。
+func structuredPrompt(prompt string) string {
+	if prompt == "" {
+		return DefaultStructuredPrompt
+	}
+	return prompt
+}
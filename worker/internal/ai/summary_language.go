@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// summaryLanguageNames 摘要語言指示使用的英文名稱；中文依地區區分繁簡。
+var summaryLanguageNames = map[string]string{
+	"zh-tw":   "Traditional Chinese",
+	"zh-hk":   "Traditional Chinese",
+	"zh-hant": "Traditional Chinese",
+	"zh-cn":   "Simplified Chinese",
+	"zh-sg":   "Simplified Chinese",
+	"zh-hans": "Simplified Chinese",
+	"zh":      "Chinese",
+	"yue":     "Cantonese (Traditional Chinese characters)",
+	"en":      "English",
+	"ja":      "Japanese",
+	"ko":      "Korean",
+	"es":      "Spanish",
+	"fr":      "French",
+	"de":      "German",
+	"it":      "Italian",
+	"pt":      "Portuguese",
+	"ru":      "Russian",
+	"vi":      "Vietnamese",
+	"th":      "Thai",
+	"id":      "Indonesian",
+	"ms":      "Malay",
+	"hi":      "Hindi",
+	"ar":      "Arabic",
+}
+
+// SummaryLanguageName 返回語言代碼（"zh-TW"、"en_us"）對應的英文名稱，未知時返回代碼本身。
+func SummaryLanguageName(lang string) string {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+	if name, ok := summaryLanguageNames[key]; ok {
+		return name
+	}
+	if name, ok := summaryLanguageNames[NormalizeLanguage(key)]; ok {
+		return name
+	}
+	return lang
+}
+
+type summaryLanguageKey struct{}
+
+// WithSummaryLanguage 返回要求摘要以 lang 撰寫的 context：system message 附加明確的語言指示，
+// 與轉錄稿的語言無關。lang 為空時不覆寫（由模型依內容決定）。
+func WithSummaryLanguage(ctx context.Context, lang string) context.Context {
+	if lang == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, summaryLanguageKey{}, lang)
+}
+
+// summaryLanguageInstruction 返回 ctx 指定摘要語言時附加於 system message 的指示。
+func summaryLanguageInstruction(ctx context.Context) string {
+	lang, _ := ctx.Value(summaryLanguageKey{}).(string)
+	if lang == "" {
+		return ""
+	}
+	return fmt.Sprintf("Write the entire response in %s, regardless of the language of the transcript. "+
+		"Keep names, product terms and quotations in their original form.", SummaryLanguageName(lang))
+}
+
+// defaultSummaryPrompts 任務與部署皆未指定摘要指示時，依轉錄稿語言選用的預設指示。
+var defaultSummaryPrompts = map[string]string{
+	"zh":  "請摘要以下內容：",
+	"yue": "請摘要以下內容：",
+	"ja":  "以下の内容を要約してください：",
+	"ko":  "다음 내용을 요약해 주세요:",
+}
+
+// englishSummaryPrompt 其他已知語言（以空白分詞）的預設摘要指示。
+const englishSummaryPrompt = "Summarize the following content:"
+
+type transcriptLanguageKey struct{}
+
+// WithTranscriptLanguage 返回帶有轉錄稿語言（偵測結果）的 context，供 provider 選用對應語言的預設摘要指示。
+func WithTranscriptLanguage(ctx context.Context, lang string) context.Context {
+	if lang == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, transcriptLanguageKey{}, NormalizeLanguage(lang))
+}
+
+// defaultSummaryPrompt 返回預設摘要指示：轉錄稿語言未知時維持中文指示，CJK 語言使用該語言，其他語言使用英文。
+func defaultSummaryPrompt(ctx context.Context) string {
+	lang, _ := ctx.Value(transcriptLanguageKey{}).(string)
+	if lang == "" {
+		return defaultSummaryPrompts["zh"]
+	}
+	if p, ok := defaultSummaryPrompts[lang]; ok {
+		return p
+	}
+	return englishSummaryPrompt
+}
+
+// CheckSummaryLanguage 以文字系統粗略檢查 text 是否以 lang 撰寫：中文 / 日文 / 韓文等依字元比例，
+// 中文另比對繁簡字形；拉丁字母語言之間無法區分，只檢查不是以 CJK 為主。無法判斷的語言一律通過。
+func CheckSummaryLanguage(text, lang string) error {
+	c := countScripts(text)
+	if c.total() == 0 {
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("summary is not written in %s", SummaryLanguageName(lang))
+	}
+
+	key := strings.ReplaceAll(strings.ToLower(lang), "_", "-")
+	switch NormalizeLanguage(key) {
+	case "zh", "yue":
+		if c.share(c.han) < 0.5 || c.kana > c.han/10 {
+			return mismatch()
+		}
+		switch summaryLanguageNames[key] {
+		case "Traditional Chinese":
+			if c.simplified > c.traditional {
+				return mismatch()
+			}
+		case "Simplified Chinese":
+			if c.traditional > c.simplified {
+				return mismatch()
+			}
+		}
+	case "ja":
+		if c.share(c.han+c.kana) < 0.5 || c.kana == 0 {
+			return mismatch()
+		}
+	case "ko":
+		if c.share(c.hangul) < 0.5 {
+			return mismatch()
+		}
+	case "ru":
+		if c.share(c.cyrillic) < 0.5 {
+			return mismatch()
+		}
+	case "ar":
+		if c.share(c.arabic) < 0.5 {
+			return mismatch()
+		}
+	case "th":
+		if c.share(c.thai) < 0.5 {
+			return mismatch()
+		}
+	case "hi":
+		if c.share(c.devanagari) < 0.5 {
+			return mismatch()
+		}
+	case "en", "es", "fr", "de", "it", "pt", "vi", "id", "ms":
+		if c.share(c.latin()) < 0.5 {
+			return mismatch()
+		}
+	}
+	return nil
+}
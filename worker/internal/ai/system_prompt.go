@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultSystemPrompt 未設定時 LLM 請求使用的 system message。
+const DefaultSystemPrompt = "You are a helpful assistant that summarizes audio transcripts."
+
+type systemPromptKey struct{}
+
+// WithSystemPrompt 返回帶有 system message 覆寫的 context，讓單次摘要套用部署或任務指定的語氣、結構與聲明。
+// prompt 為空時不覆寫。
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	if prompt == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, systemPromptKey{}, prompt)
+}
+
+// systemPrompt 返回 ctx 上的 system message 覆寫，未設定時為 DefaultSystemPrompt；指定摘要語言時附加語言指示。
+func systemPrompt(ctx context.Context) string {
+	p, ok := ctx.Value(systemPromptKey{}).(string)
+	if !ok {
+		p = DefaultSystemPrompt
+	}
+	if lang := summaryLanguageInstruction(ctx); lang != "" {
+		return strings.TrimSpace(p + "\n\n" + lang)
+	}
+	return p
+}
+
+// 提示模板的佔位符。{{prompt}} 與 {{transcript}} 由 provider 於組合 user message 時代入，
+// 其餘由呼叫端以 RenderTemplate 預先代入。
+const (
+	PlaceholderPrompt     = "{{prompt}}"
+	PlaceholderTranscript = "{{transcript}}"
+	PlaceholderLanguage   = "{{language}}"
+	PlaceholderDuration   = "{{duration}}"
+)
+
+// RenderTemplate 一次代入 vars（佔位符 → 值），代入的值中若含佔位符不會再被展開。
+func RenderTemplate(tmpl string, vars map[string]string) string {
+	pairs := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		pairs = append(pairs, k, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+type userTemplateKey struct{}
+
+// WithUserTemplate 返回帶有 user message 模板的 context；模板為空時不覆寫（沿用「prompt + 空行 + 內容」）。
+func WithUserTemplate(ctx context.Context, tmpl string) context.Context {
+	if tmpl == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userTemplateKey{}, tmpl)
+}
+
+// userMessage 組合 user message：ctx 帶有模板時代入 {{prompt}} 與 {{transcript}}，
+// 模板未包含 {{transcript}} 時內容附加在最後。
+func userMessage(ctx context.Context, prompt, text string) string {
+	tmpl, _ := ctx.Value(userTemplateKey{}).(string)
+	if tmpl == "" {
+		return fmt.Sprintf("%s\n\n%s", prompt, text)
+	}
+	msg := RenderTemplate(tmpl, map[string]string{PlaceholderPrompt: prompt, PlaceholderTranscript: text})
+	if !strings.Contains(tmpl, PlaceholderTranscript) {
+		msg += "\n\n" + text
+	}
+	return msg
+}
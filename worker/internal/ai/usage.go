@@ -0,0 +1,40 @@
+package ai
+
+import "context"
+
+// TokenUsage 單次 LLM 請求的 token 用量（取自 provider 回應），供成本統計。
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type usageCallbackKey struct{}
+
+// WithUsageCallback 返回帶有用量 callback 的 context：provider 回應含用量時，每個請求呼叫 fn 一次。
+// provider 未回報用量（例如相容 API 不支援 stream_options）時不呼叫。
+func WithUsageCallback(ctx context.Context, fn func(TokenUsage)) context.Context {
+	return context.WithValue(ctx, usageCallbackKey{}, fn)
+}
+
+// reportUsage 將用量交給 ctx 上的 callback；用量皆為 0 時略過。
+func reportUsage(ctx context.Context, u TokenUsage) {
+	if u.PromptTokens == 0 && u.CompletionTokens == 0 {
+		return
+	}
+	if fn, ok := ctx.Value(usageCallbackKey{}).(func(TokenUsage)); ok {
+		fn(u)
+	}
+}
+
+// openAIUsage Chat Completions 回應的 usage 欄位；串流時只出現在最後一個 chunk（需 stream_options.include_usage）。
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) tokens() TokenUsage {
+	if u == nil {
+		return TokenUsage{}
+	}
+	return TokenUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
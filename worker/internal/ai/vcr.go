@@ -0,0 +1,274 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// VCR 模式：record 將真實的 provider HTTP 互動寫入 cassette 目錄；replay 只從 cassette 回應，
+// 找不到時返回錯誤而不連線；auto 有 cassette 時重播，否則連線並錄製。
+const (
+	VCRRecord = "record"
+	VCRReplay = "replay"
+	VCRAuto   = "auto"
+)
+
+// vcrRedactedQuery 錄製時從 URL 移除的 query 參數（API key），也不納入比對。
+var vcrRedactedQuery = []string{"key", "api_key", "apikey", "token", "access_token"}
+
+var (
+	// vcrTokenField 錄製的回應中以 "REDACTED" 取代值的 JSON 欄位。
+	vcrTokenField = regexp.MustCompile(`("(?:access_token|refresh_token|id_token|session_token)"\s*:\s*)"[^"]*"`)
+	// vcrUUID 比對時忽略的隨機 ID（例如 provider 請求中產生的 job 名稱）。
+	vcrUUID = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+// vcrReplayToken 重播時 OAuth token 交換返回的假 token；provider 請求的比對不含認證 header。
+const vcrReplayToken = `{"access_token":"vcr-replay","token_type":"Bearer","expires_in":3600}`
+
+// VCRTransport 錄製 / 重播 AI provider 的 HTTP 互動，讓整合測試與本機開發不需 API key 與費用即可使用真實回應。
+// 請求以 method、URL（去除 key 類 query 參數）與 body（multipart boundary 正規化、UUID 忽略）的 SHA-256 比對，
+// 不含認證 header，因此重播時任意 key 皆可。相同請求重複出現（例如重試）時依序對應第 1、2… 次錄製，
+// 超出錄製次數則重播最後一次。串流回應（SSE / NDJSON）會完整錄下並一次重播。
+// OAuth token 交換（grant_type 表單，例如 Google service account）不錄製：record / auto 時照常連線，
+// replay 時返回假 token。其餘回應中的 token 欄位錄製時以 "REDACTED" 取代。
+type VCRTransport struct {
+	Mode string
+	Dir  string
+	Next http.RoundTripper // 實際連線，nil 時為 http.DefaultTransport
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewVCRTransport 建立 VCR transport；mode 為空字串時返回 next（停用）。
+func NewVCRTransport(mode, dir string, next http.RoundTripper) (http.RoundTripper, error) {
+	switch mode {
+	case "":
+		return next, nil
+	case VCRRecord, VCRReplay, VCRAuto:
+	default:
+		return nil, fmt.Errorf("unknown VCR mode %q (available: record, replay, auto)", mode)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("VCR cassette directory must be provided")
+	}
+	if mode != VCRReplay {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("NewVCRTransport(%s): %w", dir, err)
+		}
+	}
+	log.Printf("AI provider VCR: %s %s", mode, dir)
+	return &VCRTransport{Mode: mode, Dir: dir, Next: next, calls: map[string]int{}}, nil
+}
+
+// cassette 一次錄製的互動。Body 為 UTF-8 文字時原樣保存，否則以 base64 保存於 BodyBase64。
+type cassette struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"bodyBase64,omitempty"`
+}
+
+func (t *VCRTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if isTokenRequest(req, body) {
+		if t.Mode == VCRReplay {
+			return (&cassette{Status: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: vcrReplayToken}).response(req), nil
+		}
+		return next.RoundTrip(req)
+	}
+	hash := vcrHash(req, body)
+
+	t.mu.Lock()
+	n := t.calls[hash]
+	t.calls[hash] = n + 1
+	t.mu.Unlock()
+
+	if t.Mode != VCRRecord {
+		c, err := t.load(hash, n)
+		if err == nil {
+			return c.response(req), nil
+		}
+		if t.Mode == VCRReplay {
+			return nil, fmt.Errorf("vcr: no recording for %s %s (%s): %w", req.Method, redactURL(req.URL), hash[:12], err)
+		}
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	c := &cassette{Method: req.Method, URL: redactURL(req.URL), Status: resp.StatusCode, Header: resp.Header.Clone()}
+	c.Header.Del("Set-Cookie")
+	resp.Body = &vcrRecorder{ReadCloser: resp.Body, save: func(b []byte) {
+		c.setBody(vcrTokenField.ReplaceAll(b, []byte(`$1"REDACTED"`)))
+		if err := t.save(hash, n, c); err != nil {
+			log.Printf("vcr: %v", err)
+		}
+	}}
+	return resp, nil
+}
+
+// vcrHash 請求的比對鍵。
+func vcrHash(req *http.Request, body []byte) string {
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("vcr-boundary"))
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, vcrUUID.ReplaceAllString(redactURL(req.URL), "vcr-uuid"))
+	h.Write(vcrUUID.ReplaceAll(body, []byte("vcr-uuid")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isTokenRequest OAuth token 交換：POST 表單帶 grant_type。
+func isTokenRequest(req *http.Request, body []byte) bool {
+	if req.Method != http.MethodPost {
+		return false
+	}
+	if mt, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mt != "application/x-www-form-urlencoded" {
+		return false
+	}
+	form, err := url.ParseQuery(string(body))
+	return err == nil && form.Get("grant_type") != ""
+}
+
+// redactURL 移除 key 類 query 參數，其餘參數依名稱排序。
+func redactURL(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	for _, k := range vcrRedactedQuery {
+		q.Del(k)
+	}
+	c.RawQuery = q.Encode() // Encode 依 key 排序
+	return c.String()
+}
+
+func (t *VCRTransport) path(hash string, n int) string {
+	return filepath.Join(t.Dir, fmt.Sprintf("%s.%d.json", hash, n))
+}
+
+// load 讀取第 n 次的錄製，不存在時改用之前最後一次的錄製。
+func (t *VCRTransport) load(hash string, n int) (*cassette, error) {
+	b, err := os.ReadFile(t.path(hash, n))
+	for i := n - 1; os.IsNotExist(err) && i >= 0; i-- {
+		b, err = os.ReadFile(t.path(hash, i))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("vcr: %s: %w", t.path(hash, n), err)
+	}
+	return &c, nil
+}
+
+func (t *VCRTransport) save(hash string, n int, c *cassette) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path(hash, n), b, 0o644)
+}
+
+func (c *cassette) setBody(b []byte) {
+	if utf8.Valid(b) {
+		c.Body = string(b)
+		return
+	}
+	c.BodyBase64 = base64.StdEncoding.EncodeToString(b)
+}
+
+func (c *cassette) response(req *http.Request) *http.Response {
+	body := []byte(c.Body)
+	if c.BodyBase64 != "" {
+		body, _ = base64.StdEncoding.DecodeString(c.BodyBase64)
+	}
+	header := c.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Status, http.StatusText(c.Status)),
+		StatusCode:    c.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// vcrRecorder 在讀取回應的同時保留內容，讀到結尾或關閉時寫入 cassette；串流回應照常即時交給呼叫端。
+type vcrRecorder struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	save func([]byte)
+	once sync.Once
+}
+
+func (r *vcrRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.once.Do(func() { r.save(r.buf.Bytes()) })
+	}
+	return n, err
+}
+
+// Close 呼叫端未讀完即關閉時（例如只讀錯誤訊息前段）仍保存已讀取的部分。
+func (r *vcrRecorder) Close() error {
+	r.once.Do(func() { r.save(r.buf.Bytes()) })
+	return r.ReadCloser.Close()
+}
+
+// WrapVCR 以 VCR transport 包裝 c（不修改 c）；mode 為空字串時原樣返回。
+func WrapVCR(c *http.Client, mode, dir string) (*http.Client, error) {
+	if mode == "" {
+		return c, nil
+	}
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	rt, err := NewVCRTransport(mode, strings.TrimSpace(dir), next)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := *c
+	wrapped.Transport = rt
+	return &wrapped, nil
+}
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() { RegisterSTT("whispercpp", newWhisperCppSTT) }
+
+// newWhisperCppSTT 依 WHISPERCPP_* 建立本機 whisper.cpp 轉錄：設定 WHISPERCPP_URL 時呼叫 server，否則執行 WHISPERCPP_BIN。
+func newWhisperCppSTT(_ context.Context, opts Options) (STTService, error) {
+	stt := &WhisperCppSTT{
+		ServerURL: opts("WHISPERCPP_URL"),
+		Binary:    opts.String("WHISPERCPP_BIN", "whisper-cli"),
+		ModelPath: opts("WHISPERCPP_MODEL"),
+		Threads:   opts.Int("WHISPERCPP_THREADS", 0),
+		Language:  opts("WHISPERCPP_LANGUAGE"),
+	}
+	if stt.Threads < 0 {
+		return nil, fmt.Errorf("invalid WHISPERCPP_THREADS: %d (must be >= 0)", stt.Threads)
+	}
+	if stt.ServerURL == "" && stt.ModelPath == "" {
+		return nil, fmt.Errorf("WHISPERCPP_URL or WHISPERCPP_MODEL must be provided")
+	}
+	if stt.ServerURL != "" {
+		log.Printf("whisper.cpp STT enabled: server %s", stt.ServerURL)
+	} else {
+		log.Printf("whisper.cpp STT enabled: %s -m %s (threads=%d)", stt.Binary, stt.ModelPath, stt.Threads)
+	}
+	return stt, nil
+}
+
+// WhisperCppSTT 以本機 whisper.cpp 轉錄，音訊不離開部署環境。
+// 設定 ServerURL 時呼叫 whisper.cpp server 的 /inference（模型與執行緒數於 server 啟動時決定）；
+// 否則每個 chunk 執行一次 Binary（whisper-cli），載入 ModelPath 並以 Threads 個執行緒轉錄。
+type WhisperCppSTT struct {
+	ServerURL string // 例如 http://whisper:8080/inference
+	Binary    string // whisper-cli 路徑，空值為 PATH 中的 whisper-cli
+	ModelPath string // ggml 模型檔（例如 ggml-large-v3-turbo.bin）
+	Threads   int    // 0 沿用 whisper.cpp 預設
+	Language  string // 預設語言，任務的語言提示優先；空值為自動偵測
+	// HTTPClient 空值使用共用 client（見 SetHTTPClient）。
+	HTTPClient *http.Client
+}
+
+// STTModelName 以模型檔名（不含副檔名）標示，server 模式未知模型時為 whisper.cpp。
+func (w *WhisperCppSTT) STTModelName() string {
+	if w.ModelPath == "" {
+		return "whisper.cpp"
+	}
+	return strings.TrimSuffix(filepath.Base(w.ModelPath), filepath.Ext(w.ModelPath))
+}
+
+func (w *WhisperCppSTT) STT(ctx context.Context, filePath string) (string, error) {
+	res, err := w.TranscribeWithOptions(ctx, filePath, STTOptions{})
+	return res.Text, err
+}
+
+// TranscribeWithOptions 轉錄並套用語言提示與前文提示；whisper.cpp 無法切換模型，opts.Model 忽略。
+func (w *WhisperCppSTT) TranscribeWithOptions(ctx context.Context, filePath string, opts STTOptions) (STTResult, error) {
+	lang := NormalizeLanguage(opts.Language)
+	if lang == "" {
+		lang = NormalizeLanguage(w.Language)
+	}
+	if w.ServerURL != "" {
+		return w.inference(ctx, filePath, lang, opts)
+	}
+	return w.run(ctx, filePath, lang, opts)
+}
+
+// inference 呼叫 whisper.cpp server：multipart 上傳音檔，verbose_json 回應含偵測到的語言。
+func (w *WhisperCppSTT) inference(ctx context.Context, filePath, lang string, opts STTOptions) (STTResult, error) {
+	body, contentType, err := multipartFile(filePath, func(writer *multipart.Writer) {
+		_ = writer.WriteField("response_format", "verbose_json")
+		_ = writer.WriteField("temperature", "0.0")
+		if lang != "" {
+			_ = writer.WriteField("language", lang)
+		} else {
+			_ = writer.WriteField("language", "auto")
+		}
+		if opts.Prompt != "" {
+			_ = writer.WriteField("prompt", opts.Prompt)
+		}
+	})
+	if err != nil {
+		return STTResult{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.ServerURL, body)
+	if err != nil {
+		body.Close()
+		return STTResult{}, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := httpClient(w.HTTPClient).Do(req)
+	if err != nil {
+		return STTResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return STTResult{}, sttStatusError("whisper.cpp", resp.StatusCode, b)
+	}
+
+	var result struct {
+		Text     string    `json:"text"`
+		Language string    `json:"language"`
+		Segments []Segment `json:"segments"`
+		Error    string    `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return STTResult{}, err
+	}
+	if result.Error != "" {
+		return STTResult{}, fmt.Errorf("whisper.cpp stt failed: %s", result.Error)
+	}
+	detected := NormalizeLanguage(result.Language)
+	if detected == "" {
+		detected = lang
+	}
+	res := STTResult{Text: strings.TrimSpace(result.Text), Language: detected}
+	if opts.Timestamps {
+		res.Segments = trimSegments(result.Segments)
+	}
+	return res, nil
+}
+
+// run 執行 whisper-cli，-nt 不輸出時間戳、-np 不輸出進度，stdout 即為轉錄稿。
+func (w *WhisperCppSTT) run(ctx context.Context, filePath, lang string, opts STTOptions) (STTResult, error) {
+	if w.ModelPath == "" {
+		return STTResult{}, fmt.Errorf("whisper.cpp stt: model path is required without a server url")
+	}
+	bin := w.Binary
+	if bin == "" {
+		bin = "whisper-cli"
+	}
+	args := []string{"-m", w.ModelPath, "-f", filePath, "-nt", "-np"}
+	if w.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(w.Threads))
+	}
+	if lang != "" {
+		args = append(args, "-l", lang)
+	} else {
+		args = append(args, "-l", "auto")
+	}
+	if opts.Prompt != "" {
+		args = append(args, "--prompt", opts.Prompt)
+	}
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.WaitDelay = 5 * time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return STTResult{}, ctx.Err()
+		}
+		return STTResult{}, fmt.Errorf("whisper.cpp stt failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return STTResult{Text: strings.TrimSpace(stdout.String()), Language: lang}, nil
+}
@@ -1,13 +1,11 @@
 package audio
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"fmt"
+	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -16,152 +14,177 @@ import (
 type Chunk struct {
 	Index    int
 	FilePath string
+	// Gap 大於 0 表示原始錄音中此長度（秒）的音訊無法解碼而略過：沒有分片檔案，轉錄稿以缺漏標記代替。
+	Gap float64
+	// Offset 分片在原始錄音中的起點（秒）；Scale 為分片中 1 秒對應原始錄音的秒數（前處理加速時大於 1），0 視為 1。
+	// 用於將 provider 回報的時間戳換算回原始錄音。
+	Offset float64
+	Scale  float64
+	// Duration 分片長度（秒），未知時為 0；用於以每秒音訊的延遲比較 provider 速度。
+	Duration float64
 }
 
 const (
-	// MaxFileSizeNoSplit 定義不進行切割的最大檔案大小。
+	// MaxFileSizeNoSplit 預設不進行切割的最大檔案大小。
 	// 設為 1MB 以符合本地開發及低延遲處理需求。
 	MaxFileSizeNoSplit = 1 * 1024 * 1024
 	// BytesPerSecond16kMono 為 16kHz Mono 16-bit WAV 的位元率 (32,000 bytes/s)。
 	BytesPerSecond16kMono = 32000
 )
 
-// SplitAudio 將音檔切割為符合 STT 模型限制的分片。
-//
-// 策略：
-//   - 小於 MaxFileSizeNoSplit 的檔案直接轉換格式，不切割
-//   - VAD 優先：在硬性上限 (maxChunkDuration) 之前尋找最晚的靜音點
-//   - Overlap Fallback：無合適靜音點時執行硬切，銜接處加入 1.5s 重疊防止斷詞
-//   - 格式標準化：所有分片統一轉換為 16kHz Mono 16-bit WAV (保證大小)
-func SplitAudio(inputPath string, maxChunkDuration float64) ([]Chunk, error) {
-	tempDir := filepath.Join(filepath.Dir(inputPath), "chunks")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return nil, err
-	}
-
-	duration, err := getDuration(inputPath)
-	if err != nil {
-		return nil, err
-	}
-
-	// 根據時長預估輸出大小，確保轉換後的單一 WAV 檔案不超過 MaxFileSizeNoSplit
-	if duration*float64(BytesPerSecond16kMono) < float64(MaxFileSizeNoSplit) {
-		outputPath := filepath.Join(tempDir, "chunk_0.wav")
-		cmd := exec.Command("ffmpeg", "-y", "-i", inputPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", outputPath)
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to convert audio: %v", err)
-		}
-		return []Chunk{{Index: 0, FilePath: outputPath}}, nil
-	}
+// SplitOptions 切片參數。部分 STT provider 接受 25MB / 10 分鐘的 chunk，
+// 較長的 chunk 可減少請求數與銜接處誤差，較短則降低單次失敗的重做成本。
+type SplitOptions struct {
+	// MaxChunkDuration 單一分片的硬性上限（秒）。
+	MaxChunkDuration float64
+	// Overlap 無靜音點而硬切時，相鄰分片的重疊秒數。
+	Overlap float64
+	// NoSplitBytes 轉換後 WAV 預估小於此大小時不切割。
+	NoSplitBytes int64
+	// VAD 尋找切割點的語音停頓偵測，nil 時使用 ffmpeg silencedetect。
+	VAD VAD
+	// ScratchDir 分片與解碼暫存檔的目錄（其下建立 chunks/），空值為輸入檔所在目錄。
+	ScratchDir string
+	// MaxChunkBytes STT provider 的單檔上限（例如 Whisper API 的 25MB）；大於 0 時依 Encoding 的位元率
+	// 縮短 MaxChunkDuration 與 NoSplitBytes，讓分片（含尾端併入的剩餘音訊）不超過此大小。
+	MaxChunkBytes int64
+	// Encoding 分片的輸出編碼，空值為 WAV。
+	Encoding ChunkEncoding
+}
 
-	silences, err := getSilencePoints(inputPath)
-	if err != nil {
-		// VAD 偵測失敗時退化為固定時長切割
-		silences = []float64{}
-	}
+// ChunkEncoding 分片的輸出編碼。壓縮編碼讓每個請求可容納更長的音訊，但每個分片需多執行一次 ffmpeg。
+type ChunkEncoding string
 
-	const overlapDuration = 1.5 // 無靜音點時的重疊秒數，防止硬切斷詞
+const (
+	EncodingWAV  ChunkEncoding = "wav"  // 16kHz mono 16-bit PCM，由 Go 直接擷取
+	EncodingFLAC ChunkEncoding = "flac" // 無損壓縮
+	EncodingOpus ChunkEncoding = "ogg"  // Ogg Opus 24 kbps
+)
 
-	var chunks []Chunk
-	index := 0
-	start := 0.0
+// 壓縮編碼預估的位元率（bytes/s），依語音錄音的實測值保守估計；實際大小仍超過 provider 上限時，
+// 由 Worker 在 provider 返回 413 後對半切割重送。
+const (
+	flacBytesPerSecond = 24000
+	opusBytesPerSecond = 4000
+)
 
-	for start < duration {
-		targetEnd := start + maxChunkDuration
-		if targetEnd > duration {
-			targetEnd = duration
-		}
+// ParseChunkEncoding 解析 wav / flac / ogg（opus 為 ogg 的別名），空字串為 WAV。
+func ParseChunkEncoding(s string) (ChunkEncoding, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "wav":
+		return EncodingWAV, nil
+	case "flac":
+		return EncodingFLAC, nil
+	case "ogg", "opus":
+		return EncodingOpus, nil
+	}
+	return "", fmt.Errorf("unknown chunk encoding %q (wav, flac, ogg)", s)
+}
 
-		// 實作硬性上限搜尋：在不超過 targetEnd 的前提下，尋找最晚的靜音點 (確保單一分片 < 1MB)
-		actualEnd := targetEnd
-		usedSilence := false
-		if targetEnd < duration {
-			bestSilence := -1.0
-
-			// 僅搜尋 (start, targetEnd] 範圍內的靜音點，確保不超標
-			for _, s := range silences {
-				if s > start && s <= targetEnd {
-					// 取範圍內最後一個（最靠近上限）的靜音點，極大化分片效率
-					if s > bestSilence {
-						bestSilence = s
-					}
-				}
-			}
+// bytesPerSecond 分片編碼的（預估）位元率。
+func (e ChunkEncoding) bytesPerSecond() float64 {
+	switch e {
+	case EncodingFLAC:
+		return flacBytesPerSecond
+	case EncodingOpus:
+		return opusBytesPerSecond
+	}
+	return BytesPerSecond16kMono
+}
 
-			// 啟發式規則：僅在靜音點位於目標點前的 10s 內才採用
-			// 若靜音點太早，則直接執行硬切（透過 1.5s Overlap 補償語義中斷）
-			if bestSilence != -1.0 && (targetEnd-bestSilence) < 10.0 {
-				actualEnd = bestSilence
-				usedSilence = true
-			}
-		}
+// chunkDir 分片目錄：{ScratchDir 或輸入檔所在目錄}/chunks。
+func (o SplitOptions) chunkDir(inputPath string) string {
+	dir := o.ScratchDir
+	if dir == "" {
+		dir = filepath.Dir(inputPath)
+	}
+	return filepath.Join(dir, "chunks")
+}
 
-		// 避免尾端產生過短分片（<5s 直接合併至當前分片）
-		if duration-actualEnd < 5.0 && actualEnd < duration {
-			actualEnd = duration
-		}
+// DefaultSplitOptions 30 秒分片、1.5 秒重疊、1MB 以下不切割。
+func DefaultSplitOptions() SplitOptions {
+	return SplitOptions{MaxChunkDuration: 30, Overlap: 1.5, NoSplitBytes: MaxFileSizeNoSplit}
+}
 
-		outputPath := filepath.Join(tempDir, fmt.Sprintf("chunk_%d.wav", index))
+// Chunker 音檔切片的實作。Worker 透過此介面切片，可替換為其他切片方式，或在測試中注入不需 ffmpeg 的 MemoryChunker。
+type Chunker interface {
+	// SplitStream 切割 inputPath，每個分片產生時依 Index 順序交給 emit；語意同 SplitAudioStream。
+	SplitStream(ctx context.Context, inputPath string, opts SplitOptions, emit func(Chunk) error) error
+}
 
-		// 切割並轉換為 16kHz Mono 16-bit WAV (約 32,000 bytes/s)
-		chunkLen := actualEnd - start
-		cmd := exec.Command("ffmpeg", "-y", "-ss", strconv.FormatFloat(start, 'f', 3, 64),
-			"-t", strconv.FormatFloat(chunkLen, 'f', 3, 64), "-i", inputPath,
-			"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", outputPath)
+// FFmpegChunker 預設的 Chunker，即 SplitAudioStream。
+type FFmpegChunker struct{}
 
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to create chunk %d: %v", index, err)
-		}
+func (FFmpegChunker) SplitStream(ctx context.Context, inputPath string, opts SplitOptions, emit func(Chunk) error) error {
+	return SplitAudioStream(ctx, inputPath, opts, emit)
+}
 
-		chunks = append(chunks, Chunk{Index: index, FilePath: outputPath})
-		index++
+// Split 以 c 切片並等待所有分片切完才返回；失敗時刪除已產生的分片。
+func Split(ctx context.Context, c Chunker, inputPath string, opts SplitOptions) ([]Chunk, error) {
+	var chunks []Chunk
+	err := c.SplitStream(ctx, inputPath, opts, func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		CleanupChunks(chunks)
+		return nil, err
+	}
+	return chunks, nil
+}
 
-		// 靜音點切割為 clean cut，否則加入 overlap 防止斷詞
-		if usedSilence || actualEnd >= duration {
-			start = actualEnd
-		} else {
-			start = actualEnd - overlapDuration
-		}
+// SplitAudio 將音檔切割為符合 STT 模型限制的分片，等待所有分片切完才返回（見 SplitAudioStream）。
+//
+// 策略：
+//   - 小於 opts.NoSplitBytes 的檔案直接轉換格式，不切割
+//   - VAD 優先：在硬性上限 (opts.MaxChunkDuration) 之前尋找最晚的靜音點
+//   - Overlap Fallback：無合適靜音點時執行硬切，銜接處加入 opts.Overlap 秒重疊防止斷詞
+//   - 格式標準化：所有分片統一轉換為 16kHz Mono 16-bit WAV (保證大小)
+//   - 單次解碼：其他格式（含影片容器的第一條音軌）以單一 ffmpeg 程序解碼為 16kHz Mono PCM，所有分片再以 Go 擷取，
+//     避免每個分片各自以 -ss 重新讀取、解碼整個輸入（長錄音為 O(n²)）
+//   - WAV 快速路徑：已是 16kHz Mono 16-bit 的 PCM WAV 省略解碼，不需 ffmpeg/ffprobe
+//
+// ctx 取消或逾時時會中止執行中的 ffmpeg/ffprobe 並返回錯誤，已產生的分片會被刪除。
+func SplitAudio(ctx context.Context, inputPath string, opts SplitOptions) ([]Chunk, error) {
+	return Split(ctx, FFmpegChunker{}, inputPath, opts)
+}
 
-		if start >= duration {
-			break
-		}
+// pausePoints 以設定的 VAD 偵測切割點；ML VAD 失敗時退回 ffmpeg silencedetect。
+func pausePoints(ctx context.Context, inputPath string, vad VAD) ([]float64, error) {
+	if vad == nil {
+		return getSilencePoints(ctx, inputPath)
 	}
-
-	return chunks, nil
+	points, err := vad.PausePoints(ctx, inputPath)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("VAD failed, falling back to silencedetect: %v", err)
+		return getSilencePoints(ctx, inputPath)
+	}
+	return points, err
 }
 
 // getSilencePoints 使用 ffmpeg silencedetect 偵測音檔中的靜音段。
 // 返回每段靜音的中點時間戳，作為安全的切割候選點。
-func getSilencePoints(inputPath string) ([]float64, error) {
-	cmd := exec.Command("ffmpeg", "-i", inputPath, "-af", "silencedetect=noise=-30dB:d=0.5", "-f", "null", "-")
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	_ = cmd.Run()
-
-	var silences []float64
-	reStart := regexp.MustCompile(`silence_start: ([\d.]+)`)
-	reEnd := regexp.MustCompile(`silence_end: ([\d.]+)`)
-
-	scanner := bufio.NewScanner(&stderr)
-	var lastStart float64
-	for scanner.Scan() {
-		line := scanner.Text()
-		if match := reStart.FindStringSubmatch(line); match != nil {
-			lastStart, _ = strconv.ParseFloat(match[1], 64)
-		} else if match := reEnd.FindStringSubmatch(line); match != nil {
-			end, _ := strconv.ParseFloat(match[1], 64)
-			// 取靜音段中點作為切割點
-			silences = append(silences, (lastStart+end)/2.0)
-		}
+func getSilencePoints(ctx context.Context, inputPath string) ([]float64, error) {
+	ranges, err := silentRanges(ctx, inputPath, 0)
+	return midpoints(ranges), err
+}
+
+// DecodedSize 輸入解碼為 16kHz mono 16-bit PCM 後的大小（bytes），用於預估切片所需的暫存空間。
+func DecodedSize(ctx context.Context, inputPath string) (int64, error) {
+	if wav, err := readWAV(inputPath); err == nil {
+		return int64(wav.duration() * BytesPerSecond16kMono), nil
+	}
+	duration, err := getDuration(ctx, inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("DecodedSize(%s): %w", inputPath, err)
 	}
-	return silences, nil
+	return int64(duration * BytesPerSecond16kMono), nil
 }
 
 // getDuration 使用 ffprobe 取得音檔總時長（秒）。
-func getDuration(inputPath string) (float64, error) {
-	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", inputPath)
-	out, err := cmd.Output()
+func getDuration(ctx context.Context, inputPath string) (float64, error) {
+	cmd := command(ctx, "ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", inputPath)
+	out, err := output(ctx, cmd)
 	if err != nil {
 		return 0, err
 	}
@@ -171,10 +194,52 @@ func getDuration(inputPath string) (float64, error) {
 
 // CleanupChunks 刪除所有分片檔案與暫存目錄。
 func CleanupChunks(chunks []Chunk) {
+	dir := ""
 	for _, c := range chunks {
-		os.Remove(c.FilePath)
+		if c.FilePath != "" {
+			os.Remove(c.FilePath)
+			dir = filepath.Dir(c.FilePath)
+		}
 	}
-	if len(chunks) > 0 {
-		os.Remove(filepath.Dir(chunks[0].FilePath))
+	if dir != "" {
+		os.Remove(dir)
+	}
+}
+
+// SplitHalves 將 16kHz mono WAV 分片對半切割（銜接處保留 overlap 秒重疊），
+// 返回兩個新檔案路徑與後半段在原分片中的起點（秒），供 provider 因大小拒絕時重試。呼叫端負責刪除。
+func SplitHalves(ctx context.Context, inputPath string, overlap float64) ([2]string, float64, error) {
+	var halves [2]string
+	wav, wavErr := readWAV(inputPath)
+	var duration float64
+	if wavErr == nil {
+		duration = wav.duration()
+	} else {
+		d, err := getDuration(ctx, inputPath)
+		if err != nil {
+			return halves, 0, fmt.Errorf("SplitHalves(%s): %w", inputPath, err)
+		}
+		duration = d
+	}
+	mid := duration / 2
+	base := strings.TrimSuffix(inputPath, filepath.Ext(inputPath))
+	ranges := [2][2]float64{{0, min(mid+overlap/2, duration)}, {max(mid-overlap/2, 0), duration}}
+	for i, r := range ranges {
+		halves[i] = fmt.Sprintf("%s_%c.wav", base, 'a'+i)
+		if wavErr == nil && wav.isChunkFormat() {
+			if err := writeWAVSlice(inputPath, wav, halves[i], r[0], r[1]-r[0]); err != nil {
+				os.Remove(halves[0])
+				return halves, 0, fmt.Errorf("SplitHalves(%s): %w", inputPath, err)
+			}
+			continue
+		}
+		cmd := command(ctx, "ffmpeg", "-y", "-ss", strconv.FormatFloat(r[0], 'f', 3, 64),
+			"-t", strconv.FormatFloat(r[1]-r[0], 'f', 3, 64), "-i", inputPath,
+			"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", halves[i])
+		if err := run(ctx, cmd); err != nil {
+			os.Remove(halves[0])
+			return halves, 0, fmt.Errorf("SplitHalves(%s): %w", inputPath, err)
+		}
 	}
+	return halves, ranges[1][0], nil
 }
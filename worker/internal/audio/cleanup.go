@@ -0,0 +1,38 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// CleanupPresets 音訊清理預設的 ffmpeg filter chain，適用於雜訊較多的電話或現場錄音。
+var CleanupPresets = map[string]string{
+	// highpass 去除冷氣、風切、麥克風碰撞等低頻雜訊
+	"highpass": "highpass=f=100",
+	// denoise 再以 FFT 降噪壓低穩定的背景噪音
+	"denoise": "highpass=f=100,afftdn=nf=-25",
+	// phone 限制在電話語音頻段（300~3400Hz）並降噪
+	"phone": "highpass=f=300,lowpass=f=3400,afftdn=nf=-25",
+}
+
+// CleanAudio 以 preset 對應的 filter chain 清理音訊，輸出 16kHz mono WAV 至 dir，返回新檔路徑。呼叫端負責刪除輸出檔。
+func CleanAudio(ctx context.Context, inputPath, dir, preset string) (string, error) {
+	filter, ok := CleanupPresets[preset]
+	if !ok {
+		return "", fmt.Errorf("CleanAudio(%s): unknown preset %q", inputPath, preset)
+	}
+	out, err := os.CreateTemp(dir, "cleanup-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("CleanAudio(%s): %w", inputPath, err)
+	}
+	out.Close()
+
+	cmd := command(ctx, "ffmpeg", "-y", "-i", inputPath, "-vn", "-af", filter,
+		"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", out.Name())
+	if err := run(ctx, cmd); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("CleanAudio(%s): %w", inputPath, err)
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCommandTimeout ffmpeg / ffprobe 單次執行超過 commandTimeout 而被終止。
+var ErrCommandTimeout = errors.New("command timed out")
+
+// commandWaitDelay 行程結束（或被終止）後，等待仍持有 stdout / stderr 的子孫行程關閉管線的上限。
+const commandWaitDelay = 5 * time.Second
+
+// commandTimeout 單次外部指令的硬性上限，與任務 context 無關：卡住的 ffmpeg 不會讓任務永遠停在處理中。
+var commandTimeout = 30 * time.Minute
+
+// SetCommandTimeout 設定單次 ffmpeg / ffprobe 執行的上限；須涵蓋最長錄音的完整解碼（含 loudnorm 等前處理）。
+func SetCommandTimeout(d time.Duration) {
+	if d > 0 {
+		commandTimeout = d
+	}
+}
+
+// command 建立綁定 ctx 的外部指令。指令在獨立的 process group 中執行，ctx 取消或逾時時終止整個 group，
+// ffmpeg 衍生的子行程不會殘留；Wait 最多再等 commandWaitDelay 即返回，不會因管線未關閉而卡住。
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+// process 已啟動的外部指令；超過 commandTimeout 仍未結束時終止。
+type process struct {
+	cmd      *exec.Cmd
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+// start 啟動指令並開始計時。
+func start(cmd *exec.Cmd) (*process, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &process{cmd: cmd, timeout: commandTimeout}
+	p.timer = time.AfterFunc(p.timeout, func() {
+		p.timedOut.Store(true)
+		if cmd.Cancel != nil {
+			cmd.Cancel()
+		} else {
+			cmd.Process.Kill()
+		}
+	})
+	return p, nil
+}
+
+// wait 等待指令結束並記錄資源使用量；逾時被終止時返回 ErrCommandTimeout。
+func (p *process) wait(ctx context.Context) error {
+	err := p.cmd.Wait()
+	p.timer.Stop()
+	recordUsage(ctx, p.cmd)
+	if p.timedOut.Load() {
+		return fmt.Errorf("%s: %w after %s", filepath.Base(p.cmd.Path), ErrCommandTimeout, p.timeout)
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package audio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ContentHash 音訊內容的 SHA-256（hex）：解碼為 16kHz mono 16-bit PCM 後計算，與容器、檔頭中繼資料及檔名無關，
+// 同一段錄音重新封裝或以不同檔名上傳時結果相同。已是分片格式的 WAV 直接讀取 PCM，不需 ffmpeg。
+func ContentHash(ctx context.Context, inputPath string) (string, error) {
+	h := sha256.New()
+	if wav, err := readWAV(inputPath); err == nil && wav.isChunkFormat() {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			return "", fmt.Errorf("ContentHash(%s): %w", inputPath, err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(h, io.NewSectionReader(f, wav.dataOffset, wav.dataSize)); err != nil {
+			return "", fmt.Errorf("ContentHash(%s): %w", inputPath, err)
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	cmd := command(ctx, "ffmpeg", "-v", "error", "-i", inputPath,
+		"-map", "0:a:0", "-ac", "1", "-ar", "16000", "-f", "s16le", "-")
+	cmd.Stdout = h
+	if err := run(ctx, cmd); err != nil {
+		return "", fmt.Errorf("ContentHash(%s): %w", inputPath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
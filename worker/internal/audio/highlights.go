@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Clip 音檔中的一段區間（秒）。
+type Clip struct {
+	Start float64
+	End   float64
+}
+
+// MergeClips 依起點排序並合併重疊或相接的區間，丟棄長度為 0 的區間。
+func MergeClips(clips []Clip) []Clip {
+	sorted := make([]Clip, 0, len(clips))
+	for _, c := range clips {
+		if c.End > c.Start && c.Start >= 0 {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var merged []Clip
+	for _, c := range sorted {
+		if n := len(merged); n > 0 && c.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, c.End)
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// CutHighlights 依序擷取 clips 並串接為單一 AAC 音檔（outputPath，建議 .m4a）。
+// 先寫入暫存檔再 rename，避免讀取端拿到未完成的檔案。
+func CutHighlights(ctx context.Context, inputPath string, clips []Clip, outputPath string) error {
+	clips = MergeClips(clips)
+	if len(clips) == 0 {
+		return fmt.Errorf("CutHighlights: no valid clips")
+	}
+
+	var filter strings.Builder
+	for i, c := range clips {
+		fmt.Fprintf(&filter, "[0:a]atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS[a%d];", c.Start, c.End, i)
+	}
+	for i := range clips {
+		fmt.Fprintf(&filter, "[a%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(clips))
+
+	tmpPath := outputPath + ".tmp"
+	cmd := command(ctx, "ffmpeg", "-y", "-i", inputPath,
+		"-filter_complex", filter.String(), "-map", "[out]",
+		"-c:a", "aac", "-b:a", "128k", "-f", "mp4", tmpPath)
+	if err := run(ctx, cmd); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("CutHighlights: ffmpeg: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("CutHighlights: %w", err)
+	}
+	return nil
+}
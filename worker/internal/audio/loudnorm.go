@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EBU R128 目標：整合響度 -16 LUFS（語音常用）、真峰值 -1.5 dBTP、響度範圍 11 LU。
+const (
+	loudnormTarget = "I=-16:TP=-1.5:LRA=11"
+)
+
+// LoudnessLevels ffmpeg loudnorm 量測到的響度。
+type LoudnessLevels struct {
+	Integrated float64 `json:"integrated"` // LUFS
+	TruePeak   float64 `json:"truePeak"`   // dBTP
+	Range      float64 `json:"range"`      // LU
+}
+
+// LoudnessStats 正規化前後的響度，記錄於任務 metadata。
+type LoudnessStats struct {
+	Before LoudnessLevels `json:"before"`
+	After  LoudnessLevels `json:"after"`
+}
+
+// loudnormOutput loudnorm print_format=json 的輸出（數值為字串）。
+type loudnormOutput struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	OutputI      string `json:"output_i"`
+	OutputTP     string `json:"output_tp"`
+	OutputLRA    string `json:"output_lra"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// NormalizeLoudness 以兩階段 ffmpeg loudnorm（EBU R128）修正過小或削波的錄音：先量測，再以量測值做線性正規化，
+// 輸出 16kHz mono WAV 至 dir，返回新檔路徑與前後響度。呼叫端負責刪除輸出檔。
+func NormalizeLoudness(ctx context.Context, inputPath, dir string) (string, LoudnessStats, error) {
+	var stats LoudnessStats
+	measured, err := runLoudnorm(ctx, inputPath, "loudnorm="+loudnormTarget+":print_format=json", "-f", "null", "-")
+	if err != nil {
+		return "", stats, fmt.Errorf("NormalizeLoudness(%s): measure: %w", inputPath, err)
+	}
+
+	out, err := os.CreateTemp(dir, "loudnorm-*.wav")
+	if err != nil {
+		return "", stats, fmt.Errorf("NormalizeLoudness(%s): %w", inputPath, err)
+	}
+	out.Close()
+	outputPath := out.Name()
+	filter := fmt.Sprintf("loudnorm=%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true:print_format=json",
+		loudnormTarget, measured.InputI, measured.InputTP, measured.InputLRA, measured.InputThresh, measured.TargetOffset)
+	applied, err := runLoudnorm(ctx, inputPath, filter, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return "", stats, fmt.Errorf("NormalizeLoudness(%s): apply: %w", inputPath, err)
+	}
+
+	stats.Before = LoudnessLevels{Integrated: parseLevel(measured.InputI), TruePeak: parseLevel(measured.InputTP), Range: parseLevel(measured.InputLRA)}
+	stats.After = LoudnessLevels{Integrated: parseLevel(applied.OutputI), TruePeak: parseLevel(applied.OutputTP), Range: parseLevel(applied.OutputLRA)}
+	return outputPath, stats, nil
+}
+
+// runLoudnorm 執行 ffmpeg -af filter 並解析 stderr 結尾的 loudnorm JSON。
+func runLoudnorm(ctx context.Context, inputPath, filter string, outputArgs ...string) (loudnormOutput, error) {
+	var out loudnormOutput
+	args := append([]string{"-hide_banner", "-y", "-i", inputPath, "-vn", "-af", filter}, outputArgs...)
+	cmd := command(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err != nil {
+		return out, err
+	}
+	log := stderr.String()
+	start := strings.LastIndex(log, "{")
+	end := strings.LastIndex(log, "}")
+	if start < 0 || end < start {
+		return out, fmt.Errorf("no loudnorm stats in ffmpeg output")
+	}
+	if err := json.Unmarshal([]byte(log[start:end+1]), &out); err != nil {
+		return out, fmt.Errorf("parse loudnorm stats: %w", err)
+	}
+	return out, nil
+}
+
+// parseLevel 解析 loudnorm 數值；無聲音檔會回報 "-inf"，以 0 表示。
+func parseLevel(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MemoryChunker 不呼叫 ffmpeg 的 Chunker，供測試與沒有 ffmpeg 的開發環境使用：忽略輸入檔的內容，
+// 將 PCM 中的每一段（16kHz mono s16le）依序寫成分片 WAV。Err 非 nil 時在送出所有分片後返回，模擬解碼中途失敗。
+type MemoryChunker struct {
+	PCM [][]byte
+	Err error
+}
+
+func (m MemoryChunker) SplitStream(ctx context.Context, inputPath string, opts SplitOptions, emit func(Chunk) error) error {
+	dir := opts.chunkDir(inputPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("MemoryChunker.SplitStream(%s): %w", inputPath, err)
+	}
+	format := wavFormat{SampleRate: 16000, Channels: 1, BitsPerSample: 16}
+	offset := 0.0
+	for i, pcm := range m.PCM {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("chunk_%d.wav", i))
+		if err := writeMemoryWAV(path, format, pcm); err != nil {
+			return fmt.Errorf("MemoryChunker.SplitStream(%s): %w", inputPath, err)
+		}
+		seconds := float64(len(pcm)) / BytesPerSecond16kMono
+		if err := emit(Chunk{Index: i, FilePath: path, Offset: offset, Duration: seconds}); err != nil {
+			return err
+		}
+		offset += seconds
+	}
+	return m.Err
+}
+
+// writeMemoryWAV 將 PCM 寫成 WAV 檔；失敗時刪除寫到一半的檔案。
+func writeMemoryWAV(path string, f wavFormat, pcm []byte) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	writeWAVHeader(w, f, int64(len(pcm)))
+	w.Write(pcm)
+	err = w.Flush()
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
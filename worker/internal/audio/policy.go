@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 輸入政策的錯誤代碼（見 ValidationError）。
+const (
+	ErrCodeAudioTooLong  = "ERR_AUDIO_TOO_LONG"  // 長度超過 InputPolicy.MaxDuration
+	ErrCodeFileTooLarge  = "ERR_FILE_TOO_LARGE"  // 檔案超過 InputPolicy.MaxBytes
+	ErrCodeTooManyChunks = "ERR_TOO_MANY_CHUNKS" // 分片數超過 InputPolicy.MaxChunks
+)
+
+// InputPolicy 整個部署共用的輸入限制（INPUT_* 環境變數），Gateway 上傳時與 Worker 驗證時套用同一份設定。
+// 零值欄位表示不限制。
+type InputPolicy struct {
+	// Formats 允許的容器（ffprobe format_name，例如 wav、mp3、mp4、matroska；mkv / mov / m4a 等副檔名可作為別名）。
+	Formats []string
+	// Codecs 允許的音訊編碼（ffprobe codec_name，例如 pcm_s16le、mp3、aac、opus）。
+	Codecs      []string
+	MaxDuration time.Duration
+	MaxBytes    int64
+	MaxChunks   int
+}
+
+// formatAliases 副檔名 → ffprobe format_name。
+var formatAliases = map[string]string{
+	"mkv":  "matroska",
+	"mka":  "matroska",
+	"m4a":  "mp4",
+	"mov":  "mp4",
+	"oga":  "ogg",
+	"opus": "ogg",
+}
+
+// ParseList 解析逗號分隔的格式 / 編碼清單（轉小寫、去除空白與空項目）。
+func ParseList(spec string) []string {
+	var out []string
+	for _, s := range strings.Split(spec, ",") {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// allowsFormat formatName 為 ffprobe 的 format_name（可能為逗號分隔的多個名稱，例如 "mov,mp4,m4a,3gp,3g2,mj2"）。
+func (p InputPolicy) allowsFormat(formatName string) bool {
+	if len(p.Formats) == 0 {
+		return true
+	}
+	names := ParseList(formatName)
+	for _, allowed := range p.Formats {
+		if alias, ok := formatAliases[allowed]; ok {
+			allowed = alias
+		}
+		for _, n := range names {
+			if n == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowsCodec codec 為 ffprobe 的 codec_name。
+func (p InputPolicy) allowsCodec(codec string) bool {
+	if len(p.Codecs) == 0 {
+		return true
+	}
+	for _, allowed := range p.Codecs {
+		if allowed == strings.ToLower(codec) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSize 檢查檔案大小。
+func (p InputPolicy) checkSize(size int64) error {
+	if p.MaxBytes > 0 && size > p.MaxBytes {
+		return &ValidationError{Code: ErrCodeFileTooLarge, Message: fmt.Sprintf("The file is larger than the %d MB limit.", p.MaxBytes/(1024*1024))}
+	}
+	return nil
+}
+
+// checkDuration 檢查音訊長度（秒）。
+func (p InputPolicy) checkDuration(seconds float64) error {
+	if p.MaxDuration > 0 && seconds > p.MaxDuration.Seconds() {
+		return &ValidationError{Code: ErrCodeAudioTooLong, Message: fmt.Sprintf("The recording is longer than the %s limit.", p.MaxDuration)}
+	}
+	return nil
+}
+
+// CheckChunks 檢查切片後的分片數。
+func (p InputPolicy) CheckChunks(n int) error {
+	if p.MaxChunks > 0 && n > p.MaxChunks {
+		return &ValidationError{Code: ErrCodeTooManyChunks, Message: fmt.Sprintf("The recording splits into %d chunks, more than the limit of %d.", n, p.MaxChunks)}
+	}
+	return nil
+}
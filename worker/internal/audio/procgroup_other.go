@@ -0,0 +1,8 @@
+//go:build !unix
+
+package audio
+
+import "os/exec"
+
+// setProcessGroup 非 Unix 平台沿用 exec 預設行為，取消時只終止 ffmpeg 本身。
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,14 @@
+package audio
+
+import (
+	"os"
+	"syscall"
+)
+
+// maxRSS 取得子行程峰值 RSS（Linux rusage.Maxrss 單位為 KB）。
+func maxRSS(ps *os.ProcessState) int64 {
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss * 1024
+	}
+	return 0
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package audio
+
+import "os"
+
+// maxRSS 非 Linux 平台不收集子行程 RSS。
+func maxRSS(ps *os.ProcessState) int64 {
+	return 0
+}
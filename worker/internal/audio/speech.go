@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ErrCodeNoSpeech 錄音幾乎全為靜音 / 音樂，語音比例低於設定門檻（見 ValidationError）。
+const ErrCodeNoSpeech = "ERR_NO_SPEECH"
+
+var (
+	reSilenceStart = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	reSilenceEnd   = regexp.MustCompile(`silence_end: ([\d.]+)`)
+)
+
+// SpeechStats 以靜音偵測（-30dB、0.5 秒以上）統計的語音分布（秒）。
+type SpeechStats struct {
+	DurationSec float64 `json:"durationSec"`
+	SpeechSec   float64 `json:"speechSec"`
+	LeadingSec  float64 `json:"leadingSec"`  // 開頭靜音長度
+	TrailingSec float64 `json:"trailingSec"` // 結尾靜音長度
+}
+
+// Coverage 語音佔整段錄音的比例（0~1）。
+func (s SpeechStats) Coverage() float64 {
+	if s.DurationSec <= 0 {
+		return 0
+	}
+	return s.SpeechSec / s.DurationSec
+}
+
+// DetectSpeech 統計錄音中的語音長度與開頭 / 結尾靜音。16-bit PCM WAV 直接讀取，其他格式以 ffmpeg silencedetect 偵測。
+func DetectSpeech(ctx context.Context, inputPath string) (SpeechStats, error) {
+	var stats SpeechStats
+	var silences []speechSegment
+	if wav, err := readWAV(inputPath); err == nil && wav.BitsPerSample == 16 {
+		stats.DurationSec = wav.duration()
+		if silences, err = pcmSilentRanges(inputPath, wav); err != nil {
+			return stats, fmt.Errorf("DetectSpeech(%s): %w", inputPath, err)
+		}
+	} else {
+		duration, err := getDuration(ctx, inputPath)
+		if err != nil {
+			return stats, fmt.Errorf("DetectSpeech(%s): %w", inputPath, err)
+		}
+		stats.DurationSec = duration
+		if silences, err = silentRanges(ctx, inputPath, duration); err != nil {
+			return stats, fmt.Errorf("DetectSpeech(%s): %w", inputPath, err)
+		}
+	}
+
+	stats.SpeechSec = stats.DurationSec
+	for _, s := range silences {
+		stats.SpeechSec -= s.End - s.Start
+	}
+	stats.SpeechSec = max(stats.SpeechSec, 0)
+	if n := len(silences); n > 0 {
+		// 10ms 容差：偵測窗口與 ffmpeg 時間戳的捨入誤差
+		if silences[0].Start <= 0.01 {
+			stats.LeadingSec = silences[0].End
+		}
+		if silences[n-1].End >= stats.DurationSec-0.01 && stats.SpeechSec > 0 {
+			stats.TrailingSec = stats.DurationSec - silences[n-1].Start
+		}
+	}
+	return stats, nil
+}
+
+// silentRanges 以 ffmpeg silencedetect 偵測靜音段；結尾未收到 silence_end 的靜音段以 duration 作為終點。
+func silentRanges(ctx context.Context, inputPath string, duration float64) ([]speechSegment, error) {
+	cmd := command(ctx, "ffmpeg", "-i", inputPath, "-af", "silencedetect=noise=-30dB:d=0.5", "-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+
+	var ranges []speechSegment
+	start := -1.0
+	scanner := bufio.NewScanner(&stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := reSilenceStart.FindStringSubmatch(line); match != nil {
+			start, _ = strconv.ParseFloat(match[1], 64)
+			start = max(start, 0)
+		} else if match := reSilenceEnd.FindStringSubmatch(line); match != nil && start >= 0 {
+			end, _ := strconv.ParseFloat(match[1], 64)
+			ranges = append(ranges, speechSegment{Start: start, End: end})
+			start = -1
+		}
+	}
+	if start >= 0 && duration > start {
+		ranges = append(ranges, speechSegment{Start: start, End: duration})
+	}
+	return ranges, nil
+}
+
+// TrimAudio 擷取 [start, end) 秒並輸出 16kHz mono WAV 至 dir，返回新檔路徑；輸出中的時間加上 start 即為原始錄音的時間。
+// 呼叫端負責刪除輸出檔。
+func TrimAudio(ctx context.Context, inputPath, dir string, start, end float64) (string, error) {
+	if end <= start {
+		return "", fmt.Errorf("TrimAudio(%s): empty range [%.2f, %.2f)", inputPath, start, end)
+	}
+	out, err := os.CreateTemp(dir, "trim-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("TrimAudio(%s): %w", inputPath, err)
+	}
+	out.Close()
+
+	cmd := command(ctx, "ffmpeg", "-y", "-ss", strconv.FormatFloat(start, 'f', 3, 64), "-i", inputPath,
+		"-t", strconv.FormatFloat(end-start, 'f', 3, 64), "-vn", "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", out.Name())
+	if err := run(ctx, cmd); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("TrimAudio(%s): %w", inputPath, err)
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,222 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNotStereo 聲道分離模式的輸入不是雙聲道錄音。
+var ErrNotStereo = errors.New("input is not a two-channel recording")
+
+const (
+	// turnMinSpeech 短於此長度的語音區段視為雜訊（按鍵音、咳嗽），不送轉錄。
+	turnMinSpeech = 0.25
+	// turnPadding turn 前後多保留的秒數，避免切掉字首字尾。
+	turnPadding = 0.2
+)
+
+// ChannelTurn 聲道分離模式的一段發言：同一聲道上連續、未被另一聲道打斷的語音。
+type ChannelTurn struct {
+	Chunk
+	Channel int     // 0 = 左聲道，1 = 右聲道
+	Start   float64 // 在原始錄音中的起點（秒）
+	End     float64
+}
+
+// SplitChannels 將雙聲道通話錄音（每位講者各佔一個聲道）拆成左右兩條音軌，偵測各自的語音區段後依時間排序，
+// 合併同一聲道連續、未被對方打斷的區段為 turn（不超過 opts.MaxChunkDuration），每個 turn 寫成一個 16kHz mono WAV 分片。
+// 16kHz 16-bit 雙聲道 WAV 直接以 Go 拆分；其他格式先以 ffmpeg 解碼一次。輸入不是雙聲道時返回 ErrNotStereo。
+func SplitChannels(ctx context.Context, inputPath string, opts SplitOptions) ([]ChannelTurn, error) {
+	if opts.MaxChunkDuration <= 0 {
+		opts.MaxChunkDuration = DefaultSplitOptions().MaxChunkDuration
+	}
+	tempDir := opts.chunkDir(inputPath)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, err
+	}
+
+	wav, err := readWAV(inputPath)
+	if err == nil && wav.Channels != 2 {
+		return nil, ErrNotStereo
+	}
+	if err != nil {
+		streams, err := probeStreams(ctx, inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("SplitChannels(%s): probe: %w", inputPath, err)
+		}
+		channels := 0
+		for _, s := range streams {
+			if s.CodecType == "audio" {
+				channels = s.Channels
+				break
+			}
+		}
+		if channels != 2 {
+			return nil, ErrNotStereo
+		}
+	}
+	if err != nil || wav.SampleRate != 16000 || wav.BitsPerSample != 16 {
+		decoded, err := decodeStereo(ctx, inputPath, tempDir)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(decoded)
+		if wav, err = readWAV(decoded); err != nil {
+			return nil, fmt.Errorf("SplitChannels(%s): read decoded audio: %w", inputPath, err)
+		}
+		inputPath = decoded
+	}
+
+	tracks, mono, err := deinterleaveStereo(inputPath, wav, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tracks[0])
+	defer os.Remove(tracks[1])
+
+	var segments []ChannelTurn
+	for ch, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		silences, err := pcmSilentRanges(track, mono)
+		if err != nil {
+			return nil, fmt.Errorf("SplitChannels(%s): %w", inputPath, err)
+		}
+		for _, s := range speechBetween(silences, mono.duration()) {
+			if s.End-s.Start < turnMinSpeech {
+				continue
+			}
+			// 單一區段超過分片上限時硬切
+			for start := s.Start; start < s.End; start += opts.MaxChunkDuration {
+				segments = append(segments, ChannelTurn{Channel: ch, Start: start, End: min(start+opts.MaxChunkDuration, s.End)})
+			}
+		}
+	}
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+
+	var turns []ChannelTurn
+	for _, s := range segments {
+		if n := len(turns); n > 0 && turns[n-1].Channel == s.Channel && s.End-turns[n-1].Start <= opts.MaxChunkDuration {
+			turns[n-1].End = max(turns[n-1].End, s.End)
+			continue
+		}
+		turns = append(turns, s)
+	}
+
+	for i := range turns {
+		t := &turns[i]
+		start := max(0, t.Start-turnPadding)
+		t.Index = i
+		t.Offset = start
+		t.Duration = t.End + turnPadding - start
+		t.FilePath = filepath.Join(tempDir, fmt.Sprintf("chunk_%d.wav", i))
+		if err := writeWAVSlice(tracks[t.Channel], mono, t.FilePath, start, t.Duration); err != nil {
+			for _, w := range turns[:i] {
+				os.Remove(w.FilePath)
+			}
+			return nil, fmt.Errorf("SplitChannels(%s): %w", inputPath, err)
+		}
+	}
+	return turns, nil
+}
+
+// speechBetween 返回 [0, duration) 扣除靜音段後的語音區段。
+func speechBetween(silences []speechSegment, duration float64) []speechSegment {
+	var speech []speechSegment
+	pos := 0.0
+	for _, s := range silences {
+		if s.Start > pos {
+			speech = append(speech, speechSegment{Start: pos, End: s.Start})
+		}
+		pos = s.End
+	}
+	if pos < duration {
+		speech = append(speech, speechSegment{Start: pos, End: duration})
+	}
+	return speech
+}
+
+// decodeStereo 以 ffmpeg 將第一條音軌轉為 16kHz 16-bit 雙聲道 WAV（dir 內的暫存檔），呼叫端負責刪除。
+func decodeStereo(ctx context.Context, inputPath, dir string) (string, error) {
+	f, err := os.CreateTemp(dir, "stereo-*.wav")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	cmd := command(ctx, "ffmpeg", "-y", "-i", inputPath, "-map", "0:a:0", "-vn", "-ar", "16000", "-ac", "2", "-c:a", "pcm_s16le", f.Name())
+	if err := run(ctx, cmd); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("decodeStereo(%s): %w", inputPath, err)
+	}
+	return f.Name(), nil
+}
+
+// deinterleaveStereo 將 16-bit 雙聲道 PCM WAV 拆為左右兩個 mono WAV（dir 內的暫存檔），返回路徑與 mono 格式。
+func deinterleaveStereo(inputPath string, f wavFormat, dir string) ([2]string, wavFormat, error) {
+	var paths [2]string
+	mono := wavFormat{SampleRate: f.SampleRate, Channels: 1, BitsPerSample: f.BitsPerSample, dataOffset: 44, dataSize: f.dataSize / 2}
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return paths, mono, fmt.Errorf("deinterleaveStereo(%s): %w", inputPath, err)
+	}
+	defer in.Close()
+
+	var files [2]*os.File
+	var writers [2]*bufio.Writer
+	fail := func(err error) ([2]string, wavFormat, error) {
+		for i := range files {
+			if files[i] != nil {
+				files[i].Close()
+			}
+			if paths[i] != "" {
+				os.Remove(paths[i])
+			}
+		}
+		return [2]string{}, mono, fmt.Errorf("deinterleaveStereo(%s): %w", inputPath, err)
+	}
+	for ch := range files {
+		file, err := os.CreateTemp(dir, fmt.Sprintf("channel%d-*.wav", ch))
+		if err != nil {
+			return fail(err)
+		}
+		files[ch], paths[ch] = file, file.Name()
+		writers[ch] = bufio.NewWriter(file)
+		writeWAVHeader(writers[ch], mono, mono.dataSize)
+	}
+
+	sample := f.BitsPerSample / 8
+	frame := make([]byte, 4096*f.blockAlign())
+	r := bufio.NewReader(io.NewSectionReader(in, f.dataOffset, f.dataSize))
+	for {
+		n, err := io.ReadFull(r, frame)
+		for i := 0; i+2*sample <= n; i += 2 * sample {
+			writers[0].Write(frame[i : i+sample])
+			writers[1].Write(frame[i+sample : i+2*sample])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+	for ch := range files {
+		if err := writers[ch].Flush(); err != nil {
+			return fail(err)
+		}
+		err := files[ch].Close()
+		files[ch] = nil
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return paths, mono, nil
+}
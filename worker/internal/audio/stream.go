@@ -0,0 +1,437 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minTailChunk 尾端剩餘不足此長度（秒）時併入前一個分片，避免產生過短分片。
+const minTailChunk = 5.0
+
+const (
+	// decodeRetries ffmpeg 在同一位置解碼失敗時自該位置重新解碼的次數，仍失敗則略過一個分片長度的音訊。
+	decodeRetries = 2
+	// maxDecodeGaps 略過的損毀區段上限，超過時視為整個檔案無法處理。
+	maxDecodeGaps = 3
+	// chunkWriteRetries 寫出分片檔案的嘗試次數（暫時性 I/O 錯誤）。
+	chunkWriteRetries = 3
+)
+
+// withDefaults 補齊未設定或不合理的切片參數。
+func (o SplitOptions) withDefaults() SplitOptions {
+	def := DefaultSplitOptions()
+	if o.MaxChunkDuration <= 0 {
+		o.MaxChunkDuration = def.MaxChunkDuration
+	}
+	if o.Overlap < 0 || o.Overlap >= o.MaxChunkDuration/2 {
+		// 重疊過長會使切片無法前進
+		o.Overlap = min(def.Overlap, o.MaxChunkDuration/4)
+	}
+	if o.NoSplitBytes <= 0 {
+		o.NoSplitBytes = def.NoSplitBytes
+	}
+	if o.MaxChunkBytes > 0 {
+		// 單一分片最長為 MaxChunkDuration 加上尾端併入的 minTailChunk；不切割時整檔即一個分片
+		seconds := float64(o.MaxChunkBytes-wavHeaderSize) / o.Encoding.bytesPerSecond()
+		if limit := max(seconds-minTailChunk, 1); o.MaxChunkDuration > limit {
+			o.MaxChunkDuration = limit
+			o.Overlap = min(o.Overlap, limit/4)
+		}
+		o.NoSplitBytes = min(o.NoSplitBytes, int64(seconds*BytesPerSecond16kMono))
+	}
+	return o
+}
+
+// SplitAudioStream 以與 SplitAudio 相同的規則切片，但每個分片一寫入即交給 emit（依 Index 順序、在呼叫端 goroutine 執行），
+// 讓轉錄與解碼 / 切片重疊進行：第一個分片只需等待約 MaxChunkDuration 加 5 秒的音訊解碼完成，不必等整個檔案。
+// 解碼後的音訊未超過 opts.NoSplitBytes 前不會送出分片（整檔可能不切割）；指定 opts.VAD 時需整檔偵測停頓，會先解碼完成再切片。
+// emit 返回錯誤時中止切片並返回該錯誤；已送出的分片由呼叫端負責刪除。
+//
+// ffmpeg 解碼中途失敗時自失敗位置重新解碼（decodeRetries 次）；同一位置仍無法解碼時，已解碼的部分先切完，
+// 再送出一個 Gap 分片並略過 opts.MaxChunkDuration 秒的音訊繼續，單一損毀區段不會使整個任務失敗。
+// ctx 帶有 WithWaveform 收集器時，解碼完成後寫入整段錄音的波形。
+func SplitAudioStream(ctx context.Context, inputPath string, opts SplitOptions, emit func(Chunk) error) error {
+	opts = opts.withDefaults()
+	tempDir := opts.chunkDir(inputPath)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return err
+	}
+
+	src, err := openPCM(ctx, inputPath, tempDir)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+
+	det := newSilenceDetector(src.format)
+	peaks := newPeakBuilder(src.format)
+	noSplit := float64(opts.NoSplitBytes) / BytesPerSecond16kMono
+	start, index := 0.0, 0
+	// offset / resumedAt：目前這次解碼在原始錄音中的起點，以及開始時已解碼的長度
+	offset, resumedAt := 0.0, 0.0
+
+	// cut 從 start 起依序寫出已能決定終點的分片；final 表示已讀到結尾
+	cut := func(final bool, points []float64) error {
+		for start < det.pos {
+			end, clean, ok := planCut(start, det.pos, final, points, opts)
+			if !ok {
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := src.sync(); err != nil {
+				return err
+			}
+			// 從 16kHz Mono 16-bit WAV 擷取分片 (約 32,000 bytes/s)，不需重新解碼；壓縮編碼再以 ffmpeg 轉檔
+			wavPath := filepath.Join(tempDir, fmt.Sprintf("chunk_%d.wav", index))
+			if err := writeChunk(ctx, src.path, src.format, wavPath, start, end-start); err != nil {
+				return fmt.Errorf("failed to create chunk %d: %v", index, err)
+			}
+			outputPath, err := encodeChunk(ctx, wavPath, opts.Encoding)
+			if err != nil {
+				return fmt.Errorf("failed to encode chunk %d: %v", index, err)
+			}
+			if err := emit(Chunk{Index: index, FilePath: outputPath, Offset: start + offset - resumedAt, Duration: end - start}); err != nil {
+				return err
+			}
+			index++
+			// 靜音點切割為 clean cut，否則加入 overlap 防止斷詞
+			if clean || (final && end >= det.pos) {
+				start = end
+			} else {
+				start = end - opts.Overlap
+			}
+		}
+		return nil
+	}
+
+	frame := make([]byte, det.frameSize())
+	failures, gaps := 0, 0
+	for {
+		r := bufio.NewReaderSize(src.r, 64<<10)
+		for {
+			n, err := io.ReadFull(r, frame)
+			if n > 0 {
+				det.add(frame[:n])
+				peaks.add(frame[:n])
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read audio: %v", err)
+			}
+			if opts.VAD == nil && det.pos >= noSplit && det.pos >= start+opts.MaxChunkDuration+minTailChunk {
+				if err := cut(false, midpoints(det.silences())); err != nil {
+					return err
+				}
+			}
+		}
+		err := src.wait()
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil || !src.decoding() {
+			return err
+		}
+
+		// 解碼失敗：有進展時重置重試次數，完全無法解碼則直接失敗
+		pos := offset + det.pos - resumedAt
+		if det.pos > resumedAt {
+			failures = 0
+		}
+		if failures++; failures > decodeRetries {
+			if det.pos == 0 || gaps >= maxDecodeGaps {
+				return err
+			}
+			// 已解碼的部分先切完，再以 Gap 分片標示略過的區段
+			if err := cut(true, midpoints(det.silences())); err != nil {
+				return err
+			}
+			if err := emit(Chunk{Index: index, Gap: opts.MaxChunkDuration, Offset: pos}); err != nil {
+				return err
+			}
+			index++
+			start = det.pos
+			pos += opts.MaxChunkDuration
+			peaks.skip(opts.MaxChunkDuration)
+			failures = 0
+			gaps++
+			log.Printf("Audio at %.1fs could not be decoded, skipping %.0fs: %v", pos-opts.MaxChunkDuration, opts.MaxChunkDuration, err)
+		} else {
+			log.Printf("Decoding failed at %.1fs, retrying from there: %v", pos, err)
+		}
+		offset, resumedAt = pos, det.pos
+		if err := src.decode(offset); err != nil {
+			return err
+		}
+	}
+	if err := src.finish(); err != nil {
+		return err
+	}
+	if wf, ok := ctx.Value(waveformKey{}).(*Waveform); ok {
+		*wf = peaks.waveform()
+	}
+
+	// 根據解碼後的長度判斷，確保轉換後的單一 WAV 檔案不超過 NoSplitBytes
+	if index == 0 && det.pos < noSplit {
+		outputPath := filepath.Join(tempDir, "chunk_0.wav")
+		if err := writeWAVSlice(src.path, src.format, outputPath, 0, det.pos); err != nil {
+			return fmt.Errorf("failed to convert audio: %v", err)
+		}
+		if outputPath, err = encodeChunk(ctx, outputPath, opts.Encoding); err != nil {
+			return fmt.Errorf("failed to encode chunk 0: %v", err)
+		}
+		return emit(Chunk{Index: 0, FilePath: outputPath, Duration: det.pos})
+	}
+
+	points := midpoints(det.silences())
+	if opts.VAD != nil {
+		if points, err = pausePoints(ctx, src.path, opts.VAD); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// VAD 偵測失敗時退化為固定時長切割
+			points = nil
+		}
+	}
+	return cut(true, points)
+}
+
+// planCut 依切片規則決定從 start 開始的分片終點。known 為目前已解碼的長度，final 表示 known 即為總長；
+// 串流中需多讀 minTailChunk 秒才能確定不會併入尾端，資料不足時 ok 為 false。clean 表示在靜音點切割。
+func planCut(start, known float64, final bool, points []float64, opts SplitOptions) (end float64, clean, ok bool) {
+	targetEnd := start + opts.MaxChunkDuration
+	if !final && known < targetEnd+minTailChunk {
+		return 0, false, false
+	}
+	if final && targetEnd > known {
+		targetEnd = known
+	}
+
+	// 實作硬性上限搜尋：在不超過 targetEnd 的前提下，尋找最晚的靜音點
+	end = targetEnd
+	if !final || targetEnd < known {
+		best := -1.0
+		// 僅搜尋 (start, targetEnd] 範圍內的靜音點，確保不超標；取最後一個以極大化分片效率
+		for _, s := range points {
+			if s > start && s <= targetEnd && s > best {
+				best = s
+			}
+		}
+		// 啟發式規則：僅在靜音點位於目標點前的 10s（短分片為上限的 1/3）內才採用
+		// 若靜音點太早，則直接執行硬切（透過 Overlap 補償語義中斷）
+		if best != -1.0 && targetEnd-best < min(10.0, opts.MaxChunkDuration/3) {
+			end, clean = best, true
+		}
+	}
+
+	// 避免尾端產生過短分片（<5s 直接合併至當前分片）
+	if final && known-end < minTailChunk && end < known {
+		end = known
+	}
+	return end, clean, true
+}
+
+// writeChunk 擷取分片，暫時性 I/O 錯誤時重試。
+func writeChunk(ctx context.Context, inputPath string, f wavFormat, outputPath string, start, length float64) error {
+	var err error
+	for attempt := 1; attempt <= chunkWriteRetries; attempt++ {
+		if err = writeWAVSlice(inputPath, f, outputPath, start, length); err == nil {
+			return nil
+		}
+		if attempt < chunkWriteRetries {
+			select {
+			case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return err
+}
+
+// encodeChunk 將 WAV 分片轉為 enc 編碼並刪除原檔，返回新檔路徑；WAV 直接返回原路徑。
+func encodeChunk(ctx context.Context, wavPath string, enc ChunkEncoding) (string, error) {
+	var codec []string
+	switch enc {
+	case EncodingFLAC:
+		codec = []string{"-c:a", "flac"}
+	case EncodingOpus:
+		codec = []string{"-c:a", "libopus", "-b:a", "24k", "-application", "voip"}
+	default:
+		return wavPath, nil
+	}
+	outputPath := strings.TrimSuffix(wavPath, filepath.Ext(wavPath)) + "." + string(enc)
+	args := append([]string{"-v", "error", "-y", "-i", wavPath}, codec...)
+	cmd := command(ctx, "ffmpeg", append(args, outputPath)...)
+	err := run(ctx, cmd)
+	os.Remove(wavPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// pcmSource 切片用的 16kHz Mono 16-bit PCM 來源。已是分片格式的 WAV 直接讀取原檔；
+// 其他格式由 ffmpeg 解碼至 stdout，讀取時同步寫入暫存 WAV（path），供切片擷取已解碼的部分。
+type pcmSource struct {
+	r      io.Reader
+	path   string
+	format wavFormat
+	file   *os.File
+
+	// 以下僅 ffmpeg 解碼時使用
+	input   string
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cmd     *exec.Cmd
+	proc    *process
+	buf     *bufio.Writer
+	written int64
+}
+
+// openPCM 開啟 inputPath 的 PCM 來源；影片容器解碼第一條音軌。
+func openPCM(ctx context.Context, inputPath, dir string) (*pcmSource, error) {
+	if wav, err := readWAV(inputPath); err == nil && wav.isChunkFormat() {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		return &pcmSource{r: io.NewSectionReader(f, wav.dataOffset, wav.dataSize), path: inputPath, format: wav, file: f}, nil
+	}
+
+	f, err := os.CreateTemp(dir, "decoded-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	s := &pcmSource{
+		path:   f.Name(),
+		format: wavFormat{SampleRate: 16000, Channels: 1, BitsPerSample: 16, dataOffset: 44},
+		file:   f,
+		input:  inputPath,
+		parent: ctx,
+		buf:    bufio.NewWriterSize(f, 256<<10),
+	}
+	writeWAVHeader(s.buf, s.format, 0)
+	if err := s.decode(0); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return s, nil
+}
+
+// decoding 是否以 ffmpeg 解碼（而非直接讀取 WAV）。
+func (s *pcmSource) decoding() bool {
+	return s.buf != nil
+}
+
+// decode 自原始錄音的 offset 秒起啟動 ffmpeg，輸出接續寫入暫存 WAV；損毀的封包略過而不中止。
+func (s *pcmSource) decode(offset float64) error {
+	// 前一次解碼在半個取樣處中斷時補齊，維持取樣對齊
+	if s.written%2 != 0 {
+		s.buf.WriteByte(0)
+		s.written++
+	}
+	args := []string{"-v", "error"}
+	if offset > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(offset, 'f', 3, 64))
+	}
+	args = append(args, "-err_detect", "ignore_err", "-i", s.input, "-map", "0:a:0", "-vn",
+		"-ar", "16000", "-ac", "1", "-f", "s16le", "-")
+
+	s.ctx, s.cancel = context.WithCancel(s.parent)
+	s.cmd = command(s.ctx, "ffmpeg", args...)
+	stdout, err := s.cmd.StdoutPipe()
+	if err == nil {
+		s.proc, err = start(s.cmd)
+	}
+	if err != nil {
+		s.cancel()
+		s.cmd = nil
+		return fmt.Errorf("failed to decode audio: %v", err)
+	}
+	s.r = io.TeeReader(stdout, writerFunc(func(p []byte) (int, error) {
+		n, err := s.buf.Write(p)
+		s.written += int64(n)
+		return n, err
+	}))
+	return nil
+}
+
+// wait 讀到結尾後等待目前的 ffmpeg 結束。
+func (s *pcmSource) wait() error {
+	if s.cmd == nil {
+		return nil
+	}
+	err := s.proc.wait(s.ctx)
+	s.cancel()
+	s.cmd = nil
+	if err != nil {
+		if ctxErr := s.parent.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to decode audio: %v", err)
+	}
+	return nil
+}
+
+// sync 將已讀取的 PCM 寫入暫存檔，讓 writeWAVSlice 可以擷取。
+func (s *pcmSource) sync() error {
+	if !s.decoding() {
+		return nil
+	}
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write decoded audio: %v", err)
+	}
+	s.format.dataSize = s.written - s.written%s.format.blockAlign()
+	return nil
+}
+
+// finish 解碼完成後回填 WAV 檔頭長度（外部 VAD 需讀取完整檔頭）。
+func (s *pcmSource) finish() error {
+	if !s.decoding() {
+		return nil
+	}
+	if err := s.sync(); err != nil {
+		return err
+	}
+	var h bytes.Buffer
+	writeWAVHeader(&h, s.format, s.format.dataSize)
+	if _, err := s.file.WriteAt(h.Bytes(), 0); err != nil {
+		return fmt.Errorf("failed to write decoded audio: %v", err)
+	}
+	return nil
+}
+
+// close 中止尚未結束的 ffmpeg 並刪除暫存檔。
+func (s *pcmSource) close() {
+	if s.cmd != nil {
+		s.cancel()
+		s.proc.wait(s.ctx)
+	}
+	s.file.Close()
+	if s.decoding() {
+		os.Remove(s.path)
+	}
+}
+
+// writerFunc 將函式轉為 io.Writer。
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,36 @@
+package audio
+
+import "testing"
+
+func TestPlanCut(t *testing.T) {
+	opts := SplitOptions{MaxChunkDuration: 60}
+	tests := []struct {
+		name      string
+		start     float64
+		known     float64
+		final     bool
+		points    []float64
+		wantEnd   float64
+		wantClean bool
+		wantOK    bool
+	}{
+		{name: "streaming without tail margin", known: 64, wantOK: false},
+		{name: "streaming hard cut", known: 65, wantEnd: 60, wantOK: true},
+		{name: "silence near target", known: 90, points: []float64{30, 55}, wantEnd: 55, wantClean: true, wantOK: true},
+		{name: "silence too early", known: 90, points: []float64{45}, wantEnd: 60, wantOK: true},
+		{name: "silence past target ignored", known: 90, points: []float64{62}, wantEnd: 60, wantOK: true},
+		{name: "silence before start ignored", start: 100, known: 200, points: []float64{95}, wantEnd: 160, wantOK: true},
+		{name: "final shorter than max", known: 30, final: true, points: []float64{25}, wantEnd: 30, wantOK: true},
+		{name: "final short tail merged", known: 63, final: true, wantEnd: 63, wantOK: true},
+		{name: "final tail kept", known: 63, final: true, points: []float64{58}, wantEnd: 58, wantClean: true, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, clean, ok := planCut(tt.start, tt.known, tt.final, tt.points, opts)
+			if ok != tt.wantOK || (ok && (end != tt.wantEnd || clean != tt.wantClean)) {
+				t.Errorf("planCut(%v, %v, %v, %v) = (%v, %v, %v), want (%v, %v, %v)",
+					tt.start, tt.known, tt.final, tt.points, end, clean, ok, tt.wantEnd, tt.wantClean, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// 加速倍率的允許範圍：ffmpeg atempo 單一濾鏡支援 0.5~2.0；超過 1.5 倍後轉錄錯誤率明顯上升。
+const (
+	MinSpeedFactor = 1.0
+	MaxSpeedFactor = 2.0
+)
+
+// SpeedUp 以 ffmpeg atempo（保留音高）將音訊加速 factor 倍，輸出 16kHz mono WAV 至 dir，返回新檔路徑。
+// 按音訊分鐘計費的 STT provider 可藉此降低成本；輸出中的時間點乘以 factor 即為原始錄音的時間。呼叫端負責刪除輸出檔。
+func SpeedUp(ctx context.Context, inputPath, dir string, factor float64) (string, error) {
+	if factor <= MinSpeedFactor || factor > MaxSpeedFactor {
+		return "", fmt.Errorf("SpeedUp(%s): factor %.2f out of range (%.1f, %.1f]", inputPath, factor, MinSpeedFactor, MaxSpeedFactor)
+	}
+	out, err := os.CreateTemp(dir, "atempo-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("SpeedUp(%s): %w", inputPath, err)
+	}
+	out.Close()
+
+	cmd := command(ctx, "ffmpeg", "-y", "-i", inputPath, "-vn", "-af", "atempo="+strconv.FormatFloat(factor, 'f', 3, 64),
+		"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", out.Name())
+	if err := run(ctx, cmd); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("SpeedUp(%s): %w", inputPath, err)
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Usage 累計 ffmpeg/ffprobe 子行程的 CPU 時間與峰值 RSS。
+// 透過 WithUsage 掛在 context 上，SplitAudio 內所有外部指令都會回報至此。
+type Usage struct {
+	mu         sync.Mutex
+	CPUTime    time.Duration
+	PeakRSS    int64 // bytes，所有子行程中最大的 maxrss
+	Invocation int
+}
+
+type usageKey struct{}
+
+// WithUsage 返回帶有 Usage 收集器的 context。
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordUsage 將已結束的指令資源使用量寫入 ctx 上的 Usage（若有）。
+func recordUsage(ctx context.Context, cmd *exec.Cmd) {
+	u, ok := ctx.Value(usageKey{}).(*Usage)
+	if !ok || cmd.ProcessState == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Invocation++
+	u.CPUTime += cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+	if rss := maxRSS(cmd.ProcessState); rss > u.PeakRSS {
+		u.PeakRSS = rss
+	}
+}
+
+// run 執行指令（受 commandTimeout 限制）並記錄資源使用量。
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	p, err := start(cmd)
+	if err != nil {
+		return err
+	}
+	return p.wait(ctx)
+}
+
+// output 執行指令、返回 stdout 並記錄資源使用量。
+func output(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := run(ctx, cmd)
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,81 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// VAD 偵測語音停頓，返回適合切割的時間點（秒，遞增）。
+type VAD interface {
+	PausePoints(ctx context.Context, inputPath string) ([]float64, error)
+}
+
+// SilenceDetectVAD 以 ffmpeg silencedetect 偵測音量低於門檻的靜音段（預設實作）。
+// 對低音量的語音停頓不敏感，且受背景噪音影響。
+type SilenceDetectVAD struct{}
+
+// PausePoints 返回每段靜音的中點。
+func (SilenceDetectVAD) PausePoints(ctx context.Context, inputPath string) ([]float64, error) {
+	return getSilencePoints(ctx, inputPath)
+}
+
+// CommandVAD 透過外部程式執行 ML VAD（例如 vad/silero_vad.py 以 ONNX runtime 執行 Silero），
+// 避免 Worker 依賴 cgo。程式以 16kHz mono WAV 路徑為最後一個參數，於 stdout 輸出語音區段 JSON：
+//
+//	[{"start": 0.52, "end": 3.10}, ...]
+//
+// 語音區段之間長度達 MinSilence 的空隙即為停頓，取其中點作為切割點。
+type CommandVAD struct {
+	Command    []string
+	MinSilence float64
+}
+
+// speechSegment 外部 VAD 輸出的語音區段（秒）。
+type speechSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// PausePoints 將音檔轉為 16kHz mono WAV 後交給外部 VAD，返回語音停頓的中點。
+func (v CommandVAD) PausePoints(ctx context.Context, inputPath string) ([]float64, error) {
+	if len(v.Command) == 0 {
+		return nil, fmt.Errorf("CommandVAD.PausePoints: no command configured")
+	}
+	wav, err := os.CreateTemp(filepath.Dir(inputPath), "vad-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("CommandVAD.PausePoints(%s): %w", inputPath, err)
+	}
+	wav.Close()
+	defer os.Remove(wav.Name())
+
+	convert := command(ctx, "ffmpeg", "-y", "-i", inputPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav.Name())
+	if err := run(ctx, convert); err != nil {
+		return nil, fmt.Errorf("CommandVAD.PausePoints(%s): convert: %w", inputPath, err)
+	}
+
+	args := append(append([]string{}, v.Command[1:]...), wav.Name())
+	out, err := output(ctx, command(ctx, v.Command[0], args...))
+	if err != nil {
+		return nil, fmt.Errorf("CommandVAD.PausePoints(%s): %w", inputPath, err)
+	}
+	var segments []speechSegment
+	if err := json.Unmarshal(out, &segments); err != nil {
+		return nil, fmt.Errorf("CommandVAD.PausePoints(%s): invalid output: %w", inputPath, err)
+	}
+	return pausesBetween(segments, v.MinSilence), nil
+}
+
+// pausesBetween 返回相鄰語音區段之間、長度達 minSilence 的空隙中點。
+func pausesBetween(segments []speechSegment, minSilence float64) []float64 {
+	var pauses []float64
+	for i := 1; i < len(segments); i++ {
+		gapStart, gapEnd := segments[i-1].End, segments[i].Start
+		if gapEnd-gapStart >= minSilence {
+			pauses = append(pauses, (gapStart+gapEnd)/2)
+		}
+	}
+	return pauses
+}
@@ -0,0 +1,217 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// 上傳音檔驗證失敗的錯誤代碼，寫入 tasks.error_code 並隨 failed 事件 / webhook 返回，供前端顯示對應說明。
+const (
+	ErrCodeUnsupportedFormat = "ERR_UNSUPPORTED_FORMAT" // 無法辨識的容器或編碼
+	ErrCodeCorruptAudio      = "ERR_CORRUPT_AUDIO"      // 檔案損毀或截斷，無法讀取
+	ErrCodeNoAudioStream     = "ERR_NO_AUDIO_STREAM"    // 影片不含音軌
+	ErrCodeEmptyAudio        = "ERR_EMPTY_AUDIO"        // 長度為 0
+	ErrCodeInvalidBitrate    = "ERR_INVALID_BITRATE"    // 位元率與檔案大小 / 長度不符
+)
+
+// 合理位元率範圍（bps）；低於下限通常代表標頭宣告的長度與實際資料不符。
+const (
+	minSaneBitrate = 1_000
+	maxSaneBitrate = 100_000_000
+)
+
+// ValidationError 音檔未通過上傳驗證；Code 為 ErrCode* 常數，Message 為使用者可讀的說明。
+type ValidationError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *ValidationError) Error() string { return e.Code + ": " + e.Message }
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// ErrorCode 返回 err 中 ValidationError 的代碼，非驗證錯誤返回空字串。
+func ErrorCode(err error) string {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ve.Code
+	}
+	return ""
+}
+
+// probeResult ffprobe 的 format 與 stream 資訊。
+type probeResult struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+	} `json:"streams"`
+}
+
+// Metadata 驗證時讀到的錄音資訊（第一條音軌）；無法取得的欄位為零值。
+type Metadata struct {
+	Format      string
+	Codec       string
+	DurationSec float64
+	SampleRate  int
+	Channels    int
+	SizeBytes   int64
+}
+
+// Validate 在切片前檢查音檔：可辨識的音訊編碼、長度大於 0、位元率合理，以及 policy 的格式 / 編碼 / 大小 / 長度限制，
+// 並返回讀到的錄音資訊（驗證失敗時為失敗前已取得的部分）。
+// PCM WAV 直接讀檔頭檢查；其他格式以 ffprobe 檢查。未通過時返回 *ValidationError，
+// ffprobe 本身無法執行（未安裝等）時返回一般錯誤，由呼叫端決定是否略過驗證。
+func Validate(ctx context.Context, inputPath string, policy InputPolicy) (Metadata, error) {
+	var meta Metadata
+	st, err := os.Stat(inputPath)
+	if err != nil {
+		return meta, fmt.Errorf("Validate(%s): %w", inputPath, err)
+	}
+	meta.SizeBytes = st.Size()
+	if st.Size() == 0 {
+		return meta, &ValidationError{Code: ErrCodeEmptyAudio, Message: "The uploaded file is empty."}
+	}
+	if err := policy.checkSize(st.Size()); err != nil {
+		return meta, err
+	}
+	if wav, err := readWAV(inputPath); err == nil {
+		codec := fmt.Sprintf("pcm_s%dle", wav.BitsPerSample)
+		if wav.BitsPerSample == 8 {
+			codec = "pcm_u8"
+		}
+		meta.Format, meta.Codec, meta.DurationSec = "wav", codec, wav.duration()
+		meta.SampleRate, meta.Channels = wav.SampleRate, wav.Channels
+		if wav.duration() <= 0 {
+			return meta, &ValidationError{Code: ErrCodeEmptyAudio, Message: "The WAV file contains no audio samples."}
+		}
+		if !policy.allowsFormat("wav") || !policy.allowsCodec(codec) {
+			return meta, unsupported("wav", codec)
+		}
+		return meta, policy.checkDuration(wav.duration())
+	}
+
+	cmd := command(ctx, "ffprobe", "-v", "error",
+		"-show_entries", "format=format_name,duration,bit_rate:stream=codec_type,codec_name,sample_rate,channels", "-of", "json", inputPath)
+	out, err := output(ctx, cmd)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return meta, &ValidationError{Code: ErrCodeCorruptAudio, Message: "The file could not be read as audio or video; it may be corrupt or truncated.", Err: err}
+		}
+		return meta, fmt.Errorf("Validate(%s): %w", inputPath, err)
+	}
+	var probe probeResult
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return meta, fmt.Errorf("Validate(%s): %w", inputPath, err)
+	}
+	meta.Format = probe.Format.FormatName
+
+	hasAudio, hasVideo := false, false
+	for _, s := range probe.Streams {
+		switch s.CodecType {
+		case "audio":
+			if s.CodecName == "" || s.CodecName == "none" {
+				return meta, &ValidationError{Code: ErrCodeUnsupportedFormat, Message: "The audio codec of this file is not supported."}
+			}
+			if !hasAudio {
+				meta.Codec, meta.Channels = s.CodecName, s.Channels
+				meta.SampleRate, _ = strconv.Atoi(s.SampleRate)
+				if !policy.allowsCodec(s.CodecName) {
+					return meta, unsupported(probe.Format.FormatName, s.CodecName)
+				}
+			}
+			hasAudio = true
+		case "video":
+			hasVideo = true
+		}
+	}
+	if !hasAudio {
+		if hasVideo {
+			return meta, &ValidationError{Code: ErrCodeNoAudioStream, Message: "The video has no audio track.", Err: ErrNoAudioStream}
+		}
+		return meta, &ValidationError{Code: ErrCodeUnsupportedFormat, Message: fmt.Sprintf("Unsupported file format (%s).", probe.Format.FormatName)}
+	}
+	if !policy.allowsFormat(probe.Format.FormatName) {
+		return meta, unsupported(probe.Format.FormatName, "")
+	}
+
+	duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		// 部分串流錄音（例如瀏覽器 MediaRecorder 的 webm）不帶長度：有長度上限時實際解碼計算，否則略過
+		if policy.MaxDuration <= 0 {
+			return meta, nil
+		}
+		measured, err := measureDuration(ctx, inputPath, policy.MaxDuration.Seconds())
+		if err != nil {
+			return meta, fmt.Errorf("Validate(%s): measure duration: %w", inputPath, err)
+		}
+		meta.DurationSec = measured
+		if measured <= 0 {
+			return meta, &ValidationError{Code: ErrCodeEmptyAudio, Message: "The recording has zero duration."}
+		}
+		return meta, policy.checkDuration(measured)
+	}
+	meta.DurationSec = duration
+	if duration <= 0 {
+		return meta, &ValidationError{Code: ErrCodeEmptyAudio, Message: "The recording has zero duration."}
+	}
+	if err := policy.checkDuration(duration); err != nil {
+		return meta, err
+	}
+	bitrate := float64(st.Size()*8) / duration
+	if declared, err := strconv.ParseFloat(probe.Format.BitRate, 64); err == nil && declared > 0 {
+		bitrate = declared
+	}
+	if bitrate < minSaneBitrate || bitrate > maxSaneBitrate {
+		return meta, &ValidationError{
+			Code:    ErrCodeInvalidBitrate,
+			Message: fmt.Sprintf("The file reports an implausible bitrate (%.0f bps); its header may be corrupt.", bitrate),
+		}
+	}
+	return meta, nil
+}
+
+// measureBytesPerSecond measureDuration 輸出的 8kHz mono 16-bit PCM 位元率。
+const measureBytesPerSecond = 16000
+
+// measureDuration 以 ffmpeg 實際解碼計算長度（8kHz mono PCM 輸出至 stdout，只計位元組數不落地）。
+// 最多解碼 limit+1 秒，超過上限的錄音不會被整檔解碼。
+func measureDuration(ctx context.Context, inputPath string, limit float64) (float64, error) {
+	var n byteCounter
+	cmd := command(ctx, "ffmpeg", "-v", "error", "-i", inputPath,
+		"-t", strconv.FormatFloat(limit+1, 'f', 3, 64), "-vn", "-ac", "1", "-ar", "8000", "-f", "s16le", "-")
+	cmd.Stdout = &n
+	if err := run(ctx, cmd); err != nil {
+		return 0, err
+	}
+	return float64(n) / measureBytesPerSecond, nil
+}
+
+// byteCounter 只計算寫入位元組數的 io.Writer。
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// unsupported 格式或編碼不在 InputPolicy 允許清單內。
+func unsupported(format, codec string) error {
+	what := format
+	if codec != "" {
+		what += " / " + codec
+	}
+	return &ValidationError{Code: ErrCodeUnsupportedFormat, Message: fmt.Sprintf("This deployment does not accept %s files.", what)}
+}
@@ -0,0 +1,35 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNoAudioStream 上傳的影片不含任何音軌。
+var ErrNoAudioStream = errors.New("file has no audio stream")
+
+// streamInfo ffprobe -show_streams 的單一 stream。
+type streamInfo struct {
+	CodecType   string `json:"codec_type"`
+	Channels    int    `json:"channels"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+// probeStreams 以 ffprobe 讀取容器內所有 stream。
+func probeStreams(ctx context.Context, inputPath string) ([]streamInfo, error) {
+	cmd := command(ctx, "ffprobe", "-v", "error", "-show_entries", "stream=codec_type,channels:stream_disposition=attached_pic", "-of", "json", inputPath)
+	out, err := output(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Streams []streamInfo `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, err
+	}
+	return probe.Streams, nil
+}
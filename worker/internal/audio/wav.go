@@ -0,0 +1,249 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// errNotPCMWAV 檔案不是可直接讀取的 PCM WAV，需交由 ffmpeg 處理。
+var errNotPCMWAV = errors.New("not a PCM WAV file")
+
+// wavFormat PCM WAV 的格式與 data chunk 位置。
+type wavFormat struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	dataOffset    int64
+	dataSize      int64
+}
+
+// blockAlign 單一取樣（所有聲道）的位元組數。
+func (f wavFormat) blockAlign() int64 {
+	return int64(f.Channels * f.BitsPerSample / 8)
+}
+
+// duration 音訊長度（秒）。
+func (f wavFormat) duration() float64 {
+	return float64(f.dataSize/f.blockAlign()) / float64(f.SampleRate)
+}
+
+// isChunkFormat 是否已是分片格式（16kHz mono 16-bit），可直接擷取 PCM 而不需重新取樣。
+func (f wavFormat) isChunkFormat() bool {
+	return f.SampleRate == 16000 && f.Channels == 1 && f.BitsPerSample == 16
+}
+
+// readWAV 解析 RIFF/WAVE 檔頭，僅接受整數 PCM（含 WAVE_FORMAT_EXTENSIBLE 的 PCM subformat）。
+// data chunk 長度為 0 或超出檔案（串流錄音未回填長度）時以檔案實際大小為準。
+func readWAV(path string) (wavFormat, error) {
+	var f wavFormat
+	file, err := os.Open(path)
+	if err != nil {
+		return f, err
+	}
+	defer file.Close()
+	st, err := file.Stat()
+	if err != nil {
+		return f, err
+	}
+
+	var riff [12]byte
+	if _, err := io.ReadFull(file, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return f, errNotPCMWAV
+	}
+
+	offset := int64(12)
+	hasFmt := false
+	for {
+		var hdr [8]byte
+		if _, err := file.ReadAt(hdr[:], offset); err != nil {
+			return f, errNotPCMWAV
+		}
+		id, size := string(hdr[0:4]), int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return f, errNotPCMWAV
+			}
+			buf := make([]byte, min(size, 40))
+			if _, err := file.ReadAt(buf, offset); err != nil {
+				return f, errNotPCMWAV
+			}
+			format := binary.LittleEndian.Uint16(buf[0:2])
+			if format == 0xFFFE && len(buf) >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE：subformat GUID 的前兩個位元組為實際格式
+				format = binary.LittleEndian.Uint16(buf[24:26])
+			}
+			if format != 1 {
+				return f, errNotPCMWAV
+			}
+			f.Channels = int(binary.LittleEndian.Uint16(buf[2:4]))
+			f.SampleRate = int(binary.LittleEndian.Uint32(buf[4:8]))
+			f.BitsPerSample = int(binary.LittleEndian.Uint16(buf[14:16]))
+			if f.Channels == 0 || f.SampleRate == 0 || f.BitsPerSample == 0 || f.BitsPerSample%8 != 0 {
+				return f, errNotPCMWAV
+			}
+			hasFmt = true
+		case "data":
+			if !hasFmt {
+				return f, errNotPCMWAV
+			}
+			f.dataOffset = offset
+			f.dataSize = size
+			if remaining := st.Size() - offset; size == 0 || size > remaining {
+				f.dataSize = remaining
+			}
+			f.dataSize -= f.dataSize % f.blockAlign()
+			return f, nil
+		}
+		// chunk 長度為奇數時補齊一個位元組
+		offset += size + size%2
+	}
+}
+
+// writeWAVSlice 將 [start, start+length) 秒的 PCM 原樣寫入新的 WAV 檔，不經 ffmpeg。
+func writeWAVSlice(inputPath string, f wavFormat, outputPath string, start, length float64) error {
+	align := f.blockAlign()
+	from := min(int64(start*float64(f.SampleRate))*align, f.dataSize)
+	n := min(int64(math.Round(length*float64(f.SampleRate)))*align, f.dataSize-from)
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("writeWAVSlice(%s): %w", inputPath, err)
+	}
+	defer in.Close()
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("writeWAVSlice(%s): %w", inputPath, err)
+	}
+
+	w := bufio.NewWriter(out)
+	writeWAVHeader(w, f, n)
+	_, err = io.Copy(w, io.NewSectionReader(in, f.dataOffset+from, n))
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("writeWAVSlice(%s): %w", inputPath, err)
+	}
+	return nil
+}
+
+// wavHeaderSize writeWAVHeader 寫入的檔頭長度。
+const wavHeaderSize = 44
+
+// writeWAVHeader 寫入 44 bytes 的標準 PCM WAV 檔頭。
+func writeWAVHeader(w io.Writer, f wavFormat, dataSize int64) {
+	le := binary.LittleEndian
+	var h [wavHeaderSize]byte
+	copy(h[0:4], "RIFF")
+	le.PutUint32(h[4:8], uint32(36+dataSize))
+	copy(h[8:16], "WAVEfmt ")
+	le.PutUint32(h[16:20], 16)
+	le.PutUint16(h[20:22], 1)
+	le.PutUint16(h[22:24], uint16(f.Channels))
+	le.PutUint32(h[24:28], uint32(f.SampleRate))
+	le.PutUint32(h[28:32], uint32(int64(f.SampleRate)*f.blockAlign()))
+	le.PutUint16(h[32:34], uint16(f.blockAlign()))
+	le.PutUint16(h[34:36], uint16(f.BitsPerSample))
+	copy(h[36:40], "data")
+	le.PutUint32(h[40:44], uint32(dataSize))
+	w.Write(h[:])
+}
+
+// 與 silencedetect=noise=-30dB:d=0.5 相同的門檻。
+const (
+	pcmSilenceThreshold = 0.0316 // -30dBFS
+	pcmSilenceMinSec    = 0.5
+)
+
+// midpoints 返回各時間區段的中點。
+func midpoints(ranges []speechSegment) []float64 {
+	points := make([]float64, len(ranges))
+	for i, r := range ranges {
+		points[i] = (r.Start + r.End) / 2
+	}
+	return points
+}
+
+// pcmSilentRanges 返回 16-bit PCM WAV 中長度達 pcmSilenceMinSec 的靜音段（秒）。
+func pcmSilentRanges(inputPath string, f wavFormat) ([]speechSegment, error) {
+	if f.BitsPerSample != 16 {
+		return nil, errNotPCMWAV
+	}
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("pcmSilentRanges(%s): %w", inputPath, err)
+	}
+	defer in.Close()
+
+	d := newSilenceDetector(f)
+	frame := make([]byte, d.frameSize())
+	r := bufio.NewReader(io.NewSectionReader(in, f.dataOffset, f.dataSize))
+	for {
+		n, err := io.ReadFull(r, frame)
+		if n > 0 {
+			d.add(frame[:n])
+		}
+		if err != nil {
+			break
+		}
+	}
+	return d.silences(), nil
+}
+
+// silenceDetector 以 10ms 窗口的峰值逐段偵測 16-bit PCM 的靜音，可在解碼途中持續餵入資料。
+type silenceDetector struct {
+	f          wavFormat
+	limit      int
+	silentFrom float64 // 進行中靜音段的起點，-1 表示目前不是靜音
+	pos        float64 // 已處理的長度（秒）
+	ranges     []speechSegment
+}
+
+func newSilenceDetector(f wavFormat) *silenceDetector {
+	return &silenceDetector{f: f, limit: int(math.Round(pcmSilenceThreshold * math.MaxInt16)), silentFrom: -1}
+}
+
+// frameSize 10ms 窗口的位元組數。
+func (d *silenceDetector) frameSize() int {
+	return max(1, d.f.SampleRate/100) * int(d.f.blockAlign())
+}
+
+// add 處理一個窗口（最後一個可能不足 10ms）。
+func (d *silenceDetector) add(frame []byte) {
+	peak := 0
+	for i := 0; i+1 < len(frame); i += 2 {
+		s := int(int16(binary.LittleEndian.Uint16(frame[i:])))
+		peak = max(peak, s, -s)
+	}
+	if peak < d.limit {
+		if d.silentFrom < 0 {
+			d.silentFrom = d.pos
+		}
+	} else if d.silentFrom >= 0 {
+		if d.pos-d.silentFrom >= pcmSilenceMinSec {
+			d.ranges = append(d.ranges, speechSegment{Start: d.silentFrom, End: d.pos})
+		}
+		d.silentFrom = -1
+	}
+	d.pos += float64(int64(len(frame))/d.f.blockAlign()) / float64(d.f.SampleRate)
+}
+
+// silences 已結束的靜音段，加上進行中且已達最短長度的靜音段（終點暫以目前位置計）。
+func (d *silenceDetector) silences() []speechSegment {
+	if d.silentFrom >= 0 && d.pos-d.silentFrom >= pcmSilenceMinSec {
+		return append(d.ranges[:len(d.ranges):len(d.ranges)], speechSegment{Start: d.silentFrom, End: d.pos})
+	}
+	return d.ranges
+}
@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// wavChunk 組出單一 RIFF chunk；長度為奇數時補齊一個位元組。
+func wavChunk(id string, body []byte, size uint32) []byte {
+	var b bytes.Buffer
+	b.WriteString(id)
+	binary.Write(&b, binary.LittleEndian, size)
+	b.Write(body)
+	if len(body)%2 == 1 {
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+// fmtBody PCM fmt chunk 內容；extensible 時以 WAVE_FORMAT_EXTENSIBLE 包裝 format。
+func fmtBody(format uint16, channels, rate, bits int, extensible bool) []byte {
+	le := binary.LittleEndian
+	tag := format
+	if extensible {
+		tag = 0xFFFE
+	}
+	b := make([]byte, 16)
+	le.PutUint16(b[0:2], tag)
+	le.PutUint16(b[2:4], uint16(channels))
+	le.PutUint32(b[4:8], uint32(rate))
+	le.PutUint32(b[8:12], uint32(rate*channels*bits/8))
+	le.PutUint16(b[12:14], uint16(channels*bits/8))
+	le.PutUint16(b[14:16], uint16(bits))
+	if extensible {
+		ext := make([]byte, 24)
+		le.PutUint16(ext[0:2], 22)
+		le.PutUint16(ext[8:10], format)
+		b = append(b, ext...)
+	}
+	return b
+}
+
+func riff(chunks ...[]byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WAVE")
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	return b
+}
+
+func TestReadWAV(t *testing.T) {
+	pcm := make([]byte, 3200)
+	mono16k := fmtBody(1, 1, 16000, 16, false)
+	tests := []struct {
+		name    string
+		data    []byte
+		want    wavFormat
+		wantErr error
+	}{
+		{
+			name: "plain PCM",
+			data: riff(wavChunk("fmt ", mono16k, 16), wavChunk("data", pcm, 3200)),
+			want: wavFormat{SampleRate: 16000, Channels: 1, BitsPerSample: 16, dataOffset: 44, dataSize: 3200},
+		},
+		{
+			name: "extensible PCM",
+			data: riff(wavChunk("fmt ", fmtBody(1, 2, 44100, 16, true), 40), wavChunk("data", pcm, 3200)),
+			want: wavFormat{SampleRate: 44100, Channels: 2, BitsPerSample: 16, dataOffset: 68, dataSize: 3200},
+		},
+		{
+			name: "odd-sized chunk before data",
+			data: riff(wavChunk("fmt ", mono16k, 16), wavChunk("LIST", []byte("abc"), 3), wavChunk("data", pcm, 3200)),
+			want: wavFormat{SampleRate: 16000, Channels: 1, BitsPerSample: 16, dataOffset: 56, dataSize: 3200},
+		},
+		{
+			name: "streaming data size zero",
+			data: riff(wavChunk("fmt ", mono16k, 16), wavChunk("data", pcm, 0)),
+			want: wavFormat{SampleRate: 16000, Channels: 1, BitsPerSample: 16, dataOffset: 44, dataSize: 3200},
+		},
+		{
+			name: "data size past end of file",
+			data: riff(wavChunk("fmt ", fmtBody(1, 2, 16000, 16, false), 16), wavChunk("data", pcm[:1001], 0xFFFFFFFF)),
+			want: wavFormat{SampleRate: 16000, Channels: 2, BitsPerSample: 16, dataOffset: 44, dataSize: 1000},
+		},
+		{
+			name:    "float samples",
+			data:    riff(wavChunk("fmt ", fmtBody(3, 1, 16000, 32, false), 16), wavChunk("data", pcm, 3200)),
+			wantErr: errNotPCMWAV,
+		},
+		{
+			name:    "extensible float",
+			data:    riff(wavChunk("fmt ", fmtBody(3, 1, 16000, 32, true), 40), wavChunk("data", pcm, 3200)),
+			wantErr: errNotPCMWAV,
+		},
+		{
+			name:    "data before fmt",
+			data:    riff(wavChunk("data", pcm, 3200), wavChunk("fmt ", mono16k, 16)),
+			wantErr: errNotPCMWAV,
+		},
+		{
+			name:    "missing data chunk",
+			data:    riff(wavChunk("fmt ", mono16k, 16)),
+			wantErr: errNotPCMWAV,
+		},
+		{
+			name:    "not RIFF",
+			data:    append([]byte("OggS"), pcm[:40]...),
+			wantErr: errNotPCMWAV,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "in.wav")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readWAV(path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("readWAV() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readWAV() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readWAV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+)
+
+const (
+	// waveformResolution 波形的基本解析度（秒）。
+	waveformResolution = 0.1
+	// maxWaveformPeaks 輸出的點數上限，長錄音合併相鄰區間（取最大值）至此數量以內。
+	maxWaveformPeaks = 2000
+)
+
+// Waveform 降取樣的振幅包絡，前端不需下載音檔即可繪製波形並對齊逐字稿時間。
+type Waveform struct {
+	// SecondsPerPeak 每個點涵蓋的錄音秒數。
+	SecondsPerPeak float64 `json:"secondsPerPeak"`
+	// Peaks 各區間的峰值振幅（0~100，相對於 16-bit 滿刻度）。
+	Peaks []int `json:"peaks"`
+}
+
+// Pad 在前後補上 leadSec / trailSec 秒的靜音點，讓裁掉頭尾靜音後計算的波形與原始錄音的時間對齊。
+func (wf *Waveform) Pad(leadSec, trailSec float64) {
+	if wf.SecondsPerPeak <= 0 || len(wf.Peaks) == 0 {
+		return
+	}
+	lead := int(math.Round(leadSec / wf.SecondsPerPeak))
+	trail := int(math.Round(trailSec / wf.SecondsPerPeak))
+	peaks := make([]int, lead, lead+len(wf.Peaks)+trail)
+	peaks = append(peaks, wf.Peaks...)
+	wf.Peaks = append(peaks, make([]int, trail)...)
+}
+
+type waveformKey struct{}
+
+// WithWaveform 返回帶有波形收集器的 context，SplitAudioStream 切片時順帶計算波形並寫入 wf。
+func WithWaveform(ctx context.Context, wf *Waveform) context.Context {
+	return context.WithValue(ctx, waveformKey{}, wf)
+}
+
+// peakBuilder 以 waveformResolution 的窗口累積 16-bit mono PCM 的峰值。
+type peakBuilder struct {
+	samplesPerPeak int
+	count          int // 目前窗口已累積的取樣數
+	peak           int
+	peaks          []int
+}
+
+func newPeakBuilder(f wavFormat) *peakBuilder {
+	return &peakBuilder{samplesPerPeak: max(1, int(float64(f.SampleRate)*waveformResolution))}
+}
+
+// add 累積一段 PCM（長度不需與窗口對齊）。
+func (b *peakBuilder) add(pcm []byte) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s := int(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		b.peak = max(b.peak, s, -s)
+		if b.count++; b.count == b.samplesPerPeak {
+			b.flush()
+		}
+	}
+}
+
+// skip 無法解碼而略過的區段以靜音補齊，維持時間對齊。
+func (b *peakBuilder) skip(seconds float64) {
+	if b.count > 0 {
+		b.flush()
+	}
+	for n := int(math.Round(seconds / waveformResolution)); n > 0; n-- {
+		b.peaks = append(b.peaks, 0)
+	}
+}
+
+func (b *peakBuilder) flush() {
+	b.peaks = append(b.peaks, b.peak)
+	b.peak, b.count = 0, 0
+}
+
+// waveform 輸出不超過 maxWaveformPeaks 個點的波形。
+func (b *peakBuilder) waveform() Waveform {
+	if b.count > 0 {
+		b.flush()
+	}
+	group := (len(b.peaks) + maxWaveformPeaks - 1) / maxWaveformPeaks
+	group = max(group, 1)
+	wf := Waveform{SecondsPerPeak: waveformResolution * float64(group), Peaks: make([]int, 0, len(b.peaks)/group+1)}
+	for i := 0; i < len(b.peaks); i += group {
+		peak := 0
+		for _, p := range b.peaks[i:min(i+group, len(b.peaks))] {
+			peak = max(peak, p)
+		}
+		wf.Peaks = append(wf.Peaks, min(100, int(math.Round(float64(peak)*100/math.MaxInt16))))
+	}
+	return wf
+}
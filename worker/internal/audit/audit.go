@@ -0,0 +1,258 @@
+// Package audit 將安全相關事件（ownership 驗證失敗、admin 操作、資料刪除等）送往外部 SIEM。
+// Sink 以 AUDIT_SINK URL 設定：syslog+udp:// / syslog+tcp://（RFC 5424，訊息為 JSON）或 http(s)://（JSON 陣列批次 POST）。
+// 事件經緩衝佇列非同步送出，sink 故障或佇列已滿時丟棄並記錄 log，不影響請求處理。
+// 事件格式需與 Gateway（stt-gateway/internal/audit）一致。
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Outcome 事件結果。
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+// Event 單一稽核事件。
+type Event struct {
+	Time     time.Time         `json:"time"`
+	Source   string            `json:"source"`
+	Action   string            `json:"action"`
+	Outcome  string            `json:"outcome"`
+	Actor    string            `json:"actor,omitempty"`
+	Target   string            `json:"target,omitempty"`
+	RemoteIP string            `json:"remoteIp,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// sink 將一批事件送往外部系統。
+type sink interface {
+	send(ctx context.Context, events []Event) error
+}
+
+const (
+	queueSize  = 1024
+	batchSize  = 100
+	flushEvery = 2 * time.Second
+)
+
+var (
+	source  string
+	queue   chan Event
+	closing chan chan struct{}
+	dropped atomic.Int64
+)
+
+// Configure 依 sinkURL 啟動背景送出；sinkURL 為空時停用（Record 不做任何事）。token 非空時 HTTP sink 帶 Bearer 驗證。
+func Configure(src, sinkURL, token string) error {
+	if sinkURL == "" {
+		return nil
+	}
+	s, err := newSink(sinkURL, token)
+	if err != nil {
+		return fmt.Errorf("audit.Configure: %w", err)
+	}
+	source = src
+	queue = make(chan Event, queueSize)
+	closing = make(chan chan struct{})
+	go run(s)
+	log.Printf("Audit: exporting events to %s", redact(sinkURL))
+	return nil
+}
+
+// Record 送出事件（非阻塞）。未設定 sink 時直接返回。
+func Record(e Event) {
+	if queue == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.Source = source
+	select {
+	case queue <- e:
+	default:
+		if dropped.Add(1)%100 == 1 {
+			log.Printf("Audit: queue full, dropped %d event(s) so far", dropped.Load())
+		}
+	}
+}
+
+// Close 送出佇列中剩餘的事件後停止，最多等待 timeout；供 repair 等一次性子指令結束前呼叫。
+func Close(timeout time.Duration) {
+	if queue == nil {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case closing <- done:
+	case <-time.After(timeout):
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// RemoteIP 取得請求來源 IP（優先 X-Forwarded-For 第一段）。
+func RemoteIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		return strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func run(s sink) {
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+	batch := make([]Event, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := s.send(ctx, batch); err != nil {
+			log.Printf("Audit: failed to export %d event(s): %v", len(batch), err)
+		}
+		cancel()
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e := <-queue:
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-closing:
+			for len(queue) > 0 {
+				batch = append(batch, <-queue)
+			}
+			flush()
+			close(done)
+			return
+		}
+	}
+}
+
+func newSink(raw, token string) (sink, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "syslog+udp", "syslog+tcp":
+		return &syslogSink{network: strings.TrimPrefix(u.Scheme, "syslog+"), addr: u.Host}, nil
+	case "http", "https":
+		return &httpSink{url: raw, token: token, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("unsupported sink scheme %q", u.Scheme)
+}
+
+// redact 移除 URL 中的帳密，避免寫入 log。
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid>"
+	}
+	u.User = nil
+	return u.String()
+}
+
+// httpSink 以 JSON 陣列 POST 至 collector。
+type httpSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s *httpSink) send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// syslogSink 以 RFC 5424 格式送出，facility 為 authpriv（10）、severity 為 notice（5）。
+type syslogSink struct {
+	network string
+	addr    string
+	conn    net.Conn
+}
+
+func (s *syslogSink) send(ctx context.Context, events []Event) error {
+	host, _ := os.Hostname()
+	for _, e := range events {
+		msg, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		line := fmt.Sprintf("<%d>1 %s %s %s - %s - %s", 10*8+5, e.Time.Format(time.RFC3339Nano), host, "stt-"+e.Source, e.Action, msg)
+		if s.network == "tcp" {
+			// RFC 6587 octet counting
+			line = fmt.Sprintf("%d %s", len(line), line)
+		}
+		if err := s.write(ctx, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write 寫入一行，連線中斷時重連一次。
+func (s *syslogSink) write(ctx context.Context, line string) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, s.network, s.addr)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		if _, err := s.conn.Write([]byte(line)); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("write to %s://%s failed", s.network, s.addr)
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// String 取得字串環境變數，未設定時返回 fallback。
+func String(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Int 取得整數環境變數，未設定或格式錯誤時返回 fallback。
+func Int(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("config: invalid int for %s=%q, using default %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// Float 取得浮點數環境變數，未設定或格式錯誤時返回 fallback。
+func Float(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("config: invalid float for %s=%q, using default %g", key, v, fallback)
+		return fallback
+	}
+	return f
+}
+
+// Bool 取得布林環境變數（true/1/yes），未設定時返回 fallback。
+func Bool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("config: invalid bool for %s=%q, using default %t", key, v, fallback)
+		return fallback
+	}
+	return b
+}
+
+// Duration 取得 time.Duration 環境變數（例如 "30s"、"5m"），未設定或格式錯誤時返回 fallback。
+func Duration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("config: invalid duration for %s=%q, using default %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
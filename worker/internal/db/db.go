@@ -2,8 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"tts-worker/internal/models"
 
 	_ "github.com/lib/pq"
 )
@@ -23,6 +29,7 @@ func Connect() (*sql.DB, error) {
 
 // SaveTranscript 以 Transaction 原子寫入轉錄結果並將 tasks.status 更新為 stt_completed。
 // Worker 在 STT 階段完成後呼叫，中間態（stt_processing）僅存在 Redis Hash 中。
+// 任務已取消時返回 ErrStateConflict、不存在時返回 ErrTaskNotFound，且不寫入任何資料。
 func SaveTranscript(db *sql.DB, taskID, transcript string) error {
 	tx, err := db.Begin()
 	if err != nil {
@@ -30,61 +37,481 @@ func SaveTranscript(db *sql.DB, taskID, transcript string) error {
 	}
 	defer tx.Rollback()
 
+	if err := transition(tx, taskID, "stt_completed", sql.NullString{}); err != nil {
+		return fmt.Errorf("SaveTranscript: update status: %w", err)
+	}
+
 	_, err = tx.Exec(`
 		INSERT INTO task_results (task_id, transcript, updated_at)
 		VALUES ($1, $2, NOW())
-		ON CONFLICT (task_id) DO UPDATE SET transcript = $2, updated_at = NOW()`,
+		ON CONFLICT (task_id) DO UPDATE SET transcript = $2, partial = FALSE, updated_at = NOW()`,
 		taskID, transcript)
 	if err != nil {
 		return fmt.Errorf("SaveTranscript: upsert transcript: %w", err)
 	}
 
-	_, err = tx.Exec(
-		`UPDATE tasks SET status = 'stt_completed', updated_at = NOW() WHERE id = $1`,
-		taskID)
-	if err != nil {
-		return fmt.Errorf("SaveTranscript: update status: %w", err)
-	}
-
 	return tx.Commit()
 }
 
 // SaveSummary 以 Transaction 原子寫入摘要結果並將 tasks.status 更新為 completed。
-// Worker 在 Summary 階段完成後呼叫。
+// Worker 在 Summary 階段完成後呼叫；錯誤語意同 SaveTranscript。
 func SaveSummary(db *sql.DB, taskID, summary string) error {
+	if err := saveSummary(db, taskID, summary, "completed", nil); err != nil {
+		return fmt.Errorf("SaveSummary: %w", err)
+	}
+	return nil
+}
+
+// SaveFlaggedSummary 同 SaveSummary，但摘要未通過內容審核：tasks.status 改為 flagged 並記錄審核結果
+// （tasks.moderation），摘要保留供人工審核。
+func SaveFlaggedSummary(db *sql.DB, taskID, summary string, moderation any) error {
+	data, err := json.Marshal(moderation)
+	if err != nil {
+		return fmt.Errorf("SaveFlaggedSummary(%s): %w", taskID, err)
+	}
+	if err := saveSummary(db, taskID, summary, "flagged", data); err != nil {
+		return fmt.Errorf("SaveFlaggedSummary: %w", err)
+	}
+	return nil
+}
+
+func saveSummary(db *sql.DB, taskID, summary, status string, moderation []byte) error {
 	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("SaveSummary: begin tx: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
+	if err := transition(tx, taskID, status, sql.NullString{}); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	if moderation != nil {
+		if _, err := tx.Exec(`UPDATE tasks SET moderation = $2 WHERE id = $1`, taskID, moderation); err != nil {
+			return fmt.Errorf("update moderation: %w", err)
+		}
+	}
+
 	_, err = tx.Exec(`
 		INSERT INTO task_results (task_id, summary, updated_at)
 		VALUES ($1, $2, NOW())
-		ON CONFLICT (task_id) DO UPDATE SET summary = $2, updated_at = NOW()`,
+		ON CONFLICT (task_id) DO UPDATE SET summary = $2, review_reasons = NULL, updated_at = NOW()`,
 		taskID, summary)
 	if err != nil {
-		return fmt.Errorf("SaveSummary: upsert summary: %w", err)
+		return fmt.Errorf("upsert summary: %w", err)
 	}
 
-	_, err = tx.Exec(
-		`UPDATE tasks SET status = 'completed', updated_at = NOW() WHERE id = $1`,
-		taskID)
+	return tx.Commit()
+}
+
+// FlagTask 將轉錄稿未通過內容審核的任務改為 flagged 並記錄審核結果（tasks.moderation）。
+// 需在 SaveTranscript 之後呼叫，已取消的任務返回 ErrStateConflict。
+func FlagTask(db *sql.DB, taskID string, moderation any) error {
+	data, err := json.Marshal(moderation)
 	if err != nil {
-		return fmt.Errorf("SaveSummary: update status: %w", err)
+		return fmt.Errorf("FlagTask(%s): %w", taskID, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("FlagTask(%s): %w", taskID, err)
+	}
+	defer tx.Rollback()
+	if err := transition(tx, taskID, "flagged", sql.NullString{}); err != nil {
+		return fmt.Errorf("FlagTask(%s): %w", taskID, err)
+	}
+	if _, err := tx.Exec(`UPDATE tasks SET moderation = $2 WHERE id = $1`, taskID, data); err != nil {
+		return fmt.Errorf("FlagTask(%s): %w", taskID, err)
 	}
-
 	return tx.Commit()
 }
 
 // SetTaskStatus 更新任務至終態（failed / cancelled）。
-// 不帶 Atomic Check：Worker 已透過 Redis BLPOP 保證不重複消費。
+// 已取消（含取消後被強制標記 failed）的任務不會被改為其他狀態（返回 ErrStateConflict），任務不存在時返回 ErrTaskNotFound。
 func SetTaskStatus(db *sql.DB, taskID, status, errMsg string) error {
+	if err := transition(db, taskID, status, sql.NullString{String: errMsg, Valid: true}); err != nil {
+		return fmt.Errorf("SetTaskStatus(%s, %s): %w", taskID, status, err)
+	}
+	return nil
+}
+
+// ForceFailCancelled 將已取消但 Worker 未能在期限內停止的任務改標為 failed。
+// 只作用於 status = 'cancelled'，不受 transition 的取消保護限制；error_message 加上 forcedFailPrefix，
+// 之後 transition 同樣拒絕改寫，卡住的處理返回時只會得到 ErrStateConflict。
+func ForceFailCancelled(db *sql.DB, taskID, errMsg string) error {
 	_, err := db.Exec(
-		`UPDATE tasks SET status = $1, error_message = $2, updated_at = NOW() WHERE id = $3`,
-		status, errMsg, taskID)
+		`UPDATE tasks SET status = 'failed', error_message = $2, updated_at = NOW() WHERE id = $1 AND status = 'cancelled'`,
+		taskID, forcedFailPrefix+errMsg)
 	if err != nil {
-		return fmt.Errorf("SetTaskStatus(%s, %s): %w", taskID, status, err)
+		return fmt.Errorf("ForceFailCancelled(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// CreateTask 建立任務紀錄（status=pending）。一般任務由 API Service 建立，
+// Worker 僅在內部排程任務（例如 Canary）時使用。
+func CreateTask(db *sql.DB, taskID, userID, filePath string) error {
+	_, err := db.Exec(
+		`INSERT INTO tasks (id, user_id, status, file_path) VALUES ($1, $2, 'pending', $3)`,
+		taskID, userID, filePath)
+	if err != nil {
+		return fmt.Errorf("CreateTask(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// DeleteTask 刪除任務紀錄，task_results 透過 ON DELETE CASCADE 一併刪除。
+func DeleteTask(db *sql.DB, taskID string) error {
+	if _, err := db.Exec(`DELETE FROM tasks WHERE id = $1`, taskID); err != nil {
+		return fmt.Errorf("DeleteTask(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// TaskResult 任務狀態與結果的合併視圖。
+type TaskResult struct {
+	Status       string
+	ErrorMessage string
+	Transcript   string
+	Summary      string
+}
+
+// GetTaskResult 查詢任務狀態與 transcript/summary，任務不存在時返回 ErrTaskNotFound。
+func GetTaskResult(db *sql.DB, taskID string) (*TaskResult, error) {
+	var r TaskResult
+	var errMsg, transcript, summary sql.NullString
+	err := db.QueryRow(`
+		SELECT t.status, t.error_message, r.transcript, r.summary
+		FROM tasks t
+		LEFT JOIN task_results r ON t.id = r.task_id
+		WHERE t.id = $1`, taskID).Scan(&r.Status, &errMsg, &transcript, &summary)
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskResult(%s): %w", taskID, notFound(err))
+	}
+	r.ErrorMessage = errMsg.String
+	r.Transcript = transcript.String
+	r.Summary = summary.String
+	return &r, nil
+}
+
+// SetWebhookURL 記錄任務的 webhook callback URL，空字串表示不通知。
+func SetWebhookURL(db *sql.DB, taskID, url string) error {
+	if _, err := db.Exec(`UPDATE tasks SET webhook_url = $1 WHERE id = $2`, url, taskID); err != nil {
+		return fmt.Errorf("SetWebhookURL(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// GetWebhookURL 查詢任務的 webhook callback URL，未設定時返回空字串。
+func GetWebhookURL(db *sql.DB, taskID string) (string, error) {
+	var url sql.NullString
+	err := db.QueryRow(`SELECT webhook_url FROM tasks WHERE id = $1`, taskID).Scan(&url)
+	if err != nil {
+		return "", fmt.Errorf("GetWebhookURL(%s): %w", taskID, err)
+	}
+	return url.String, nil
+}
+
+// RecordWebhookAttempt 寫入一次 webhook 投遞嘗試的結果。statusCode 為 0 代表未取得 HTTP 回應。
+func RecordWebhookAttempt(db *sql.DB, taskID, event string, attempt, statusCode int, errMsg string, delivered bool) error {
+	_, err := db.Exec(`
+		INSERT INTO webhook_deliveries (task_id, event, attempt, status_code, error, delivered)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, ''), $6)`,
+		taskID, event, attempt, statusCode, errMsg, delivered)
+	if err != nil {
+		return fmt.Errorf("RecordWebhookAttempt(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SetHighlightsPath 記錄任務 highlight reel 的檔案路徑。
+func SetHighlightsPath(db *sql.DB, taskID, path string) error {
+	if _, err := db.Exec(`UPDATE tasks SET highlights_path = $1, updated_at = NOW() WHERE id = $2`, path, taskID); err != nil {
+		return fmt.Errorf("SetHighlightsPath(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SetTaskTitle 記錄自動產生的任務標題。
+func SetTaskTitle(db *sql.DB, taskID, title string) error {
+	if _, err := db.Exec(`UPDATE tasks SET title = $1, updated_at = NOW() WHERE id = $2`, title, taskID); err != nil {
+		return fmt.Errorf("SetTaskTitle(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SetDetectedLanguage 記錄自第一個 chunk 偵測到的語言（tasks.detected_language）。
+func SetDetectedLanguage(db *sql.DB, taskID, lang string) error {
+	if _, err := db.Exec(`UPDATE tasks SET detected_language = $1, updated_at = NOW() WHERE id = $2`, lang, taskID); err != nil {
+		return fmt.Errorf("SetDetectedLanguage(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// GetDetectedLanguage 取得任務偵測到的語言，未偵測時返回空字串。
+func GetDetectedLanguage(db *sql.DB, taskID string) (string, error) {
+	var lang sql.NullString
+	if err := db.QueryRow(`SELECT detected_language FROM tasks WHERE id = $1`, taskID).Scan(&lang); err != nil {
+		return "", fmt.Errorf("GetDetectedLanguage(%s): %w", taskID, err)
+	}
+	return lang.String, nil
+}
+
+// SetTaskWorker 記錄目前處理任務的 Worker instance ID。
+func SetTaskWorker(db *sql.DB, taskID, workerID string) error {
+	if _, err := db.Exec(`UPDATE tasks SET worker_id = $1 WHERE id = $2`, workerID, taskID); err != nil {
+		return fmt.Errorf("SetTaskWorker(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveSegments 寫入依 chunk 切分、標記語言的轉錄段落（JSON）。需在 SaveTranscript 之後呼叫。
+func SaveSegments(db *sql.DB, taskID string, segments any) error {
+	data, err := json.Marshal(segments)
+	if err != nil {
+		return fmt.Errorf("SaveSegments(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE task_results SET segments = $1 WHERE task_id = $2`, data, taskID); err != nil {
+		return fmt.Errorf("SaveSegments(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveTimings 寫入逐段 / 逐字時間戳（task_results.timings）。需在 SaveTranscript 之後呼叫。
+func SaveTimings(db *sql.DB, taskID string, timings any) error {
+	data, err := json.Marshal(timings)
+	if err != nil {
+		return fmt.Errorf("SaveTimings(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE task_results SET timings = $1 WHERE task_id = $2`, data, taskID); err != nil {
+		return fmt.Errorf("SaveTimings(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveStructuredSummary 寫入結構化摘要（task_results.structured_summary），summary 為 nil 時清除。
+func SaveStructuredSummary(db *sql.DB, taskID string, summary any) error {
+	var data []byte
+	if summary != nil {
+		var err error
+		if data, err = json.Marshal(summary); err != nil {
+			return fmt.Errorf("SaveStructuredSummary(%s): %w", taskID, err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE task_results SET structured_summary = $1 WHERE task_id = $2`, data, taskID); err != nil {
+		return fmt.Errorf("SaveStructuredSummary(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveWaveform 寫入降取樣的波形（task_results.waveform），供前端繪製。需在 SaveTranscript 之後呼叫。
+func SaveWaveform(db *sql.DB, taskID string, waveform any) error {
+	data, err := json.Marshal(waveform)
+	if err != nil {
+		return fmt.Errorf("SaveWaveform(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE task_results SET waveform = $1 WHERE task_id = $2`, data, taskID); err != nil {
+		return fmt.Errorf("SaveWaveform(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// FlagSummaryForReview 記錄摘要未通過驗證的原因，供人工審核；重新摘要成功時由 SaveSummary 清除。
+func FlagSummaryForReview(db *sql.DB, taskID string, reasons []string) error {
+	if _, err := db.Exec(`UPDATE task_results SET review_reasons = $1 WHERE task_id = $2`, strings.Join(reasons, "\n"), taskID); err != nil {
+		return fmt.Errorf("FlagSummaryForReview(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveBenchmarkResult 寫入 benchmark 任務中單一 provider 的結果；wer < 0 表示未提供 reference。
+func SaveBenchmarkResult(db *sql.DB, taskID, provider, model, transcript string, wer float64, elapsed time.Duration, errMsg string) error {
+	var werValue sql.NullFloat64
+	if wer >= 0 {
+		werValue = sql.NullFloat64{Float64: wer, Valid: true}
+	}
+	_, err := db.Exec(`
+		INSERT INTO benchmark_results (task_id, provider, model, transcript, wer, duration_ms, error_message)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (task_id, provider) DO UPDATE
+		SET model = $3, transcript = $4, wer = $5, duration_ms = $6, error_message = NULLIF($7, ''), created_at = NOW()`,
+		taskID, provider, model, transcript, werValue, elapsed.Milliseconds(), errMsg)
+	if err != nil {
+		return fmt.Errorf("SaveBenchmarkResult(%s, %s): %w", taskID, provider, err)
+	}
+	return nil
+}
+
+// SavePartialTranscript 保存失敗任務已完成部分的轉錄稿（partial = TRUE），不變更任務狀態。
+func SavePartialTranscript(db *sql.DB, taskID, transcript string) error {
+	_, err := db.Exec(`
+		INSERT INTO task_results (task_id, transcript, partial, updated_at)
+		VALUES ($1, $2, TRUE, NOW())
+		ON CONFLICT (task_id) DO UPDATE SET transcript = $2, partial = TRUE, updated_at = NOW()`,
+		taskID, transcript)
+	if err != nil {
+		return fmt.Errorf("SavePartialTranscript(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveSourceChecksum 記錄 Worker 實際處理的音檔 SHA-256（task_results.source_sha256）。
+func SaveSourceChecksum(db *sql.DB, taskID, sum string) error {
+	_, err := db.Exec(`UPDATE task_results SET source_sha256 = $2 WHERE task_id = $1`, taskID, sum)
+	if err != nil {
+		return fmt.Errorf("SaveSourceChecksum(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveSummaryConfig 保存最近一次摘要使用的設定（tasks.summary_config），重新摘要時沿用。
+func SaveSummaryConfig(db *sql.DB, taskID string, cfg any) error {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("SaveSummaryConfig(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE tasks SET summary_config = $2 WHERE id = $1`, taskID, b); err != nil {
+		return fmt.Errorf("SaveSummaryConfig(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// GetUserGlossary 取得用戶的預設詞彙表（user_glossaries），未設定時返回 nil。
+func GetUserGlossary(db *sql.DB, userID string) ([]string, error) {
+	var data []byte
+	err := db.QueryRow(`SELECT terms FROM user_glossaries WHERE user_id = $1`, userID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetUserGlossary(%s): %w", userID, err)
+	}
+	var terms []string
+	if err := json.Unmarshal(data, &terms); err != nil {
+		return nil, fmt.Errorf("GetUserGlossary(%s): %w", userID, err)
+	}
+	return terms, nil
+}
+
+// SaveTaskGlossary 記錄任務套用的詞彙表（tasks.glossary），供之後的摘要階段沿用。
+func SaveTaskGlossary(db *sql.DB, taskID string, terms []string) error {
+	data, err := json.Marshal(terms)
+	if err != nil {
+		return fmt.Errorf("SaveTaskGlossary(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE tasks SET glossary = $2 WHERE id = $1`, taskID, data); err != nil {
+		return fmt.Errorf("SaveTaskGlossary(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// GetTaskGlossary 取得任務於轉錄時套用的詞彙表，未設定時返回 nil。
+func GetTaskGlossary(db *sql.DB, taskID string) ([]string, error) {
+	var data []byte
+	if err := db.QueryRow(`SELECT glossary FROM tasks WHERE id = $1`, taskID).Scan(&data); err != nil {
+		return nil, fmt.Errorf("GetTaskGlossary(%s): %w", taskID, err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var terms []string
+	if err := json.Unmarshal(data, &terms); err != nil {
+		return nil, fmt.Errorf("GetTaskGlossary(%s): %w", taskID, err)
+	}
+	return terms, nil
+}
+
+// SetTaskErrorCode 記錄使用者可讀的錯誤代碼（tasks.error_code，例如 ERR_UNSUPPORTED_FORMAT）。
+func SetTaskErrorCode(db *sql.DB, taskID, code string) error {
+	if _, err := db.Exec(`UPDATE tasks SET error_code = $2 WHERE id = $1`, taskID, code); err != nil {
+		return fmt.Errorf("SetTaskErrorCode(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SetAudioHash 記錄音訊內容 hash（tasks.audio_hash）與 STT 設定指紋（tasks.stt_fingerprint），
+// 供之後相同錄音、相同轉錄設定的任務沿用結果。
+func SetAudioHash(db *sql.DB, taskID, hash, fingerprint string) error {
+	if _, err := db.Exec(`UPDATE tasks SET audio_hash = $2, stt_fingerprint = $3 WHERE id = $1`, taskID, hash, fingerprint); err != nil {
+		return fmt.Errorf("SetAudioHash(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// DuplicateResult 相同音訊已完成轉錄的任務結果；Segments / Timings / Waveform / Language 可能為空。
+type DuplicateResult struct {
+	TaskID     string
+	Transcript string
+	Segments   json.RawMessage
+	Timings    json.RawMessage
+	Waveform   json.RawMessage
+	Language   string
+}
+
+// FindDuplicateResult 查找 audio_hash 與 stt_fingerprint 皆相同、已有完整（非部分）轉錄稿的最近一個其他任務；
+// userID 非空時只查同一使用者的任務。找不到時返回 nil, nil。
+func FindDuplicateResult(db *sql.DB, hash, fingerprint, taskID, userID string) (*DuplicateResult, error) {
+	var r DuplicateResult
+	var lang sql.NullString
+	var segments, timings, waveform []byte
+	err := db.QueryRow(`
+		SELECT t.id, r.transcript, r.segments, r.timings, r.waveform, t.detected_language
+		FROM tasks t
+		JOIN task_results r ON t.id = r.task_id
+		WHERE t.audio_hash = $1 AND t.stt_fingerprint = $2 AND t.id <> $3 AND ($4 = '' OR t.user_id = $4)
+		  AND t.status IN ('stt_completed', 'completed', 'completed_no_summary')
+		  AND r.transcript IS NOT NULL AND NOT r.partial
+		ORDER BY t.updated_at DESC
+		LIMIT 1`, hash, fingerprint, taskID, userID).Scan(&r.TaskID, &r.Transcript, &segments, &timings, &waveform, &lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FindDuplicateResult(%s): %w", taskID, err)
+	}
+	r.Segments = segments
+	r.Timings = timings
+	r.Waveform = waveform
+	r.Language = lang.String
+	return &r, nil
+}
+
+// FindReusableSummary 任務的轉錄稿沿用自重複音訊的任務（audio_metadata.deduplicatedFrom）時，返回來源任務 ID 與摘要；
+// 來源任務須已完成、轉錄稿相同，且摘要設定（summary_config）與 cfg 完全相同。不符時返回空字串。
+func FindReusableSummary(db *sql.DB, taskID string, cfg any) (string, string, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", "", fmt.Errorf("FindReusableSummary(%s): %w", taskID, err)
+	}
+	var source, summary string
+	err = db.QueryRow(`
+		SELECT s.id, sr.summary
+		FROM tasks t
+		JOIN task_results r ON r.task_id = t.id
+		JOIN tasks s ON s.id::text = t.audio_metadata->>'deduplicatedFrom'
+		JOIN task_results sr ON sr.task_id = s.id
+		WHERE t.id = $1 AND s.status = 'completed'
+		  AND s.summary_config = $2::jsonb
+		  AND sr.transcript = r.transcript
+		  AND COALESCE(sr.summary, '') <> ''`, taskID, b).Scan(&source, &summary)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("FindReusableSummary(%s): %w", taskID, err)
+	}
+	return source, summary, nil
+}
+
+// SaveAudioInfo 記錄上傳錄音的格式、編碼、長度、取樣率、聲道數與原始大小（tasks.audio_*）。
+func SaveAudioInfo(db *sql.DB, taskID string, info models.AudioInfo) error {
+	_, err := db.Exec(`
+		UPDATE tasks SET audio_format = $2, audio_codec = $3, audio_duration_sec = $4,
+			audio_sample_rate = $5, audio_channels = $6, audio_size_bytes = $7
+		WHERE id = $1`,
+		taskID, info.Format, info.Codec, sql.NullFloat64{Float64: info.DurationSec, Valid: info.DurationSec > 0},
+		sql.NullInt64{Int64: int64(info.SampleRate), Valid: info.SampleRate > 0},
+		sql.NullInt64{Int64: int64(info.Channels), Valid: info.Channels > 0}, info.SizeBytes)
+	if err != nil {
+		return fmt.Errorf("SaveAudioInfo(%s): %w", taskID, err)
 	}
 	return nil
 }
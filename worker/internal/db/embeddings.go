@@ -0,0 +1,48 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SaveEmbeddings 以 chunks 與對應向量取代任務既有的 transcript_embeddings（重跑 STT 時不留下舊段落）。
+// 需 pgvector 與 migration 000029 建立的資料表。
+func SaveEmbeddings(db *sql.DB, taskID, model string, chunks []string, vectors [][]float32) error {
+	if len(chunks) != len(vectors) {
+		return fmt.Errorf("SaveEmbeddings(%s): %d chunks but %d vectors", taskID, len(chunks), len(vectors))
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("SaveEmbeddings(%s): %w", taskID, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM transcript_embeddings WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("SaveEmbeddings(%s): %w", taskID, err)
+	}
+	for i, chunk := range chunks {
+		_, err := tx.Exec(`
+			INSERT INTO transcript_embeddings (task_id, chunk_index, content, embedding, model)
+			VALUES ($1, $2, $3, $4::vector, $5)`,
+			taskID, i, chunk, vectorLiteral(vectors[i]), model)
+		if err != nil {
+			return fmt.Errorf("SaveEmbeddings(%s): %w", taskID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// vectorLiteral 將向量轉為 pgvector 的文字格式 "[x,y,...]"。
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTaskNotFound 任務不存在（已被刪除或 ID 錯誤）。
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrStateConflict 任務目前狀態不允許此轉換，例如 Worker 完成時任務已被使用者取消。
+	ErrStateConflict = errors.New("task state transition rejected")
+)
+
+// StateConflictError 狀態轉換被拒絕的詳細資訊，errors.Is(err, ErrStateConflict) 成立。
+type StateConflictError struct {
+	TaskID  string
+	Current string
+	Target  string
+}
+
+func (e *StateConflictError) Error() string {
+	return fmt.Sprintf("task %s: %s → %s: %v", e.TaskID, e.Current, e.Target, ErrStateConflict)
+}
+
+func (e *StateConflictError) Is(target error) bool {
+	return target == ErrStateConflict
+}
+
+// execer *sql.DB 與 *sql.Tx 共用的查詢介面。
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// forcedFailPrefix ForceFailCancelled 寫入 error_message 的前綴。帶此前綴的 failed 任務是使用者取消後
+// 被強制標記的，視同 cancelled：卡住的處理稍後返回時不得再改寫其狀態。
+const forcedFailPrefix = "[cancelled] "
+
+// transition 將任務狀態更新為 target；已取消的任務只能維持 cancelled，取消後被強制標記 failed 的任務
+// 不再轉換。errMsg 無值時保留原 error_message。未更新任何列時區分 ErrTaskNotFound 與 *StateConflictError。
+func transition(q execer, taskID, target string, errMsg sql.NullString) error {
+	res, err := q.Exec(`
+		UPDATE tasks SET status = $1, error_message = COALESCE($2, error_message), updated_at = NOW()
+		WHERE id = $3 AND (status <> 'cancelled' OR $1 = 'cancelled')
+		  AND NOT (status = 'failed' AND starts_with(COALESCE(error_message, ''), $4))`,
+		target, errMsg, taskID, forcedFailPrefix)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	var current string
+	if err := q.QueryRow(`SELECT status FROM tasks WHERE id = $1`, taskID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
+		return err
+	}
+	return &StateConflictError{TaskID: taskID, Current: current, Target: target}
+}
+
+// notFound 將 sql.ErrNoRows 轉為 ErrTaskNotFound。
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	return err
+}
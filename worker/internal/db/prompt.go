@@ -0,0 +1,44 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// PromptTemplate prompt_templates 的一列；空字串表示該欄未設定。
+type PromptTemplate struct {
+	System string
+	User   string
+}
+
+// GetPromptTemplate 取得名為 name 的提示模板，不存在時返回零值。
+func GetPromptTemplate(db *sql.DB, name string) (PromptTemplate, error) {
+	var system, user sql.NullString
+	err := db.QueryRow(`SELECT system_template, user_template FROM prompt_templates WHERE name = $1`, name).Scan(&system, &user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PromptTemplate{}, nil
+	}
+	if err != nil {
+		return PromptTemplate{}, fmt.Errorf("GetPromptTemplate(%s): %w", name, err)
+	}
+	return PromptTemplate{System: system.String, User: user.String}, nil
+}
+
+// GetPromptContext 取得提示模板變數：偵測到的語言（tasks.detected_language，未記錄時為 task_results.segments
+// 中最常見的語言）與錄音長度（秒），未知時分別為空字串與 0。
+func GetPromptContext(db *sql.DB, taskID string) (language string, durationSec float64, err error) {
+	var lang sql.NullString
+	var duration sql.NullFloat64
+	err = db.QueryRow(`
+		SELECT t.audio_duration_sec, COALESCE(t.detected_language,
+			(SELECT s->>'language' FROM jsonb_array_elements(COALESCE(r.segments, '[]'::jsonb)) s
+			 WHERE COALESCE(s->>'language', '') <> ''
+			 GROUP BY 1 ORDER BY COUNT(*) DESC LIMIT 1))
+		FROM tasks t LEFT JOIN task_results r ON r.task_id = t.id
+		WHERE t.id = $1`, taskID).Scan(&duration, &lang)
+	if err != nil {
+		return "", 0, fmt.Errorf("GetPromptContext(%s): %w", taskID, err)
+	}
+	return lang.String, duration.Float64, nil
+}
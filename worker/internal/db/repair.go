@@ -0,0 +1,99 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// InconsistentTask 修復掃描找到的問題任務。
+type InconsistentTask struct {
+	ID            string
+	Status        string
+	HasTranscript bool
+}
+
+// FindCompletedWithoutResults 找出 status=completed 但缺少 summary 的任務，
+// 以及 status=stt_completed 但缺少 transcript 的任務。
+func FindCompletedWithoutResults(db *sql.DB) ([]InconsistentTask, error) {
+	rows, err := db.Query(`
+		SELECT t.id, t.status, COALESCE(r.transcript, '') <> ''
+		FROM tasks t
+		LEFT JOIN task_results r ON t.id = r.task_id
+		WHERE (t.status = 'completed' AND COALESCE(r.summary, '') = '')
+		   OR (t.status = 'stt_completed' AND COALESCE(r.transcript, '') = '')`)
+	if err != nil {
+		return nil, fmt.Errorf("FindCompletedWithoutResults: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []InconsistentTask
+	for rows.Next() {
+		var t InconsistentTask
+		if err := rows.Scan(&t.ID, &t.Status, &t.HasTranscript); err != nil {
+			return nil, fmt.Errorf("FindCompletedWithoutResults: scan: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// DeleteOrphanedResults 刪除沒有對應 tasks 紀錄的 task_results（FK 被停用或手動操作後可能出現）。
+// dryRun 時只計數不刪除。
+func DeleteOrphanedResults(db *sql.DB, dryRun bool) (int64, error) {
+	query := `DELETE FROM task_results r WHERE NOT EXISTS (SELECT 1 FROM tasks t WHERE t.id = r.task_id)`
+	if dryRun {
+		var n int64
+		err := db.QueryRow(`SELECT COUNT(*) FROM task_results r WHERE NOT EXISTS (SELECT 1 FROM tasks t WHERE t.id = r.task_id)`).Scan(&n)
+		if err != nil {
+			return 0, fmt.Errorf("DeleteOrphanedResults: %w", err)
+		}
+		return n, nil
+	}
+	res, err := db.Exec(query)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteOrphanedResults: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// FindSentOutboxWithoutSummary 找出已標記 sent 但對應任務缺少 summary 的 outbox 事件。
+// outbox_events 已於 000002 移除，表不存在時返回 (nil, false, nil)。
+func FindSentOutboxWithoutSummary(db *sql.DB) ([]string, bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass('outbox_events') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, false, fmt.Errorf("FindSentOutboxWithoutSummary: %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT DISTINCT e.aggregate_id
+		FROM outbox_events e
+		LEFT JOIN task_results r ON e.aggregate_id = r.task_id
+		WHERE e.status = 'sent' AND COALESCE(r.summary, '') = ''`)
+	if err != nil {
+		return nil, true, fmt.Errorf("FindSentOutboxWithoutSummary: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, true, fmt.Errorf("FindSentOutboxWithoutSummary: scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, true, rows.Err()
+}
+
+// GetTaskStatus 查詢任務狀態，任務不存在時返回 ErrTaskNotFound。
+func GetTaskStatus(db *sql.DB, taskID string) (string, error) {
+	var status string
+	err := db.QueryRow(`SELECT status FROM tasks WHERE id = $1`, taskID).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("GetTaskStatus(%s): %w", taskID, notFound(err))
+	}
+	return status, nil
+}
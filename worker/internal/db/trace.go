@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tts-worker/internal/models"
+)
+
+// RecordQueueWait 以 tasks.created_at 至今的時間寫入 trace.queuedMs，只記錄第一次開始處理（重試不覆寫）。
+func RecordQueueWait(db *sql.DB, taskID string) error {
+	_, err := db.Exec(`
+		UPDATE tasks SET trace = COALESCE(trace, '{}'::jsonb) ||
+			jsonb_build_object('queuedMs', GREATEST(0, (EXTRACT(EPOCH FROM NOW() - created_at) * 1000)::bigint))
+		WHERE id = $1 AND NOT COALESCE(trace ? 'queuedMs', FALSE)`,
+		taskID)
+	if err != nil {
+		return fmt.Errorf("RecordQueueWait(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// MergeTaskTrace 將 t 的非零欄位合併至 tasks.trace。
+func MergeTaskTrace(db *sql.DB, taskID string, t models.ExecutionTrace) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("MergeTaskTrace(%s): %w", taskID, err)
+	}
+	_, err = db.Exec(`UPDATE tasks SET trace = COALESCE(trace, '{}'::jsonb) || $2::jsonb WHERE id = $1`, taskID, b)
+	if err != nil {
+		return fmt.Errorf("MergeTaskTrace(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// GetTaskTrace 讀取任務的執行紀錄，尚無紀錄時返回 nil。
+func GetTaskTrace(db *sql.DB, taskID string) (*models.ExecutionTrace, error) {
+	var raw []byte
+	if err := db.QueryRow(`SELECT trace FROM tasks WHERE id = $1`, taskID).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("GetTaskTrace(%s): %w", taskID, notFound(err))
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	var t models.ExecutionTrace
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("GetTaskTrace(%s): %w", taskID, err)
+	}
+	return &t, nil
+}
+
+// MergeAudioMetadata 將 fields 合併至 tasks.audio_metadata（前處理量測值等音檔資訊）。
+func MergeAudioMetadata(db *sql.DB, taskID string, fields map[string]any) error {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("MergeAudioMetadata(%s): %w", taskID, err)
+	}
+	_, err = db.Exec(`UPDATE tasks SET audio_metadata = COALESCE(audio_metadata, '{}'::jsonb) || $2::jsonb WHERE id = $1`, taskID, b)
+	if err != nil {
+		return fmt.Errorf("MergeAudioMetadata(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveMergeReport 寫入 chunk 重疊合併的品質紀錄（tasks.merge_report），重試時覆寫。
+func SaveMergeReport(db *sql.DB, taskID string, report any) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("SaveMergeReport(%s): %w", taskID, err)
+	}
+	if _, err := db.Exec(`UPDATE tasks SET merge_report = $2 WHERE id = $1`, taskID, b); err != nil {
+		return fmt.Errorf("SaveMergeReport(%s): %w", taskID, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// STTUsage STT 階段的資源使用量。
+type STTUsage struct {
+	ChunkingMs         int64
+	STTMs              int64
+	FFmpegCPUMs        int64
+	FFmpegPeakRSSBytes int64
+	PeakRSSDeltaBytes  int64
+	InputBytes         int64
+	ChunkBytes         int64
+}
+
+// SaveSTTUsage upsert STT 階段的 task_usage 欄位，不覆蓋 summary_ms。
+func SaveSTTUsage(db *sql.DB, taskID string, u STTUsage) error {
+	_, err := db.Exec(`
+		INSERT INTO task_usage (task_id, chunking_ms, stt_ms, ffmpeg_cpu_ms, ffmpeg_peak_rss_bytes,
+			peak_rss_delta_bytes, input_bytes, chunk_bytes, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (task_id) DO UPDATE SET
+			chunking_ms = $2, stt_ms = $3, ffmpeg_cpu_ms = $4, ffmpeg_peak_rss_bytes = $5,
+			peak_rss_delta_bytes = GREATEST(COALESCE(task_usage.peak_rss_delta_bytes, 0), $6),
+			input_bytes = $7, chunk_bytes = $8, updated_at = NOW()`,
+		taskID, u.ChunkingMs, u.STTMs, u.FFmpegCPUMs, u.FFmpegPeakRSSBytes, u.PeakRSSDeltaBytes, u.InputBytes, u.ChunkBytes)
+	if err != nil {
+		return fmt.Errorf("SaveSTTUsage(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// SaveSummaryUsage upsert Summary 階段的 task_usage 欄位。
+func SaveSummaryUsage(db *sql.DB, taskID string, summaryMs, peakRSSDeltaBytes int64) error {
+	_, err := db.Exec(`
+		INSERT INTO task_usage (task_id, summary_ms, peak_rss_delta_bytes, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (task_id) DO UPDATE SET
+			summary_ms = $2,
+			peak_rss_delta_bytes = GREATEST(COALESCE(task_usage.peak_rss_delta_bytes, 0), $3),
+			updated_at = NOW()`,
+		taskID, summaryMs, peakRSSDeltaBytes)
+	if err != nil {
+		return fmt.Errorf("SaveSummaryUsage(%s): %w", taskID, err)
+	}
+	return nil
+}
+
+// AddTokenUsage 累加 LLM token 用量（task_usage.llm_prompt_tokens / llm_completion_tokens），
+// 重試、驗證與標題產生的請求都會計入。
+func AddTokenUsage(db *sql.DB, taskID string, promptTokens, completionTokens int) error {
+	_, err := db.Exec(`
+		INSERT INTO task_usage (task_id, llm_prompt_tokens, llm_completion_tokens, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (task_id) DO UPDATE SET
+			llm_prompt_tokens = COALESCE(task_usage.llm_prompt_tokens, 0) + $2,
+			llm_completion_tokens = COALESCE(task_usage.llm_completion_tokens, 0) + $3,
+			updated_at = NOW()`,
+		taskID, promptTokens, completionTokens)
+	if err != nil {
+		return fmt.Errorf("AddTokenUsage(%s): %w", taskID, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package diagnostics 提供 pprof 與執行期快照的私有 HTTP 端點，用於在正式環境排查 goroutine 洩漏、
+// 卡住的 SSE 發布或 ffmpeg 殭屍行程而不需重啟。端點未做驗證，只應綁定在內部位址（例如 127.0.0.1:6060）。
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshot /debug/runtime 的回應內容。
+type Snapshot struct {
+	Time          time.Time      `json:"time"`
+	Uptime        string         `json:"uptime"`
+	Goroutines    int            `json:"goroutines"`
+	HeapAlloc     uint64         `json:"heapAllocBytes"`
+	HeapObjects   uint64         `json:"heapObjects"`
+	Sys           uint64         `json:"sysBytes"`
+	NumGC         uint32         `json:"numGC"`
+	LastGCPause   string         `json:"lastGCPause"`
+	ChildProcs    []ChildProcess `json:"childProcesses"`
+	InFlightTasks []string       `json:"inFlightTasks,omitempty"`
+}
+
+// ChildProcess 本行程的子行程（通常為 ffmpeg / ffprobe）；State 為 Z 表示殭屍行程。
+type ChildProcess struct {
+	PID     int    `json:"pid"`
+	State   string `json:"state"`
+	Command string `json:"command"`
+}
+
+var started = time.Now()
+
+// mux 診斷端點的路由，其他不對外開放的維運端點（例如 POST /drain）可透過 Handle 掛載於同一埠。
+var mux = http.NewServeMux()
+
+// Handle 在診斷端點上註冊額外路由，須在 Serve 之前呼叫。
+func Handle(pattern string, h http.Handler) {
+	mux.Handle(pattern, h)
+}
+
+// Serve 在 addr 上啟動 /debug/pprof/* 與 /debug/runtime，addr 為空時不啟動。
+// inFlight 返回進行中的任務 ID，可為 nil。應以獨立 goroutine 呼叫。
+func Serve(addr string, inFlight func() []string) {
+	if addr == "" {
+		return
+	}
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/runtime", func(rw http.ResponseWriter, r *http.Request) {
+		snap := TakeSnapshot()
+		if inFlight != nil {
+			snap.InFlightTasks = inFlight()
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(snap)
+	})
+
+	log.Printf("Diagnostics endpoint listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Diagnostics endpoint stopped: %v", err)
+	}
+}
+
+// TakeSnapshot 擷取目前的 goroutine 數、heap 統計與子行程。
+func TakeSnapshot() Snapshot {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return Snapshot{
+		Time:        time.Now(),
+		Uptime:      time.Since(started).Round(time.Second).String(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   ms.HeapAlloc,
+		HeapObjects: ms.HeapObjects,
+		Sys:         ms.Sys,
+		NumGC:       ms.NumGC,
+		LastGCPause: time.Duration(ms.PauseNs[(ms.NumGC+255)%256]).String(),
+		ChildProcs:  childProcesses(),
+	}
+}
+
+// childProcesses 掃描 /proc 找出 PPID 為本行程的子行程；非 Linux 環境返回空清單。
+func childProcesses() []ChildProcess {
+	self := os.Getpid()
+	children := []ChildProcess{}
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	for _, path := range stats {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// 格式：pid (comm) state ppid ...；comm 可能含空白，以最後一個 ')' 切分
+		s := string(b)
+		open, end := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+		if open < 0 || end < open {
+			continue
+		}
+		fields := strings.Fields(s[end+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		if ppid != self {
+			continue
+		}
+		pid, _ := strconv.Atoi(strings.TrimSpace(s[:open]))
+		children = append(children, ChildProcess{PID: pid, State: fields[0], Command: s[open+1 : end]})
+	}
+	return children
+}
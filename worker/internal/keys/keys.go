@@ -0,0 +1,106 @@
+// Package keys 集中定義 Redis key、Pub/Sub channel 與佇列名稱，並套用環境前綴（ENV_PREFIX）。
+// 多個環境（staging / prod）共用同一組 Redis 或 broker 時，以前綴隔離彼此的資料。
+// 命名需與 Gateway（stt-gateway/internal/keys）及 API Service（src/lib/keys.ts）一致。
+package keys
+
+import (
+	"fmt"
+	"strings"
+)
+
+var prefix string
+
+// SetPrefix 設定環境前綴，例如 "staging" → 所有 key 以 "staging:" 開頭。空字串表示不加前綴。
+func SetPrefix(env string) {
+	env = strings.TrimSuffix(env, ":")
+	if env == "" {
+		prefix = ""
+		return
+	}
+	prefix = env + ":"
+}
+
+// Prefix 返回目前的前綴（含結尾冒號）。
+func Prefix() string {
+	return prefix
+}
+
+// Queue 返回套用前綴後的佇列名稱（queue.STT → staging:stt:queue）。
+func Queue(name string) string {
+	return prefix + name
+}
+
+// Task 任務 live 狀態 HASH。
+func Task(taskID string) string {
+	return fmt.Sprintf("%stask:%s", prefix, taskID)
+}
+
+// TaskOwner 任務擁有者，Gateway SSE 驗證用。
+func TaskOwner(taskID string) string {
+	return fmt.Sprintf("%stask:owner:%s", prefix, taskID)
+}
+
+// TranscriptBuffer 進行中的累積轉錄稿，SSE 重連時回補。
+func TranscriptBuffer(taskID string) string {
+	return fmt.Sprintf("%stranscript:buffer:%s", prefix, taskID)
+}
+
+// SummaryBuffer 進行中的累積摘要，SSE 重連時回補。
+func SummaryBuffer(taskID string) string {
+	return fmt.Sprintf("%ssummary:buffer:%s", prefix, taskID)
+}
+
+// Progress 任務進度 Pub/Sub channel。
+func Progress(taskID string) string {
+	return fmt.Sprintf("%sprogress:%s", prefix, taskID)
+}
+
+// Events 任務事件 Stream，Gateway 斷線重連時由此補送遺失事件。
+func Events(taskID string) string {
+	return fmt.Sprintf("%sevents:%s", prefix, taskID)
+}
+
+// DebugTask 存在時表示該任務開啟 live log tail，由 Gateway admin API 設定（帶 TTL）。
+func DebugTask(taskID string) string {
+	return fmt.Sprintf("%sdebug:task:%s", prefix, taskID)
+}
+
+// CancelChannel API Service 發布取消信號的 Pub/Sub channel。
+func CancelChannel() string {
+	return prefix + "cancel_channel"
+}
+
+// Lock Worker 之間的分散式鎖（worker:{name}:lock）。
+func Lock(name string) string {
+	return fmt.Sprintf("%sworker:%s:lock", prefix, name)
+}
+
+// Workers 存活 Worker instance 的 ZSET（score 為最後 heartbeat 的 Unix 秒）。
+func Workers() string {
+	return prefix + "workers"
+}
+
+// WorkerInstance 單一 Worker instance 的狀態 HASH（帶 TTL）。
+func WorkerInstance(id string) string {
+	return fmt.Sprintf("%sworker:instance:%s", prefix, id)
+}
+
+// DrainChannel Worker drain 信號的 Pub/Sub channel，訊息為 {"workerId": "<id>" | "*"}。
+func DrainChannel() string {
+	return prefix + "worker:drain"
+}
+
+// QueueDepth 佇列積壓量（由 Worker 定期寫入，供 autoscaler 讀取）。
+func QueueDepth(queue string) string {
+	return fmt.Sprintf("%squeue:depth:%s", prefix, queue)
+}
+
+// ProviderConfig AI provider 熱更新設定（JSON STRING），AI_CONFIG_REDIS=true 時由 Worker 輪詢。
+func ProviderConfig() string {
+	return prefix + "config:ai"
+}
+
+// UserTasks 使用者處理中任務的 ZSET（member 為 taskID、score 為名額租約到期時間），公平性限制用。
+func UserTasks(userID string) string {
+	return fmt.Sprintf("%suser:tasks:%s", prefix, userID)
+}
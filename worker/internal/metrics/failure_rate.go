@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 失敗率滾動窗口：以每分鐘一個 bucket 保存最近 1 小時的任務結果。
+const (
+	failureBucketWidth = time.Minute
+	failureBucketCount = 60
+	failureRefresh     = 15 * time.Second
+)
+
+// failureWindows 對外輸出的窗口長度，label 值與 Prometheus 慣用寫法一致。
+var failureWindows = []struct {
+	label string
+	span  time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+var (
+	failureRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stt_worker_failure_rate",
+		Help: "Rolling failure rate (failures / finished tasks) per error class and window.",
+	}, []string{"window", "class"})
+
+	failureRateThreshold = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stt_worker_failure_rate_threshold",
+		Help: "Configured alerting threshold for the rolling failure rate per window.",
+	}, []string{"window"})
+
+	failureRateAlert = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stt_worker_failure_rate_alert",
+		Help: "1 when the rolling failure rate of an error class exceeds its window threshold with enough samples.",
+	}, []string{"window", "class"})
+)
+
+// FailureAlertConfig 失敗率告警門檻。MinSamples 避免低流量時單一失敗即觸發告警。
+type FailureAlertConfig struct {
+	Threshold5m float64
+	Threshold1h float64
+	MinSamples  int
+}
+
+type failureBucket struct {
+	start    int64 // bucket 起始時間（Unix 分鐘）
+	total    int
+	failures map[string]int
+}
+
+// FailureRateTracker 統計各錯誤分類的滾動失敗率並更新 Prometheus gauges。
+type FailureRateTracker struct {
+	mu      sync.Mutex
+	buckets [failureBucketCount]failureBucket
+	classes map[string]bool // 曾出現過的分類，確保恢復後 gauge 歸零而非殘留舊值
+	cfg     FailureAlertConfig
+}
+
+// Failures 全域失敗率追蹤器，Worker 於任務結束時呼叫 RecordOutcome 寫入。
+var Failures = NewFailureRateTracker(FailureAlertConfig{Threshold5m: 0.25, Threshold1h: 0.10, MinSamples: 5})
+
+// NewFailureRateTracker 建立追蹤器並輸出門檻 gauges。
+func NewFailureRateTracker(cfg FailureAlertConfig) *FailureRateTracker {
+	t := &FailureRateTracker{classes: make(map[string]bool)}
+	t.Configure(cfg)
+	return t
+}
+
+// Configure 更新告警門檻（通常於啟動時由環境變數載入）。
+func (t *FailureRateTracker) Configure(cfg FailureAlertConfig) {
+	t.mu.Lock()
+	t.cfg = cfg
+	t.mu.Unlock()
+	failureRateThreshold.WithLabelValues("5m").Set(cfg.Threshold5m)
+	failureRateThreshold.WithLabelValues("1h").Set(cfg.Threshold1h)
+}
+
+// RecordOutcome 記錄一次任務結果；class 為空字串代表成功。
+func (t *FailureRateTracker) RecordOutcome(stage, class string) {
+	result := class
+	if result == "" {
+		result = "success"
+	}
+	TasksTotal.WithLabelValues(stage, result).Inc()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.bucketFor(time.Now())
+	b.total++
+	if class != "" {
+		b.failures[class]++
+		t.classes[class] = true
+	}
+}
+
+// bucketFor 取得 now 所屬的 bucket，過期 bucket 直接重置重用。呼叫端需持有 mu。
+func (t *FailureRateTracker) bucketFor(now time.Time) *failureBucket {
+	minute := now.Unix() / int64(failureBucketWidth/time.Second)
+	b := &t.buckets[minute%failureBucketCount]
+	if b.start != minute {
+		*b = failureBucket{start: minute, failures: make(map[string]int)}
+	}
+	return b
+}
+
+// Run 定期重新計算各窗口失敗率，直到 ctx 取消。
+func (t *FailureRateTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(failureRefresh)
+	defer ticker.Stop()
+	for {
+		t.refresh(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *FailureRateTracker) refresh(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := now.Unix() / int64(failureBucketWidth/time.Second)
+	for _, w := range failureWindows {
+		span := int64(w.span / failureBucketWidth)
+		total := 0
+		failures := make(map[string]int)
+		for i := range t.buckets {
+			b := &t.buckets[i]
+			if b.failures == nil || current-b.start >= span {
+				continue
+			}
+			total += b.total
+			for class, n := range b.failures {
+				failures[class] += n
+			}
+		}
+
+		threshold := t.cfg.Threshold5m
+		if w.label == "1h" {
+			threshold = t.cfg.Threshold1h
+		}
+		for class := range t.classes {
+			rate := 0.0
+			if total > 0 {
+				rate = float64(failures[class]) / float64(total)
+			}
+			failureRate.WithLabelValues(w.label, class).Set(rate)
+
+			alert := 0.0
+			if total >= t.cfg.MinSamples && threshold > 0 && rate >= threshold {
+				alert = 1
+			}
+			failureRateAlert.WithLabelValues(w.label, class).Set(alert)
+		}
+	}
+}
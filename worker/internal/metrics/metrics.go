@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// TasksTotal 各階段任務結果計數，result 為 "success" 或錯誤分類（例如 "stt_provider"）。
+var TasksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "stt_worker_tasks_total",
+	Help: "Processed tasks by stage and result (success or error class).",
+}, []string{"stage", "result"})
+
+// Canary 端到端監控指標。
+var (
+	CanarySuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_canary_success",
+		Help: "1 if the most recent canary task passed end-to-end verification, 0 otherwise.",
+	})
+	CanaryRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_worker_canary_runs_total",
+		Help: "Canary runs by result (pass, fail, error).",
+	}, []string{"result"})
+	CanaryDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_canary_duration_seconds",
+		Help: "End-to-end duration of the most recent canary task.",
+	})
+	CanaryLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_canary_last_run_timestamp_seconds",
+		Help: "Unix timestamp of the most recent finished canary run.",
+	})
+)
+
+// Serve 在 addr 上啟動 Prometheus /metrics 端點，addr 為空時不啟動。
+// 應以獨立 goroutine 呼叫；監聽失敗只記錄 log，不影響任務處理。
+func Serve(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	log.Printf("Metrics endpoint listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Metrics endpoint stopped: %v", err)
+	}
+}
+
+// Worker instance 指標。
+var (
+	WorkerInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stt_worker_info",
+		Help: "Always 1; labels identify the worker instance.",
+	}, []string{"worker_id", "hostname"})
+	InFlightTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_in_flight_tasks",
+		Help: "STT and summary tasks currently being processed by this instance.",
+	})
+)
+
+// 自適應 STT chunk 並發指標。
+var (
+	STTConcurrencyLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_stt_concurrency_limit",
+		Help: "Current adaptive limit of concurrent STT chunk requests.",
+	})
+	STTChunksInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stt_worker_stt_chunks_in_flight",
+		Help: "STT chunk requests currently in flight on this instance.",
+	})
+)
+
+// SummaryFlags 摘要未通過驗證的次數，reason 為 refusal / unsupported_entities / self_check / empty。
+var SummaryFlags = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "stt_worker_summary_flags_total",
+	Help: "Summaries flagged by post-summary verification, by reason.",
+}, []string{"reason"})
+
+// chunk 轉錄稿重疊合併品質指標，用於偵測 mergeTranscripts 的退化（重複句子、比對失敗）。
+var (
+	MergeOverlapTokens = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "stt_worker_merge_overlap_tokens",
+		Help:    "Tokens removed as overlap at each chunk boundary.",
+		Buckets: []float64{0, 1, 2, 3, 4, 6, 8, 10, 15, 20},
+	})
+	MergeBoundaries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_worker_merge_boundaries_total",
+		Help: "Chunk boundaries merged, by result (matched, zero_overlap, suspect_duplicate, skipped).",
+	}, []string{"result"})
+)
+
+// FairnessDeferrals 因使用者並發上限而延後的任務次數。
+var FairnessDeferrals = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "stt_worker_fairness_deferrals_total",
+	Help: "Tasks deferred because their user reached the concurrent-task limit.",
+})
+
+// QueueDepth 各佇列尚未被取出的訊息數，供 HPA / KEDA 依 backlog 擴縮 Worker。
+var QueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "stt_worker_queue_depth",
+	Help: "Messages waiting in each task queue.",
+}, []string{"queue"})
+
+// AI provider API key 輪替指標；key 標籤只含序號與末四碼。
+var (
+	APIKeyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_worker_api_key_requests_total",
+		Help: "Provider requests sent with each API key.",
+	}, []string{"provider", "key"})
+	APIKeyThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stt_worker_api_key_throttled_total",
+		Help: "Rate-limit (429) or quota (402) responses per API key; each parks the key.",
+	}, []string{"provider", "key"})
+	APIKeyParkedUntil = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stt_worker_api_key_parked_until_timestamp_seconds",
+		Help: "Unix timestamp until which the API key is skipped after its last throttled response.",
+	}, []string{"provider", "key"})
+)
+
+// ModerationFlagged 未通過內容審核的任務數，stage 為 transcript 或 summary。
+var ModerationFlagged = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "stt_worker_moderation_flagged_total",
+	Help: "Tasks flagged by content moderation, by stage (transcript, summary).",
+}, []string{"stage"})
@@ -11,20 +11,64 @@ const (
 	StatusSummaryQueued     = "summary_queued"
 	StatusSummaryProcessing = "summary_processing"
 	StatusCompleted         = "completed"
-	StatusFailed            = "failed"
-	StatusCancelled         = "cancelled"
+	// StatusCompletedNoSummary LLM 重試用盡後的降級終態：transcript 已交付，摘要可稍後重試。
+	StatusCompletedNoSummary = "completed_no_summary"
+	StatusFailed             = "failed"
+	StatusCancelled          = "cancelled"
+	// StatusFlagged 轉錄稿或摘要未通過內容審核，內容保留供人工審核。
+	StatusFlagged = "flagged"
 )
 
+// ModeBenchmark 以多個 STT provider 轉錄同一音檔並產生比較報告。
+const ModeBenchmark = "benchmark"
+
+// ModeSummaryRetry 使用者對已完成或失敗的任務重新產生摘要，沿用儲存的轉錄稿與上一次的摘要設定。
+const ModeSummaryRetry = "summary_retry"
+
 // STTPayload stt:queue 中的任務訊息格式。
 // JSON tags 與 API Service 的 STTPayload interface 對齊。
 type STTPayload struct {
 	TaskID   string `json:"taskId"`
 	UserID   string `json:"userId"`
 	FilePath string `json:"filePath"`
+	// SourceURL 遠端音檔（podcast、雲端硬碟分享連結等）；有值時 Worker 先下載至 FilePath 或暫存目錄再處理。
+	SourceURL string `json:"sourceUrl,omitempty"`
+	// Checksum 上傳時計算的 SHA-256（hex），切片前驗證檔案未在共享 volume 上損毀或截斷。
+	Checksum string `json:"checksum,omitempty"`
 	Config   struct {
 		Language string `json:"language"`
-		STTModel string `json:"sttModel"`
+		// LanguageExplicit Language 由使用者為此任務指定，而非 Gateway / API Service 套用的部署預設；
+		// 只有指定的語言視為確定的轉錄稿語言，否則仍以 provider 回報或轉錄文字偵測。
+		LanguageExplicit bool          `json:"languageExplicit,omitempty"`
+		STTModel         string        `json:"sttModel"`
+		Timeouts         StageTimeouts `json:"timeouts"`
+		// Prompt 初始 STT 提示（人名、專有名詞的寫法等），送往支援 prompt 的 provider。
+		Prompt string `json:"prompt,omitempty"`
+		// Glossary 此任務的詞彙表（產品名稱、術語、人名），空值時套用用戶的預設詞彙表。
+		Glossary []string `json:"glossary,omitempty"`
+		// Cleanup 切片前的音訊清理預設（highpass / denoise / phone / off），nil 沿用 Worker 設定。
+		Cleanup *string `json:"cleanup,omitempty"`
+		// Loudnorm 切片前是否做 EBU R128 響度正規化，nil 沿用 Worker 設定。
+		Loudnorm *bool `json:"loudnorm,omitempty"`
+		// Chunking 任務層級的切片參數覆寫。
+		Chunking ChunkingOptions `json:"chunking"`
+		// StereoSplit 雙聲道通話錄音分聲道轉錄並標記講者，nil 沿用 Worker 設定。
+		StereoSplit *bool `json:"stereoSplit,omitempty"`
+		// WebhookURL 任務終態（completed/failed/cancelled）時接收簽章通知的 callback URL。
+		WebhookURL string `json:"webhookUrl,omitempty"`
+		// BenchmarkProviders benchmark 任務比較的 provider 名稱，空值表示全部。
+		BenchmarkProviders []string `json:"benchmarkProviders,omitempty"`
+		// Reference benchmark 任務計算 WER 用的人工逐字稿。
+		Reference string `json:"reference,omitempty"`
 	} `json:"config"`
+	// Mode 任務類型：空值為一般轉錄，ModeBenchmark 比較多個 STT provider。
+	Mode string `json:"mode,omitempty"`
+	// NotBefore 排程任務：在此時間之前不處理（例如離峰時段批次轉錄）。
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// Canary 標記由 Worker 內部排程的監控任務，可改用獨立的 AI provider。
+	Canary bool `json:"canary,omitempty"`
+	// RetryCount 暫時性錯誤後已延遲重新投遞的次數。
+	RetryCount int `json:"retryCount,omitempty"`
 }
 
 // SummaryPayload summary:queue 中的任務訊息格式。
@@ -34,8 +78,108 @@ type SummaryPayload struct {
 	UserID     string `json:"userId"`
 	Transcript string `json:"transcript"`
 	Config     struct {
-		SummaryPrompt string `json:"summaryPrompt"`
+		SummaryPrompt string        `json:"summaryPrompt"`
+		Timeouts      StageTimeouts `json:"timeouts"`
+		// Persona 選用部署設定 SUMMARY_PERSONAS 中的人設名稱，未列於清單時忽略。
+		Persona string `json:"persona,omitempty"`
+		// Generation 覆寫部署的 LLM 取樣參數，未設定的欄位沿用部署設定。
+		Generation *GenerationParams `json:"generation,omitempty"`
+		// Structured 是否另外產生結構化摘要，nil 沿用部署設定 SUMMARY_STRUCTURED。
+		Structured *bool `json:"structured,omitempty"`
+		// SummaryLanguage 摘要輸出語言（例如 "zh-TW"、"en"），與轉錄稿語言無關；空值沿用部署設定 SUMMARY_LANGUAGE。
+		SummaryLanguage string `json:"summaryLanguage,omitempty"`
 	} `json:"config"`
+	Canary     bool `json:"canary,omitempty"`
+	RetryCount int  `json:"retryCount,omitempty"`
+	// GuardrailRetries 摘要未通過驗證而重新生成的次數。
+	GuardrailRetries int `json:"guardrailRetries,omitempty"`
+	// NotBefore 排程任務：在此時間之前不處理。
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// Notes 使用者標註（task_notes），附在 transcript 後供 LLM 參考。
+	Notes []TaskNote `json:"notes,omitempty"`
+	// Mode 空值為一般摘要，ModeSummaryRetry 為使用者要求的重新摘要。
+	Mode string `json:"mode,omitempty"`
+}
+
+// GenerationParams 任務指定的 LLM 取樣參數，欄位與 ai.GenerationParams 相同。
+type GenerationParams struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	MaxTokens        int      `json:"maxTokens,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+}
+
+// Moderation 內容審核未通過的結果（tasks.moderation）；Stage 為 transcript 或 summary。
+type Moderation struct {
+	Stage      string             `json:"stage"`
+	Categories []string           `json:"categories"`
+	Scores     map[string]float64 `json:"scores,omitempty"`
+}
+
+// TaskNote 使用者於音檔時間軸上的標註；highlight 以 StartSec~EndSec 標出片段。
+type TaskNote struct {
+	Kind     string   `json:"kind"`
+	StartSec float64  `json:"startSec"`
+	EndSec   *float64 `json:"endSec,omitempty"`
+	Body     string   `json:"body"`
+}
+
+// HighlightPayload highlight:queue 中的任務訊息格式：依 Clips 從原始音檔剪出 highlight reel。
+type HighlightPayload struct {
+	TaskID   string          `json:"taskId"`
+	UserID   string          `json:"userId"`
+	FilePath string          `json:"filePath"`
+	Clips    []HighlightClip `json:"clips"`
+}
+
+// HighlightClip highlight 片段（秒）。
+type HighlightClip struct {
+	StartSec float64 `json:"startSec"`
+	EndSec   float64 `json:"endSec"`
+}
+
+// TranscriptSegment 依 chunk 切分的轉錄段落與其語言（ISO-639-1），存於 task_results.segments。
+// 聲道分離模式另帶講者與在原始錄音中的時間（秒）。
+type TranscriptSegment struct {
+	Index    int     `json:"index"`
+	Language string  `json:"language,omitempty"`
+	Text     string  `json:"text"`
+	Speaker  string  `json:"speaker,omitempty"`
+	StartSec float64 `json:"startSec,omitempty"`
+	EndSec   float64 `json:"endSec,omitempty"`
+}
+
+// TimedText 帶有在原始錄音中起訖時間（秒）的一段或一個詞。
+type TimedText struct {
+	Text     string  `json:"text"`
+	StartSec float64 `json:"startSec"`
+	EndSec   float64 `json:"endSec"`
+}
+
+// TranscriptTimings 逐段 / 逐字時間戳，存於 task_results.timings，供字幕、章節與點擊跳轉使用。
+// provider 未回報逐字時間時 Words 為空。
+type TranscriptTimings struct {
+	Segments []TimedText `json:"segments"`
+	Words    []TimedText `json:"words,omitempty"`
+}
+
+// StageTimeouts 任務層級的階段 deadline 覆寫（秒），0 表示沿用 Worker 設定。
+// 僅能縮短 Worker 設定的上限。
+type StageTimeouts struct {
+	ChunkingSec int `json:"chunkingSec,omitempty"`
+	STTSec      int `json:"sttSec,omitempty"`
+	STTChunkSec int `json:"sttChunkSec,omitempty"`
+	SummarySec  int `json:"summarySec,omitempty"`
+}
+
+// ChunkingOptions 任務層級的切片參數覆寫，0 表示沿用 Worker 設定；
+// 分片長度與不切割門檻不可超過 Worker 設定的上限。
+type ChunkingOptions struct {
+	MaxChunkSec  float64 `json:"maxChunkSec,omitempty"`
+	OverlapSec   float64 `json:"overlapSec,omitempty"`
+	NoSplitBytes int64   `json:"noSplitBytes,omitempty"`
 }
 
 // TaskStatus 對應 DB tasks 表結構，用於查詢任務狀態。
@@ -50,12 +194,46 @@ type TaskStatus struct {
 }
 
 // SSEEvent 透過 Redis Pub/Sub 發布的統一事件格式，Gateway 接收後轉發至 SSE。
-// Type 可為 "progress", "transcript_update", "stt_completed", "summary_chunk", "completed", "failed", "cancelled"。
+// Type 可為 "progress", "transcript_update", "stt_completed", "summary_chunk", "completed", "failed", "cancelled", "flagged"。
+// EventID 為事件在 Redis Stream 中的 entry ID，僅在 Pub/Sub 訊息中帶出。
 type SSEEvent struct {
+	EventID  string `json:"eventId,omitempty"`
 	TaskID   string `json:"taskId"`
 	Type     string `json:"type"`
 	Status   string `json:"status,omitempty"`
 	Progress int    `json:"progress,omitempty"`
 	Message  string `json:"message,omitempty"`
 	Content  string `json:"content,omitempty"`
+	// ErrorCode 上傳驗證失敗的錯誤代碼（例如 ERR_UNSUPPORTED_FORMAT），僅 failed 事件帶出。
+	ErrorCode string `json:"errorCode,omitempty"`
+	// Trace 僅 completed 事件帶出。
+	Trace *ExecutionTrace `json:"trace,omitempty"`
+	// Audio 僅 audio_info 事件帶出。
+	Audio *AudioInfo `json:"audio,omitempty"`
+	// Language 僅 language_detected 事件帶出（ISO-639-1），Message 為偵測來源。
+	Language string `json:"language,omitempty"`
+}
+
+// AudioInfo 上傳錄音的格式資訊，存於 tasks.audio_* 欄位，供前端顯示與計費使用。
+type AudioInfo struct {
+	Format      string  `json:"format,omitempty"`
+	Codec       string  `json:"codec"`
+	DurationSec float64 `json:"durationSec,omitempty"`
+	SampleRate  int     `json:"sampleRate,omitempty"`
+	Channels    int     `json:"channels,omitempty"`
+	SizeBytes   int64   `json:"sizeBytes"`
+}
+
+// ExecutionTrace 提供給使用者的處理時間分解，存於 tasks.trace（JSONB）。
+// 只包含耗時、分段數與模型名稱，不含檔案路徑、Worker instance 等內部資訊。
+type ExecutionTrace struct {
+	QueuedMs       int64  `json:"queuedMs,omitempty"`
+	ChunkingMs     int64  `json:"chunkingMs,omitempty"`
+	Chunks         int    `json:"chunks,omitempty"`
+	TranscribeMs   int64  `json:"transcribeMs,omitempty"`
+	STTModel       string `json:"sttModel,omitempty"`
+	STTRetries     int    `json:"sttRetries,omitempty"`
+	SummaryMs      int64  `json:"summaryMs,omitempty"`
+	LLMModel       string `json:"llmModel,omitempty"`
+	SummaryRetries int    `json:"summaryRetries,omitempty"`
 }
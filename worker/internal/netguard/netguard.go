@@ -0,0 +1,41 @@
+// Package netguard 防止以使用者提供的 URL（Webhook、遠端音檔來源）探測內部服務（SSRF）。
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress 連線位址為 loopback / 內網 / link-local / multicast。
+var ErrPrivateAddress = errors.New("address is not public")
+
+// Control 作為 net.Dialer.Control，在連線前檢查實際連線的 IP（DNS 解析與每次轉址之後），拒絕非公開位址。
+func Control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// Transport 返回對外請求用的 http.Transport。allowPrivate 為 false 時每次連線都以 Control 檢查 IP，
+// 並且不使用 HTTP(S)_PROXY：經由 proxy 時 Control 只會看到 proxy 的位址，無法檢查實際目標。
+func Transport(dialTimeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = Control
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,241 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig Kafka backend 設定。
+type KafkaConfig struct {
+	Brokers []string
+	GroupID string // consumer group，所有 Worker 共用同一個 group 以分攤 partition
+
+	// Topic 宣告參數，EnsureTopics 建立不存在的 topic 時使用；0 / 空值沿用 broker 預設。
+	// 已存在的 topic 不會被修改。
+	Partitions        int
+	ReplicationFactor int
+	MinInSyncReplicas int           // 搭配 RequireAll 寫入，replica 不足時拒絕寫入而非靜默降級
+	Retention         time.Duration // retention.ms
+}
+
+// KafkaBroker 以 Kafka topic 作為佇列，Worker 透過 consumer group 分攤 partition。
+// Kafka 以 partition offset 追蹤進度，Ack 不直接 commit 該訊息：每個 partition 只 commit 從最早取出的訊息起
+// 連續完成的最後一個 offset，較早的訊息仍在處理中時，較晚完成的訊息等它完成後才一併 commit，
+// 確保 Worker 中止後未完成的訊息會重新投遞（已完成者可能重複投遞）。
+type KafkaBroker struct {
+	cfg    KafkaConfig
+	writer *kafka.Writer
+
+	mu      sync.Mutex
+	readers map[string]*kafka.Reader
+
+	// offsetMu 保護 offsets 與其 pending / done / ready；commit 本身不持有此鎖
+	offsetMu sync.Mutex
+	offsets  map[partitionKey]*partitionOffsets
+}
+
+// partitionKey 識別 topic 內的 partition。
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets 單一 partition 已取出但尚未確認的 offset（依取出順序遞增）與其是否已完成。
+// ready 為連續完成的最後一個 offset；commitMu 讓同一 partition 的 commit 依序送出，
+// committed 為已 commit 的 offset，避免較晚送出的較小 offset 覆寫較大的。
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]bool
+	ready   int64
+
+	commitMu  sync.Mutex
+	committed int64
+}
+
+// NewKafkaBroker 建立 Kafka backend。Reader 於首次 Consume 時建立。
+func NewKafkaBroker(cfg KafkaConfig) *KafkaBroker {
+	return &KafkaBroker{
+		cfg: cfg,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Balancer:               &kafka.LeastBytes{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		readers: make(map[string]*kafka.Reader),
+		offsets: make(map[partitionKey]*partitionOffsets),
+	}
+}
+
+// EnsureTopics 依設定建立佇列對應的 topic（已存在則略過），須連線至 controller 執行。
+func (b *KafkaBroker) EnsureTopics(ctx context.Context, queues ...string) error {
+	var d kafka.Dialer
+	conn, err := d.DialContext(ctx, "tcp", b.cfg.Brokers[0])
+	if err != nil {
+		return fmt.Errorf("kafka: dial: %w", err)
+	}
+	defer conn.Close()
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("kafka: find controller: %w", err)
+	}
+	cc, err := d.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("kafka: dial controller: %w", err)
+	}
+	defer cc.Close()
+
+	var entries []kafka.ConfigEntry
+	if b.cfg.MinInSyncReplicas > 0 {
+		entries = append(entries, kafka.ConfigEntry{ConfigName: "min.insync.replicas", ConfigValue: strconv.Itoa(b.cfg.MinInSyncReplicas)})
+	}
+	if b.cfg.Retention > 0 {
+		entries = append(entries, kafka.ConfigEntry{ConfigName: "retention.ms", ConfigValue: strconv.FormatInt(b.cfg.Retention.Milliseconds(), 10)})
+	}
+	topics := make([]kafka.TopicConfig, 0, len(queues))
+	for _, q := range queues {
+		topics = append(topics, kafka.TopicConfig{
+			Topic:             TopicName(q),
+			NumPartitions:     orDefault(b.cfg.Partitions),
+			ReplicationFactor: orDefault(b.cfg.ReplicationFactor),
+			ConfigEntries:     entries,
+		})
+	}
+	if err := cc.CreateTopics(topics...); err != nil {
+		return fmt.Errorf("kafka: create topics: %w", err)
+	}
+	return nil
+}
+
+// orDefault 0 轉為 -1，表示由 broker 的 num.partitions / default.replication.factor 決定。
+func orDefault(n int) int {
+	if n <= 0 {
+		return -1
+	}
+	return n
+}
+
+// TopicName 將佇列名稱轉換為合法的 Kafka topic（Kafka 不允許 ':'）：stt:queue → stt.queue。
+func TopicName(queue string) string {
+	return strings.ReplaceAll(queue, ":", ".")
+}
+
+// Publish 同步寫入 topic，等待所有 ISR 確認。
+func (b *KafkaBroker) Publish(ctx context.Context, queue string, body []byte) error {
+	return b.writer.WriteMessages(ctx, kafka.Message{Topic: TopicName(queue), Value: body})
+}
+
+// Consume 從 consumer group 取得下一筆訊息（不自動 commit）。
+func (b *KafkaBroker) Consume(ctx context.Context, queue string) (*Delivery, error) {
+	msg, err := b.reader(queue).FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.fetched(msg)
+	return &Delivery{Queue: queue, Body: msg.Value, handle: msg}, nil
+}
+
+// fetched 記錄取出的 offset。offset 未大於已記錄者表示 rebalance 後從已 commit 的位置重新投遞，
+// 先前未 commit 的紀錄作廢（那些訊息會再次取出）。
+func (b *KafkaBroker) fetched(msg kafka.Message) {
+	b.offsetMu.Lock()
+	defer b.offsetMu.Unlock()
+	key := partitionKey{msg.Topic, msg.Partition}
+	p := b.offsets[key]
+	if p == nil || (len(p.pending) > 0 && msg.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: make(map[int64]bool), ready: -1, committed: -1}
+		b.offsets[key] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+	p.done[msg.Offset] = false
+}
+
+// Depth 返回本 instance reader 所分配 partition 的 consumer lag。
+// 僅涵蓋本 instance 分配到的 partition，且在首次 fetch 之前為 0。
+func (b *KafkaBroker) Depth(ctx context.Context, queue string) (int64, error) {
+	return b.reader(queue).Stats().Lag, nil
+}
+
+// Ack 標記訊息完成，並 commit 該 partition 從最早取出的訊息起連續完成的最後一個 offset；
+// 更早的訊息仍在處理中時不 commit。commit 是對 broker 的網路請求，在 offsetMu 之外送出，
+// 不阻塞其他 partition 的 Ack 與 Consume。
+func (b *KafkaBroker) Ack(ctx context.Context, d *Delivery) error {
+	msg := d.handle.(kafka.Message)
+	p := b.complete(msg)
+	if p == nil {
+		return nil
+	}
+
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+	// 等待期間其他 Ack 可能已推進 ready，一次 commit 最新的位置
+	b.offsetMu.Lock()
+	target := p.ready
+	b.offsetMu.Unlock()
+	if target <= p.committed {
+		return nil
+	}
+	if err := b.reader(d.Queue).CommitMessages(ctx, kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: target}); err != nil {
+		return err
+	}
+	p.committed = target
+	return nil
+}
+
+// complete 標記 msg 完成並推進 ready；連續完成的位置有前進時返回該 partition 的紀錄，否則返回 nil。
+func (b *KafkaBroker) complete(msg kafka.Message) *partitionOffsets {
+	b.offsetMu.Lock()
+	defer b.offsetMu.Unlock()
+	p := b.offsets[partitionKey{msg.Topic, msg.Partition}]
+	if p == nil {
+		return nil
+	}
+	if _, ok := p.done[msg.Offset]; !ok {
+		// rebalance 後已作廢的紀錄，訊息會重新投遞
+		return nil
+	}
+	p.done[msg.Offset] = true
+	n := 0
+	for n < len(p.pending) && p.done[p.pending[n]] {
+		delete(p.done, p.pending[n])
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	p.ready = p.pending[n-1]
+	p.pending = p.pending[n:]
+	return p
+}
+
+// Close 關閉 writer 與所有 reader。
+func (b *KafkaBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, r := range b.readers {
+		r.Close()
+	}
+	return b.writer.Close()
+}
+
+func (b *KafkaBroker) reader(queue string) *kafka.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.readers[queue]
+	if !ok {
+		r = kafka.NewReader(kafka.ReaderConfig{
+			Brokers: b.cfg.Brokers,
+			GroupID: b.cfg.GroupID,
+			Topic:   TopicName(queue),
+		})
+		b.readers[queue] = r
+	}
+	return r
+}
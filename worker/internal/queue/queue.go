@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 任務佇列名稱（Redis LIST key；Kafka 等 backend 會轉換為合法的 topic 名稱）。
+const (
+	STT       = "stt:queue"
+	Summary   = "summary:queue"
+	Highlight = "highlight:queue"
+)
+
+// ErrClosed Broker 已關閉，Consume 不會再返回訊息。
+var ErrClosed = errors.New("queue: broker closed")
+
+// Delivery 從佇列取出的單一訊息。handle 由各 backend 存放 Ack 所需的資訊。
+type Delivery struct {
+	Queue  string
+	Body   []byte
+	handle any
+}
+
+// Broker 任務佇列抽象。語意為 at-least-once：
+// Consume 取出的訊息在 Ack 前若 Worker 中止，會由 backend 的機制（Redis Reaper / Kafka offset）重新投遞。
+type Broker interface {
+	// Publish 將訊息放入佇列。
+	Publish(ctx context.Context, queue string, body []byte) error
+	// Consume 阻塞直到取得下一筆訊息或 ctx 取消。
+	Consume(ctx context.Context, queue string) (*Delivery, error)
+	// Ack 確認訊息已處理完成（成功或已寫入終態），不再重新投遞。
+	Ack(ctx context.Context, d *Delivery) error
+	// Close 釋放 backend 連線。
+	Close() error
+}
+
+// DepthReporter 可回報佇列積壓量的 backend，供 autoscaler 依 backlog 擴縮 Worker。
+type DepthReporter interface {
+	// Depth 返回尚未被取出的訊息數（近似值）。
+	Depth(ctx context.Context, queue string) (int64, error)
+}
+
+// DelayedPublisher 支援延遲投遞的 backend（Redis、SQS），供暫時性錯誤的延遲重試使用。
+type DelayedPublisher interface {
+	// PublishDelayed 將訊息於 delay 之後放入佇列。
+	PublishDelayed(ctx context.Context, queue string, body []byte, delay time.Duration) error
+}
@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroker 以 Redis LIST 作為 FIFO 佇列：LPUSH 入列（與 API Service、Gateway 相同）、BRPOP 自另一端取出，
+// 取出後 ZADD 至 {name}:processing ZSET（score 為取出時間）供 Reaper 回收超時任務，Ack 時 ZREM。
+// 延遲訊息暫存於 {name}:delayed ZSET（score 為到期時間），由 RunDelayed 到期後移入佇列。
+type RedisBroker struct {
+	rdb *redis.Client
+}
+
+// NewRedisBroker 建立 Redis backend。
+func NewRedisBroker(rdb *redis.Client) *RedisBroker {
+	return &RedisBroker{rdb: rdb}
+}
+
+// ProcessingKey 返回佇列對應的 processing ZSET key（stt:queue → stt:processing）。
+func ProcessingKey(queue string) string {
+	return strings.TrimSuffix(queue, ":queue") + ":processing"
+}
+
+// DelayedKey 返回佇列對應的延遲 ZSET key（stt:queue → stt:delayed）。
+func DelayedKey(queue string) string {
+	return strings.TrimSuffix(queue, ":queue") + ":delayed"
+}
+
+// promoteScript 原子執行「取出到期訊息 → ZREM → LPUSH」，多個 Worker 同時執行也不會重複投遞。
+// KEYS[1] = delayed ZSET, KEYS[2] = queue, ARGV[1] = now Unix timestamp
+var promoteScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '0', ARGV[1], 'LIMIT', 0, 100)
+for _, member in ipairs(members) do
+    redis.call('ZREM', KEYS[1], member)
+    redis.call('LPUSH', KEYS[2], member)
+end
+return #members
+`)
+
+// Publish LPUSH 至佇列。
+func (b *RedisBroker) Publish(ctx context.Context, queue string, body []byte) error {
+	return b.rdb.LPush(ctx, queue, body).Err()
+}
+
+// PublishDelayed ZADD 至延遲 ZSET，到期後由 RunDelayed 移入佇列。
+func (b *RedisBroker) PublishDelayed(ctx context.Context, queue string, body []byte, delay time.Duration) error {
+	return b.rdb.ZAdd(ctx, DelayedKey(queue), redis.Z{
+		Score:  float64(time.Now().Add(delay).Unix()),
+		Member: body,
+	}).Err()
+}
+
+// RunDelayed 每秒將到期的延遲訊息移入佇列，到 ctx 取消時退出。
+func (b *RedisBroker) RunDelayed(ctx context.Context, queue string) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().Unix()
+			if err := promoteScript.Run(ctx, b.rdb, []string{DelayedKey(queue), queue}, now).Err(); err != nil && ctx.Err() == nil {
+				log.Printf("RunDelayed (%s): promote failed: %v", queue, err)
+			}
+		}
+	}
+}
+
+// Depth 返回佇列 LIST 長度。
+func (b *RedisBroker) Depth(ctx context.Context, queue string) (int64, error) {
+	return b.rdb.LLen(ctx, queue).Result()
+}
+
+// Consume BRPOP 取出最早入列的訊息並登記至 processing ZSET。
+func (b *RedisBroker) Consume(ctx context.Context, queue string) (*Delivery, error) {
+	result, err := b.rdb.BRPop(ctx, 0, queue).Result()
+	if err != nil {
+		return nil, err
+	}
+	raw := result[1]
+	b.rdb.ZAdd(ctx, ProcessingKey(queue), redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: raw,
+	})
+	return &Delivery{Queue: queue, Body: []byte(raw), handle: raw}, nil
+}
+
+// Ack 自 processing ZSET 移除訊息。
+func (b *RedisBroker) Ack(ctx context.Context, d *Delivery) error {
+	return b.rdb.ZRem(ctx, ProcessingKey(d.Queue), d.handle.(string)).Err()
+}
+
+// Close Redis 連線由呼叫端管理，此處不關閉。
+func (b *RedisBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,229 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQSConfig SQS backend 設定。
+type SQSConfig struct {
+	Region string
+	// Endpoint 自訂端點（例如 LocalStack），空字串使用 AWS 預設。
+	Endpoint string
+	// VisibilityTimeout 訊息取出後對其他 consumer 隱藏的時間；處理中會每 VisibilityTimeout/2 延長一次。
+	VisibilityTimeout time.Duration
+	// DLQArn 非空時於啟動時設定 RedrivePolicy：收取超過 MaxReceiveCount 次的訊息移至 DLQ。
+	DLQArn          string
+	MaxReceiveCount int
+}
+
+// SQSBroker 以 AWS SQS 作為佇列。
+// 處理中的訊息由背景 goroutine 持續延長 visibility timeout，Ack 時刪除訊息並停止延長；
+// Worker 中止時訊息於 timeout 後重新可見，重複失敗超過 MaxReceiveCount 次則由 SQS 移至 DLQ。
+type SQSBroker struct {
+	client *sqs.Client
+	cfg    SQSConfig
+
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+// sqsHandle Ack 所需的資訊與 visibility 延長 goroutine 的停止函數。
+type sqsHandle struct {
+	url     string
+	receipt string
+	stop    context.CancelFunc
+}
+
+// NewSQSBroker 以 AWS 預設憑證鏈（環境變數 / IAM role）建立 SQS backend。
+func NewSQSBroker(ctx context.Context, cfg SQSConfig) (*SQSBroker, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("NewSQSBroker: load aws config: %w", err)
+	}
+	client := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+	return &SQSBroker{client: client, cfg: cfg, urls: make(map[string]string)}, nil
+}
+
+// SQSQueueName 將佇列名稱轉換為合法的 SQS 名稱（僅允許英數、- 與 _）：stt:queue → stt-queue。
+func SQSQueueName(queue string) string {
+	return strings.ReplaceAll(queue, ":", "-")
+}
+
+// queueURL 取得並快取佇列 URL；首次取得時套用 DLQ RedrivePolicy。
+func (b *SQSBroker) queueURL(ctx context.Context, queue string) (string, error) {
+	b.mu.Lock()
+	url, ok := b.urls[queue]
+	b.mu.Unlock()
+	if ok {
+		return url, nil
+	}
+
+	out, err := b.client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(SQSQueueName(queue))})
+	if err != nil {
+		return "", fmt.Errorf("sqs: get queue url for %s: %w", queue, err)
+	}
+	url = aws.ToString(out.QueueUrl)
+
+	if b.cfg.DLQArn != "" {
+		policy, _ := json.Marshal(map[string]any{
+			"deadLetterTargetArn": b.cfg.DLQArn,
+			"maxReceiveCount":     b.cfg.MaxReceiveCount,
+		})
+		_, err := b.client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+			QueueUrl:   aws.String(url),
+			Attributes: map[string]string{string(types.QueueAttributeNameRedrivePolicy): string(policy)},
+		})
+		if err != nil {
+			log.Printf("sqs: set redrive policy on %s: %v", queue, err)
+		}
+	}
+
+	b.mu.Lock()
+	b.urls[queue] = url
+	b.mu.Unlock()
+	return url, nil
+}
+
+// Publish SendMessage 至佇列。
+func (b *SQSBroker) Publish(ctx context.Context, queue string, body []byte) error {
+	url, err := b.queueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(url),
+		MessageBody: aws.String(string(body)),
+	})
+	return err
+}
+
+// sqsMaxDelay SQS DelaySeconds 上限。
+const sqsMaxDelay = 15 * time.Minute
+
+// PublishDelayed 以 DelaySeconds 延遲投遞，超過 15 分鐘的延遲以上限計。
+func (b *SQSBroker) PublishDelayed(ctx context.Context, queue string, body []byte, delay time.Duration) error {
+	url, err := b.queueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(url),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: int32(min(delay, sqsMaxDelay).Seconds()),
+	})
+	return err
+}
+
+// Depth 返回 ApproximateNumberOfMessages（可見、尚未被取出的訊息數）。
+func (b *SQSBroker) Depth(ctx context.Context, queue string) (int64, error) {
+	url, err := b.queueURL(ctx, queue)
+	if err != nil {
+		return 0, err
+	}
+	out, err := b.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(url),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("sqs: get queue attributes for %s: %w", queue, err)
+	}
+	return strconv.ParseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)], 10, 64)
+}
+
+// Consume 以 long polling（20s）等待下一筆訊息，取得後啟動 visibility 延長。
+func (b *SQSBroker) Consume(ctx context.Context, queue string) (*Delivery, error) {
+	url, err := b.queueURL(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		out, err := b.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(url),
+			MaxNumberOfMessages: 1,
+			WaitTimeSeconds:     20,
+			VisibilityTimeout:   int32(b.cfg.VisibilityTimeout.Seconds()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(out.Messages) == 0 {
+			continue
+		}
+
+		msg := out.Messages[0]
+		extendCtx, stop := context.WithCancel(context.Background())
+		h := &sqsHandle{url: url, receipt: aws.ToString(msg.ReceiptHandle), stop: stop}
+		go b.keepInvisible(extendCtx, h)
+		return &Delivery{Queue: queue, Body: []byte(aws.ToString(msg.Body)), handle: h}, nil
+	}
+}
+
+// keepInvisible 每 VisibilityTimeout/2 延長一次，直到 Ack 停止。
+func (b *SQSBroker) keepInvisible(ctx context.Context, h *sqsHandle) {
+	ticker := time.NewTicker(b.cfg.VisibilityTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := b.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(h.url),
+				ReceiptHandle:     aws.String(h.receipt),
+				VisibilityTimeout: int32(b.cfg.VisibilityTimeout.Seconds()),
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("sqs: extend visibility: %v", err)
+			}
+		}
+	}
+}
+
+// Ack 停止延長並刪除訊息。
+func (b *SQSBroker) Ack(ctx context.Context, d *Delivery) error {
+	h := d.handle.(*sqsHandle)
+	h.stop()
+	_, err := b.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(h.url),
+		ReceiptHandle: aws.String(h.receipt),
+	})
+	return err
+}
+
+// RedriveDLQ 將 DLQ 中的訊息移回原佇列（修復 provider 問題後手動執行）。
+func (b *SQSBroker) RedriveDLQ(ctx context.Context) (string, error) {
+	if b.cfg.DLQArn == "" {
+		return "", fmt.Errorf("sqs: DLQ arn not configured")
+	}
+	out, err := b.client.StartMessageMoveTask(ctx, &sqs.StartMessageMoveTaskInput{
+		SourceArn: aws.String(b.cfg.DLQArn),
+	})
+	if err != nil {
+		return "", fmt.Errorf("sqs: start message move task: %w", err)
+	}
+	return aws.ToString(out.TaskHandle), nil
+}
+
+// Close SQS client 無需關閉。
+func (b *SQSBroker) Close() error {
+	return nil
+}
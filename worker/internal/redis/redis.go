@@ -2,29 +2,116 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// Connect 建立 Redis 連線，透過 docker bridge network 連接。
-func Connect() *redis.Client {
-	return redis.NewClient(&redis.Options{
+// 事件 Stream 設定：每個任務保留最近 streamMaxLen 筆事件，最後一次寫入後 streamTTL 過期。
+var (
+	streamMaxLen int64 = 1000
+	streamTTL          = time.Hour
+)
+
+// ConfigureEventStream 調整事件 Stream 的長度上限與 TTL。
+func ConfigureEventStream(maxLen int64, ttl time.Duration) {
+	streamMaxLen = maxLen
+	streamTTL = ttl
+}
+
+// Connect 建立 Redis 連線，預設透過 docker bridge network 以 REDIS_HOST / REDIS_PORT 連接。
+// REDIS_URL（redis:// 或 rediss://，可含帳密與 DB 編號）優先；rediss:// 或 REDIS_TLS=true 啟用 TLS，
+// 並可以 REDIS_TLS_CA_FILE / REDIS_TLS_CERT_FILE / REDIS_TLS_KEY_FILE 指定自訂 CA 與 client 憑證。
+func Connect() (*redis.Client, error) {
+	opts := &redis.Options{
 		Addr: fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")),
-	})
+	}
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		parsed, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("Connect: parse REDIS_URL: %w", err)
+		}
+		opts = parsed
+	}
+
+	if opts.TLSConfig == nil && os.Getenv("REDIS_TLS") == "true" {
+		host, _, _ := net.SplitHostPort(opts.Addr)
+		opts.TLSConfig = &tls.Config{ServerName: host}
+	}
+	if opts.TLSConfig != nil {
+		opts.TLSConfig.MinVersion = tls.VersionTLS12
+		if err := loadTLSFiles(opts.TLSConfig); err != nil {
+			return nil, fmt.Errorf("Connect: %w", err)
+		}
+	}
+
+	if d, err := time.ParseDuration(os.Getenv("REDIS_DIAL_TIMEOUT")); err == nil {
+		opts.DialTimeout = d
+	}
+	return redis.NewClient(opts), nil
+}
+
+// loadTLSFiles 載入自訂 CA 與 client 憑證（mTLS），未設定的項目沿用系統預設。
+func loadTLSFiles(cfg *tls.Config) error {
+	if caFile := os.Getenv("REDIS_TLS_CA_FILE"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	certFile, keyFile := os.Getenv("REDIS_TLS_CERT_FILE"), os.Getenv("REDIS_TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return nil
 }
 
-// PublishProgress 將進度事件發布至 Redis Pub/Sub channel（progress:{taskID}）。
-// Gateway 訂閱該 channel 後即時推送 SSE 至前端。
-func PublishProgress(rdb *redis.Client, ctx context.Context, taskID string, progress interface{}) error {
-	payload, _ := json.Marshal(progress)
-	return rdb.Publish(ctx, fmt.Sprintf("progress:%s", taskID), payload).Err()
+// PublishProgress 先將事件 XADD 至任務 Stream（keys.Events）保存，
+// 再帶上 Stream entry ID（eventId）發布至 Pub/Sub channel（keys.Progress）。
+// Gateway 訂閱 channel 即時推送 SSE，並以 eventId 作為 SSE id，供 Last-Event-ID 重連補送。
+// Stream 寫入失敗時仍發布 Pub/Sub，只是該事件無法被補送。
+func PublishProgress(rdb *redis.Client, ctx context.Context, taskID string, event models.SSEEvent) error {
+	data, _ := json.Marshal(event)
+	key := keys.Events(taskID)
+
+	var add *redis.StringCmd
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		add = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: streamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"data": data},
+		})
+		pipe.Expire(ctx, key, streamTTL)
+		return nil
+	})
+	if err == nil {
+		event.EventID = add.Val()
+		data, _ = json.Marshal(event)
+	}
+
+	return rdb.Publish(ctx, keys.Progress(taskID), data).Err()
 }
 
 // SubscribeToCancellations 訂閱 cancel_channel，接收 API Service 發出的取消信號。
 // Worker 收到信號後透過 context.Cancel() 終止進行中的 STT/LLM 作業。
 func SubscribeToCancellations(rdb *redis.Client, ctx context.Context) *redis.PubSub {
-	return rdb.Subscribe(ctx, "cancel_channel")
+	return rdb.Subscribe(ctx, keys.CancelChannel())
 }
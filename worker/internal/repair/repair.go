@@ -0,0 +1,181 @@
+package repair
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"tts-worker/internal/audit"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Options repair 指令參數。
+type Options struct {
+	DryRun    bool   // 只回報不修改
+	UploadDir string // 上傳音檔根目錄（{UploadDir}/{userId}/{taskId}/chunks）
+	// ScratchDir Worker 的暫存根目錄（{ScratchDir}/{taskId}），空值表示分片寫在上傳目錄。
+	ScratchDir string
+}
+
+// Report 掃描結果統計。
+type Report struct {
+	ResetToSTTCompleted []string // completed 但缺 summary、仍有 transcript → 退回 stt_completed 可重新摘要
+	MarkedFailed        []string // 缺 transcript 的 completed / stt_completed → failed
+	OrphanedResults     int64
+	OutboxChecked       bool
+	OutboxMissing       []string // 僅回報：outbox 已 sent 但缺 summary 的任務
+	RemovedChunkDirs    []string
+}
+
+// Run 掃描並修復 DB / Redis / 磁碟之間的不一致狀態，事故後由維運手動執行。
+// 每一類檢查獨立執行，單項失敗不影響其他項目，最後合併返回錯誤。
+func Run(ctx context.Context, postgres *sql.DB, rdb *redis.Client, opts Options) (*Report, error) {
+	report := &Report{}
+	var errs []error
+
+	if err := repairMissingResults(ctx, postgres, rdb, opts, report); err != nil {
+		errs = append(errs, err)
+	}
+
+	n, err := db.DeleteOrphanedResults(postgres, opts.DryRun)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	report.OrphanedResults = n
+	if !opts.DryRun && n > 0 {
+		audit.Record(audit.Event{
+			Action:  "data.delete_orphaned_results",
+			Outcome: audit.OutcomeSuccess,
+			Actor:   "repair",
+			Details: map[string]string{"count": strconv.FormatInt(n, 10)},
+		})
+	}
+
+	ids, checked, err := db.FindSentOutboxWithoutSummary(postgres)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	report.OutboxChecked = checked
+	report.OutboxMissing = ids
+
+	if opts.UploadDir != "" {
+		if err := removeLeftoverChunks(postgres, opts, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+func repairMissingResults(ctx context.Context, postgres *sql.DB, rdb *redis.Client, opts Options, report *Report) error {
+	tasks, err := db.FindCompletedWithoutResults(postgres)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		target, msg := models.StatusFailed, "repair: transcript missing"
+		if t.Status == models.StatusCompleted && t.HasTranscript {
+			target, msg = models.StatusSttCompleted, ""
+		}
+		if target == models.StatusSttCompleted {
+			report.ResetToSTTCompleted = append(report.ResetToSTTCompleted, t.ID)
+		} else {
+			report.MarkedFailed = append(report.MarkedFailed, t.ID)
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := db.SetTaskStatus(postgres, t.ID, target, msg); err != nil {
+			return err
+		}
+		// API Service 以 Redis live 狀態為主，需同步更新避免讀到舊值
+		rdb.HSet(ctx, keys.Task(t.ID), "status", target)
+	}
+	return nil
+}
+
+// removeLeftoverChunks 刪除已結束（或不存在）任務殘留的 chunks 目錄與暫存目錄；進行中任務的目錄保留。
+func removeLeftoverChunks(postgres *sql.DB, opts Options, report *Report) error {
+	dirs, err := filepath.Glob(filepath.Join(opts.UploadDir, "*", "*", "chunks"))
+	if err != nil {
+		return fmt.Errorf("removeLeftoverChunks: %w", err)
+	}
+	// dir → taskID：上傳目錄下為 {taskId}/chunks，暫存根目錄下為 {taskId}
+	owners := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		owners[dir] = filepath.Base(filepath.Dir(dir))
+	}
+	if opts.ScratchDir != "" {
+		scratch, err := filepath.Glob(filepath.Join(opts.ScratchDir, "*"))
+		if err != nil {
+			return fmt.Errorf("removeLeftoverChunks: %w", err)
+		}
+		for _, dir := range scratch {
+			if st, err := os.Stat(dir); err == nil && st.IsDir() {
+				dirs = append(dirs, dir)
+				owners[dir] = filepath.Base(dir)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		taskID := owners[dir]
+		status, err := db.GetTaskStatus(postgres, taskID)
+		if err != nil && !errors.Is(err, db.ErrTaskNotFound) {
+			log.Printf("repair: skip %s: %v", dir, err)
+			continue
+		}
+		if err == nil && !isTerminal(status) {
+			continue
+		}
+		report.RemovedChunkDirs = append(report.RemovedChunkDirs, dir)
+		if opts.DryRun {
+			continue
+		}
+		err = os.RemoveAll(dir)
+		e := audit.Event{Action: "data.delete_chunks", Outcome: audit.OutcomeSuccess, Actor: "repair", Target: taskID}
+		if err != nil {
+			e.Outcome = audit.OutcomeFailure
+			e.Details = map[string]string{"error": err.Error()}
+		}
+		audit.Record(e)
+		if err != nil {
+			return fmt.Errorf("removeLeftoverChunks: %w", err)
+		}
+	}
+	return nil
+}
+
+// isTerminal 判斷 DB 狀態是否代表不會再有 Worker 讀取 chunks。
+// stt_completed 之後只剩 Summary 階段，不再需要音檔分片。
+func isTerminal(status string) bool {
+	switch status {
+	case models.StatusSttCompleted, models.StatusCompleted, models.StatusCompletedNoSummary, models.StatusFailed, models.StatusCancelled, models.StatusFlagged:
+		return true
+	}
+	return false
+}
+
+// Print 將報告輸出至 log。
+func (r *Report) Print(dryRun bool) {
+	verb := "fixed"
+	if dryRun {
+		verb = "found (dry run)"
+	}
+	log.Printf("repair: completed→stt_completed %s: %d %v", verb, len(r.ResetToSTTCompleted), r.ResetToSTTCompleted)
+	log.Printf("repair: missing transcript→failed %s: %d %v", verb, len(r.MarkedFailed), r.MarkedFailed)
+	log.Printf("repair: orphaned task_results %s: %d", verb, r.OrphanedResults)
+	if r.OutboxChecked {
+		log.Printf("repair: sent outbox events without summary (report only): %d %v", len(r.OutboxMissing), r.OutboxMissing)
+	} else {
+		log.Println("repair: outbox_events table not present, skipped")
+	}
+	log.Printf("repair: leftover chunk dirs %s: %d %v", verb, len(r.RemovedChunkDirs), r.RemovedChunkDirs)
+}
@@ -0,0 +1,158 @@
+package textnorm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const zhNum = `[零〇一二兩两三四五六七八九十百千萬万億亿]`
+
+var (
+	zhPercent  = regexp.MustCompile(`百分之(` + zhNum + `+)`)
+	zhYear     = regexp.MustCompile(`([零〇一二三四五六七八九]{4})年`)
+	zhMonthDay = regexp.MustCompile(`(` + zhNum + `{1,3})月(` + zhNum + `{1,3})[日號号]`)
+	zhMonth    = regexp.MustCompile(`(` + zhNum + `{1,3})月份`)
+	zhTime     = regexp.MustCompile(`(上午|下午|早上|晚上|中午|凌晨|傍晚)?(` + zhNum + `{1,3})[點点](半|整|(` + zhNum + `{1,3})分)?`)
+	zhNumber   = regexp.MustCompile(zhNum + `+`)
+)
+
+// zhIdioms 含數字字元但非數量的常用詞，整段比對時不轉換。
+var zhIdioms = map[string]bool{
+	"十分": true, "千萬": true, "萬一": true, "万一": true, "萬萬": true, "万万": true, "百百": true,
+}
+
+func normalizeChinese(text string) string {
+	text = zhPercent.ReplaceAllStringFunc(text, func(m string) string {
+		n, ok := parseZhNumber(zhPercent.FindStringSubmatch(m)[1])
+		if !ok {
+			return m
+		}
+		return strconv.FormatInt(n, 10) + "%"
+	})
+	text = zhYear.ReplaceAllStringFunc(text, func(m string) string {
+		return zhDigits(strings.TrimSuffix(m, "年")) + "年"
+	})
+	text = zhMonthDay.ReplaceAllStringFunc(text, func(m string) string {
+		sub := zhMonthDay.FindStringSubmatch(m)
+		month, ok1 := parseZhNumber(sub[1])
+		day, ok2 := parseZhNumber(sub[2])
+		if !ok1 || !ok2 || month < 1 || month > 12 || day < 1 || day > 31 {
+			return m
+		}
+		return fmt.Sprintf("%d月%d日", month, day)
+	})
+	text = zhMonth.ReplaceAllStringFunc(text, func(m string) string {
+		month, ok := parseZhNumber(zhMonth.FindStringSubmatch(m)[1])
+		if !ok || month < 1 || month > 12 {
+			return m
+		}
+		return fmt.Sprintf("%d月份", month)
+	})
+	text = zhTime.ReplaceAllStringFunc(text, normalizeZhTime)
+	return zhNumber.ReplaceAllStringFunc(text, func(m string) string {
+		// 需帶位數（十百千萬億）且以數字或「十」開頭，單一數字（一起、第一、三心二意）不轉換
+		if len([]rune(m)) < 2 || zhIdioms[m] || !strings.ContainsAny(m, "十百千萬万億亿") || strings.ContainsAny(string([]rune(m)[0]), "百千萬万億亿") {
+			return m
+		}
+		n, ok := parseZhNumber(m)
+		if !ok {
+			return m
+		}
+		return strconv.FormatInt(n, 10)
+	})
+}
+
+// normalizeZhTime 「下午三點半」→「下午3:30」；沒有時段也沒有分鐘的「三點」可能是「三點意見」，不轉換。
+func normalizeZhTime(m string) string {
+	sub := zhTime.FindStringSubmatch(m)
+	period, hourText, suffix, minuteText := sub[1], sub[2], sub[3], sub[4]
+	if period == "" && suffix == "" {
+		return m
+	}
+	hour, ok := parseZhNumber(hourText)
+	if !ok || hour > 24 {
+		return m
+	}
+	switch {
+	case suffix == "半":
+		return fmt.Sprintf("%s%d:30", period, hour)
+	case minuteText != "":
+		minute, ok := parseZhNumber(minuteText)
+		if !ok || minute > 59 {
+			return m
+		}
+		return fmt.Sprintf("%s%d:%02d", period, hour, minute)
+	case suffix == "整":
+		return fmt.Sprintf("%s%d:00", period, hour)
+	}
+	return fmt.Sprintf("%s%d點", period, hour)
+}
+
+var zhDigitValues = map[rune]int64{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '兩': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+var zhUnitValues = map[rune]int64{'十': 10, '百': 100, '千': 1000}
+
+var zhBigUnitValues = map[rune]int64{'萬': 1e4, '万': 1e4, '億': 1e8, '亿': 1e8}
+
+// zhDigits 逐字轉換（「二零二四」→「2024」）。
+func zhDigits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if d, ok := zhDigitValues[r]; ok {
+			sb.WriteString(strconv.FormatInt(d, 10))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// parseZhNumber 解析中文數字（「三百五十」、「兩萬三」、「十二」），不含位數時視為逐字數字（「一二三」→ 123）。
+func parseZhNumber(s string) (int64, bool) {
+	if !strings.ContainsAny(s, "十百千萬万億亿") {
+		n, err := strconv.ParseInt(zhDigits(s), 10, 64)
+		return n, err == nil
+	}
+	var total, section, num, lastUnit int64
+	pendingDigit := false
+	for _, r := range s {
+		if d, ok := zhDigitValues[r]; ok {
+			num = d
+			pendingDigit = true
+			continue
+		}
+		if u, ok := zhUnitValues[r]; ok {
+			if !pendingDigit {
+				if u != 10 {
+					return 0, false
+				}
+				num = 1 // 「十二」省略「一」
+			}
+			section += num * u
+			num, pendingDigit, lastUnit = 0, false, u
+			continue
+		}
+		if u, ok := zhBigUnitValues[r]; ok {
+			section += num
+			if section == 0 {
+				return 0, false
+			}
+			total += section * u
+			section, num, pendingDigit, lastUnit = 0, 0, false, u
+			continue
+		}
+		return 0, false
+	}
+	if pendingDigit {
+		// 口語省略末位單位：「三百五」= 350、「兩萬三」= 23000
+		if lastUnit >= 10 && num != 0 && !strings.ContainsAny(string([]rune(s)[len([]rune(s))-2]), "零〇") {
+			num *= lastUnit / 10
+		}
+		section += num
+	}
+	return total + section, true
+}
@@ -0,0 +1,180 @@
+package textnorm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var enSmall = map[string]int64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9,
+	"ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16,
+	"seventeen": 17, "eighteen": 18, "nineteen": 19,
+}
+
+var enTens = map[string]int64{
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+var enScales = map[string]int64{"hundred": 100, "thousand": 1e3, "million": 1e6, "billion": 1e9}
+
+var enOrdinals = map[string]int64{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9,
+	"tenth": 10, "eleventh": 11, "twelfth": 12, "thirteenth": 13, "fourteenth": 14, "fifteenth": 15,
+	"sixteenth": 16, "seventeenth": 17, "eighteenth": 18, "nineteenth": 19, "twentieth": 20, "thirtieth": 30,
+}
+
+const enMonths = `January|February|March|April|May|June|July|August|September|October|November|December`
+
+var (
+	// 「March fifth」、「March the twenty-first」
+	enMonthOrdinal = regexp.MustCompile(`\b(` + enMonths + `)\s+(?:the\s+)?((?:twenty|thirty)[\s-])?([A-Za-z]+)\b`)
+	// 「the fifth of March」
+	enOrdinalOfMonth = regexp.MustCompile(`(?i)\bthe\s+((?:twenty|thirty)[\s-])?([a-z]+)\s+of\s+(` + enMonths + `)\b`)
+	enWordToken      = regexp.MustCompile(`[A-Za-z]+(?:-[A-Za-z]+)?`)
+)
+
+func normalizeEnglish(text string) string {
+	text = enMonthOrdinal.ReplaceAllStringFunc(text, func(m string) string {
+		sub := enMonthOrdinal.FindStringSubmatch(m)
+		day, ok := ordinalDay(sub[2], sub[3])
+		if !ok {
+			return m
+		}
+		return sub[1] + " " + strconv.FormatInt(day, 10)
+	})
+	text = enOrdinalOfMonth.ReplaceAllStringFunc(text, func(m string) string {
+		sub := enOrdinalOfMonth.FindStringSubmatch(m)
+		day, ok := ordinalDay(sub[1], sub[2])
+		if !ok {
+			return m
+		}
+		return sub[3] + " " + strconv.FormatInt(day, 10)
+	})
+	return replaceNumberWords(text)
+}
+
+// ordinalDay 解析日期序數（「fifth」、「twenty-first」），超出 1~31 時返回 false。
+func ordinalDay(tens, word string) (int64, bool) {
+	day, ok := enOrdinals[strings.ToLower(word)]
+	if !ok {
+		return 0, false
+	}
+	if tens != "" {
+		if day >= 10 {
+			return 0, false
+		}
+		day += enTens[strings.ToLower(strings.TrimRight(tens, " -"))]
+	}
+	return day, day >= 1 && day <= 31
+}
+
+// replaceNumberWords 將連續的英文數字詞轉為阿拉伯數字。小於 10 的數字依書寫慣例保留英文（「one of them」），
+// 但後接 percent 時一律轉為「N%」。
+func replaceNumberWords(text string) string {
+	locs := enWordToken.FindAllStringIndex(text, -1)
+	var sb strings.Builder
+	last := 0
+	for i := 0; i < len(locs); {
+		end, value, ok := parseNumberRun(text, locs, i)
+		if !ok {
+			i++
+			continue
+		}
+		start, stop := locs[i][0], locs[end-1][1]
+		replacement := strconv.FormatInt(value, 10)
+		if end < len(locs) && strings.EqualFold(text[locs[end][0]:locs[end][1]], "percent") && onlySpaces(text[stop:locs[end][0]]) {
+			replacement += "%"
+			stop = locs[end][1]
+			end++
+		} else if value < 10 {
+			i = end
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(replacement)
+		last = stop
+		i = end
+	}
+	if last == 0 {
+		return text
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// parseNumberRun 自 locs[i] 起解析以空白分隔的數字詞（可含 and、連字號），返回結束位置與數值。
+// 個位數之後不再接數字詞，「one two three」視為三個數字而非相加。
+func parseNumberRun(text string, locs [][]int, i int) (int, int64, bool) {
+	var total, current int64
+	end := i
+	seen, afterScale, afterTens := false, false, false
+	for j := i; j < len(locs); j++ {
+		if j > i && !onlySpaces(text[locs[j-1][1]:locs[j][0]]) {
+			break
+		}
+		word := strings.ToLower(text[locs[j][0]:locs[j][1]])
+		if word == "and" {
+			// 只接受「one hundred and five」形式
+			if !afterScale {
+				break
+			}
+			continue
+		}
+		v, ok := numberWord(word)
+		if !ok {
+			break
+		}
+		if scale, isScale := enScales[word]; isScale {
+			if !seen {
+				return i, 0, false
+			}
+			if scale >= 1000 {
+				total += current * scale
+				current = 0
+			} else {
+				current *= scale
+			}
+			afterScale, afterTens = true, false
+		} else {
+			if seen && !afterScale && !(afterTens && v < 10) {
+				break
+			}
+			current += v
+			afterScale, afterTens = false, v >= 20 && v%10 == 0
+		}
+		seen = true
+		end = j + 1
+	}
+	if !seen {
+		return i, 0, false
+	}
+	return end, total + current, true
+}
+
+// numberWord 解析單一數字詞，含連字號複合詞（「twenty-one」）。
+func numberWord(word string) (int64, bool) {
+	if tens, ones, ok := strings.Cut(word, "-"); ok {
+		t, ok1 := enTens[tens]
+		o, ok2 := enSmall[ones]
+		if !ok1 || !ok2 || o == 0 || o >= 10 {
+			return 0, false
+		}
+		return t + o, true
+	}
+	if v, ok := enSmall[word]; ok {
+		return v, true
+	}
+	if v, ok := enTens[word]; ok {
+		return v, true
+	}
+	if _, ok := enScales[word]; ok {
+		return 0, true
+	}
+	return 0, false
+}
+
+func onlySpaces(s string) bool {
+	return s != "" && strings.TrimFunc(s, unicode.IsSpace) == ""
+}
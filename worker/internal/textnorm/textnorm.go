@@ -0,0 +1,18 @@
+// Package textnorm 轉錄稿後處理：將口語數字轉為阿拉伯數字，並統一日期、時間與百分比的寫法，
+// 讓摘要與待辦事項的期限更可靠。只處理語意明確的情況（帶位數、日期 / 時間 / 百分比上下文），
+// 成語與慣用語（十分、千萬、萬一、一起）維持原樣。
+package textnorm
+
+// Normalize 依語言（ISO-639-1，例如 "zh"、"en"）正規化 text；語言未知時中英文規則皆套用，
+// 兩者只作用於各自的文字，不會互相影響。
+func Normalize(text, lang string) string {
+	switch lang {
+	case "zh":
+		return normalizeChinese(text)
+	case "en":
+		return normalizeEnglish(text)
+	case "":
+		return normalizeEnglish(normalizeChinese(text))
+	}
+	return text
+}
@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/netguard"
+)
+
+const (
+	// HeaderSignature HMAC-SHA256 簽章，格式 "sha256=<hex>"，簽章內容為 "<timestamp>.<body>"。
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderTimestamp 簽章時間（Unix 秒），接收端應拒絕過舊的請求以防重放。
+	HeaderTimestamp = "X-Webhook-Timestamp"
+)
+
+// Event 任務終態通知的 JSON 內容。
+type Event struct {
+	TaskID       string    `json:"taskId"`
+	Event        string    `json:"event"` // completed / failed / cancelled
+	ErrorMessage string    `json:"errorMessage,omitempty"`
+	ErrorCode    string    `json:"errorCode,omitempty"` // 上傳驗證失敗的錯誤代碼
+	OccurredAt   time.Time `json:"occurredAt"`
+}
+
+// Config Dispatcher 的重試與簽章設定。
+type Config struct {
+	Secret      string
+	MaxAttempts int
+	BaseBackoff time.Duration // 第 n 次重試等待 BaseBackoff * 2^(n-1)，上限 MaxBackoff
+	MaxBackoff  time.Duration
+	Timeout     time.Duration // 單次 HTTP 請求 timeout
+	// AllowPrivate 允許投遞至 loopback / 內網位址；預設拒絕，callback URL 由使用者提供，避免被用來探測內部服務。
+	AllowPrivate bool
+}
+
+// Dispatcher 以 HMAC 簽章 POST 任務事件至任務註冊的 callback URL，
+// 失敗時以指數退避重試，每次嘗試寫入 webhook_deliveries。
+type Dispatcher struct {
+	db     *sql.DB
+	cfg    Config
+	client *http.Client
+}
+
+// NewDispatcher 建立 Dispatcher。
+func NewDispatcher(postgres *sql.DB, cfg Config) *Dispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &Dispatcher{
+		db:     postgres,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout, Transport: netguard.Transport(30*time.Second, cfg.AllowPrivate)},
+	}
+}
+
+// ValidateURL 檢查 callback URL 是否為合法的 http(s) 絕對網址。
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute http(s) url: %q", raw)
+	}
+	return nil
+}
+
+// Notify 查詢任務的 callback URL 並在背景投遞事件；未註冊 webhook 的任務直接略過。
+// 投遞不阻塞任務處理，ctx 取消時（Worker 關閉）放棄剩餘重試。
+func (d *Dispatcher) Notify(ctx context.Context, ev Event) {
+	target, err := db.GetWebhookURL(d.db, ev.TaskID)
+	if err != nil {
+		log.Printf("Webhook: lookup url for task %s: %v", ev.TaskID, err)
+		return
+	}
+	if target == "" {
+		return
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	go d.deliver(ctx, target, ev)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, target string, ev Event) {
+	body, _ := json.Marshal(ev)
+
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		status, err := d.post(ctx, target, body)
+		delivered := err == nil
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if dbErr := db.RecordWebhookAttempt(d.db, ev.TaskID, ev.Event, attempt, status, errMsg, delivered); dbErr != nil {
+			log.Printf("Webhook: %v", dbErr)
+		}
+		if delivered {
+			return
+		}
+		log.Printf("Webhook attempt %d/%d for task %s failed: %v", attempt, d.cfg.MaxAttempts, ev.TaskID, err)
+		if errors.Is(err, netguard.ErrPrivateAddress) {
+			// 位址不會因重試而改變
+			return
+		}
+
+		if attempt == d.cfg.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+	log.Printf("Webhook for task %s (%s) gave up after %d attempts", ev.TaskID, ev.Event, d.cfg.MaxAttempts)
+}
+
+// post 送出單次簽章請求，非 2xx 視為失敗。返回 HTTP 狀態碼（無回應時為 0）。
+func (d *Dispatcher) post(ctx context.Context, target string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(d.cfg.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff 計算第 attempt 次失敗後的等待時間。
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.BaseBackoff << (attempt - 1)
+	if d.cfg.MaxBackoff > 0 && (wait > d.cfg.MaxBackoff || wait <= 0) {
+		wait = d.cfg.MaxBackoff
+	}
+	return wait
+}
+
+// Sign 計算 "<timestamp>.<body>" 的 HMAC-SHA256（hex）。接收端以相同方式驗證。
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
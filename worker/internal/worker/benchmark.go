@@ -0,0 +1,262 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"tts-worker/internal/ai"
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	"unicode"
+)
+
+// BenchmarkProvider benchmark 任務可比較的 STT provider。
+type BenchmarkProvider struct {
+	Name  string
+	Model string
+	STT   ai.STTService
+}
+
+// SetBenchmarkProviders 設定 benchmark 任務使用的 STT provider 清單。
+func (w *Worker) SetBenchmarkProviders(p []BenchmarkProvider) {
+	w.benchmarkProviders = p
+}
+
+// benchmarkResult 單一 provider 對整個音檔的轉錄結果。
+type benchmarkResult struct {
+	provider   BenchmarkProvider
+	transcript string
+	elapsed    time.Duration
+	wer        float64 // < 0 表示未提供 reference
+	err        error
+}
+
+// handleBenchmark 以同一組 chunks 依序交給每個 provider 轉錄（provider 之間並行），
+// 各結果與 WER 寫入 benchmark_results，比較報告存為任務摘要後直接 completed。
+func (w *Worker) handleBenchmark(ctx context.Context, payload models.STTPayload, d *queue.Delivery) {
+	w.logf(payload.TaskID, "Processing benchmark task: %s", payload.TaskID)
+
+	w.claimTask(payload.TaskID)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSttProcessing, "startedAt", fmt.Sprintf("%d", time.Now().Unix()))
+	w.notifyProgress(payload.TaskID, 10, "音檔處理中...")
+
+	providers := w.selectBenchmarkProviders(payload.Config.BenchmarkProviders)
+	if len(providers) == 0 {
+		w.handleSTTError(ctx, payload, d, errors.New("benchmark: no matching STT providers configured"))
+		return
+	}
+
+	checksum, err := verifyUpload(payload)
+	if err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+
+	defer w.removeScratchDir(payload)
+	if err := w.checkScratchSpace(ctx, payload); err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassStorage, err))
+		return
+	}
+
+	deadlines := w.sttDeadlines(payload)
+	chunkingCtx, chunkingCancel := context.WithTimeout(ctx, deadlines.Chunking)
+	chunks, err := audio.Split(chunkingCtx, w.chunker, payload.FilePath, w.splitOptions(payload))
+	chunkingCancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+	defer audio.CleanupChunks(chunks)
+
+	w.notifyProgress(payload.TaskID, 30, fmt.Sprintf("以 %d 個 provider 轉譯中（%d 段）...", len(providers), len(chunks)))
+
+	sttCtx, sttCancel := context.WithTimeout(ctx, deadlines.STT)
+	defer sttCancel()
+
+	hint := languageHint(payload)
+	results := make([]benchmarkResult, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = w.benchmarkProvider(sttCtx, payload.TaskID, p, chunks, hint, deadlines.STTChunk)
+			w.logf(payload.TaskID, "Benchmark task %s: %s finished in %s (err=%v)", payload.TaskID, p.Name, results[i].elapsed, results[i].err)
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		w.handleSTTError(ctx, payload, d, ctx.Err())
+		return
+	}
+
+	reference := strings.TrimSpace(payload.Config.Reference)
+	for i := range results {
+		results[i].wer = -1
+		if reference != "" && results[i].err == nil {
+			results[i].wer = wordErrorRate(reference, results[i].transcript)
+		}
+		r := results[i]
+		errMsg := ""
+		if r.err != nil {
+			errMsg = r.err.Error()
+		}
+		if err := db.SaveBenchmarkResult(w.DB, payload.TaskID, r.provider.Name, r.provider.Model, r.transcript, r.wer, r.elapsed, errMsg); err != nil {
+			w.handleSTTError(ctx, payload, d, withClass(errClassStorage, err))
+			return
+		}
+	}
+
+	if err := db.SaveSummary(w.DB, payload.TaskID, benchmarkReport(results, len(chunks))); err != nil {
+		if w.discardStale(d, payload.TaskID, err) {
+			w.cleanup(payload.FilePath)
+			return
+		}
+		w.handleSTTError(ctx, payload, d, withClass(errClassStorage, err))
+		return
+	}
+
+	w.saveSourceChecksum(payload.TaskID, checksum)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusCompleted)
+	w.ack(d)
+	w.notifyCompleted(payload.TaskID)
+	w.notifyWebhook(payload.TaskID, models.StatusCompleted, "")
+	if !w.keepSourceAudio {
+		w.cleanup(payload.FilePath)
+	}
+}
+
+// selectBenchmarkProviders 依任務指定的名稱篩選 provider，未指定時使用全部。
+func (w *Worker) selectBenchmarkProviders(names []string) []BenchmarkProvider {
+	if len(names) == 0 {
+		return w.benchmarkProviders
+	}
+	var selected []BenchmarkProvider
+	for _, p := range w.benchmarkProviders {
+		if slices.Contains(names, p.Name) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// benchmarkProvider 以單一 provider 依序轉錄所有 chunks；任一 chunk 失敗即停止，結果記錄錯誤。
+func (w *Worker) benchmarkProvider(ctx context.Context, taskID string, p BenchmarkProvider, chunks []audio.Chunk, hint string, chunkTimeout time.Duration) benchmarkResult {
+	res := benchmarkResult{provider: p}
+	started := time.Now()
+	for i, c := range chunks {
+		if c.Gap > 0 {
+			res.transcript = w.merge.merge(res.transcript, partialGap)
+			continue
+		}
+		chunkCtx, cancel := context.WithTimeout(ctx, chunkTimeout)
+		out, err := w.transcribeChunk(chunkCtx, p.STT, taskID, c.FilePath, hint, sttPrompt{})
+		cancel()
+		if err != nil {
+			res.err = fmt.Errorf("chunk %d: %w", i, err)
+			break
+		}
+		res.transcript = w.merge.merge(res.transcript, out.Text)
+	}
+	res.elapsed = time.Since(started)
+	return res
+}
+
+// benchmarkReport 產生 Markdown 比較表，依 WER（有 reference 時）再依耗時排序。
+func benchmarkReport(results []benchmarkResult, chunks int) string {
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b benchmarkResult) int {
+		if (a.err == nil) != (b.err == nil) {
+			if a.err == nil {
+				return -1
+			}
+			return 1
+		}
+		if a.wer != b.wer {
+			if a.wer < b.wer {
+				return -1
+			}
+			return 1
+		}
+		return int(a.elapsed - b.elapsed)
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## STT Benchmark（%d 段）\n\n", chunks)
+	sb.WriteString("| Provider | Model | WER | 耗時 | 字數 | 狀態 |\n|---|---|---|---|---|---|\n")
+	for _, r := range sorted {
+		wer, status := "-", "ok"
+		if r.wer >= 0 {
+			wer = fmt.Sprintf("%.1f%%", r.wer*100)
+		}
+		if r.err != nil {
+			status = strings.ReplaceAll(truncateRunes(r.err.Error(), 80), "|", "/")
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %d | %s |\n",
+			r.provider.Name, r.provider.Model, wer, r.elapsed.Round(100*time.Millisecond), len([]rune(r.transcript)), status)
+	}
+	return sb.String()
+}
+
+// wordErrorRate 以編輯距離計算 WER；中日韓文字逐字計算（即 CER），其餘以空白分詞，忽略大小寫與標點。
+func wordErrorRate(reference, hypothesis string) float64 {
+	ref, hyp := werTokens(reference), werTokens(hypothesis)
+	if len(ref) == 0 {
+		if len(hyp) == 0 {
+			return 0
+		}
+		return 1
+	}
+	prev := make([]int, len(hyp)+1)
+	curr := make([]int, len(hyp)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ref); i++ {
+		curr[0] = i
+		for j := 1; j <= len(hyp); j++ {
+			cost := 1
+			if ref[i-1] == hyp[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return float64(prev[len(hyp)]) / float64(len(ref))
+}
+
+func werTokens(s string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
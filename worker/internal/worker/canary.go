@@ -0,0 +1,213 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+
+	"github.com/google/uuid"
+)
+
+const (
+	canaryUserID       = "canary"
+	canaryPollInterval = 2 * time.Second
+)
+
+// CanaryConfig 定義 Canary 任務的排程與驗證條件。
+type CanaryConfig struct {
+	Interval         time.Duration // 兩次 Canary 之間的間隔
+	Timeout          time.Duration // 單次 Canary 從入列到 completed 的最長等待時間
+	AudioPath        string        // 已知內容的測試音檔
+	UploadDir        string        // 共享 volume 上的暫存目錄，Worker 會從此讀取音檔
+	ExpectTranscript string        // transcript 須包含的文字，空字串僅檢查非空
+	ExpectSummary    string        // summary 須包含的文字，空字串僅檢查非空
+}
+
+// Canary 定期投遞一個小型已知音檔任務，走完整條 STT → Summary pipeline 後驗證結果，
+// 以 Prometheus 指標回報 pass/fail，作為端到端監控。
+// 與 Reaper 相同透過 Redis SetNX 確保多 Worker 部署時只有一個實例排程。
+type Canary struct {
+	w   *Worker
+	cfg CanaryConfig
+}
+
+// NewCanary 建立 Canary 排程器。
+func NewCanary(w *Worker, cfg CanaryConfig) *Canary {
+	return &Canary{w: w, cfg: cfg}
+}
+
+// Start 依 Interval 定期執行 Canary，直到 ctx 取消。
+func (c *Canary) Start(ctx context.Context) {
+	log.Printf("Canary started: every %s, audio %s", c.cfg.Interval, c.cfg.AudioPath)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Canary stopped")
+			return
+		case <-ticker.C:
+			// lock TTL 涵蓋整個 Canary 執行期間，避免其他 Worker 同時投遞
+			acquired, err := c.w.Redis.SetNX(ctx, keys.Lock("canary"), "locked", c.cfg.Timeout).Result()
+			if err != nil {
+				log.Printf("Canary: failed to acquire lock: %v", err)
+				continue
+			}
+			if !acquired {
+				continue
+			}
+			c.runOnce(ctx)
+			c.w.Redis.Del(ctx, keys.Lock("canary"))
+		}
+	}
+}
+
+// runOnce 執行單次 Canary 並更新指標。
+func (c *Canary) runOnce(ctx context.Context) {
+	start := time.Now()
+	result := "pass"
+	if err := c.run(ctx); err != nil {
+		var verifyErr *canaryVerifyError
+		if errors.As(err, &verifyErr) {
+			result = "fail"
+		} else {
+			result = "error"
+		}
+		log.Printf("Canary %s: %v", result, err)
+	}
+
+	metrics.CanaryRunsTotal.WithLabelValues(result).Inc()
+	metrics.CanaryDuration.Set(time.Since(start).Seconds())
+	metrics.CanaryLastRun.Set(float64(time.Now().Unix()))
+	if result == "pass" {
+		metrics.CanarySuccess.Set(1)
+	} else {
+		metrics.CanarySuccess.Set(0)
+	}
+}
+
+// canaryVerifyError 代表 pipeline 已跑完但結果不符預期（相對於基礎設施錯誤）。
+type canaryVerifyError struct{ msg string }
+
+func (e *canaryVerifyError) Error() string { return e.msg }
+
+func (c *Canary) run(ctx context.Context) error {
+	taskID := uuid.New().String()
+	taskDir := filepath.Join(c.cfg.UploadDir, taskID)
+	filePath := filepath.Join(taskDir, filepath.Base(c.cfg.AudioPath))
+
+	if err := os.MkdirAll(taskDir, 0755); err != nil {
+		return fmt.Errorf("prepare dir: %w", err)
+	}
+	defer os.RemoveAll(taskDir)
+	if err := copyFile(c.cfg.AudioPath, filePath); err != nil {
+		return fmt.Errorf("copy audio: %w", err)
+	}
+
+	if err := db.CreateTask(c.w.DB, taskID, canaryUserID, filePath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := db.DeleteTask(c.w.DB, taskID); err != nil {
+			log.Printf("Canary: cleanup task %s: %v", taskID, err)
+		}
+		c.w.Redis.Del(context.Background(), keys.Task(taskID))
+	}()
+
+	payload := models.STTPayload{TaskID: taskID, UserID: canaryUserID, FilePath: filePath, Canary: true}
+	raw, _ := json.Marshal(payload)
+	c.w.Redis.HSet(ctx, keys.Task(taskID), "status", models.StatusSttQueued, "userId", canaryUserID)
+	if err := c.w.Broker.Publish(ctx, keys.Queue(queue.STT), raw); err != nil {
+		return fmt.Errorf("enqueue stt: %w", err)
+	}
+
+	deadline, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	summaryQueued := false
+	ticker := time.NewTicker(canaryPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline.Done():
+			return fmt.Errorf("task %s did not complete within %s", taskID, c.cfg.Timeout)
+		case <-ticker.C:
+		}
+
+		res, err := db.GetTaskResult(c.w.DB, taskID)
+		if err != nil {
+			return err
+		}
+
+		switch res.Status {
+		case models.StatusSttCompleted:
+			// 一般任務由使用者觸發摘要，Canary 自行投遞 Summary 任務
+			if summaryQueued {
+				continue
+			}
+			if err := c.verify("transcript", res.Transcript, c.cfg.ExpectTranscript); err != nil {
+				return err
+			}
+			sp := models.SummaryPayload{TaskID: taskID, UserID: canaryUserID, Transcript: res.Transcript, Canary: true}
+			raw, _ := json.Marshal(sp)
+			c.w.Redis.HSet(ctx, keys.Task(taskID), "status", models.StatusSummaryQueued)
+			if err := c.w.Broker.Publish(ctx, keys.Queue(queue.Summary), raw); err != nil {
+				return fmt.Errorf("enqueue summary: %w", err)
+			}
+			summaryQueued = true
+		case models.StatusCompleted:
+			if err := c.verify("transcript", res.Transcript, c.cfg.ExpectTranscript); err != nil {
+				return err
+			}
+			if err := c.verify("summary", res.Summary, c.cfg.ExpectSummary); err != nil {
+				return err
+			}
+			log.Printf("Canary task %s passed", taskID)
+			return nil
+		case models.StatusFailed, models.StatusCancelled:
+			return &canaryVerifyError{msg: fmt.Sprintf("task %s ended as %s: %s", taskID, res.Status, res.ErrorMessage)}
+		}
+	}
+}
+
+// verify 檢查結果是否非空且包含預期文字。
+func (c *Canary) verify(field, got, expect string) error {
+	if strings.TrimSpace(got) == "" {
+		return &canaryVerifyError{msg: fmt.Sprintf("%s is empty", field)}
+	}
+	if expect != "" && !strings.Contains(got, expect) {
+		return &canaryVerifyError{msg: fmt.Sprintf("%s does not contain %q", field, expect)}
+	}
+	return nil
+}
+
+// copyFile 複製 Canary 音檔至任務目錄，Worker 處理完成後會刪除原檔。
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+)
+
+// activeTask 進行中任務的取消控制。done 於任務 goroutine 結束（chunk 與音檔已清理）時關閉。
+type activeTask struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	watching atomic.Bool
+	forced   atomic.Bool
+}
+
+// SetCancelDeadline 設定取消後等待任務停止的上限，逾時強制標記 failed。0 表示不限。
+func (w *Worker) SetCancelDeadline(d time.Duration) {
+	w.cancelDeadline = d
+}
+
+// trackTask 登記進行中任務，供取消信號與 InFlightTasks 使用。
+func (w *Worker) trackTask(taskID string, cancel context.CancelFunc) *activeTask {
+	t := &activeTask{cancel: cancel, done: make(chan struct{})}
+	w.activeCancels.Store(taskID, t)
+	return t
+}
+
+// finishTask 於任務 goroutine 結束時呼叫（此時 STT goroutine 已全部返回、chunks 與音檔已清理）。
+// 任務是被取消的才在此推送 cancelled 事件，讓前端確認 Worker 確實已停止。
+func (w *Worker) finishTask(taskID string, t *activeTask, ctx context.Context) {
+	w.activeCancels.CompareAndDelete(taskID, t)
+	close(t.done)
+	if ctx.Err() == nil || t.forced.Load() {
+		return
+	}
+	w.logf(taskID, "Task %s stopped after cancellation", taskID)
+	w.notifyEvent(taskID, models.StatusCancelled, "已停止處理並清除暫存檔")
+}
+
+// cancelTask 取消本 instance 上進行中的任務，並在 cancelDeadline 後檢查是否確實停止。
+func (w *Worker) cancelTask(taskID string) {
+	v, ok := w.activeCancels.Load(taskID)
+	if !ok {
+		return
+	}
+	t := v.(*activeTask)
+	t.cancel()
+	if w.cancelDeadline > 0 && !t.watching.Swap(true) {
+		go w.enforceCancelDeadline(taskID, t)
+	}
+}
+
+// enforceCancelDeadline 任務在期限內未停止（例如卡在無法中斷的 provider 呼叫）時強制標記 failed，
+// 讓前端與 webhook 收到終態；任務 goroutine 稍後結束時不再推送 cancelled，其寫入 DB 的狀態轉換一律被拒絕
+// （db.ForceFailCancelled），不會再次改寫狀態或送出 webhook。
+func (w *Worker) enforceCancelDeadline(taskID string, t *activeTask) {
+	select {
+	case <-t.done:
+		return
+	case <-time.After(w.cancelDeadline):
+	}
+	t.forced.Store(true)
+
+	msg := fmt.Sprintf("cancellation did not complete within %s", w.cancelDeadline)
+	w.logf(taskID, "Task %s: %s, force-failing", taskID, msg)
+	if err := db.ForceFailCancelled(w.DB, taskID, msg); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+	w.Redis.HSet(context.Background(), keys.Task(taskID), "status", models.StatusFailed)
+	w.notifyEvent(taskID, models.StatusFailed, msg)
+	w.notifyWebhook(taskID, models.StatusFailed, msg)
+}
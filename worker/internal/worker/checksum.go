@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// ErrChecksumMismatch 共享 volume 上的音檔與上傳時記錄的 SHA-256 不符（損毀或截斷）。
+var ErrChecksumMismatch = errors.New("upload checksum mismatch")
+
+// verifyUpload 計算音檔 SHA-256，payload 帶有上傳時的 checksum 時比對，不符返回 ErrChecksumMismatch。
+// 未帶 checksum（例如 Canary 任務）時只返回計算結果。
+func verifyUpload(payload models.STTPayload) (string, error) {
+	f, err := os.Open(payload.FilePath)
+	if err != nil {
+		return "", fmt.Errorf("verifyUpload: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("verifyUpload: %w", err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if payload.Checksum != "" && !strings.EqualFold(payload.Checksum, sum) {
+		return sum, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, payload.Checksum, sum)
+	}
+	return sum, nil
+}
+
+// saveSourceChecksum 將驗證過的 checksum 與結果一併保存；失敗只記錄 log。
+func (w *Worker) saveSourceChecksum(taskID, sum string) {
+	if err := db.SaveSourceChecksum(w.DB, taskID, sum); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
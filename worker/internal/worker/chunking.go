@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/models"
+)
+
+// ChunkingPolicy 部署層級的切片設定。
+type ChunkingPolicy struct {
+	// Default 任務未指定時使用的切片參數。
+	Default audio.SplitOptions
+	// MaxChunkDuration / MaxNoSplitBytes 任務覆寫可使用的上限，避免超過 provider 的單檔限制。
+	MaxChunkDuration float64
+	MaxNoSplitBytes  int64
+}
+
+// DefaultChunkingPolicy 預設 30 秒分片；任務可覆寫至 10 分鐘 / 25MB（OpenAI Whisper API 的單檔上限），
+// 分片大小同樣以 25MB 為上限。
+func DefaultChunkingPolicy() ChunkingPolicy {
+	def := audio.DefaultSplitOptions()
+	def.MaxChunkBytes = 25 * 1024 * 1024
+	return ChunkingPolicy{
+		Default:          def,
+		MaxChunkDuration: 600,
+		MaxNoSplitBytes:  25 * 1024 * 1024,
+	}
+}
+
+// SetChunkingPolicy 設定音檔切片參數與任務覆寫上限。
+func (w *Worker) SetChunkingPolicy(p ChunkingPolicy) {
+	w.chunking = p
+}
+
+// SetChunker 替換切片實作（預設 audio.FFmpegChunker）；測試可注入 audio.MemoryChunker，不需安裝 ffmpeg。
+func (w *Worker) SetChunker(c audio.Chunker) {
+	w.chunker = c
+}
+
+// splitTurns 聲道分離模式以 audio.SplitChannels 切為講者 turn，不做前處理（loudnorm / 清理會混為單聲道）。
+// 未啟用、非雙聲道或偵測不到語音時返回 nil，改以 splitStream 切片。
+func (w *Worker) splitTurns(ctx context.Context, payload models.STTPayload) ([]audio.ChannelTurn, error) {
+	if !w.stereoSplitEnabled(payload) {
+		return nil, nil
+	}
+	turns, err := audio.SplitChannels(ctx, payload.FilePath, w.splitOptions(payload))
+	switch {
+	case errors.Is(err, audio.ErrNotStereo):
+		w.logf(payload.TaskID, "Task %s: not a two-channel recording, transcribing mixed audio", payload.TaskID)
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case len(turns) == 0:
+		w.logf(payload.TaskID, "Task %s: no speech detected on either channel, transcribing mixed audio", payload.TaskID)
+		return nil, nil
+	}
+	return turns, nil
+}
+
+// splitStream 裁掉頭尾靜音、前處理後以 Chunker 切片，每個分片產生時交給 emit，並順帶計算波形寫入 wf。
+// 加速過的音訊中略過的長度、分片起點與波形時間換算回原始錄音的秒數，裁掉的頭尾以靜音補回波形。
+func (w *Worker) splitStream(ctx context.Context, payload models.STTPayload, speech audio.SpeechStats, wf *audio.Waveform, emit func(audio.Chunk) error) error {
+	lead, trail := 0.0, 0.0
+	if start, end, ok := w.trimRange(speech); ok {
+		trimmed, err := audio.TrimAudio(ctx, payload.FilePath, w.taskScratchDir(payload), start, end)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logf(payload.TaskID, "Task %s: silence trimming skipped: %v", payload.TaskID, err)
+			}
+		} else {
+			defer os.Remove(trimmed)
+			payload.FilePath = trimmed
+			lead, trail = start, speech.DurationSec-end
+			w.logf(payload.TaskID, "Task %s: trimmed %.1fs leading / %.1fs trailing silence", payload.TaskID, lead, trail)
+		}
+	}
+
+	sourcePath, speed, cleanupSource := w.preprocessAudio(ctx, payload)
+	defer cleanupSource()
+	err := w.chunker.SplitStream(audio.WithWaveform(ctx, wf), sourcePath, w.splitOptions(payload), func(c audio.Chunk) error {
+		c.Gap *= speed
+		c.Offset, c.Scale = c.Offset*speed+lead, speed
+		return emit(c)
+	})
+	wf.SecondsPerPeak *= speed
+	wf.Pad(lead, trail)
+	return err
+}
+
+// splitOptions 合併部署設定與任務 payload 的切片參數。
+func (w *Worker) splitOptions(p models.STTPayload) audio.SplitOptions {
+	opts := w.chunking.Default
+	opts.ScratchDir = w.taskScratchDir(p)
+	o := p.Config.Chunking
+	if o.MaxChunkSec > 0 {
+		opts.MaxChunkDuration = o.MaxChunkSec
+		if w.chunking.MaxChunkDuration > 0 {
+			opts.MaxChunkDuration = min(o.MaxChunkSec, w.chunking.MaxChunkDuration)
+		}
+	}
+	if o.OverlapSec > 0 {
+		opts.Overlap = o.OverlapSec
+	}
+	if o.NoSplitBytes > 0 {
+		opts.NoSplitBytes = o.NoSplitBytes
+		if w.chunking.MaxNoSplitBytes > 0 {
+			opts.NoSplitBytes = min(o.NoSplitBytes, w.chunking.MaxNoSplitBytes)
+		}
+	}
+	return opts
+}
@@ -0,0 +1,277 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tts-worker/internal/metrics"
+)
+
+// baselineDecay 每個週期延遲基準向較慢的平均值靠攏的比例。
+const baselineDecay = 0.1
+
+// ConcurrencyConfig 自適應 STT chunk 並發設定。
+// 並發上限在 [Min, Max] 之間調整：記憶體使用率超過 MemoryHighWater 時減半；
+// provider 錯誤率超過 MaxErrorRate 或每秒音訊的平均延遲超過基準的 LatencyFactor 倍時減一；
+// 其餘情況下若上一週期並發已滿載則加一。基準為衰減最小值：較快的週期直接取代，較慢的週期以
+// baselineDecay 的比例拉高基準，provider 長期變慢（或錄音組成改變）後基準會逐漸跟上，不會永久壓低並發。
+type ConcurrencyConfig struct {
+	Min, Initial, Max int
+	Interval          time.Duration
+	MemoryHighWater   float64 // 0~1，記憶體使用量 / 上限
+	MemoryLimit       uint64  // bytes；0 表示讀取 cgroup 限制，讀不到時不依記憶體調整
+	MaxErrorRate      float64
+	LatencyFactor     float64
+}
+
+// DefaultConcurrencyConfig 預設 1~8 個 chunk 並發，起始 2。
+func DefaultConcurrencyConfig() ConcurrencyConfig {
+	return ConcurrencyConfig{
+		Min:             1,
+		Initial:         2,
+		Max:             8,
+		Interval:        10 * time.Second,
+		MemoryHighWater: 0.85,
+		MaxErrorRate:    0.2,
+		LatencyFactor:   2,
+	}
+}
+
+// ConcurrencyLimiter 整個 Worker instance 共用的 STT chunk 並發控制器，
+// 取代每個任務固定的 semaphore，讓大機器能多跑、小機器遇到長錄音時不致 OOM。
+type ConcurrencyLimiter struct {
+	cfg ConcurrencyConfig
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	inFlight int
+
+	// 本週期統計
+	saturated bool
+	calls     int
+	failures  int
+	// latency / audioSec 本週期已知長度的成功呼叫的延遲總和與音訊總長（秒）
+	latency  time.Duration
+	audioSec float64
+	// baseline 每秒音訊延遲（秒）的衰減最小值，作為延遲劣化的比較基準
+	baseline float64
+}
+
+// NewConcurrencyLimiter 建立控制器，Min / Initial / Max 不合理時自動修正。
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) *ConcurrencyLimiter {
+	if cfg.Min < 1 {
+		cfg.Min = 1
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	cfg.Initial = min(max(cfg.Initial, cfg.Min), cfg.Max)
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	l := &ConcurrencyLimiter{cfg: cfg, limit: cfg.Initial}
+	l.cond = sync.NewCond(&l.mu)
+	metrics.STTConcurrencyLimit.Set(float64(l.limit))
+	return l
+}
+
+// Acquire 等待可用的並發名額，ctx 結束時返回其錯誤。
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.limit {
+		l.saturated = true
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	l.inFlight++
+	if l.inFlight >= l.limit {
+		l.saturated = true
+	}
+	metrics.STTChunksInFlight.Set(float64(l.inFlight))
+	return nil
+}
+
+// Release 歸還名額並記錄該次 provider 呼叫的延遲、分片長度（秒）與結果；取消造成的中止不計入錯誤率，
+// 長度未知（0）的分片不計入延遲。
+func (l *ConcurrencyLimiter) Release(elapsed time.Duration, audioSec float64, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	metrics.STTChunksInFlight.Set(float64(l.inFlight))
+	if err == nil {
+		l.calls++
+		if audioSec > 0 {
+			l.latency += elapsed
+			l.audioSec += audioSec
+		}
+	} else if !errors.Is(err, context.Canceled) {
+		l.calls++
+		l.failures++
+	}
+	l.cond.Signal()
+}
+
+// Run 每 Interval 依記憶體、延遲與錯誤率調整並發上限，直到 ctx 結束。
+func (l *ConcurrencyLimiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.adjust(memoryUsage(l.cfg.MemoryLimit))
+		}
+	}
+}
+
+// adjust 依本週期統計調整上限。usage < 0 表示無法取得記憶體使用率。
+func (l *ConcurrencyLimiter) adjust(usage float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prev := l.limit
+	// 每秒音訊的延遲，與分片長短無關
+	var rtf float64
+	if l.audioSec > 0 {
+		rtf = l.latency.Seconds() / l.audioSec
+	}
+	baseline := l.baseline
+	if rtf > 0 {
+		if l.baseline == 0 || rtf < l.baseline {
+			l.baseline = rtf
+		} else {
+			l.baseline += (rtf - l.baseline) * baselineDecay
+		}
+	}
+
+	reason := ""
+	switch {
+	case l.cfg.MemoryHighWater > 0 && usage >= l.cfg.MemoryHighWater:
+		l.limit = max(l.cfg.Min, l.limit/2)
+		reason = "memory " + strconv.FormatFloat(usage*100, 'f', 0, 64) + "%"
+	case l.calls > 0 && float64(l.failures)/float64(l.calls) > l.cfg.MaxErrorRate:
+		l.limit = max(l.cfg.Min, l.limit-1)
+		reason = "provider errors " + strconv.Itoa(l.failures) + "/" + strconv.Itoa(l.calls)
+	case rtf > 0 && baseline > 0 && l.cfg.LatencyFactor > 0 && rtf > baseline*l.cfg.LatencyFactor:
+		l.limit = max(l.cfg.Min, l.limit-1)
+		reason = "latency " + strconv.FormatFloat(rtf, 'f', 2, 64) + "s per audio second"
+	case l.saturated:
+		l.limit = min(l.cfg.Max, l.limit+1)
+		reason = "saturated"
+	}
+
+	l.saturated = l.inFlight >= l.limit
+	l.calls, l.failures, l.latency, l.audioSec = 0, 0, 0, 0
+
+	if l.limit != prev {
+		log.Printf("STT concurrency %d → %d (%s)", prev, l.limit, reason)
+		metrics.STTConcurrencyLimit.Set(float64(l.limit))
+		l.cond.Broadcast()
+	}
+}
+
+// memoryUsage 返回記憶體使用率（0~1），優先讀取 cgroup（容器內含 ffmpeg 子行程），
+// limit > 0 時以其為上限；無法判斷時返回 -1。
+func memoryUsage(limit uint64) float64 {
+	used, cgLimit := cgroupMemory()
+	if limit == 0 {
+		limit = cgLimit
+	}
+	if used == 0 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		used = ms.Sys
+	}
+	if limit == 0 {
+		return -1
+	}
+	return float64(used) / float64(limit)
+}
+
+// cgroupMemory 讀取 cgroup v2（或 v1）的 working set 與記憶體上限，讀不到或無上限時為 0。
+// 使用量包含可回收的 page cache（Worker 持續寫入解碼後的 WAV 與 chunk），與 kubelet / cAdvisor 相同
+// 扣除 memory.stat 的 inactive_file，避免 cache 讓使用率長期接近上限而壓低並發。
+func cgroupMemory() (used, limit uint64) {
+	for _, files := range [][3]string{
+		{"/sys/fs/cgroup/memory.current", "/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory.stat"},
+		{"/sys/fs/cgroup/memory/memory.usage_in_bytes", "/sys/fs/cgroup/memory/memory.limit_in_bytes", "/sys/fs/cgroup/memory/memory.stat"},
+	} {
+		u, ok := readUint(files[0])
+		if !ok {
+			continue
+		}
+		l, _ := readUint(files[1])
+		// cgroup v1 無上限時為接近 MaxInt64 的值
+		if l >= 1<<62 {
+			l = 0
+		}
+		// v2 為 inactive_file，v1 另有含子 cgroup 的 total_inactive_file
+		stat := readMemoryStat(files[2])
+		inactive := stat["inactive_file"]
+		if v, ok := stat["total_inactive_file"]; ok {
+			inactive = v
+		}
+		if inactive < u {
+			u -= inactive
+		}
+		return u, l
+	}
+	return 0, 0
+}
+
+// readMemoryStat 解析 memory.stat（每行 "key value"），讀不到時返回 nil。
+func readMemoryStat(path string) map[string]uint64 {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	stat := make(map[string]uint64)
+	for _, line := range strings.Split(string(b), "\n") {
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err == nil {
+			stat[name] = n
+		}
+	}
+	return stat
+}
+
+func readUint(path string) (uint64, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, false // cgroup v2 無上限時為 "max"
+	}
+	return n, true
+}
+
+// SetConcurrencyLimiter 設定 STT chunk 並發控制器，未設定時每個任務固定 2 個 chunk 並發。
+func (w *Worker) SetConcurrencyLimiter(l *ConcurrencyLimiter) {
+	w.concurrency = l
+}
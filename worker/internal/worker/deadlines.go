@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"time"
+	"tts-worker/internal/models"
+)
+
+// Deadlines 各處理階段的時間上限。
+// 階段 deadline 以 context 向下傳遞，單一 chunk 的 timeout 不會超過整個 STT 階段剩餘時間。
+type Deadlines struct {
+	Chunking time.Duration // 音檔切片（單次 ffmpeg 解碼 + 靜音偵測）
+	STT      time.Duration // 所有 chunk 的轉錄總時間（含 retry）；與切片重疊進行，實際上限為 Chunking + STT
+	STTChunk time.Duration // 單一 chunk 單次 STT 呼叫
+	Summary  time.Duration // LLM 串流摘要
+}
+
+// DefaultDeadlines 預設 deadline；STTChunk 沿用原本的 5 分鐘。
+func DefaultDeadlines() Deadlines {
+	return Deadlines{
+		Chunking: 10 * time.Minute,
+		STT:      60 * time.Minute,
+		STTChunk: 5 * time.Minute,
+		Summary:  10 * time.Minute,
+	}
+}
+
+// SetDeadlines 設定 Worker 的部署層級 deadline。
+func (w *Worker) SetDeadlines(d Deadlines) {
+	w.deadlines = d
+}
+
+// sttDeadlines 合併部署設定與任務 payload 的覆寫值。
+// 任務只能縮短 deadline，不能超過部署上限，避免單一任務無限佔用 Worker。
+func (w *Worker) sttDeadlines(p models.STTPayload) Deadlines {
+	d := w.deadlines
+	d.Chunking = shorterOf(d.Chunking, p.Config.Timeouts.ChunkingSec)
+	d.STT = shorterOf(d.STT, p.Config.Timeouts.STTSec)
+	d.STTChunk = shorterOf(d.STTChunk, p.Config.Timeouts.STTChunkSec)
+	return d
+}
+
+// summaryDeadline 合併部署設定與任務 payload 的摘要 deadline。
+func (w *Worker) summaryDeadline(p models.SummaryPayload) time.Duration {
+	return shorterOf(w.deadlines.Summary, p.Config.Timeouts.SummarySec)
+}
+
+// shorterOf 返回 limit 與覆寫秒數中較短者；覆寫值 <= 0 表示未指定。
+func shorterOf(limit time.Duration, overrideSec int) time.Duration {
+	if overrideSec <= 0 {
+		return limit
+	}
+	override := time.Duration(overrideSec) * time.Second
+	if limit <= 0 || override < limit {
+		return override
+	}
+	return limit
+}
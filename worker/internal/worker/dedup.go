@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+)
+
+// 相同音訊重複上傳時沿用既有結果的範圍（SetDedupScope）。
+const (
+	DedupOff    = "off"    // 不計算內容 hash，每次都重新轉錄
+	DedupUser   = "user"   // 只沿用同一使用者的任務結果
+	DedupGlobal = "global" // 沿用任何使用者的任務結果（共用錄音、公開會議等）
+)
+
+// SetDedupScope 設定重複音訊的比對範圍（Dedup* 常數）。
+func (w *Worker) SetDedupScope(scope string) {
+	w.dedupScope = scope
+}
+
+// sttFingerprint 影響轉錄結果的任務設定（語言提示及是否為使用者指定、模型、初始提示、實際套用的詞彙表、聲道分離、
+// 音訊前處理與切片覆寫）的 hash；相同音訊但設定不同的任務不沿用彼此的轉錄稿。
+func (w *Worker) sttFingerprint(payload models.STTPayload, glossary []string) string {
+	b, _ := json.Marshal(struct {
+		Language    string                 `json:"language"`
+		Explicit    bool                   `json:"languageExplicit"`
+		STTModel    string                 `json:"sttModel"`
+		Prompt      string                 `json:"prompt"`
+		Glossary    []string               `json:"glossary"`
+		StereoSplit bool                   `json:"stereoSplit"`
+		Cleanup     *string                `json:"cleanup"`
+		Loudnorm    *bool                  `json:"loudnorm"`
+		Chunking    models.ChunkingOptions `json:"chunking"`
+	}{
+		Language:    languageHint(payload),
+		Explicit:    payload.Config.LanguageExplicit,
+		STTModel:    payload.Config.STTModel,
+		Prompt:      payload.Config.Prompt,
+		Glossary:    glossary,
+		StereoSplit: w.stereoSplitEnabled(payload),
+		Cleanup:     payload.Config.Cleanup,
+		Loudnorm:    payload.Config.Loudnorm,
+		Chunking:    payload.Config.Chunking,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// reuseDuplicate 計算音訊內容 hash 並查找相同錄音、相同轉錄設定已完成的任務：找到時複製其轉錄稿、段落與波形，
+// 不再呼叫 STT provider，推送 deduplicated 事件後停在 stt_completed 返回 true（摘要由 reuseSummary 決定是否沿用）。
+// 找不到或任何步驟失敗時返回 false，照常轉錄。Canary 任務用於監控 provider，一律實際轉錄。
+func (w *Worker) reuseDuplicate(ctx context.Context, payload models.STTPayload, d *queue.Delivery, checksum string, glossary []string, timeout time.Duration) bool {
+	if w.dedupScope == "" || w.dedupScope == DedupOff || payload.Canary {
+		return false
+	}
+	hctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	hash, err := audio.ContentHash(hctx, payload.FilePath)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logf(payload.TaskID, "Task %s: content hash skipped: %v", payload.TaskID, err)
+		}
+		return false
+	}
+	fingerprint := w.sttFingerprint(payload, glossary)
+	if err := db.SetAudioHash(w.DB, payload.TaskID, hash, fingerprint); err != nil {
+		w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		return false
+	}
+
+	owner := payload.UserID
+	if w.dedupScope == DedupGlobal {
+		owner = ""
+	}
+	dup, err := db.FindDuplicateResult(w.DB, hash, fingerprint, payload.TaskID, owner)
+	if err != nil {
+		w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		return false
+	}
+	if dup == nil {
+		return false
+	}
+
+	if err := db.SaveTranscript(w.DB, payload.TaskID, dup.Transcript); err != nil {
+		if w.discardStale(d, payload.TaskID, err) {
+			w.cleanup(payload.FilePath)
+			return true
+		}
+		w.logf(payload.TaskID, "Task %s: reusing transcript of %s failed, transcribing: %v", payload.TaskID, dup.TaskID, err)
+		return false
+	}
+	w.logf(payload.TaskID, "STT task %s: identical audio already transcribed by task %s, reusing result", payload.TaskID, dup.TaskID)
+	w.saveSourceChecksum(payload.TaskID, checksum)
+	if len(dup.Segments) > 0 {
+		if err := db.SaveSegments(w.DB, payload.TaskID, dup.Segments); err != nil {
+			w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		}
+	}
+	if len(dup.Timings) > 0 {
+		if err := db.SaveTimings(w.DB, payload.TaskID, dup.Timings); err != nil {
+			w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		}
+	}
+	if len(dup.Waveform) > 0 {
+		if err := db.SaveWaveform(w.DB, payload.TaskID, dup.Waveform); err != nil {
+			w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		}
+	}
+	if dup.Language != "" {
+		w.reportLanguage(payload.TaskID, dup.Language, languageFromDuplicate)
+	}
+	if err := db.MergeAudioMetadata(w.DB, payload.TaskID, map[string]any{"deduplicatedFrom": dup.TaskID}); err != nil {
+		w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+	}
+
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSttCompleted)
+	w.ack(d)
+	w.notifyEvent(payload.TaskID, "deduplicated", dup.TaskID)
+	w.notifyTranscriptUpdate(payload.TaskID, dup.Transcript)
+	w.notifySTTCompleted(payload.TaskID)
+	w.recordOutcome("stt", payload.Canary, nil)
+	go w.generateTitle(payload.TaskID, dup.Transcript, payload.Canary)
+	go w.embedTranscript(payload.TaskID, dup.Transcript, payload.Canary)
+	if !w.keepSourceAudio {
+		w.cleanup(payload.FilePath)
+	}
+	w.notifyProgress(payload.TaskID, 75, "轉錄完成，等待觸發摘要...")
+	return true
+}
+
+// reuseSummary 轉錄稿沿用自重複音訊的任務時，若來源任務以完全相同的摘要設定完成摘要，直接沿用其摘要並完成任務，
+// 返回 true；否則返回 false，照常生成。重新摘要與 canary 任務一律實際生成。
+func (w *Worker) reuseSummary(ctx context.Context, payload models.SummaryPayload, d *queue.Delivery) bool {
+	if w.dedupScope == "" || w.dedupScope == DedupOff || payload.Canary ||
+		payload.Mode == models.ModeSummaryRetry || payload.GuardrailRetries > 0 {
+		return false
+	}
+	source, summary, err := db.FindReusableSummary(w.DB, payload.TaskID, payload.Config)
+	if err != nil {
+		w.logf(payload.TaskID, "Summary task %s: %v", payload.TaskID, err)
+		return false
+	}
+	if summary == "" {
+		return false
+	}
+	if err := db.SaveSummary(w.DB, payload.TaskID, summary); err != nil {
+		if w.discardStale(d, payload.TaskID, err) {
+			return true
+		}
+		w.logf(payload.TaskID, "Summary task %s: reusing summary of %s failed, generating: %v", payload.TaskID, source, err)
+		return false
+	}
+	w.logf(payload.TaskID, "Summary task %s: same transcript and summary config as task %s, reusing summary", payload.TaskID, source)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusCompleted)
+	w.ack(d)
+	w.notifySummaryChunk(payload.TaskID, summary)
+	w.notifyCompleted(payload.TaskID)
+	w.notifyWebhook(payload.TaskID, models.StatusCompleted, "")
+	w.recordOutcome("summary", payload.Canary, nil)
+	return true
+}
@@ -0,0 +1,12 @@
+package worker
+
+import "syscall"
+
+// freeBytes 取得 dir 所在檔案系統一般使用者可用的空間（bytes）。
+func freeBytes(dir string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package worker
+
+// freeBytes 非 Linux 平台不檢查可用空間。
+func freeBytes(dir string) (int64, bool) {
+	return 0, false
+}
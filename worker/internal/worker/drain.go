@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+	"tts-worker/internal/audit"
+	"tts-worker/internal/keys"
+)
+
+// ConsumeContext 返回供佇列 consumer 使用的 context：parent 結束或 Drain 被呼叫時取消，
+// consumer 隨即停止取新訊息，已取得的任務則在獨立 context 中繼續完成。須在 Drain 之前呼叫。
+func (w *Worker) ConsumeContext(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+	w.stopConsuming = cancel
+	return ctx
+}
+
+// Drain 停止消費佇列（可重複呼叫）。進行中的任務不受影響，以 WaitInFlight 等待其完成。
+func (w *Worker) Drain(reason string) {
+	if w.draining.Swap(true) {
+		return
+	}
+	log.Printf("Draining worker %s (%s): stop consuming, %d task(s) in flight", w.instanceID, reason, len(w.InFlightTasks()))
+	if w.stopConsuming != nil {
+		w.stopConsuming()
+	}
+}
+
+// Draining 是否已進入 drain 狀態。
+func (w *Worker) Draining() bool {
+	return w.draining.Load()
+}
+
+// WaitInFlight 等待所有已取得的任務結束，逾時返回 false（未 Ack 的訊息由 backend 重新投遞）。
+func (w *Worker) WaitInFlight(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		w.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// ListenDrain 訂閱 drain channel，訊息內容為本 instance ID 或 "*" 時進入 drain。
+// 供 rolling deploy 在替換 instance 前先行交接，ctx 結束時返回。
+func (w *Worker) ListenDrain(ctx context.Context) {
+	for {
+		pubsub := w.Redis.Subscribe(ctx, keys.DrainChannel())
+		for msg := range pubsub.Channel() {
+			var req struct {
+				WorkerID string `json:"workerId"`
+			}
+			if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil {
+				continue
+			}
+			if req.WorkerID == "*" || req.WorkerID == w.instanceID {
+				audit.Record(audit.Event{Action: "worker.drain", Outcome: audit.OutcomeSuccess, Actor: "drain channel", Target: w.instanceID})
+				w.Drain("drain message")
+			}
+		}
+		pubsub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("Drain listener disconnected, resubscribing in 3s...")
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// DrainHandler 處理 POST /drain（例如 Kubernetes preStop hook），回應目前進行中的任務。
+func (w *Worker) DrainHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		audit.Record(audit.Event{Action: "worker.drain", Outcome: audit.OutcomeSuccess, Actor: "http", Target: w.instanceID, RemoteIP: audit.RemoteIP(r)})
+		w.Drain("HTTP request")
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(rw).Encode(map[string]any{"draining": true, "inFlight": w.InFlightTasks()})
+	})
+}
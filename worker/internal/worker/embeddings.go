@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/db"
+)
+
+const embeddingTimeout = 2 * time.Minute
+
+// EmbeddingPolicy STT 完成後將轉錄稿切段並產生 embeddings（transcript_embeddings），供語意搜尋與問答使用。
+// Embedder 為 nil 時停用。
+type EmbeddingPolicy struct {
+	Embedder ai.Embedder
+	// ChunkRunes 每段的字元上限，優先在句尾切開。
+	ChunkRunes int
+	// BatchSize 單次請求送出的段數上限。
+	BatchSize int
+}
+
+// DefaultEmbeddingPolicy 預設停用，段落約 800 字、每批 64 段。
+func DefaultEmbeddingPolicy() EmbeddingPolicy {
+	return EmbeddingPolicy{ChunkRunes: 800, BatchSize: 64}
+}
+
+// SetEmbeddingPolicy 設定轉錄稿 embeddings 產生。
+func (w *Worker) SetEmbeddingPolicy(p EmbeddingPolicy) {
+	w.embeddings = p
+}
+
+// embedTranscript 切段並產生 embeddings 後寫入 DB。與標題產生相同，於背景執行，失敗僅記錄 log。
+// canary 與未通過審核（flagged）的任務不會呼叫。
+func (w *Worker) embedTranscript(taskID, transcript string, canary bool) {
+	p := w.embeddings
+	if p.Embedder == nil || canary || strings.TrimSpace(transcript) == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), embeddingTimeout)
+	defer cancel()
+
+	chunks := chunkTranscript(transcript, p.ChunkRunes)
+	vectors := make([][]float32, 0, len(chunks))
+	for start := 0; start < len(chunks); start += p.BatchSize {
+		end := min(start+p.BatchSize, len(chunks))
+		batch, err := p.Embedder.Embed(ctx, chunks[start:end])
+		if err != nil {
+			w.logf(taskID, "Task %s: embedding generation failed: %v", taskID, err)
+			return
+		}
+		vectors = append(vectors, batch...)
+	}
+	if err := db.SaveEmbeddings(w.DB, taskID, ai.EmbeddingModelName(p.Embedder), chunks, vectors); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+		return
+	}
+	w.logf(taskID, "Task %s: stored %d transcript embeddings", taskID, len(chunks))
+}
+
+// chunkTranscript 將轉錄稿切成每段最多 maxRunes 字：依句尾標點與換行斷句後合併，
+// 單句超過上限時直接截斷。
+func chunkTranscript(transcript string, maxRunes int) []string {
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+	}
+	for _, sentence := range splitSentences(transcript) {
+		if utf8.RuneCountInString(cur.String())+utf8.RuneCountInString(sentence) > maxRunes {
+			flush()
+		}
+		for utf8.RuneCountInString(sentence) > maxRunes {
+			r := []rune(sentence)
+			chunks = append(chunks, strings.TrimSpace(string(r[:maxRunes])))
+			sentence = string(r[maxRunes:])
+		}
+		cur.WriteString(sentence)
+	}
+	flush()
+	return chunks
+}
+
+// splitSentences 在句尾標點（中英文）與換行之後切開，保留標點。
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		switch r {
+		case '。', '！', '？', '；', '.', '!', '?', '\n':
+			end := i + utf8.RuneLen(r)
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"errors"
+)
+
+// 錯誤分類，用於失敗率指標的 class label。
+const (
+	errClassAudio       = "audio"
+	errClassSTTProvider = "stt_provider"
+	errClassLLMProvider = "llm_provider"
+	errClassStorage     = "storage"
+	errClassDownload    = "download"
+	errClassTimeout     = "timeout"
+	errClassModeration  = "moderation"
+	errClassUnknown     = "unknown"
+)
+
+// classifiedError 為錯誤附加分類，保留原始錯誤供 errors.Is/As 判斷。
+type classifiedError struct {
+	class string
+	err   error
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+func (e *classifiedError) Unwrap() error { return e.err }
+
+// withClass 以指定分類包裝錯誤；err 為 nil 時返回 nil。
+func withClass(class string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// errorClass 取得錯誤分類。逾時優先於來源分類，讓 provider 卡住與 provider 報錯可以分開告警。
+func errorClass(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errClassTimeout
+	}
+	var ce *classifiedError
+	if errors.As(err, &ce) {
+		return ce.class
+	}
+	return errClassUnknown
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	rdb_lib "tts-worker/internal/redis"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FairnessPolicy 每位使用者同時處理中的任務上限（跨所有 Worker instance，STT 與 Summary 合計）。
+// 超過上限的任務延後 Defer 後重新投遞，避免單一使用者大量上傳佔滿所有處理名額。
+type FairnessPolicy struct {
+	MaxPerUser int           // 0 表示不限制
+	Lease      time.Duration // 名額租約；Worker 異常中止時名額最多佔用此時間
+	Defer      time.Duration
+}
+
+// SetFairnessPolicy 設定每位使用者的並發上限。
+func (w *Worker) SetFairnessPolicy(p FairnessPolicy) {
+	if p.Lease <= 0 {
+		p.Lease = 5 * time.Minute
+	}
+	if p.Defer <= 0 {
+		p.Defer = 15 * time.Second
+	}
+	w.fairness = p
+}
+
+// acquireSlotScript 清除過期租約後，若使用者名額未滿（或任務已持有名額）則以 taskID 登記並返回 1。
+// KEYS[1] 使用者 ZSET（member=taskID, score=租約到期 Unix 毫秒）；ARGV: now, expiresAt, limit, taskID
+var acquireSlotScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZSCORE', KEYS[1], ARGV[4]) == false and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+    return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+return 1
+`)
+
+// tryUserSlot 嘗試取得名額；Redis 錯誤時放行（fail open），不因公平性機制阻擋任務。
+func (w *Worker) tryUserSlot(ctx context.Context, userID, taskID string) bool {
+	now := time.Now()
+	ok, err := acquireSlotScript.Run(ctx, w.Redis, []string{keys.UserTasks(userID)},
+		now.UnixMilli(), now.Add(w.fairness.Lease).UnixMilli(), w.fairness.MaxPerUser, taskID).Int()
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logf(taskID, "Task %s: user slot check failed, proceeding: %v", taskID, err)
+		}
+		return true
+	}
+	return ok == 1
+}
+
+// userSlot 為任務取得使用者名額，返回釋放函式；deferred 為 true 表示訊息已延後重新投遞並 Ack，呼叫端不再處理。
+// backend 不支援延遲投遞時在此輪詢等待名額。持有期間定期延長租約。
+func (w *Worker) userSlot(ctx context.Context, d *queue.Delivery, userID, taskID, queuedStatus string) (release func(), deferred bool) {
+	if w.fairness.MaxPerUser <= 0 || userID == "" || userID == canaryUserID {
+		return func() {}, false
+	}
+
+	for !w.tryUserSlot(ctx, userID, taskID) {
+		metrics.FairnessDeferrals.Inc()
+		w.Redis.HSet(ctx, keys.Task(taskID), "status", queuedStatus)
+		rdb_lib.PublishProgress(w.Redis, ctx, taskID, models.SSEEvent{
+			TaskID:  taskID,
+			Type:    "waiting",
+			Status:  queuedStatus,
+			Message: "排隊中：您已有其他任務正在處理",
+		})
+		if dp, ok := w.Broker.(queue.DelayedPublisher); ok {
+			if err := dp.PublishDelayed(ctx, d.Queue, d.Body, w.fairness.Defer); err == nil {
+				w.ack(d)
+				return nil, true
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return func() {}, false
+		case <-time.After(w.fairness.Defer):
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.fairness.Lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w.tryUserSlot(context.Background(), userID, taskID)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		w.Redis.ZRem(context.Background(), keys.UserTasks(userID), taskID)
+	}, false
+}
@@ -0,0 +1,24 @@
+package worker
+
+import (
+	"tts-worker/internal/ai"
+	"tts-worker/internal/models"
+)
+
+// SetGenerationParams 設定摘要的 LLM 取樣參數部署預設（見 ai.ParseGenerationParams）。
+func (w *Worker) SetGenerationParams(p ai.GenerationParams) {
+	w.generation = p
+}
+
+// summaryGeneration 以任務 config.generation 覆寫部署預設；覆寫值不合法時整組忽略並記錄。
+func (w *Worker) summaryGeneration(p models.SummaryPayload) ai.GenerationParams {
+	if p.Config.Generation == nil {
+		return w.generation
+	}
+	override := ai.GenerationParams(*p.Config.Generation)
+	if err := override.Validate(); err != nil {
+		w.logf(p.TaskID, "Task %s: ignoring generation override: %v", p.TaskID, err)
+		return w.generation
+	}
+	return w.generation.Merge(override)
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"strings"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// maxGlossaryTerms 詞彙表的詞數上限，與 API Service 的驗證一致；Whisper 的 prompt 約 224 token。
+const maxGlossaryTerms = 100
+
+// taskGlossary 任務的詞彙表：payload 指定（非空）時優先，否則為用戶的預設詞彙表（user_glossaries）。
+// 結果記錄於 tasks.glossary，供之後的摘要階段沿用。
+func (w *Worker) taskGlossary(payload models.STTPayload) []string {
+	terms := payload.Config.Glossary
+	if len(terms) == 0 && payload.UserID != "" {
+		var err error
+		if terms, err = db.GetUserGlossary(w.DB, payload.UserID); err != nil {
+			w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		}
+	}
+	terms = normalizeGlossary(terms)
+	if len(terms) > 0 {
+		if err := db.SaveTaskGlossary(w.DB, payload.TaskID, terms); err != nil {
+			w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+		}
+	}
+	return terms
+}
+
+// normalizeGlossary 去除空白與重複（不分大小寫，保留第一次出現的寫法），超過上限的詞捨棄。
+func normalizeGlossary(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	var out []string
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if out = append(out, t); len(out) == maxGlossaryTerms {
+			break
+		}
+	}
+	return out
+}
+
+// summarySystemPromptWithGlossary 於摘要的 system prompt 後附上任務轉錄時套用的詞彙表，
+// 讓摘要沿用相同的名稱與術語寫法。Canary 任務不查詢。
+func (w *Worker) summarySystemPromptWithGlossary(p models.SummaryPayload, base string) string {
+	system := w.summarySystemPrompt(p, base)
+	if p.Canary {
+		return system
+	}
+	terms, err := db.GetTaskGlossary(w.DB, p.TaskID)
+	if err != nil {
+		w.logf(p.TaskID, "Summary task %s: %v", p.TaskID, err)
+	}
+	if len(terms) == 0 {
+		return system
+	}
+	if system == "" {
+		system = ai.DefaultSystemPrompt
+	}
+	return system + "\n\nGlossary — use exactly these spellings for names and terms that appear in the transcript: " + strings.Join(terms, ", ")
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+)
+
+// GuardrailPolicy 摘要產生後的驗證設定。
+// 被標記的摘要先自動重新生成 MaxRetries 次，仍不通過時照常交付但標記待審核（task_results.review_reasons）。
+type GuardrailPolicy struct {
+	Enabled bool
+	// MaxUnsupported 摘要中不存在於 transcript 的專有名詞 / 數字超過此數量即標記。
+	MaxUnsupported int
+	// SelfCheck 額外請 LLM 比對摘要與 transcript（多一次 LLM 呼叫）。
+	SelfCheck  bool
+	MaxRetries int
+}
+
+// DefaultGuardrailPolicy 預設只做啟發式檢查，重新生成 1 次。
+func DefaultGuardrailPolicy() GuardrailPolicy {
+	return GuardrailPolicy{Enabled: true, MaxUnsupported: 3, MaxRetries: 1}
+}
+
+// SetGuardrailPolicy 設定摘要驗證策略。
+func (w *Worker) SetGuardrailPolicy(p GuardrailPolicy) {
+	w.guardrails = p
+}
+
+// 拒答常見開頭；只比對摘要前段，避免摘要內容本身引用到這些字句而誤判。
+var refusalPatterns = []string{
+	"i'm sorry", "i am sorry", "i can't", "i cannot", "i'm unable", "i am unable", "as an ai",
+	"抱歉，我無法", "抱歉，我不能", "很抱歉", "我無法協助", "我無法提供", "作為一個ai", "作為ai",
+}
+
+var (
+	latinEntity  = regexp.MustCompile(`\b[A-Z][A-Za-z0-9&.\-]*[A-Za-z0-9](?:\s+[A-Z][A-Za-z0-9&.\-]*[A-Za-z0-9])*\b`)
+	numberEntity = regexp.MustCompile(`\d[\d,.]*\d%?`)
+	quotedEntity = regexp.MustCompile(`[「『《]([^」』》]{1,40})[」』》]`)
+)
+
+const selfCheckPrompt = `以下提供「逐字稿」與根據它產生的「摘要」。請檢查摘要是否包含逐字稿沒有提到的人名、組織、數字或事件，或是拒絕回答而非摘要。
+只回答一行：沒有問題回答 PASS；有問題回答 FAIL: 加上簡短原因。`
+
+// checkSummary 返回摘要被標記的原因（refusal / unsupported_entities / language / self_check），通過時為 nil。
+// 指定摘要語言時，即使未啟用驗證也檢查輸出語言。
+func (w *Worker) checkSummary(ctx context.Context, payload models.SummaryPayload, summary string) []string {
+	if !w.guardrails.Enabled {
+		return countFlags(w.checkSummaryLanguage(payload, summary))
+	}
+	var reasons []string
+	if strings.TrimSpace(summary) == "" {
+		return []string{"empty: 摘要為空"}
+	}
+	reasons = append(reasons, w.checkSummaryLanguage(payload, summary)...)
+	if isRefusal(summary) {
+		reasons = append(reasons, "refusal: 摘要為拒答")
+	}
+	// 摘要語言與轉錄稿不同時，人名與術語可能被翻譯或音譯，無法逐字比對
+	if !w.translatedSummary(payload) {
+		if missing := unsupportedEntities(payload.Transcript, summary); len(missing) > w.guardrails.MaxUnsupported {
+			reasons = append(reasons, "unsupported_entities: 逐字稿未提及 "+strings.Join(missing, "、"))
+		}
+	}
+	if w.guardrails.SelfCheck && len(reasons) == 0 {
+		input := fmt.Sprintf("逐字稿：\n%s\n\n摘要：\n%s", payload.Transcript, summary)
+		verdict, err := w.llmFor(payload.Canary).Summarize(ctx, input, selfCheckPrompt)
+		switch {
+		case err != nil:
+			// 自我檢查本身失敗不阻擋交付
+			w.logf(payload.TaskID, "Summary self-check failed for task %s: %v", payload.TaskID, err)
+		case strings.HasPrefix(strings.ToUpper(strings.TrimSpace(verdict)), "FAIL"):
+			reasons = append(reasons, "self_check: "+truncateRunes(strings.TrimSpace(verdict), 200))
+		}
+	}
+	return countFlags(reasons)
+}
+
+// countFlags 以原因類別累計 SummaryFlags 指標並原樣返回。
+func countFlags(reasons []string) []string {
+	for _, r := range reasons {
+		kind, _, _ := strings.Cut(r, ":")
+		metrics.SummaryFlags.WithLabelValues(kind).Inc()
+	}
+	return reasons
+}
+
+// isRefusal 判斷摘要開頭是否為 LLM 拒答。
+func isRefusal(summary string) bool {
+	head := strings.ToLower(truncateRunes(strings.TrimSpace(summary), 120))
+	head = strings.ReplaceAll(head, "’", "'")
+	for _, p := range refusalPatterns {
+		if strings.Contains(head, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedEntities 擷取摘要中的英文專有名詞、數字與引號括起的詞，返回 transcript 中找不到的項目（不分大小寫、忽略空白）。
+func unsupportedEntities(transcript, summary string) []string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), ""))
+	}
+	source := normalize(transcript)
+
+	var candidates []string
+	candidates = append(candidates, latinEntity.FindAllString(summary, -1)...)
+	candidates = append(candidates, numberEntity.FindAllString(summary, -1)...)
+	for _, m := range quotedEntity.FindAllStringSubmatch(summary, -1) {
+		candidates = append(candidates, m[1])
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, c := range candidates {
+		key := normalize(strings.TrimRight(c, "%.,"))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !strings.Contains(source, key) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// regenerateSummary 被標記的摘要重新投遞至 Summary 佇列，成功時 Ack 原訊息並通知前端清空已串流的摘要。
+// 已達重試上限或投遞失敗時返回 false，呼叫端改為交付並標記待審核。
+func (w *Worker) regenerateSummary(ctx context.Context, payload models.SummaryPayload, d *queue.Delivery, reasons []string) bool {
+	if payload.GuardrailRetries >= w.guardrails.MaxRetries {
+		return false
+	}
+	next := payload
+	next.GuardrailRetries++
+	body, err := json.Marshal(next)
+	if err != nil {
+		return false
+	}
+	if err := w.Broker.Publish(ctx, d.Queue, body); err != nil {
+		w.logf(payload.TaskID, "Task %s: re-queue flagged summary failed: %v", payload.TaskID, err)
+		return false
+	}
+	w.logf(payload.TaskID, "Summary of task %s flagged (%s), regenerating %d/%d",
+		payload.TaskID, strings.Join(reasons, "; "), next.GuardrailRetries, w.guardrails.MaxRetries)
+
+	w.Redis.Del(ctx, keys.SummaryBuffer(payload.TaskID))
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSummaryQueued)
+	w.ack(d)
+	w.notifyEvent(payload.TaskID, "summary_rejected", "摘要未通過檢查，重新生成中")
+	return true
+}
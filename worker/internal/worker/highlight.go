@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// highlightsFileName highlight reel 與原始音檔放在同一目錄。
+const highlightsFileName = "highlights.m4a"
+
+// SetKeepSourceAudio STT 完成後保留原始音檔，供之後剪輯 highlight reel。
+// 關閉時（預設）STT 完成即刪除音檔，highlight 匯出會因找不到音檔而失敗。
+func (w *Worker) SetKeepSourceAudio(keep bool) {
+	w.keepSourceAudio = keep
+}
+
+// ConsumeHighlightQueue 阻塞消費 highlight 佇列。剪輯不影響任務狀態，結果以 SSE 事件通知。
+func (w *Worker) ConsumeHighlightQueue(ctx context.Context) {
+	log.Println("Highlight queue consumer started")
+	for {
+		d, err := w.Broker.Consume(ctx, keys.Queue(queue.Highlight))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("ConsumeHighlightQueue: consume error: %v, retrying in 1s...", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var payload models.HighlightPayload
+		if err := json.Unmarshal(d.Body, &payload); err != nil {
+			log.Printf("ConsumeHighlightQueue: unmarshal error: %v, discarding message", err)
+			w.ack(d)
+			continue
+		}
+		w.tasks.Add(1)
+		go func() {
+			defer w.tasks.Done()
+			w.handleHighlight(payload, d)
+		}()
+	}
+}
+
+// handleHighlight 以 ffmpeg 剪出 highlight 片段並串接，寫入 tasks.highlights_path。
+func (w *Worker) handleHighlight(payload models.HighlightPayload, d *queue.Delivery) {
+	defer w.ack(d)
+	w.logf(payload.TaskID, "Processing highlight reel: %s (%d clips)", payload.TaskID, len(payload.Clips))
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.deadlines.Chunking)
+	defer cancel()
+
+	clips := make([]audio.Clip, 0, len(payload.Clips))
+	for _, c := range payload.Clips {
+		clips = append(clips, audio.Clip{Start: c.StartSec, End: c.EndSec})
+	}
+	outputPath := filepath.Join(filepath.Dir(payload.FilePath), highlightsFileName)
+
+	err := func() error {
+		if _, err := os.Stat(payload.FilePath); err != nil {
+			return fmt.Errorf("source audio unavailable: %w", err)
+		}
+		if err := audio.CutHighlights(ctx, payload.FilePath, clips, outputPath); err != nil {
+			return err
+		}
+		return db.SetHighlightsPath(w.DB, payload.TaskID, outputPath)
+	}()
+	if err != nil {
+		w.logf(payload.TaskID, "Highlight reel for task %s failed: %v", payload.TaskID, err)
+		w.notifyHighlights(payload.TaskID, "highlights_failed", err.Error())
+		return
+	}
+	w.notifyHighlights(payload.TaskID, "highlights_ready", "")
+}
+
+func (w *Worker) notifyHighlights(taskID, eventType, msg string) {
+	rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, models.SSEEvent{
+		TaskID:  taskID,
+		Type:    eventType,
+		Message: msg,
+	})
+}
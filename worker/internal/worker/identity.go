@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewInstanceID 產生 Worker instance ID：hostname（容器內為 container ID）加上隨機後綴，
+// 同一主機上的多個進程也不會重複。
+func NewInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// SetInstanceID 設定 Worker instance ID，記錄於 log、tasks.worker_id、指標與 Redis registry。
+func (w *Worker) SetInstanceID(id string) {
+	w.instanceID = id
+	host, _ := os.Hostname()
+	metrics.WorkerInfo.WithLabelValues(id, host).Set(1)
+}
+
+// InstanceID 返回 Worker instance ID。
+func (w *Worker) InstanceID() string {
+	return w.instanceID
+}
+
+// claimTask 記錄任務由本 instance 處理。
+func (w *Worker) claimTask(taskID string) {
+	if w.instanceID == "" {
+		return
+	}
+	if err := db.SetTaskWorker(w.DB, taskID, w.instanceID); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
+
+// InFlightTasks 返回進行中的 STT / Summary 任務 ID。
+func (w *Worker) InFlightTasks() []string {
+	ids := []string{}
+	w.activeCancels.Range(func(k, _ any) bool {
+		ids = append(ids, k.(string))
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
+
+// RunRegistry 定期將本 instance 的狀態（啟動時間、heartbeat、進行中任務）寫入 Redis registry，
+// 供維運查看各 instance 正在處理的任務。ctx 取消時移除登記。
+func (w *Worker) RunRegistry(ctx context.Context, interval time.Duration) {
+	startedAt := time.Now().Unix()
+	host, _ := os.Hostname()
+	key := keys.WorkerInstance(w.instanceID)
+
+	beat := func() {
+		inFlight := w.InFlightTasks()
+		metrics.InFlightTasks.Set(float64(len(inFlight)))
+		tasks, _ := json.Marshal(inFlight)
+		now := time.Now().Unix()
+		draining := "0"
+		if w.Draining() {
+			draining = "1"
+		}
+		_, err := w.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, "id", w.instanceID, "hostname", host, "startedAt", startedAt, "heartbeatAt", now, "inFlight", string(tasks), "draining", draining)
+			pipe.Expire(ctx, key, 3*interval)
+			pipe.ZAdd(ctx, keys.Workers(), redis.Z{Score: float64(now), Member: w.instanceID})
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Registry: heartbeat failed: %v", err)
+		}
+	}
+
+	beat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.Redis.Del(context.Background(), key)
+			w.Redis.ZRem(context.Background(), keys.Workers(), w.instanceID)
+			return
+		case <-ticker.C:
+			beat()
+		}
+	}
+}
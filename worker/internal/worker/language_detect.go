@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// 語言偵測來源，作為 language_detected 事件的 message。
+const (
+	languageFromHint       = "hint"         // 任務指定的語言提示
+	languageFromProvider   = "provider"     // STT provider 回報的語言
+	languageFromTranscript = "transcript"   // 以轉錄文字判斷（ai.GuessLanguage）
+	languageFromDuplicate  = "deduplicated" // 沿用相同音訊任務的偵測結果
+)
+
+// detectLanguage 決定轉錄稿的語言：使用者指定的語言（explicit）優先，其次為 provider 回報的語言，
+// 都沒有時以文字判斷；無法判斷時返回空字串。語言提示為部署預設時 provider 回報的語言通常只是回應提示，
+// 因此先以文字判斷，判斷不出時才採用。
+func detectLanguage(hint string, explicit bool, reported, text string) (lang, source string) {
+	switch {
+	case hint != "" && explicit:
+		return hint, languageFromHint
+	case reported != "" && hint == "":
+		return ai.NormalizeLanguage(reported), languageFromProvider
+	}
+	if lang := ai.GuessLanguage(text); lang != "" {
+		return lang, languageFromTranscript
+	}
+	if reported != "" {
+		return ai.NormalizeLanguage(reported), languageFromProvider
+	}
+	return "", ""
+}
+
+// reportLanguage 記錄偵測到的語言（tasks.detected_language）並推送 language_detected 事件；失敗只記錄 log。
+func (w *Worker) reportLanguage(taskID, lang, source string) {
+	w.logf(taskID, "Task %s: detected language %s (%s)", taskID, lang, source)
+	if err := db.SetDetectedLanguage(w.DB, taskID, lang); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+	rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, models.SSEEvent{
+		TaskID:   taskID,
+		Type:     "language_detected",
+		Message:  source,
+		Language: lang,
+	})
+}
+
+// transcriptLanguage 返回 STT 階段偵測到的語言，供摘要選用對應語言的預設指示；未知時為空字串。
+func (w *Worker) transcriptLanguage(taskID string) string {
+	lang, err := db.GetDetectedLanguage(w.DB, taskID)
+	if err != nil {
+		w.logf(taskID, "Summary task %s: %v", taskID, err)
+	}
+	return lang
+}
@@ -0,0 +1,267 @@
+package worker
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tts-worker/internal/ai"
+)
+
+// MergeStrategy 相鄰 chunk 轉錄稿重疊區段的合併設定。
+// chunk 之間有重疊音訊，前一段結尾與後一段開頭會出現相同內容，合併時需去除重複。
+type MergeStrategy struct {
+	// MaxWindow 比對重疊的最大 token 數。
+	MaxWindow int
+	// Fuzzy 以正規化（大小寫、標點、英文縮寫展開）後的編輯距離比對重疊，
+	// 容忍 "we'll" / "we will" 等轉錄差異；false 時需逐字完全相同。
+	Fuzzy bool
+	// Threshold 模糊比對的最低相似度（0~1）。
+	Threshold float64
+	// Unspaced 不以空白分詞的語言（中文、日文、泰文等）：以單一字元為 token 比對重疊，窗口放大為
+	// unspacedWindowFactor 倍，接縫不插入空白。依任務偵測到的語言由 forLanguage 設定。
+	Unspaced bool
+}
+
+// unspacedWindowFactor 字元 token 相對於英文單字的窗口倍數（一個英文單字約當數個中文字的語音長度）。
+const unspacedWindowFactor = 3
+
+// unspacedLanguages 不以空白分詞的語言（ISO-639-1）。
+var unspacedLanguages = map[string]bool{"zh": true, "yue": true, "ja": true, "th": true, "lo": true, "km": true, "my": true}
+
+// DefaultMergeStrategy 預設 10 個 token 窗口、相似度 0.8 的模糊比對。
+func DefaultMergeStrategy() MergeStrategy {
+	return MergeStrategy{MaxWindow: 10, Fuzzy: true, Threshold: 0.8}
+}
+
+// SetMergeStrategy 設定 chunk 轉錄稿的重疊合併策略。
+func (w *Worker) SetMergeStrategy(m MergeStrategy) {
+	if m.MaxWindow <= 0 {
+		m.MaxWindow = DefaultMergeStrategy().MaxWindow
+	}
+	w.merge = m
+}
+
+// forLanguage 返回適用於語言 lang 的合併策略：不以空白分詞的語言改以字元比對，其他語言（含未知）沿用原設定。
+func (m MergeStrategy) forLanguage(lang string) MergeStrategy {
+	m.Unspaced = unspacedLanguages[ai.NormalizeLanguage(lang)]
+	return m
+}
+
+// window 返回比對重疊的最大 token 數。
+func (m MergeStrategy) window() int {
+	if m.Unspaced {
+		return m.MaxWindow * unspacedWindowFactor
+	}
+	return m.MaxWindow
+}
+
+// merge 合併兩段可能重疊的文字，t2 開頭與 t1 結尾重疊的 token 只保留 t1 的版本。
+func (m MergeStrategy) merge(t1, t2 string) string {
+	merged, _ := m.mergeBoundary(t1, t2)
+	return merged
+}
+
+// mergeBoundary 同 merge，另返回該接縫的比對結果供品質監控。
+func (m MergeStrategy) mergeBoundary(t1, t2 string) (string, MergeBoundary) {
+	t1 = strings.TrimSpace(t1)
+	t2 = strings.TrimSpace(t2)
+	if t1 == "" || t2 == "" {
+		return t1 + t2, MergeBoundary{Skipped: true}
+	}
+
+	w1, _ := m.tokens(t1)
+	w2, starts := m.tokens(t2)
+
+	var overlap int
+	if m.Fuzzy {
+		overlap = m.fuzzyOverlap(w1, w2)
+	} else {
+		overlap = m.exactOverlap(w1, w2)
+	}
+	if m.Unspaced && overlap > 0 && overlap < len(w2) && w2[overlap] == w1[len(w1)-1] && isPunctToken(w2[overlap]) {
+		// 正規化時忽略標點，重疊區段結尾的標點（例如「。」）需另外去除，避免重複
+		overlap++
+	}
+
+	remainingW2 := w2[overlap:]
+	boundary := MergeBoundary{Overlap: overlap, Duplicated: m.duplicatedRun(w1, remainingW2)}
+	if len(remainingW2) == 0 {
+		return t1, boundary
+	}
+	if m.Unspaced {
+		// 保留 t2 原本的空白（例如中英夾雜的 "用 Google 的"）
+		return joinUnspaced(t1, t2[starts[overlap]:]), boundary
+	}
+	return t1 + " " + strings.Join(remainingW2, " "), boundary
+}
+
+// tokens 將文字切為比對用的 token：一般以空白分詞；Unspaced 時 CJK / 泰文等字元與標點各為一個 token，
+// 其餘（例如夾雜的英文單字、數字）以連續字元為一個 token。starts 為各 token 的起始 byte 位置，僅 Unspaced 時返回。
+func (m MergeStrategy) tokens(s string) (tokens []string, starts []int) {
+	if !m.Unspaced {
+		return strings.Fields(s), nil
+	}
+	wordStart := -1
+	flush := func(end int) {
+		if wordStart >= 0 {
+			tokens = append(tokens, s[wordStart:end])
+			starts = append(starts, wordStart)
+			wordStart = -1
+		}
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush(i)
+		case isUnspacedRune(r) || unicode.IsPunct(r):
+			flush(i)
+			tokens = append(tokens, string(r))
+			starts = append(starts, i)
+		case wordStart < 0:
+			wordStart = i
+		}
+	}
+	flush(len(s))
+	return tokens, starts
+}
+
+// isPunctToken 判斷 token 是否為單一標點。
+func isPunctToken(t string) bool {
+	r, size := utf8.DecodeRuneInString(t)
+	return size == len(t) && unicode.IsPunct(r)
+}
+
+// isUnspacedRune 判斷字元是否屬於不以空白分詞的文字系統。
+func isUnspacedRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+// joinUnspaced 直接串接兩段文字；只有接縫兩側皆為以空白分詞的字元（例如英文單字）時才插入空白。
+func joinUnspaced(t1, t2 string) string {
+	last, _ := utf8.DecodeLastRuneInString(t1)
+	first, _ := utf8.DecodeRuneInString(t2)
+	isWord := func(r rune) bool { return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isUnspacedRune(r) }
+	if isWord(last) && isWord(first) {
+		return t1 + " " + t2
+	}
+	return t1 + t2
+}
+
+// exactOverlap 返回 w1 結尾與 w2 開頭完全相同的最長 token 數。
+func (m MergeStrategy) exactOverlap(w1, w2 []string) int {
+	maxMatch := min(m.window(), len(w1), len(w2))
+	best := 0
+	for i := 1; i <= maxMatch; i++ {
+		match := true
+		for j := 0; j < i; j++ {
+			if w1[len(w1)-i+j] != w2[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			best = i
+		}
+	}
+	if m.Unspaced && best < 2 {
+		// 單一字元（例如「的」）相同多半是巧合
+		return 0
+	}
+	return best
+}
+
+// fuzzyOverlap 返回應從 w2 開頭移除的 token 數。兩側窗口長度可相差約三分之一（縮寫展開、斷詞差異），
+// 優先選擇較長的重疊；單一 token 的窗口誤判風險高，需正規化後完全相同。
+func (m MergeStrategy) fuzzyOverlap(w1, w2 []string) int {
+	best, bestLen, bestSim := 0, 0, 0.0
+	window := m.window()
+	for i := 1; i <= min(window, len(w1)); i++ {
+		if m.Unspaced && i == 1 {
+			// 單一字元（例如「的」）相同多半是巧合
+			continue
+		}
+		suffix := normalizeOverlap(w1[len(w1)-i:])
+		slack := max(1, i/3)
+		for j := max(1, i-slack); j <= min(i+slack, window, len(w2)); j++ {
+			prefix := normalizeOverlap(w2[:j])
+			if suffix == "" || prefix == "" {
+				continue
+			}
+			sim := similarity(suffix, prefix)
+			if i == 1 || j == 1 {
+				if suffix != prefix {
+					continue
+				}
+			} else if sim < m.Threshold {
+				continue
+			}
+			if i > bestLen || (i == bestLen && sim > bestSim) {
+				best, bestLen, bestSim = j, i, sim
+			}
+		}
+	}
+	return best
+}
+
+// 常見英文縮寫展開，讓 "we'll" 與 "we will" 正規化後相同。
+var contractions = []struct{ suffix, expansion string }{
+	{"n't", " not"}, {"'ll", " will"}, {"'re", " are"}, {"'ve", " have"}, {"'m", " am"}, {"'d", " would"},
+}
+
+// normalizeOverlap 將 token 轉小寫、展開縮寫並移除標點，以單一空白連接。
+func normalizeOverlap(tokens []string) string {
+	var words []string
+	for _, t := range tokens {
+		t = strings.ToLower(strings.ReplaceAll(t, "’", "'"))
+		t = strings.TrimFunc(t, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if t == "can't" {
+			t = "cannot"
+		}
+		for _, c := range contractions {
+			if strings.HasSuffix(t, c.suffix) {
+				t = strings.TrimSuffix(t, c.suffix) + c.expansion
+				break
+			}
+		}
+		t = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' {
+				return r
+			}
+			return -1
+		}, t)
+		words = append(words, strings.Fields(t)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// similarity 以字元編輯距離計算兩字串的相似度（1 為完全相同）。
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance Levenshtein 距離。
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
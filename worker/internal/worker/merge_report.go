@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"tts-worker/internal/db"
+	"tts-worker/internal/metrics"
+)
+
+// suspectDuplicateTokens 合併後接縫兩側仍重複出現的連續 token 數達此值即視為疑似重複句子。
+const suspectDuplicateTokens = 4
+
+// MergeBoundary 相鄰兩個 chunk 接縫的合併結果。
+type MergeBoundary struct {
+	// Chunk 接縫後方 chunk 的索引。
+	Chunk int `json:"chunk"`
+	// Overlap 從後方 chunk 開頭移除的重疊 token 數。
+	Overlap int `json:"overlap"`
+	// Duplicated 合併後接縫兩側仍相同的最長連續 token 數（正規化後比較），
+	// 通常代表重疊比對失敗、同一句話被保留兩次。
+	Duplicated int `json:"duplicated,omitempty"`
+	// Skipped 任一側轉錄稿為空，未進行比對。
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// MergeReport 單一任務的重疊合併品質紀錄，存於 tasks.merge_report 供排查。
+type MergeReport struct {
+	Boundaries []MergeBoundary `json:"boundaries"`
+	// ZeroOverlap 有內容但找不到任何重疊的接縫數。
+	ZeroOverlap int `json:"zeroOverlap"`
+	// SuspectDuplicates Duplicated 達 suspectDuplicateTokens 的接縫數。
+	SuspectDuplicates int `json:"suspectDuplicates"`
+}
+
+// mergeAll 依序合併所有 chunk 的轉錄稿並記錄每個接縫的比對結果。
+func (m MergeStrategy) mergeAll(transcripts []string) (string, MergeReport) {
+	report := MergeReport{Boundaries: []MergeBoundary{}}
+	if len(transcripts) == 0 {
+		return "", report
+	}
+	full := transcripts[0]
+	for i := 1; i < len(transcripts); i++ {
+		var b MergeBoundary
+		full, b = m.mergeBoundary(full, transcripts[i])
+		b.Chunk = i
+		if !b.Skipped && b.Overlap == 0 {
+			report.ZeroOverlap++
+		}
+		if b.Duplicated >= suspectDuplicateTokens {
+			report.SuspectDuplicates++
+		}
+		report.Boundaries = append(report.Boundaries, b)
+	}
+	return full, report
+}
+
+// duplicatedRun 返回 w1 結尾與 rest 開頭各 2 倍窗口內、正規化後相同的最長連續 token 數。
+func (m MergeStrategy) duplicatedRun(w1, rest []string) int {
+	span := 2 * m.window()
+	tail := normalizedTokens(w1[max(0, len(w1)-span):])
+	head := normalizedTokens(rest[:min(span, len(rest))])
+
+	// 最長共同子序列（連續）的動態規劃
+	best := 0
+	prev := make([]int, len(head)+1)
+	curr := make([]int, len(head)+1)
+	for i := 1; i <= len(tail); i++ {
+		for j := 1; j <= len(head); j++ {
+			if tail[i-1] != "" && tail[i-1] == head[j-1] {
+				curr[j] = prev[j-1] + 1
+				best = max(best, curr[j])
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return best
+}
+
+// normalizedTokens 逐 token 正規化（見 normalizeOverlap），保留原本的 token 位置。
+func normalizedTokens(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = normalizeOverlap([]string{t})
+	}
+	return out
+}
+
+// recordMergeReport 更新合併品質指標，並將報告寫入 DB 與任務 debug log。
+func (w *Worker) recordMergeReport(taskID string, report MergeReport) {
+	for _, b := range report.Boundaries {
+		if b.Skipped {
+			metrics.MergeBoundaries.WithLabelValues("skipped").Inc()
+			continue
+		}
+		metrics.MergeOverlapTokens.Observe(float64(b.Overlap))
+		switch {
+		case b.Duplicated >= suspectDuplicateTokens:
+			metrics.MergeBoundaries.WithLabelValues("suspect_duplicate").Inc()
+		case b.Overlap == 0:
+			metrics.MergeBoundaries.WithLabelValues("zero_overlap").Inc()
+		default:
+			metrics.MergeBoundaries.WithLabelValues("matched").Inc()
+		}
+	}
+	if len(report.Boundaries) == 0 {
+		return
+	}
+	if report.ZeroOverlap > 0 || report.SuspectDuplicates > 0 {
+		w.logf(taskID, "Merge of task %s: %d boundaries, %d without overlap match, %d suspected duplicates",
+			taskID, len(report.Boundaries), report.ZeroOverlap, report.SuspectDuplicates)
+	}
+	if err := db.SaveMergeReport(w.DB, taskID, report); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeOverlap(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   string
+	}{
+		{"lowercase and punctuation", []string{"Hello,", "World."}, "hello world"},
+		{"contraction", []string{"We'll", "see"}, "we will see"},
+		{"curly apostrophe", []string{"don’t"}, "do not"},
+		{"can't", []string{"Can't"}, "cannot"},
+		{"inner punctuation", []string{"U.S."}, "us"},
+		{"punctuation only", []string{"—", "..."}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeOverlap(tt.tokens); got != tt.want {
+				t.Errorf("normalizeOverlap(%q) = %q, want %q", tt.tokens, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyOverlap(t *testing.T) {
+	tests := []struct {
+		name   string
+		t1, t2 string
+		want   int
+	}{
+		{"exact overlap", "the quick brown fox jumps", "brown fox jumps over the lazy dog", 3},
+		{"contraction expanded", "and then we'll see", "we will see what happens next", 3},
+		{"contraction contracted", "we will see", "we'll see what happens next", 2},
+		{"case and punctuation", "it was late. Very late", "very late, so we left", 2},
+		{"no overlap", "hello world", "completely different text here", 0},
+		{"single token prefix of word", "at the end of the", "then we went home", 0},
+		{"similar but different words", "i said no", "i saw snow falling", 0},
+	}
+	m := DefaultMergeStrategy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.fuzzyOverlap(strings.Fields(tt.t1), strings.Fields(tt.t2)); got != tt.want {
+				t.Errorf("fuzzyOverlap(%q, %q) = %d, want %d", tt.t1, tt.t2, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/models"
+)
+
+// ModerationPolicy 交付前的內容審核。Moderator 為 nil 時停用。
+// 啟用時轉錄稿與摘要不再即時串流，審核通過後才一次送出；未通過的任務改為 flagged，內容保留供人工審核。
+type ModerationPolicy struct {
+	Moderator ai.Moderator
+	// FailClosed 審核服務失敗時視為任務錯誤（依重試政策重試或 failed）；否則略過審核並記錄。
+	FailClosed bool
+}
+
+// SetModerationPolicy 設定內容審核。
+func (w *Worker) SetModerationPolicy(p ModerationPolicy) {
+	w.moderation = p
+}
+
+// moderating 是否需審核此任務；canary 使用固定的測試音檔，不送審。
+func (w *Worker) moderating(canary bool) bool {
+	return w.moderation.Moderator != nil && !canary
+}
+
+// moderate 審核 stage（transcript / summary）的內容，未通過時返回審核結果，通過時返回 nil。
+func (w *Worker) moderate(ctx context.Context, taskID, stage, text string, canary bool) (*models.Moderation, error) {
+	if !w.moderating(canary) || strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	res, err := w.moderation.Moderator.Moderate(ctx, text)
+	if err != nil {
+		if w.moderation.FailClosed {
+			return nil, withClass(errClassModeration, fmt.Errorf("moderation: %w", err))
+		}
+		w.logf(taskID, "Task %s: %s moderation failed, delivering unchecked: %v", taskID, stage, err)
+		return nil, nil
+	}
+	if !res.Flagged {
+		return nil, nil
+	}
+	w.logf(taskID, "Task %s: %s flagged by moderation: %s", taskID, stage, strings.Join(res.Categories, ", "))
+	return &models.Moderation{Stage: stage, Categories: res.Categories, Scores: res.Scores}, nil
+}
+
+// notifyFlagged 更新 Redis 狀態並發送 flagged 事件與 webhook。DB 狀態由 db.FlagTask / db.SaveFlaggedSummary 更新。
+func (w *Worker) notifyFlagged(ctx context.Context, taskID string, m *models.Moderation) {
+	w.Redis.HSet(ctx, keys.Task(taskID), "status", models.StatusFlagged)
+	msg := fmt.Sprintf("內容未通過審核（%s）：%s", m.Stage, strings.Join(m.Categories, ", "))
+	w.notifyEvent(taskID, models.StatusFlagged, msg)
+	w.notifyWebhook(taskID, models.StatusFlagged, msg)
+	metrics.ModerationFlagged.WithLabelValues(m.Stage).Inc()
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"tts-worker/internal/ai"
+	"tts-worker/internal/models"
+)
+
+// LanguageRouting 多語言（code-switching）錄音的處理設定。
+type LanguageRouting struct {
+	// Detect 每個 chunk 請 provider 偵測語言，並記錄於 task_results.segments。
+	Detect bool
+	// Models 語言 → 專用 STT 模型。偵測到的語言有專用模型時，以該模型加上語言提示重新轉錄該 chunk。
+	Models map[string]string
+}
+
+// SetLanguageRouting 設定多語言偵測與語言專用模型。
+func (w *Worker) SetLanguageRouting(r LanguageRouting) {
+	w.langRouting = r
+}
+
+// ParseLanguageModels 解析 "zh=breeze-asr,en=whisper-large-v3" 格式的語言模型對應。
+func ParseLanguageModels(spec string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		lang, model, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || model == "" {
+			continue
+		}
+		m[ai.NormalizeLanguage(lang)] = strings.TrimSpace(model)
+	}
+	return m
+}
+
+// languageHint 任務指定的語言；空值、auto、multi 表示由 provider 逐段偵測。
+func languageHint(payload models.STTPayload) string {
+	if strings.EqualFold(payload.Config.Language, "multi") {
+		return ""
+	}
+	return ai.NormalizeLanguage(payload.Config.Language)
+}
+
+// transcribeOnce 轉錄單一音檔並返回其語言，prompt 為詞彙表、初始提示與前文提示（可為空）；啟用時間戳時一併返回逐段 / 逐字時間。
+// 未啟用偵測與時間戳、無語言提示且無任何提示，或 provider 不支援語言選項時，退回一般 STT。
+func (w *Worker) transcribeOnce(ctx context.Context, svc ai.STTService, taskID, path, hint string, p sttPrompt) (ai.STTResult, error) {
+	prompt := p.String()
+	las, ok := svc.(ai.LanguageAwareSTT)
+	if !ok || (!w.langRouting.Detect && !w.timestamps && hint == "" && prompt == "") {
+		text, err := svc.STT(ctx, path)
+		return ai.STTResult{Text: text, Language: hint}, err
+	}
+
+	res, err := las.TranscribeWithOptions(ctx, path, ai.STTOptions{Language: hint, DetectLanguage: w.langRouting.Detect, Prompt: prompt, Phrases: p.terms, Timestamps: w.timestamps})
+	if err != nil {
+		return ai.STTResult{}, err
+	}
+	if model := w.langRouting.Models[res.Language]; hint == "" && model != "" {
+		// 以偵測到的語言與專用模型重新轉錄，避免混合語言會議套用錯誤的語言模型而產生亂碼
+		routed, err := las.TranscribeWithOptions(ctx, path, ai.STTOptions{Language: res.Language, Model: model, Prompt: prompt, Phrases: p.terms, Timestamps: w.timestamps})
+		if err != nil {
+			w.logf(taskID, "Task %s: %s model %s failed, keeping detected transcript: %v", taskID, res.Language, model, err)
+		} else {
+			routed.Language = res.Language
+			res = routed
+		}
+	}
+	return res, nil
+}
+
+// buildSegments 將各 chunk 的轉錄與語言組為段落標記；所有語言皆未知時返回 nil。
+func buildSegments(transcripts, languages []string) []models.TranscriptSegment {
+	known := false
+	segments := make([]models.TranscriptSegment, len(transcripts))
+	for i := range transcripts {
+		segments[i] = models.TranscriptSegment{Index: i, Language: languages[i], Text: transcripts[i]}
+		known = known || languages[i] != ""
+	}
+	if !known {
+		return nil
+	}
+	return segments
+}
@@ -0,0 +1,18 @@
+package worker
+
+import "tts-worker/internal/textnorm"
+
+// SetTranscriptNormalization 啟用轉錄稿的數字與日期正規化（「三月五號下午三點半」→「3月5日下午3:30」），
+// 讓摘要與待辦事項期限更可靠。
+func (w *Worker) SetTranscriptNormalization(enabled bool) {
+	w.normalizeText = enabled
+}
+
+// normalizeTranscript 依 chunk 語言正規化轉錄文字；未啟用時原樣返回。
+// 在合併前逐段處理，重疊區段兩側經相同規則轉換，不影響合併比對。
+func (w *Worker) normalizeTranscript(text, lang string) string {
+	if !w.normalizeText {
+		return text
+	}
+	return textnorm.Normalize(text, lang)
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"tts-worker/internal/models"
+)
+
+// summaryInput 組合送交 LLM 的內容：transcript 之後附上使用者標註，讓摘要涵蓋使用者標記的重點。
+func summaryInput(payload models.SummaryPayload) string {
+	if len(payload.Notes) == 0 {
+		return payload.Transcript
+	}
+	var b strings.Builder
+	b.WriteString(payload.Transcript)
+	b.WriteString("\n\n使用者標註（請在摘要中優先涵蓋）：\n")
+	for _, n := range payload.Notes {
+		fmt.Fprintf(&b, "- [%s", formatOffset(n.StartSec))
+		if n.EndSec != nil {
+			fmt.Fprintf(&b, "–%s", formatOffset(*n.EndSec))
+		}
+		b.WriteString("]")
+		if n.Kind == "highlight" {
+			b.WriteString(" ★")
+		}
+		if n.Body != "" {
+			b.WriteString(" " + n.Body)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatOffset 將秒數格式化為 hh:mm:ss。
+func formatOffset(sec float64) string {
+	s := int(sec)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s%3600/60, s%60)
+}
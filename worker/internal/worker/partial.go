@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// partialGap 標示失敗 chunk 在部分轉錄稿中的位置，以及無法解碼而略過的音訊區段。
+const partialGap = "[…]"
+
+// skippedAudio 因無法解碼而略過的音訊區段統計。
+type skippedAudio struct {
+	Gaps    int     `json:"gaps"`
+	Seconds float64 `json:"seconds"`
+}
+
+func (s *skippedAudio) add(sec float64) {
+	s.Gaps++
+	s.Seconds += sec
+}
+
+// recordSkippedAudio 將略過的區段記錄於 tasks.audio_metadata.skipped；失敗只記錄 log。
+func (w *Worker) recordSkippedAudio(taskID string, s skippedAudio) {
+	if s.Gaps == 0 {
+		return
+	}
+	w.logf(taskID, "Task %s: skipped %d undecodable section(s), %.0fs of audio", taskID, s.Gaps, s.Seconds)
+	if err := db.MergeAudioMetadata(w.DB, taskID, map[string]any{"skipped": s}); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
+
+// failSTTWithPartial STT 在部分 chunk 已成功後失敗且不會再重試時，先保存部分轉錄稿（task_results.partial）
+// 並推送 partial_result 事件，再交由 handleSTTError 標記終態。使用者取消時不保存。
+func (w *Worker) failSTTWithPartial(ctx context.Context, payload models.STTPayload, d *queue.Delivery, transcripts []string, err error) {
+	if !errors.Is(err, context.Canceled) && !w.willRetry(payload.Canary, payload.RetryCount, err) {
+		if text, done := partialTranscript(transcripts, w.merge); done > 0 {
+			if saveErr := db.SavePartialTranscript(w.DB, payload.TaskID, text); saveErr != nil {
+				w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, saveErr)
+			} else {
+				w.logf(payload.TaskID, "STT task %s: saved partial transcript (%d/%d chunks)", payload.TaskID, done, len(transcripts))
+				rdb_lib.PublishProgress(w.Redis, context.Background(), payload.TaskID, models.SSEEvent{
+					TaskID:  payload.TaskID,
+					Type:    "partial_result",
+					Content: text,
+					Message: fmt.Sprintf("轉譯中斷，已保留 %d/%d 段的部分結果", done, len(transcripts)),
+				})
+			}
+		}
+	}
+	w.handleSTTError(ctx, payload, d, err)
+}
+
+// willRetry 判斷 handleSTTError / handleSummaryError 是否會延遲重試此錯誤。
+func (w *Worker) willRetry(canary bool, retryCount int, err error) bool {
+	_, delayed := w.Broker.(queue.DelayedPublisher)
+	return delayed && w.retryable(canary, retryCount, err)
+}
+
+// partialTranscript 依序合併已成功的 chunk，缺漏處以 partialGap 標示；返回成功的 chunk 數。
+func partialTranscript(transcripts []string, m MergeStrategy) (string, int) {
+	var parts []string
+	current, done := "", 0
+	for _, t := range transcripts {
+		if t == "" {
+			if current != "" {
+				parts = append(parts, current)
+				current = ""
+			}
+			if len(parts) == 0 || parts[len(parts)-1] != partialGap {
+				parts = append(parts, partialGap)
+			}
+			continue
+		}
+		done++
+		current = m.merge(current, t)
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	if done == 0 {
+		return "", 0
+	}
+	return strings.Join(parts, "\n"), done
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"tts-worker/internal/models"
+)
+
+// PersonaPolicy 摘要可選用的人設。部署層級的基礎指示（語氣、結構、法律聲明）見 PromptTemplates.System；
+// 任務只能以名稱選用 Personas 中預先核准的人設，不接受任意文字，避免使用者覆寫基礎指示。
+type PersonaPolicy struct {
+	Personas map[string]string
+}
+
+// ParsePersonas 解析 SUMMARY_PERSONAS（JSON 物件：名稱 → 附加的 system 指示）。
+func ParsePersonas(spec string) (map[string]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	var personas map[string]string
+	if err := json.Unmarshal([]byte(spec), &personas); err != nil {
+		return nil, fmt.Errorf("ParsePersonas: %w", err)
+	}
+	return personas, nil
+}
+
+// SetPersonaPolicy 設定摘要可選用的人設清單。
+func (w *Worker) SetPersonaPolicy(p PersonaPolicy) {
+	w.personas = p
+}
+
+// summarySystemPrompt 組合部署的基礎指示 system 與任務選用的人設；皆未設定時返回空字串（沿用 provider 預設）。
+// 未在清單中的人設名稱會被忽略並記錄。
+func (w *Worker) summarySystemPrompt(p models.SummaryPayload, system string) string {
+	name := p.Config.Persona
+	if name == "" {
+		return system
+	}
+	persona, ok := w.personas.Personas[name]
+	if !ok {
+		w.logf(p.TaskID, "Task %s: persona %q is not allowlisted, using the default system prompt", p.TaskID, name)
+		return system
+	}
+	if system == "" {
+		return persona
+	}
+	return system + "\n\n" + persona
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"os"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// SetLoudnorm 設定未指定 config.loudnorm 的任務是否在切片前做響度正規化。
+func (w *Worker) SetLoudnorm(enabled bool) {
+	w.loudnorm = enabled
+}
+
+// SetAudioCleanup 設定未指定 config.cleanup 的任務使用的音訊清理預設（audio.CleanupPresets），空字串表示不清理。
+func (w *Worker) SetAudioCleanup(preset string) {
+	w.cleanupPreset = preset
+}
+
+// SetSpeedUp 設定切片前的加速倍率（audio.SpeedUp），1 表示不加速。
+func (w *Worker) SetSpeedUp(factor float64) {
+	w.speed = factor
+}
+
+// preprocessAudio 切片前的音訊前處理：依任務設定先清理雜訊、再正規化響度，最後依部署設定加速，
+// 返回後續切片使用的音檔路徑與實際套用的加速倍率（分片中的時間乘以倍率即為原始錄音時間）。
+// 各步驟失敗時記錄 log 並沿用前一步的檔案，不讓前處理導致任務失敗。返回的 cleanup 需在切片完成後呼叫。
+func (w *Worker) preprocessAudio(ctx context.Context, payload models.STTPayload) (string, float64, func()) {
+	path := payload.FilePath
+	speed := 1.0
+	var temps []string
+	done := func() {
+		for _, t := range temps {
+			os.Remove(t)
+		}
+	}
+
+	dir := w.taskScratchDir(payload)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		w.logf(payload.TaskID, "Task %s: preprocessing skipped: %v", payload.TaskID, err)
+		return path, speed, done
+	}
+
+	if preset := w.cleanupFor(payload); preset != "" {
+		cleaned, err := audio.CleanAudio(ctx, path, dir, preset)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logf(payload.TaskID, "Task %s: audio cleanup skipped: %v", payload.TaskID, err)
+			}
+		} else {
+			temps = append(temps, cleaned)
+			path = cleaned
+			if err := db.MergeAudioMetadata(w.DB, payload.TaskID, map[string]any{"cleanup": preset}); err != nil {
+				w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+			}
+		}
+	}
+
+	if w.loudnormFor(payload) {
+		normalized, stats, err := audio.NormalizeLoudness(ctx, path, dir)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logf(payload.TaskID, "Task %s: loudness normalization skipped: %v", payload.TaskID, err)
+			}
+		} else {
+			temps = append(temps, normalized)
+			path = normalized
+			w.logf(payload.TaskID, "Task %s: loudness %.1f → %.1f LUFS", payload.TaskID, stats.Before.Integrated, stats.After.Integrated)
+			if err := db.MergeAudioMetadata(w.DB, payload.TaskID, map[string]any{"loudness": stats}); err != nil {
+				w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+			}
+		}
+	}
+
+	if w.speed > audio.MinSpeedFactor {
+		faster, err := audio.SpeedUp(ctx, path, dir, w.speed)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logf(payload.TaskID, "Task %s: speed-up skipped: %v", payload.TaskID, err)
+			}
+		} else {
+			temps = append(temps, faster)
+			path = faster
+			speed = w.speed
+			if err := db.MergeAudioMetadata(w.DB, payload.TaskID, map[string]any{"speed": speed}); err != nil {
+				w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+			}
+		}
+	}
+	return path, speed, done
+}
+
+// cleanupFor 任務實際使用的音訊清理預設，空字串表示不清理。
+func (w *Worker) cleanupFor(payload models.STTPayload) string {
+	preset := w.cleanupPreset
+	if payload.Config.Cleanup != nil {
+		preset = *payload.Config.Cleanup
+	}
+	if preset == "off" {
+		return ""
+	}
+	return preset
+}
+
+// loudnormFor 任務是否做響度正規化：任務設定優先，否則沿用 Worker 設定。
+func (w *Worker) loudnormFor(payload models.STTPayload) bool {
+	if payload.Config.Loudnorm != nil {
+		return *payload.Config.Loudnorm
+	}
+	return w.loudnorm
+}
+
+// preprocessSteps 任務啟用的前處理步驟數，每個步驟在暫存目錄產生一份完整的 16kHz WAV。
+func (w *Worker) preprocessSteps(payload models.STTPayload) int {
+	n := 0
+	if w.speech.Trim {
+		n++
+	}
+	if w.cleanupFor(payload) != "" {
+		n++
+	}
+	if w.loudnormFor(payload) {
+		n++
+	}
+	if w.speed > audio.MinSpeedFactor {
+		n++
+	}
+	return n
+}
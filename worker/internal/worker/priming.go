@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/models"
+)
+
+// ContextPriming 以前一個 chunk 轉錄稿的結尾作為下一個 chunk 的 STT prompt（OpenAI 相容 API 的 prompt 欄位），
+// 讓 provider 延續專有名詞拼寫與句子，改善接縫處的連貫性，也可搭配較短的 CHUNK_OVERLAP_SEC。
+// 需等待前一個 chunk 完成，啟用後 chunk 的轉錄會趨於依序進行。
+type ContextPriming struct {
+	Enabled bool
+	// TailChars 取前一段轉錄稿結尾的字元數上限（Whisper prompt 約 224 token）。
+	TailChars int
+	// MaxWait 等待前一個 chunk 的上限，逾時則不帶 prompt 轉錄以保留並發；0 表示一律等待。
+	MaxWait time.Duration
+}
+
+// DefaultContextPriming 預設停用，啟用時取 200 字元並一律等待。
+func DefaultContextPriming() ContextPriming {
+	return ContextPriming{TailChars: 200}
+}
+
+// SetContextPriming 設定 chunk 接縫的前文提示。
+func (w *Worker) SetContextPriming(p ContextPriming) {
+	if p.TailChars <= 0 {
+		p.TailChars = DefaultContextPriming().TailChars
+	}
+	w.priming = p
+}
+
+// chunkSequence 追蹤各 chunk 的轉錄完成狀態，讓並發的 chunk goroutine 取得前一個 chunk 的轉錄稿。
+// 串流切片時 chunk 陸續加入（add）。nil 表示未啟用前文提示。
+type chunkSequence struct {
+	mu    sync.Mutex
+	steps []*chunkStep
+}
+
+// chunkStep 單一 chunk 的完成訊號與轉錄稿。
+type chunkStep struct {
+	done chan struct{}
+	text string
+	once sync.Once
+}
+
+// newChunkSequence 啟用前文提示且 provider 支援 prompt 時返回 chunkSequence，否則返回 nil。
+func (w *Worker) newChunkSequence(svc ai.STTService) *chunkSequence {
+	if _, ok := svc.(ai.LanguageAwareSTT); !ok || !w.priming.Enabled {
+		return nil
+	}
+	return &chunkSequence{}
+}
+
+// add 登記下一個 chunk，需在該 chunk 的 goroutine 啟動前呼叫。
+func (s *chunkSequence) add() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.steps = append(s.steps, &chunkStep{done: make(chan struct{})})
+	s.mu.Unlock()
+}
+
+// step 返回 chunk idx 的狀態。
+func (s *chunkSequence) step(idx int) *chunkStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.steps[idx]
+}
+
+// finish 記錄 chunk idx 的轉錄稿並喚醒等待中的下一個 chunk；失敗時以空字串呼叫。
+func (s *chunkSequence) finish(idx int, text string) {
+	if s == nil {
+		return
+	}
+	st := s.step(idx)
+	st.once.Do(func() {
+		st.text = text
+		close(st.done)
+	})
+}
+
+// prompt 等待前一個 chunk 完成（至多 maxWait）並返回其轉錄稿結尾；第一個 chunk 或逾時返回空字串。
+func (s *chunkSequence) prompt(ctx context.Context, idx int, p ContextPriming) string {
+	if s == nil || idx == 0 {
+		return ""
+	}
+	var timeout <-chan time.Time
+	if p.MaxWait > 0 {
+		timer := time.NewTimer(p.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	prev := s.step(idx - 1)
+	select {
+	case <-prev.done:
+		return primingTail(prev.text, p.TailChars)
+	case <-timeout:
+	case <-ctx.Done():
+	}
+	return ""
+}
+
+// primingTail 返回 text 結尾至多 n 個字元；以空白分詞的語言從字詞邊界開始，避免半個單字。
+func primingTail(text string, n int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	tail := string(runes[len(runes)-n:])
+	if i := strings.IndexFunc(tail, unicode.IsSpace); i >= 0 && i < len(tail)/2 {
+		tail = tail[i:]
+	}
+	return strings.TrimSpace(tail)
+}
+
+// sttPrompt 送往 provider 的 prompt：任務的詞彙表與初始提示（config.prompt，例如人名、專有名詞的寫法）
+// 加上前一段轉錄稿的結尾（前文提示）。詞彙表與初始提示每個 chunk 都會帶上，前文提示隨 chunk 更新。
+type sttPrompt struct {
+	terms    []string // 詞彙表的個別詞（Google 等以片語清單提示的 provider）
+	glossary string
+	initial  string
+	tail     string
+}
+
+// taskPrompt 任務層級的 prompt（詞彙表與初始提示），前文提示由各 chunk 填入 tail。
+func taskPrompt(payload models.STTPayload, glossary []string) sttPrompt {
+	return sttPrompt{terms: glossary, glossary: strings.Join(glossary, ", "), initial: strings.TrimSpace(payload.Config.Prompt)}
+}
+
+// String 依詞彙表、初始提示、前文提示的順序以換行串接非空的部分；Whisper 只保留 prompt 的結尾，
+// 過長時最先被截掉的是詞彙表。
+func (p sttPrompt) String() string {
+	var parts []string
+	for _, s := range []string{p.glossary, p.initial, p.tail} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"os"
+	"strings"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// promptTemplateName DB prompt_templates 中摘要使用的列。
+const promptTemplateName = "summary"
+
+// PromptTemplates 摘要的提示模板。System 為部署的基礎 system 指示，User 為 user message 模板
+// （{{prompt}} 為任務或 provider 的摘要指示、{{transcript}} 為轉錄稿）；兩者皆可使用 {{language}}、{{duration}}。
+// 值以 @ 開頭時視為檔案路徑，每次摘要重新讀取；DB prompt_templates 中 "summary" 列有設定的欄位優先，
+// 修改後不需重新部署。
+type PromptTemplates struct {
+	System string
+	User   string
+}
+
+// SetPromptTemplates 設定摘要的提示模板。
+func (w *Worker) SetPromptTemplates(t PromptTemplates) {
+	w.prompts = t
+}
+
+// summaryTemplates 解析本次摘要的模板（DB > 檔案 / 環境變數）並代入 {{language}}、{{duration}}；
+// system 模板另代入 {{transcript}}，user 模板的 {{prompt}} 與 {{transcript}} 由 provider 代入。
+func (w *Worker) summaryTemplates(p models.SummaryPayload) PromptTemplates {
+	t := PromptTemplates{
+		System: w.loadTemplate(p.TaskID, w.prompts.System),
+		User:   w.loadTemplate(p.TaskID, w.prompts.User),
+	}
+	if row, err := db.GetPromptTemplate(w.DB, promptTemplateName); err != nil {
+		w.logf(p.TaskID, "Summary task %s: %v", p.TaskID, err)
+	} else {
+		if row.System != "" {
+			t.System = row.System
+		}
+		if row.User != "" {
+			t.User = row.User
+		}
+	}
+	if !strings.Contains(t.System+t.User, "{{") {
+		return t
+	}
+
+	lang, duration, err := db.GetPromptContext(w.DB, p.TaskID)
+	if err != nil {
+		w.logf(p.TaskID, "Summary task %s: %v", p.TaskID, err)
+	}
+	vars := map[string]string{ai.PlaceholderLanguage: lang, ai.PlaceholderDuration: ""}
+	if duration > 0 {
+		vars[ai.PlaceholderDuration] = formatOffset(duration)
+	}
+	t.User = ai.RenderTemplate(t.User, vars)
+	vars[ai.PlaceholderTranscript] = summaryInput(p)
+	t.System = ai.RenderTemplate(t.System, vars)
+	return t
+}
+
+// loadTemplate 讀取 @ 開頭的模板檔案；讀取失敗時記錄並沿用預設（空字串）。
+func (w *Worker) loadTemplate(taskID, spec string) string {
+	path, ok := strings.CutPrefix(spec, "@")
+	if !ok {
+		return spec
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		w.logf(taskID, "Summary task %s: prompt template: %v", taskID, err)
+		return ""
+	}
+	return string(b)
+}
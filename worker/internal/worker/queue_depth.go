@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
+	"tts-worker/internal/queue"
+)
+
+// PublishQueueDepth 定期讀取各佇列積壓量，寫入 Prometheus gauge 與 Redis key（keys.QueueDepth，TTL 為 3 個週期）。
+// backend 不支援 DepthReporter 時直接返回。
+func (w *Worker) PublishQueueDepth(ctx context.Context, interval time.Duration, queues ...string) {
+	reporter, ok := w.Broker.(queue.DepthReporter)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, q := range queues {
+			depth, err := reporter.Depth(ctx, q)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("PublishQueueDepth (%s): %v", q, err)
+				}
+				continue
+			}
+			metrics.QueueDepth.WithLabelValues(q).Set(float64(depth))
+			w.Redis.Set(ctx, keys.QueueDepth(q), strconv.FormatInt(depth, 10), 3*interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
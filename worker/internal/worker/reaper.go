@@ -13,17 +13,21 @@ const (
 	reaperInterval    = 10 * time.Minute
 	reaperLockTTL     = 2 * time.Minute
 	reaperLockKeyBase = "worker:reaper:lock"
-	taskTimeout       = 30 * time.Minute
+	// taskTimeout 未依階段 deadline 計算時（例如精華片段）的預設超時。
+	taskTimeout = 30 * time.Minute
+	// reaperMargin 階段 deadline 之外額外保留的時間（驗證、前處理、審核、寫入 DB 等），避免仍在執行的任務被重新入列。
+	reaperMargin = 10 * time.Minute
 )
 
-// reaperScript 原子執行「掃描超時任務 → ZREM → LPUSH 重新入列」。
+// reaperScript 原子執行「掃描超時任務 → ZREM → RPUSH 重新入列」。
+// 佇列以 BRPOP 取出，RPUSH 讓超時任務排在佇列最前面優先處理，不必再等整個 backlog。
 // KEYS[1] = processingZSet, KEYS[2] = queue, ARGV[1] = cutoff Unix timestamp（string）
 // 回傳重新入列的任務數量。
 var reaperScript = redis.NewScript(`
 local members = redis.call('ZRANGEBYSCORE', KEYS[1], '0', ARGV[1])
 for _, member in ipairs(members) do
     redis.call('ZREM', KEYS[1], member)
-    redis.call('LPUSH', KEYS[2], member)
+    redis.call('RPUSH', KEYS[2], member)
 end
 return #members
 `)
@@ -31,18 +35,33 @@ return #members
 // Reaper 負責定期掃描 processing ZSET，將超時卡死的任務重新入列。
 // 透過 Redis SetNX leader election 確保多 Worker 部署時只有一個執行。
 type Reaper struct {
-	rdb *redis.Client
+	rdb     *redis.Client
+	timeout time.Duration
 }
 
-// NewReaper 建立 Reaper 實例。
-func NewReaper(rdb *redis.Client) *Reaper {
-	return &Reaper{rdb: rdb}
+// NewReaper 建立 Reaper 實例；timeout 為任務在 processing 中超過多久視為卡死，<= 0 時使用 taskTimeout。
+// timeout 必須大於該佇列任務的最長執行時間，否則仍在執行的任務會被重新入列而重複處理（見 STTReaperTimeout）。
+func NewReaper(rdb *redis.Client, timeout time.Duration) *Reaper {
+	if timeout <= 0 {
+		timeout = taskTimeout
+	}
+	return &Reaper{rdb: rdb, timeout: timeout}
+}
+
+// STTReaperTimeout 依設定的 deadline 計算 STT 佇列的 Reaper 超時：遠端來源下載 + 切片 + 轉錄，再加上 reaperMargin。
+func (w *Worker) STTReaperTimeout() time.Duration {
+	return max(taskTimeout, w.source.Timeout+w.deadlines.Chunking+w.deadlines.STT+reaperMargin)
+}
+
+// SummaryReaperTimeout 依設定的摘要 deadline 計算 Summary 佇列的 Reaper 超時。
+func (w *Worker) SummaryReaperTimeout() time.Duration {
+	return max(taskTimeout, w.deadlines.Summary+reaperMargin)
 }
 
 // Start 啟動 Reaper，定期掃描指定的 processingKey（ZSET）並將超時任務重新推回 queueKey（LIST）。
 // 到 ctx 取消時退出。
 func (r *Reaper) Start(ctx context.Context, processingKey, queueKey string) {
-	log.Printf("Reaper started: %s → %s", processingKey, queueKey)
+	log.Printf("Reaper started: %s → %s (timeout %s)", processingKey, queueKey, r.timeout)
 	ticker := time.NewTicker(reaperInterval)
 	defer ticker.Stop()
 
@@ -63,7 +82,7 @@ func (r *Reaper) Start(ctx context.Context, processingKey, queueKey string) {
 				continue
 			}
 
-			cutoff := time.Now().Add(-taskTimeout).Unix()
+			cutoff := time.Now().Add(-r.timeout).Unix()
 			count, err := reaperScript.Run(ctx, r.rdb, []string{processingKey, queueKey}, cutoff).Int()
 			if err != nil {
 				log.Printf("Reaper (%s): requeue script failed: %v", processingKey, err)
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/audio"
+)
+
+// maxResplitDepth provider 因大小拒絕時對半切割的最大層數（最小為原 chunk 的 1/8）。
+const maxResplitDepth = 3
+
+// transcribeChunk 轉錄單一 chunk 並返回其語言。provider 以 413 / payload too large 拒絕時，
+// 將 chunk 對半切割後分別轉錄再合併，使未公開大小上限的 provider 也能完成任務。
+// 啟用前文提示（見 ContextPriming）時，後半段改以前半段的結尾提示，任務的初始提示維持不變。
+func (w *Worker) transcribeChunk(ctx context.Context, svc ai.STTService, taskID, path, hint string, prompt sttPrompt) (ai.STTResult, error) {
+	return w.transcribeResplit(ctx, svc, taskID, path, hint, prompt, 0)
+}
+
+func (w *Worker) transcribeResplit(ctx context.Context, svc ai.STTService, taskID, path, hint string, prompt sttPrompt, depth int) (ai.STTResult, error) {
+	res, err := w.transcribeOnce(ctx, svc, taskID, path, hint, prompt)
+	if err == nil || !errors.Is(err, ai.ErrPayloadTooLarge) || depth >= maxResplitDepth {
+		return res, err
+	}
+
+	halves, secondStart, splitErr := audio.SplitHalves(ctx, path, w.chunking.Default.Overlap)
+	if splitErr != nil {
+		w.logf(taskID, "Task %s: failed to re-split oversized chunk %s: %v", taskID, path, splitErr)
+		return ai.STTResult{}, err
+	}
+	defer os.Remove(halves[0])
+	defer os.Remove(halves[1])
+	w.logf(taskID, "Task %s: provider rejected %s as too large, retrying in halves (depth %d)", taskID, path, depth+1)
+
+	first, err := w.transcribeResplit(ctx, svc, taskID, halves[0], hint, prompt, depth+1)
+	if err != nil {
+		return ai.STTResult{}, err
+	}
+	if w.priming.Enabled {
+		prompt.tail = primingTail(first.Text, w.priming.TailChars)
+	}
+	second, err := w.transcribeResplit(ctx, svc, taskID, halves[1], hint, prompt, depth+1)
+	if err != nil {
+		return ai.STTResult{}, err
+	}
+	first.Text = w.merge.merge(first.Text, second.Text)
+	appendShifted(&first, second, secondStart)
+	return first, nil
+}
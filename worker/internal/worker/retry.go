@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// RetryPolicy 暫時性錯誤的延遲重試策略：第 n 次重試延遲 BaseDelay * 2^(n-1)，上限 MaxDelay。
+// 需 backend 支援延遲投遞（queue.DelayedPublisher），否則直接標記 failed。
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy 預設重試 2 次，延遲 30s、60s。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: 2, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute}
+}
+
+// SetRetryPolicy 設定延遲重試策略，MaxRetries 為 0 時停用。
+func (w *Worker) SetRetryPolicy(p RetryPolicy) {
+	w.retryPolicy = p
+}
+
+// retryable 判斷錯誤是否值得重新投遞：provider、儲存與逾時屬暫時性錯誤；
+// 音檔錯誤重試結果相同，使用者取消與 Canary 任務不重試。
+func (w *Worker) retryable(canary bool, retryCount int, err error) bool {
+	if canary || retryCount >= w.retryPolicy.MaxRetries || errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch errorClass(err) {
+	case errClassSTTProvider, errClassLLMProvider, errClassStorage, errClassDownload, errClassTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay 第 attempt 次重試（1-based）的延遲。
+func (w *Worker) retryDelay(attempt int) time.Duration {
+	delay := w.retryPolicy.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > w.retryPolicy.MaxDelay {
+		delay = w.retryPolicy.MaxDelay
+	}
+	return delay
+}
+
+// scheduleRetry 將 next（RetryCount 已遞增）延遲投遞回 queueName，成功後 Ack 原訊息並將狀態退回 queuedStatus。
+// 返回 false 表示 backend 不支援延遲投遞或投遞失敗，呼叫端應繼續原本的失敗流程。
+func (w *Worker) scheduleRetry(d *queue.Delivery, taskID, queuedStatus string, attempt int, next any, cause error) bool {
+	dp, ok := w.Broker.(queue.DelayedPublisher)
+	if !ok {
+		return false
+	}
+	body, err := json.Marshal(next)
+	if err != nil {
+		return false
+	}
+
+	ctx := context.Background()
+	delay := w.retryDelay(attempt)
+	if err := dp.PublishDelayed(ctx, d.Queue, body, delay); err != nil {
+		w.logf(taskID, "Task %s: schedule retry failed: %v", taskID, err)
+		return false
+	}
+	w.logf(taskID, "Task %s failed transiently (%v), retry %d/%d in %s", taskID, cause, attempt, w.retryPolicy.MaxRetries, delay)
+
+	w.Redis.HSet(ctx, keys.Task(taskID), "status", queuedStatus)
+	w.ack(d)
+	rdb_lib.PublishProgress(w.Redis, ctx, taskID, models.SSEEvent{
+		TaskID:  taskID,
+		Type:    "retry_scheduled",
+		Status:  queuedStatus,
+		Message: fmt.Sprintf("暫時性錯誤，%s 後重試（%d/%d）", delay, attempt, w.retryPolicy.MaxRetries),
+	})
+	return true
+}
@@ -0,0 +1,13 @@
+package worker
+
+import "syscall"
+
+// selfPeakRSS 取得 Worker 行程自啟動以來的峰值 RSS（bytes）。
+// 以任務前後的差值估算該任務推高的記憶體峰值；並發任務時為近似值。
+func selfPeakRSS() int64 {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return ru.Maxrss * 1024
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package worker
+
+// selfPeakRSS 非 Linux 平台不收集 RSS。
+func selfPeakRSS() int64 {
+	return 0
+}
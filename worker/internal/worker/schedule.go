@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// deferUntil 任務帶有尚未到達的 notBefore 時延後處理，返回 true 表示訊息已原樣重新延遲投遞並 Ack，呼叫端不再處理。
+// backend 支援延遲投遞時重新投遞（SQS 單次上限 15 分鐘，屆時再次延遲）；
+// 否則（Kafka）在此等待至 notBefore 或任務被取消後返回 false，由呼叫端照常處理。
+func (w *Worker) deferUntil(ctx context.Context, d *queue.Delivery, taskID, queuedStatus string, notBefore *time.Time) bool {
+	if notBefore == nil {
+		return false
+	}
+	wait := time.Until(*notBefore)
+	if wait <= 0 {
+		return false
+	}
+
+	w.Redis.HSet(ctx, keys.Task(taskID), "status", queuedStatus, "notBefore", notBefore.Unix())
+	rdb_lib.PublishProgress(w.Redis, ctx, taskID, models.SSEEvent{
+		TaskID:  taskID,
+		Type:    "scheduled",
+		Status:  queuedStatus,
+		Message: fmt.Sprintf("排程於 %s 開始處理", notBefore.Local().Format("2006-01-02 15:04")),
+	})
+
+	if dp, ok := w.Broker.(queue.DelayedPublisher); ok {
+		err := dp.PublishDelayed(ctx, d.Queue, d.Body, wait)
+		if err == nil {
+			w.logf(taskID, "Task %s scheduled for %s", taskID, notBefore.Format(time.RFC3339))
+			w.ack(d)
+			return true
+		}
+		w.logf(taskID, "Task %s: park until %s failed: %v, waiting in-process", taskID, notBefore.Format(time.RFC3339), err)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
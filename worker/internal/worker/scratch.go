@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/models"
+)
+
+// ErrInsufficientScratchSpace 暫存目錄的可用空間不足以容納預估的前處理檔、解碼檔與分片。
+var ErrInsufficientScratchSpace = errors.New("insufficient scratch space")
+
+// scratchHeadroom 預估暫存空間的安全係數（WAV 檔頭、重疊區段與估算誤差）。
+const scratchHeadroom = 1.2
+
+// SetScratchDir 設定分片與前處理暫存檔的根目錄（例如 tmpfs），每個任務使用 {dir}/{taskId}；
+// 空字串表示寫在上傳音檔旁。
+func (w *Worker) SetScratchDir(dir string) {
+	w.scratchDir = dir
+}
+
+// taskScratchDir 任務的暫存目錄。
+func (w *Worker) taskScratchDir(p models.STTPayload) string {
+	if w.scratchDir == "" {
+		return filepath.Dir(p.FilePath)
+	}
+	return filepath.Join(w.scratchDir, p.TaskID)
+}
+
+// removeScratchDir 刪除任務專屬的暫存目錄（分片清理之後應已為空）；未設定 scratch 目錄時為上傳目錄，不刪除。
+func (w *Worker) removeScratchDir(p models.STTPayload) {
+	if w.scratchDir != "" {
+		os.RemoveAll(w.taskScratchDir(p))
+	}
+}
+
+// checkScratchSpace 切片前依解碼後的 PCM 大小預估所需暫存空間（解碼暫存檔、各前處理輸出、分片與重疊；
+// 聲道分離模式另需雙聲道解碼與兩條單聲道音軌），可用空間不足時返回 ErrInsufficientScratchSpace，
+// 而不是在切片途中遇到 ENOSPC。無法預估或取得可用空間時略過檢查。
+func (w *Worker) checkScratchSpace(ctx context.Context, payload models.STTPayload) error {
+	dir := w.taskScratchDir(payload)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("checkScratchSpace: %w", err)
+	}
+	free, ok := freeBytes(dir)
+	if !ok {
+		return nil
+	}
+	pcm, err := audio.DecodedSize(ctx, payload.FilePath)
+	if err != nil {
+		w.logf(payload.TaskID, "Task %s: skipping scratch space check: %v", payload.TaskID, err)
+		return nil
+	}
+
+	opts := w.splitOptions(payload)
+	copies := 1 + float64(w.preprocessSteps(payload)) + (1 + opts.Overlap/opts.MaxChunkDuration)
+	if w.stereoSplitEnabled(payload) {
+		copies = max(copies, 6)
+	}
+	need := int64(float64(pcm) * copies * scratchHeadroom)
+	if need > free {
+		return fmt.Errorf("%w: need about %d MB in %s, %d MB free", ErrInsufficientScratchSpace, need>>20, dir, free>>20)
+	}
+	return nil
+}
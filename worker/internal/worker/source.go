@@ -0,0 +1,219 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/models"
+	"tts-worker/internal/netguard"
+)
+
+// ErrCodeSourceUnavailable 遠端音檔無法下載（4xx、內網位址等，重試也不會成功）。
+const ErrCodeSourceUnavailable = "ERR_SOURCE_UNAVAILABLE"
+
+// partSuffix 下載中的暫存檔後綴，完成後才改名為正式路徑。
+const partSuffix = ".part"
+
+// SourceDownload 遠端音檔（payload.sourceUrl）的下載設定。
+type SourceDownload struct {
+	Timeout     time.Duration // 整個下載（含續傳）的時間上限
+	MaxAttempts int           // 連線中斷時以 Range 續傳的次數上限
+	// AllowPrivate 允許下載 loopback / 內網位址；預設拒絕，避免任務被用來探測內部服務。
+	AllowPrivate bool
+}
+
+// DefaultSourceDownload 預設下載設定。
+func DefaultSourceDownload() SourceDownload {
+	return SourceDownload{Timeout: 30 * time.Minute, MaxAttempts: 5}
+}
+
+// SetSourceDownload 設定遠端音檔的下載時間上限、續傳次數與是否允許內網位址。
+func (w *Worker) SetSourceDownload(cfg SourceDownload) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	w.source = cfg
+}
+
+// sourcePath 遠端音檔的下載位置：有 scratch 目錄且不保留原始音檔時寫入任務暫存目錄，
+// 否則寫入 payload.FilePath（上傳目錄）。
+func (w *Worker) sourcePath(p models.STTPayload) string {
+	if w.scratchDir == "" || w.keepSourceAudio {
+		return p.FilePath
+	}
+	return filepath.Join(w.taskScratchDir(p), filepath.Base(p.FilePath))
+}
+
+// fetchSource 下載 payload.SourceURL 並返回本機路徑，之後的驗證與切片與上傳的音檔相同。
+// 內容先寫入 {path}.part，連線中斷時以 Range 從已下載的位置續傳（重試的任務同樣接續），完成後才改名；
+// 已下載完成時直接沿用。大小以 InputPolicy.MaxBytes 限制，Content-Type 需為音訊 / 影片。
+func (w *Worker) fetchSource(ctx context.Context, p models.STTPayload) (string, error) {
+	if p.FilePath == "" {
+		return "", fmt.Errorf("fetchSource(%s): payload has no filePath", p.SourceURL)
+	}
+	path := w.sourcePath(p)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	u, err := url.Parse(p.SourceURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &audio.ValidationError{Code: ErrCodeSourceUnavailable, Message: "The source URL must be an absolute http(s) URL.", Err: err}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("fetchSource(%s): %w", p.SourceURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.source.Timeout)
+	defer cancel()
+	client := w.source.client()
+	part := path + partSuffix
+	for attempt := 1; ; attempt++ {
+		err = w.download(ctx, client, p.SourceURL, part)
+		if err == nil {
+			break
+		}
+		if audio.ErrorCode(err) != "" {
+			os.Remove(part)
+			return "", err
+		}
+		if ctx.Err() != nil || attempt >= w.source.MaxAttempts {
+			return "", fmt.Errorf("fetchSource(%s): %w", p.SourceURL, err)
+		}
+		w.logf(p.TaskID, "Task %s: source download interrupted (attempt %d/%d), resuming: %v", p.TaskID, attempt, w.source.MaxAttempts, err)
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return "", fmt.Errorf("fetchSource(%s): %w", p.SourceURL, ctx.Err())
+		}
+	}
+	if err := os.Rename(part, path); err != nil {
+		return "", fmt.Errorf("fetchSource(%s): %w", p.SourceURL, err)
+	}
+	return path, nil
+}
+
+// download 單次 GET：part 已有內容時帶 Range 續傳，伺服器不支援 Range（返回 200）時從頭下載。
+func (w *Worker) download(ctx context.Context, client *http.Client, rawURL, part string) error {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, netguard.ErrPrivateAddress) {
+			return &audio.ValidationError{Code: ErrCodeSourceUnavailable, Message: "The source URL points to a private or local address.", Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+			if offset, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 上次已下載完整但尚未改名；長度不符時從頭下載
+		if total, ok := rangeTotal(resp.Header.Get("Content-Range")); ok && total == offset {
+			return nil
+		}
+		f.Truncate(0)
+		return fmt.Errorf("download: range %d- not satisfiable", offset)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return &audio.ValidationError{Code: ErrCodeSourceUnavailable, Message: fmt.Sprintf("The source URL returned HTTP %d.", resp.StatusCode)}
+	default:
+		return fmt.Errorf("download: unexpected status %s", resp.Status)
+	}
+	if !sourceTypeAllowed(resp.Header.Get("Content-Type")) {
+		return &audio.ValidationError{Code: audio.ErrCodeUnsupportedFormat, Message: fmt.Sprintf("The source URL serves %s, not audio or video.", resp.Header.Get("Content-Type"))}
+	}
+
+	limit := w.inputPolicy.MaxBytes
+	if limit > 0 && resp.ContentLength >= 0 && offset+resp.ContentLength > limit {
+		return sourceTooLarge(limit)
+	}
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = io.LimitReader(body, limit-offset+1)
+	}
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && offset+n > limit {
+		return sourceTooLarge(limit)
+	}
+	if resp.ContentLength >= 0 && n < resp.ContentLength {
+		return io.ErrUnexpectedEOF
+	}
+	return f.Close()
+}
+
+// sourceTooLarge 遠端音檔超過 InputPolicy.MaxBytes，與上傳超限使用相同的錯誤代碼。
+func sourceTooLarge(limit int64) error {
+	return &audio.ValidationError{Code: audio.ErrCodeFileTooLarge, Message: fmt.Sprintf("The file is larger than the %d MB limit.", limit/(1024*1024))}
+}
+
+// rangeTotal 解析 416 回應的 "bytes */<total>"。
+func rangeTotal(contentRange string) (int64, bool) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	return n, err == nil
+}
+
+// sourceTypeAllowed 接受音訊、影片與 ogg；雲端硬碟與物件儲存常以 octet-stream 提供，未標示時同樣交由 ffprobe 驗證。
+func sourceTypeAllowed(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mt, "audio/"), strings.HasPrefix(mt, "video/"),
+		mt == "application/ogg", mt == "application/octet-stream", mt == "binary/octet-stream":
+		return true
+	}
+	return false
+}
+
+// client 建立下載用的 HTTP client；未允許內網時在連線前檢查實際連線的 IP（含轉址與 DNS 解析結果），
+// 並停用環境變數的 proxy，避免經由 proxy 連線而略過檢查。
+func (c SourceDownload) client() *http.Client {
+	return &http.Client{Transport: netguard.Transport(30*time.Second, c.AllowPrivate)}
+}
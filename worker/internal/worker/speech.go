@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// 裁切頭尾靜音時保留的邊界，避免切掉第一個字的起音；頭尾靜音合計不足 minTrimSec 時不值得多一次解碼。
+const (
+	trimPaddingSec = 0.3
+	minTrimSec     = 1.0
+)
+
+// SpeechPolicy 切片前的語音偵測：裁掉頭尾靜音，語音比例過低（整段靜音 / 音樂）時不呼叫 STT 直接失敗。
+type SpeechPolicy struct {
+	Trim bool
+	// MinCoverage 語音佔錄音長度的最低比例（0~1），低於時以 ERR_NO_SPEECH 失敗；0 表示不檢查。
+	MinCoverage float64
+}
+
+// SetSpeechPolicy 設定頭尾靜音裁切與最低語音比例。
+func (w *Worker) SetSpeechPolicy(p SpeechPolicy) {
+	w.speech = p
+}
+
+// detectSpeech 以 audio.DetectSpeech 統計語音分布並寫入 audio_metadata.speech；語音比例低於 MinCoverage 時
+// 返回 *audio.ValidationError。未啟用或偵測失敗（只記錄 log）時返回零值，不裁切也不檢查。
+func (w *Worker) detectSpeech(ctx context.Context, payload models.STTPayload) (audio.SpeechStats, error) {
+	if !w.speech.Trim && w.speech.MinCoverage <= 0 {
+		return audio.SpeechStats{}, nil
+	}
+	stats, err := audio.DetectSpeech(ctx, payload.FilePath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return audio.SpeechStats{}, ctx.Err()
+		}
+		w.logf(payload.TaskID, "Task %s: speech detection skipped: %v", payload.TaskID, err)
+		return audio.SpeechStats{}, nil
+	}
+	if err := db.MergeAudioMetadata(w.DB, payload.TaskID, map[string]any{"speech": stats}); err != nil {
+		w.logf(payload.TaskID, "Task %s: %v", payload.TaskID, err)
+	}
+	if w.speech.MinCoverage > 0 && stats.Coverage() < w.speech.MinCoverage {
+		return stats, &audio.ValidationError{
+			Code:    audio.ErrCodeNoSpeech,
+			Message: fmt.Sprintf("No speech detected: only %.0f%% of the recording contains speech (minimum %.0f%%).", stats.Coverage()*100, w.speech.MinCoverage*100),
+		}
+	}
+	return stats, nil
+}
+
+// trimRange 依語音統計計算保留的範圍（秒）；未啟用裁切、沒有語音或頭尾靜音太短時 ok 為 false。
+func (w *Worker) trimRange(stats audio.SpeechStats) (start, end float64, ok bool) {
+	if !w.speech.Trim || stats.SpeechSec <= 0 || stats.LeadingSec+stats.TrailingSec < minTrimSec {
+		return 0, 0, false
+	}
+	start = max(stats.LeadingSec-trimPaddingSec, 0)
+	end = min(stats.DurationSec-stats.TrailingSec+trimPaddingSec, stats.DurationSec)
+	return start, end, end > start
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/models"
+)
+
+// StereoSplit 雙聲道通話錄音的聲道分離：客服系統常將兩位講者各錄於一個聲道，
+// 分別轉錄後依時間交錯並標上講者，不需額外的 diarization 模型。
+type StereoSplit struct {
+	Enabled bool
+	// Labels 左、右聲道的講者名稱。
+	Labels [2]string
+}
+
+// DefaultStereoSplit 預設停用，講者標記為 Speaker 1 / Speaker 2。
+func DefaultStereoSplit() StereoSplit {
+	return StereoSplit{Labels: [2]string{"Speaker 1", "Speaker 2"}}
+}
+
+// SetStereoSplit 設定聲道分離模式，任務可於 config.stereoSplit 覆寫是否啟用。
+func (w *Worker) SetStereoSplit(s StereoSplit) {
+	def := DefaultStereoSplit()
+	for i := range s.Labels {
+		if s.Labels[i] == "" {
+			s.Labels[i] = def.Labels[i]
+		}
+	}
+	w.stereo = s
+}
+
+// stereoSplitEnabled 任務設定優先，否則沿用部署設定。
+func (w *Worker) stereoSplitEnabled(p models.STTPayload) bool {
+	if p.Config.StereoSplit != nil {
+		return *p.Config.StereoSplit
+	}
+	return w.stereo.Enabled
+}
+
+// appendTranscript 累進組合轉錄稿：一般模式以 m 重疊合併，聲道分離模式（turns 非 nil）逐行附加講者與時間。
+func (w *Worker) appendTranscript(m MergeStrategy, acc string, turns []audio.ChannelTurn, idx int, text string) string {
+	if turns == nil {
+		return m.merge(acc, text)
+	}
+	return w.stereo.appendTurn(acc, turns, idx, text)
+}
+
+// appendTurn 附加一個 turn 的轉錄；與上一個非空 turn 為同一講者時併入同一行。
+func (s StereoSplit) appendTurn(acc string, turns []audio.ChannelTurn, idx int, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return acc
+	}
+	t := turns[idx]
+	if acc != "" && strings.HasPrefix(lastLine(acc), s.prefix(t.Channel)) {
+		return acc + " " + text
+	}
+	line := fmt.Sprintf("[%s] %s%s", formatOffset(t.Start), s.prefix(t.Channel), text)
+	if acc == "" {
+		return line
+	}
+	return acc + "\n" + line
+}
+
+// prefix 講者標記，例如 "Speaker 1: "。
+func (s StereoSplit) prefix(channel int) string {
+	return s.Labels[channel] + ": "
+}
+
+// lastLine 去除 "[hh:mm:ss] " 時間標記後的最後一行。
+func lastLine(text string) string {
+	line := text[strings.LastIndexByte(text, '\n')+1:]
+	if _, rest, ok := strings.Cut(line, "] "); ok {
+		return rest
+	}
+	return line
+}
+
+// assembleTurns 依時間順序組合所有 turn 的轉錄。
+func (s StereoSplit) assembleTurns(turns []audio.ChannelTurn, transcripts []string) string {
+	var acc string
+	for i, text := range transcripts {
+		acc = s.appendTurn(acc, turns, i, text)
+	}
+	return acc
+}
+
+// turnSegments 將各 turn 的轉錄組為帶講者與時間的段落，略過沒有內容的 turn。
+func (s StereoSplit) turnSegments(turns []audio.ChannelTurn, transcripts, languages []string) []models.TranscriptSegment {
+	var segments []models.TranscriptSegment
+	for i, t := range turns {
+		if strings.TrimSpace(transcripts[i]) == "" {
+			continue
+		}
+		segments = append(segments, models.TranscriptSegment{
+			Index:    i,
+			Language: languages[i],
+			Text:     transcripts[i],
+			Speaker:  s.Labels[t.Channel],
+			StartSec: t.Start,
+			EndSec:   t.End,
+		})
+	}
+	return segments
+}
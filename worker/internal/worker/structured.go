@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// SetStructuredSummary 設定是否預設產生結構化摘要；任務可以 config.structured 覆寫。
+func (w *Worker) SetStructuredSummary(enabled bool) {
+	w.structured = enabled
+}
+
+// wantsStructured 任務的 config.structured 優先，未指定時沿用部署設定。
+func (w *Worker) wantsStructured(p models.SummaryPayload) bool {
+	if p.Config.Structured != nil {
+		return *p.Config.Structured
+	}
+	return w.structured
+}
+
+// saveStructuredSummary 串流摘要完成後，以 provider 的結構化輸出再生成一份 StructuredSummary 並寫入
+// task_results.structured_summary。失敗不影響文字摘要，只清除上一次的結果並記錄。
+func (w *Worker) saveStructuredSummary(ctx context.Context, p models.SummaryPayload) {
+	if !w.wantsStructured(p) {
+		return
+	}
+	var result any
+	if llm, ok := w.llmFor(p.Canary).(ai.StructuredSummarizer); !ok {
+		w.logf(p.TaskID, "Task %s: LLM provider does not support structured summaries", p.TaskID)
+	} else if s, err := llm.SummarizeStructured(ctx, summaryInput(p), ""); err != nil {
+		w.logf(p.TaskID, "Task %s: structured summary failed: %v", p.TaskID, err)
+	} else {
+		result = s
+	}
+	if p.Canary {
+		return
+	}
+	if err := db.SaveStructuredSummary(w.DB, p.TaskID, result); err != nil {
+		w.logf(p.TaskID, "Summary task %s: %v", p.TaskID, err)
+	}
+}
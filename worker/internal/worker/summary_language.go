@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"strings"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/models"
+)
+
+// SetSummaryLanguage 設定部署預設的摘要輸出語言（例如 "zh-TW"），空值表示由模型依轉錄稿決定。
+func (w *Worker) SetSummaryLanguage(lang string) {
+	w.summaryLang = lang
+}
+
+// summaryLanguage 返回本次摘要的輸出語言：任務 config.summaryLanguage 優先，其次為部署預設。
+func (w *Worker) summaryLanguage(p models.SummaryPayload) string {
+	if lang := strings.TrimSpace(p.Config.SummaryLanguage); lang != "" {
+		return lang
+	}
+	return w.summaryLang
+}
+
+// checkSummaryLanguage 指定輸出語言時檢查摘要是否以該語言撰寫，不符時返回 language 標記原因。
+func (w *Worker) checkSummaryLanguage(p models.SummaryPayload, summary string) []string {
+	lang := w.summaryLanguage(p)
+	if lang == "" || strings.TrimSpace(summary) == "" {
+		return nil
+	}
+	if err := ai.CheckSummaryLanguage(summary, lang); err != nil {
+		return []string{"language: 摘要不是以 " + ai.SummaryLanguageName(lang) + " 撰寫"}
+	}
+	return nil
+}
+
+// translatedSummary 指定的摘要語言與轉錄稿語言（偵測結果，未偵測時以文字判斷）不同時返回 true。
+// 任一語言未知時視為相同。
+func (w *Worker) translatedSummary(p models.SummaryPayload) bool {
+	target := w.summaryLanguage(p)
+	if target == "" {
+		return false
+	}
+	source := w.transcriptLanguage(p.TaskID)
+	if source == "" {
+		source = ai.GuessLanguage(p.Transcript)
+	}
+	if source == "" {
+		return false
+	}
+	return ai.NormalizeLanguage(target) != ai.NormalizeLanguage(source)
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
+)
+
+// SummaryPolicy LLM 摘要的同步重試與降級策略（降級在延遲重試用盡後才套用）。
+type SummaryPolicy struct {
+	MaxAttempts int           // 尚未輸出任何片段前失敗時的最大嘗試次數
+	Backoff     time.Duration // 第 n 次重試等待 Backoff * n
+	// TranscriptOnly 為 true 時，LLM 重試用盡後任務以 completed_no_summary 結束並保留 transcript，
+	// 使用者可透過 POST /api/tasks/{id}/summarize 重新摘要，而非整個任務標記 failed。
+	TranscriptOnly bool
+}
+
+// DefaultSummaryPolicy 預設重試 3 次、不降級（維持原本的 failed 行為）。
+func DefaultSummaryPolicy() SummaryPolicy {
+	return SummaryPolicy{MaxAttempts: 3, Backoff: 2 * time.Second}
+}
+
+// SetSummaryPolicy 設定摘要重試與降級策略。
+func (w *Worker) SetSummaryPolicy(p SummaryPolicy) {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	w.summaryPolicy = p
+}
+
+// shouldDegrade 判斷摘要失敗是否適用 transcript-only 降級：僅限 provider 錯誤與摘要逾時，
+// 使用者取消與 DB 寫入失敗不降級。
+func (w *Worker) shouldDegrade(ctx context.Context, err error) bool {
+	if !w.summaryPolicy.TranscriptOnly || ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	class := errorClass(err)
+	return class == errClassLLMProvider || class == errClassTimeout
+}
+
+// completeWithoutSummary 以 completed_no_summary 結束任務：transcript 已在 STT 階段保存，
+// 通知前端可直接使用轉錄稿並稍後重試摘要。
+func (w *Worker) completeWithoutSummary(ctx context.Context, payload models.SummaryPayload, d *queue.Delivery, cause error) {
+	w.logf(payload.TaskID, "Summary task %s degraded to transcript-only: %v", payload.TaskID, cause)
+	w.recordOutcome("summary", payload.Canary, cause)
+
+	if err := db.SetTaskStatus(w.DB, payload.TaskID, models.StatusCompletedNoSummary, cause.Error()); err != nil {
+		w.logf(payload.TaskID, "Summary task %s: failed to persist terminal status: %v", payload.TaskID, err)
+	}
+	w.Redis.HSet(context.Background(), keys.Task(payload.TaskID), "status", models.StatusCompletedNoSummary)
+	w.ack(d)
+	w.notifyEvent(payload.TaskID, models.StatusCompletedNoSummary, "摘要服務暫時無法使用，已保留轉錄稿，可稍後重試摘要")
+	w.notifyWebhook(payload.TaskID, models.StatusCompletedNoSummary, cause.Error())
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+// SetDebugLogStreaming 開關 debug log 轉送功能（admin flag）。關閉時 logf 只寫本機 log。
+func (w *Worker) SetDebugLogStreaming(enabled bool) {
+	w.debugLogs = enabled
+}
+
+// logf 寫入本機 log；若該任務開啟了 debug tail，另將同一行以 "debug" 事件發布至任務的進度 channel，
+// 讓支援人員不需登入 Worker 主機即可即時觀察任務內部狀態。
+func (w *Worker) logf(taskID, format string, args ...any) {
+	line := fmt.Sprintf(format, args...)
+	log.Print(line)
+
+	if !w.debugLogs || taskID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if n, err := w.Redis.Exists(ctx, keys.DebugTask(taskID)).Result(); err != nil || n == 0 {
+		return
+	}
+	rdb_lib.PublishProgress(w.Redis, ctx, taskID, models.SSEEvent{
+		TaskID:  taskID,
+		Type:    "debug",
+		Message: line,
+	})
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"sort"
+	"strings"
+
+	"tts-worker/internal/ai"
+	"tts-worker/internal/audio"
+	"tts-worker/internal/models"
+)
+
+// SetTimestamps 啟用逐段 / 逐字時間戳：請 STT provider 回報時間，換算回原始錄音後存於 task_results.timings。
+func (w *Worker) SetTimestamps(enabled bool) {
+	w.timestamps = enabled
+}
+
+// appendShifted 將 next（起點位於 dst 音檔的 offset 秒）的時間戳接在 dst 之後。
+// 兩段的重疊區段各轉錄一次：起點早於 dst 最後一個詞結束的詞略過，段落起點則延後至 dst 最後一段結束。
+func appendShifted(dst *ai.STTResult, next ai.STTResult, offset float64) {
+	lastWord, lastSeg := 0.0, 0.0
+	if n := len(dst.Words); n > 0 {
+		lastWord = dst.Words[n-1].End
+	}
+	if n := len(dst.Segments); n > 0 {
+		lastSeg = dst.Segments[n-1].End
+	}
+	for _, wd := range next.Words {
+		if wd.Start += offset; wd.Start < lastWord {
+			continue
+		}
+		wd.End += offset
+		dst.Words = append(dst.Words, wd)
+	}
+	for _, seg := range next.Segments {
+		seg.Start, seg.End = max(seg.Start+offset, lastSeg), seg.End+offset
+		if seg.End > seg.Start {
+			dst.Segments = append(dst.Segments, seg)
+		}
+	}
+}
+
+// chunkTimings 將 provider 回報的時間（相對於分片）換算回原始錄音。provider 只回報逐字時間時，
+// 以首尾詞的時間將整個分片視為一段。text 為正規化後的分片轉錄稿。
+func chunkTimings(c audio.Chunk, res ai.STTResult, text string) models.TranscriptTimings {
+	scale := c.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	at := func(sec float64) float64 { return c.Offset + sec*scale }
+
+	var t models.TranscriptTimings
+	for _, seg := range res.Segments {
+		t.Segments = append(t.Segments, models.TimedText{Text: seg.Text, StartSec: at(seg.Start), EndSec: at(seg.End)})
+	}
+	for _, wd := range res.Words {
+		t.Words = append(t.Words, models.TimedText{Text: wd.Text, StartSec: at(wd.Start), EndSec: at(wd.End)})
+	}
+	if len(t.Segments) == 0 && len(t.Words) > 0 && strings.TrimSpace(text) != "" {
+		t.Segments = []models.TimedText{{Text: text, StartSec: t.Words[0].StartSec, EndSec: t.Words[len(t.Words)-1].EndSec}}
+	}
+	return t
+}
+
+// joinTimings 依分片順序串接時間戳；沒有任何時間戳時返回 nil。
+// 相鄰分片的重疊區段兩邊都會轉錄，處理方式同 appendShifted。聲道分離模式的 turn 在時間上可能交錯，
+// 不去除重疊，改為依起點排序。
+func joinTimings(parts []models.TranscriptTimings, interleaved bool) *models.TranscriptTimings {
+	var t models.TranscriptTimings
+	for _, p := range parts {
+		t.Segments = appendTimed(t.Segments, p.Segments, interleaved, true)
+		t.Words = appendTimed(t.Words, p.Words, interleaved, false)
+	}
+	if len(t.Segments) == 0 {
+		return nil
+	}
+	if interleaved {
+		sort.SliceStable(t.Segments, func(i, j int) bool { return t.Segments[i].StartSec < t.Segments[j].StartSec })
+		sort.SliceStable(t.Words, func(i, j int) bool { return t.Words[i].StartSec < t.Words[j].StartSec })
+	}
+	return &t
+}
+
+// appendTimed 將 next 接在 dst 之後。非 interleaved 時，起點早於 dst 最後結束時間者視為重疊：
+// clip 為 true（段落）時延後起點，否則（詞）略過。
+func appendTimed(dst, next []models.TimedText, interleaved, clip bool) []models.TimedText {
+	if interleaved || len(dst) == 0 {
+		return append(dst, next...)
+	}
+	last := dst[len(dst)-1].EndSec
+	for _, x := range next {
+		if x.StartSec < last {
+			if !clip {
+				continue
+			}
+			x.StartSec = last
+		}
+		if x.EndSec > x.StartSec || !clip {
+			dst = append(dst, x)
+		}
+	}
+	return dst
+}
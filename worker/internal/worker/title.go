@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"time"
+	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/models"
+	rdb_lib "tts-worker/internal/redis"
+)
+
+const (
+	titleInputRunes = 2000 // 只取 transcript 開頭，控制 token 成本
+	titleMaxRunes   = 60
+	titleTimeout    = 30 * time.Second
+	titlePrompt     = "請根據以下逐字稿開頭，產生一個 20 字以內、描述會議或錄音主題的標題。只輸出標題本身，不要加引號或其他說明："
+)
+
+// SetAutoTitle 開關 STT 完成後的自動標題產生。
+func (w *Worker) SetAutoTitle(enabled bool) {
+	w.autoTitle = enabled
+}
+
+// generateTitle 以 transcript 開頭請 LLM 產生短標題，寫入 tasks.title 並推送 "title" 事件。
+// 失敗僅記錄 log，不影響任務狀態。
+func (w *Worker) generateTitle(taskID, transcript string, canary bool) {
+	if !w.autoTitle || canary || strings.TrimSpace(transcript) == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(w.withTokenUsage(context.Background(), taskID), titleTimeout)
+	defer cancel()
+
+	raw, err := w.llmFor(false).Summarize(ctx, truncateRunes(transcript, titleInputRunes), titlePrompt)
+	if err != nil {
+		w.logf(taskID, "Task %s: title generation failed: %v", taskID, err)
+		return
+	}
+	title := cleanTitle(raw)
+	if title == "" {
+		return
+	}
+	if err := db.SetTaskTitle(w.DB, taskID, title); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+		return
+	}
+	w.Redis.HSet(ctx, keys.Task(taskID), "title", title)
+	rdb_lib.PublishProgress(w.Redis, ctx, taskID, models.SSEEvent{
+		TaskID:  taskID,
+		Type:    "title",
+		Content: title,
+	})
+}
+
+// cleanTitle 取第一個非空行，去除 Markdown 標記與引號並限制長度。
+func cleanTitle(raw string) string {
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#*- "))
+		line = strings.Trim(line, "\"'「」『』*")
+		if line != "" {
+			return truncateRunes(strings.TrimPrefix(line, "標題："), titleMaxRunes)
+		}
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
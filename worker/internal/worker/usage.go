@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"time"
+	"tts-worker/internal/ai"
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+)
+
+// sttUsage 收集單一 STT 任務的資源使用量，任務結束（成功或失敗）時寫入 task_usage。
+type sttUsage struct {
+	start      time.Time
+	rssStart   int64
+	chunking   time.Duration
+	sttStart   time.Time
+	stt        time.Duration
+	ffmpeg     audio.Usage
+	inputBytes int64
+	chunkBytes int64
+}
+
+func newSTTUsage(filePath string) *sttUsage {
+	u := &sttUsage{start: time.Now(), rssStart: selfPeakRSS()}
+	if fi, err := os.Stat(filePath); err == nil {
+		u.inputBytes = fi.Size()
+	}
+	return u
+}
+
+// sttStarted 記錄第一個分片開始轉錄的時間；串流切片時早於切片完成。
+func (u *sttUsage) sttStarted() {
+	u.sttStart = time.Now()
+}
+
+// chunked 記錄切片完成時間與分片總大小。
+func (u *sttUsage) chunked(chunks []audio.Chunk) {
+	u.chunking = time.Since(u.start)
+	if u.sttStart.IsZero() {
+		u.sttStart = time.Now()
+	}
+	for _, c := range chunks {
+		if fi, err := os.Stat(c.FilePath); err == nil {
+			u.chunkBytes += fi.Size()
+		}
+	}
+}
+
+// transcribed 記錄轉錄完成時間。
+func (u *sttUsage) transcribed() {
+	if !u.sttStart.IsZero() {
+		u.stt = time.Since(u.sttStart)
+	}
+}
+
+// saveSTTUsage 寫入 task_usage；失敗只記錄 log，不影響任務結果。
+func (w *Worker) saveSTTUsage(taskID string, u *sttUsage) {
+	if u.chunking == 0 {
+		u.chunking = time.Since(u.start)
+	}
+	err := db.SaveSTTUsage(w.DB, taskID, db.STTUsage{
+		ChunkingMs:         u.chunking.Milliseconds(),
+		STTMs:              u.stt.Milliseconds(),
+		FFmpegCPUMs:        u.ffmpeg.CPUTime.Milliseconds(),
+		FFmpegPeakRSSBytes: u.ffmpeg.PeakRSS,
+		PeakRSSDeltaBytes:  selfPeakRSS() - u.rssStart,
+		InputBytes:         u.inputBytes,
+		ChunkBytes:         u.chunkBytes,
+	})
+	if err != nil {
+		w.logf(taskID, "STT task %s: %v", taskID, err)
+	}
+}
+
+// saveSummaryUsage 寫入 Summary 階段的耗時。
+func (w *Worker) saveSummaryUsage(taskID string, start time.Time, rssStart int64) {
+	if err := db.SaveSummaryUsage(w.DB, taskID, time.Since(start).Milliseconds(), selfPeakRSS()-rssStart); err != nil {
+		w.logf(taskID, "Summary task %s: %v", taskID, err)
+	}
+}
+
+// withTokenUsage 返回會將 LLM token 用量累加至 task_usage 的 context；寫入失敗只記錄 log。
+func (w *Worker) withTokenUsage(ctx context.Context, taskID string) context.Context {
+	return ai.WithUsageCallback(ctx, func(u ai.TokenUsage) {
+		if err := db.AddTokenUsage(w.DB, taskID, u.PromptTokens, u.CompletionTokens); err != nil {
+			w.logf(taskID, "Task %s: %v", taskID, err)
+		}
+	})
+}
+
+// recordQueueWait 記錄任務第一次開始處理前的等待時間；失敗只記錄 log。
+func (w *Worker) recordQueueWait(taskID string) {
+	if err := db.RecordQueueWait(w.DB, taskID); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
+
+// mergeTrace 合併使用者可見的執行紀錄（tasks.trace）；失敗只記錄 log。
+func (w *Worker) mergeTrace(taskID string, t models.ExecutionTrace) {
+	if err := db.MergeTaskTrace(w.DB, taskID, t); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"tts-worker/internal/audio"
+	"tts-worker/internal/db"
+	"tts-worker/internal/models"
+	rdb_lib "tts-worker/internal/redis"
+	"tts-worker/internal/webhook"
+)
+
+// validateTimeout 上傳驗證的時間上限；未帶長度的錄音需實際解碼至 INPUT_MAX_DURATION 才能判斷是否超長。
+const validateTimeout = 2 * time.Minute
+
+// SetInputPolicy 設定輸入限制（允許的格式 / 編碼、長度、大小、分片數），需與 Gateway 的 INPUT_* 設定一致。
+func (w *Worker) SetInputPolicy(p audio.InputPolicy) {
+	w.inputPolicy = p
+}
+
+// validateUpload 切片前以 ffprobe 驗證音檔，損毀或不支援的格式直接以 *audio.ValidationError 失敗，
+// 不必等到切片時才出現難以理解的 ffmpeg 錯誤。ffprobe 無法執行時只記錄 log 並略過驗證。
+// 讀到的錄音資訊（含驗證失敗的錄音）寫入 tasks 並推送 audio_info 事件。
+func (w *Worker) validateUpload(ctx context.Context, taskID, path string) error {
+	vctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	meta, err := audio.Validate(vctx, path, w.inputPolicy)
+	if meta.Codec != "" {
+		w.saveAudioInfo(taskID, meta)
+	}
+	if err == nil || audio.ErrorCode(err) != "" || ctx.Err() != nil {
+		return err
+	}
+	w.logf(taskID, "Task %s: skipping upload validation: %v", taskID, err)
+	return nil
+}
+
+// notifyFailure 推送終態事件與 webhook；err 為上傳驗證錯誤時保存並附上錯誤代碼。
+// cancelled 事件由 finishTask 在清理完成後推送，此處只送 webhook。
+func (w *Worker) notifyFailure(taskID, eventType string, err error) {
+	code := audio.ErrorCode(err)
+	if code != "" {
+		if dbErr := db.SetTaskErrorCode(w.DB, taskID, code); dbErr != nil {
+			w.logf(taskID, "Task %s: %v", taskID, dbErr)
+		}
+	}
+	if eventType != models.StatusCancelled {
+		rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, models.SSEEvent{
+			TaskID:    taskID,
+			Type:      eventType,
+			Status:    eventType,
+			Message:   err.Error(),
+			ErrorCode: code,
+		})
+	}
+	if w.webhooks != nil {
+		w.webhooks.Notify(context.Background(), webhook.Event{
+			TaskID:       taskID,
+			Event:        eventType,
+			ErrorMessage: err.Error(),
+			ErrorCode:    code,
+		})
+	}
+}
+
+// saveAudioInfo 保存錄音資訊（tasks.audio_*）並推送給前端；失敗只記錄 log。
+func (w *Worker) saveAudioInfo(taskID string, meta audio.Metadata) {
+	info := models.AudioInfo{
+		Format:      meta.Format,
+		Codec:       meta.Codec,
+		DurationSec: meta.DurationSec,
+		SampleRate:  meta.SampleRate,
+		Channels:    meta.Channels,
+		SizeBytes:   meta.SizeBytes,
+	}
+	if err := db.SaveAudioInfo(w.DB, taskID, info); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	}
+	rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, models.SSEEvent{
+		TaskID: taskID,
+		Type:   "audio_info",
+		Audio:  &info,
+	})
+}
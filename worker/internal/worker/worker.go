@@ -15,37 +15,126 @@ import (
 	"tts-worker/internal/ai"
 	"tts-worker/internal/audio"
 	"tts-worker/internal/db"
+	"tts-worker/internal/keys"
+	"tts-worker/internal/metrics"
 	"tts-worker/internal/models"
+	"tts-worker/internal/queue"
 	rdb_lib "tts-worker/internal/redis"
+	"tts-worker/internal/webhook"
 
 	"github.com/redis/go-redis/v9"
 )
 
-const (
-	queueSTT        = "stt:queue"
-	queueSummary    = "summary:queue"
-	processingSTT   = "stt:processing"
-	processingSummary = "summary:processing"
-)
-
 // Worker 任務處理器，持有所有外部依賴的連線。
-// activeCancels 儲存進行中任務的 cancel 函數，供取消信號觸發時使用。
+// activeCancels 儲存進行中任務的 *activeTask，供取消信號觸發時使用。
 type Worker struct {
 	DB            *sql.DB
 	Redis         *redis.Client
+	Broker        queue.Broker
 	STT           ai.STTService
 	LLM           ai.Summarizer
 	activeCancels sync.Map
+
+	// canarySTT / canaryLLM 供 Canary 任務使用的 provider，未設定時沿用 STT / LLM。
+	canarySTT ai.STTService
+	canaryLLM ai.Summarizer
+
+	deadlines Deadlines
+	webhooks  *webhook.Dispatcher
+	debugLogs bool
+
+	summaryPolicy SummaryPolicy
+	retryPolicy   RetryPolicy
+	merge         MergeStrategy
+	chunking      ChunkingPolicy
+	chunker       audio.Chunker
+	personas      PersonaPolicy
+	prompts       PromptTemplates
+	summaryLang   string
+	generation    ai.GenerationParams
+	structured    bool
+	moderation    ModerationPolicy
+	embeddings    EmbeddingPolicy
+	loudnorm      bool
+	cleanupPreset string
+	speed         float64
+	dedupScope    string
+	scratchDir    string
+	inputPolicy   audio.InputPolicy
+	source        SourceDownload
+	speech        SpeechPolicy
+
+	keepSourceAudio bool
+	autoTitle       bool
+	normalizeText   bool
+
+	instanceID  string
+	langRouting LanguageRouting
+	timestamps  bool
+	priming     ContextPriming
+	stereo      StereoSplit
+	concurrency *ConcurrencyLimiter
+
+	cancelDeadline time.Duration
+	guardrails     GuardrailPolicy
+	fairness       FairnessPolicy
+
+	benchmarkProviders []BenchmarkProvider
+
+	// drain：stopConsuming 停止 consumer，tasks 追蹤已取得但尚未結束的任務
+	stopConsuming context.CancelFunc
+	draining      atomic.Bool
+	tasks         sync.WaitGroup
 }
 
 // NewWorker 建立 Worker 實例，注入所有外部依賴。
-func NewWorker(postgres *sql.DB, rdb *redis.Client, sttSvc ai.STTService, llmSvc ai.Summarizer) *Worker {
+func NewWorker(postgres *sql.DB, rdb *redis.Client, broker queue.Broker, sttSvc ai.STTService, llmSvc ai.Summarizer) *Worker {
 	return &Worker{
-		DB:    postgres,
-		Redis: rdb,
-		STT:   sttSvc,
-		LLM:   llmSvc,
+		DB:        postgres,
+		Redis:     rdb,
+		Broker:    broker,
+		STT:       sttSvc,
+		LLM:       llmSvc,
+		deadlines: DefaultDeadlines(),
+
+		summaryPolicy: DefaultSummaryPolicy(),
+		retryPolicy:   DefaultRetryPolicy(),
+		merge:         DefaultMergeStrategy(),
+		chunking:      DefaultChunkingPolicy(),
+		chunker:       audio.FFmpegChunker{},
+		priming:       DefaultContextPriming(),
+		stereo:        DefaultStereoSplit(),
+		guardrails:    DefaultGuardrailPolicy(),
+		source:        DefaultSourceDownload(),
+		embeddings:    DefaultEmbeddingPolicy(),
+	}
+}
+
+// SetCanaryProviders 指定 Canary 任務使用的 AI provider（例如 Mock），不影響一般任務。
+func (w *Worker) SetCanaryProviders(sttSvc ai.STTService, llmSvc ai.Summarizer) {
+	w.canarySTT = sttSvc
+	w.canaryLLM = llmSvc
+}
+
+// SetWebhooks 啟用任務終態的 webhook 通知。
+func (w *Worker) SetWebhooks(d *webhook.Dispatcher) {
+	w.webhooks = d
+}
+
+// sttFor 依任務類型選擇 STT provider。
+func (w *Worker) sttFor(canary bool) ai.STTService {
+	if canary && w.canarySTT != nil {
+		return w.canarySTT
+	}
+	return w.STT
+}
+
+// llmFor 依任務類型選擇 LLM provider。
+func (w *Worker) llmFor(canary bool) ai.Summarizer {
+	if canary && w.canaryLLM != nil {
+		return w.canaryLLM
 	}
+	return w.LLM
 }
 
 // StartCancellationListener 訂閱 Redis cancel_channel，
@@ -74,272 +163,714 @@ func (w *Worker) listenCancellations(ctx context.Context) {
 			TaskID string `json:"taskId"`
 		}
 		if err := json.Unmarshal([]byte(msg.Payload), &cancelMsg); err == nil {
-			if cancel, ok := w.activeCancels.Load(cancelMsg.TaskID); ok {
+			if _, ok := w.activeCancels.Load(cancelMsg.TaskID); ok {
 				log.Printf("Received cancellation for task %s", cancelMsg.TaskID)
-				cancel.(context.CancelFunc)()
+				w.cancelTask(cancelMsg.TaskID)
 			}
 		}
 	}
 }
 
-// ConsumeSTTQueue 阻塞消費 stt:queue，每個任務在獨立 goroutine 中處理。
-// BLPOP 原子取出後立即 ZADD 至 stt:processing ZSET 供 Reaper 追蹤。
+// ConsumeSTTQueue 阻塞消費 STT 佇列，每個任務在獨立 goroutine 中處理。
+// 訊息在任務寫入終態後才 Ack，Worker 中途中止時由 backend 重新投遞。
 func (w *Worker) ConsumeSTTQueue(ctx context.Context) {
 	log.Println("STT queue consumer started")
 	for {
-		result, err := w.Redis.BLPop(ctx, 0, queueSTT).Result()
+		d, err := w.Broker.Consume(ctx, keys.Queue(queue.STT))
 		if err != nil {
 			if ctx.Err() != nil {
 				return
 			}
-			log.Printf("ConsumeSTTQueue: BLPOP error: %v, retrying in 1s...", err)
+			log.Printf("ConsumeSTTQueue: consume error: %v, retrying in 1s...", err)
 			time.Sleep(time.Second)
 			continue
 		}
 
-		rawPayload := result[1]
-		w.Redis.ZAdd(ctx, processingSTT, redis.Z{
-			Score:  float64(time.Now().Unix()),
-			Member: rawPayload,
-		})
-
 		var payload models.STTPayload
-		if err := json.Unmarshal([]byte(rawPayload), &payload); err != nil {
+		if err := json.Unmarshal(d.Body, &payload); err != nil {
 			log.Printf("ConsumeSTTQueue: unmarshal error: %v, discarding message", err)
-			w.Redis.ZRem(ctx, processingSTT, rawPayload)
+			w.ack(d)
 			continue
 		}
 
-		go func(p models.STTPayload, raw string) {
+		w.tasks.Add(1)
+		go func(p models.STTPayload, d *queue.Delivery) {
+			defer w.tasks.Done()
 			taskCtx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			w.activeCancels.Store(p.TaskID, cancel)
-			defer w.activeCancels.Delete(p.TaskID)
-			w.handleSTT(taskCtx, p, raw)
-		}(payload, rawPayload)
+			t := w.trackTask(p.TaskID, cancel)
+			defer w.finishTask(p.TaskID, t, taskCtx)
+			if w.deferUntil(taskCtx, d, p.TaskID, models.StatusSttQueued, p.NotBefore) {
+				return
+			}
+			release, deferred := w.userSlot(taskCtx, d, p.UserID, p.TaskID, models.StatusSttQueued)
+			if deferred {
+				return
+			}
+			defer release()
+			w.handleSTT(taskCtx, p, d)
+		}(payload, d)
 	}
 }
 
-// ConsumeSummaryQueue 阻塞消費 summary:queue，每個任務在獨立 goroutine 中處理。
+// ConsumeSummaryQueue 阻塞消費 Summary 佇列，每個任務在獨立 goroutine 中處理。
 func (w *Worker) ConsumeSummaryQueue(ctx context.Context) {
 	log.Println("Summary queue consumer started")
 	for {
-		result, err := w.Redis.BLPop(ctx, 0, queueSummary).Result()
+		d, err := w.Broker.Consume(ctx, keys.Queue(queue.Summary))
 		if err != nil {
 			if ctx.Err() != nil {
 				return
 			}
-			log.Printf("ConsumeSummaryQueue: BLPOP error: %v, retrying in 1s...", err)
+			log.Printf("ConsumeSummaryQueue: consume error: %v, retrying in 1s...", err)
 			time.Sleep(time.Second)
 			continue
 		}
 
-		rawPayload := result[1]
-		w.Redis.ZAdd(ctx, processingSummary, redis.Z{
-			Score:  float64(time.Now().Unix()),
-			Member: rawPayload,
-		})
-
 		var payload models.SummaryPayload
-		if err := json.Unmarshal([]byte(rawPayload), &payload); err != nil {
+		if err := json.Unmarshal(d.Body, &payload); err != nil {
 			log.Printf("ConsumeSummaryQueue: unmarshal error: %v, discarding message", err)
-			w.Redis.ZRem(ctx, processingSummary, rawPayload)
+			w.ack(d)
 			continue
 		}
 
-		go func(p models.SummaryPayload, raw string) {
+		w.tasks.Add(1)
+		go func(p models.SummaryPayload, d *queue.Delivery) {
+			defer w.tasks.Done()
 			taskCtx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			w.activeCancels.Store(p.TaskID, cancel)
-			defer w.activeCancels.Delete(p.TaskID)
-			w.handleSummary(taskCtx, p, raw)
-		}(payload, rawPayload)
+			t := w.trackTask(p.TaskID, cancel)
+			defer w.finishTask(p.TaskID, t, taskCtx)
+			if w.deferUntil(taskCtx, d, p.TaskID, models.StatusSummaryQueued, p.NotBefore) {
+				return
+			}
+			release, deferred := w.userSlot(taskCtx, d, p.UserID, p.TaskID, models.StatusSummaryQueued)
+			if deferred {
+				return
+			}
+			defer release()
+			w.handleSummary(taskCtx, p, d)
+		}(payload, d)
 	}
 }
 
-// handleSTT 執行 STT 階段：音檔切片 → 並發轉錄（retry x3）→ mergeTranscripts → 儲存 transcript → 通知 stt_completed。
-func (w *Worker) handleSTT(ctx context.Context, payload models.STTPayload, rawPayload string) {
-	log.Printf("Processing STT task: %s", payload.TaskID)
+// ack 確認訊息已處理。使用獨立 context：任務被取消後仍必須 Ack，否則會被重新投遞。
+func (w *Worker) ack(d *queue.Delivery) {
+	if err := w.Broker.Ack(context.Background(), d); err != nil {
+		log.Printf("Ack %s message failed: %v", d.Queue, err)
+	}
+}
+
+// handleSTT 執行 STT 階段：音檔切片 → 並發轉錄（retry x3）→ 合併重疊區段 → 儲存 transcript → 通知 stt_completed。
+func (w *Worker) handleSTT(ctx context.Context, payload models.STTPayload, d *queue.Delivery) {
+	if payload.Mode == models.ModeBenchmark {
+		w.handleBenchmark(ctx, payload, d)
+		return
+	}
+	w.logf(payload.TaskID, "Processing STT task: %s", payload.TaskID)
 
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", models.StatusSttProcessing, "startedAt", fmt.Sprintf("%d", time.Now().Unix()))
+	usage := newSTTUsage(payload.FilePath)
+	defer w.saveSTTUsage(payload.TaskID, usage)
+
+	w.claimTask(payload.TaskID)
+	w.recordQueueWait(payload.TaskID)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSttProcessing, "startedAt", fmt.Sprintf("%d", time.Now().Unix()))
 	w.notifyProgress(payload.TaskID, 10, "音檔處理中...")
 
-	// 1. 音檔切片（VAD 優先）
-	const defaultMaxChunkDuration = 30.0
-	chunks, err := audio.SplitAudio(payload.FilePath, defaultMaxChunkDuration)
-	if err != nil {
-		w.handleSTTError(ctx, payload, rawPayload, err)
-		return
+	deadlines := w.sttDeadlines(payload)
+
+	if u := payload.Config.WebhookURL; u != "" && w.webhooks != nil {
+		if err := webhook.ValidateURL(u); err != nil {
+			w.logf(payload.TaskID, "STT task %s: ignoring webhook: %v", payload.TaskID, err)
+		} else if err := db.SetWebhookURL(w.DB, payload.TaskID, u); err != nil {
+			w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, err)
+		}
 	}
-	defer audio.CleanupChunks(chunks)
 
-	w.notifyProgress(payload.TaskID, 30, fmt.Sprintf("語音轉譯中（%d 段）...", len(chunks)))
+	// 0. 遠端來源先下載至暫存空間，之後與上傳的音檔走相同流程
+	if payload.SourceURL != "" {
+		path, err := w.fetchSource(ctx, payload)
+		if err != nil {
+			class := errClassDownload
+			if audio.ErrorCode(err) != "" {
+				class = errClassAudio
+			}
+			w.handleSTTError(ctx, payload, d, withClass(class, err))
+			return
+		}
+		payload.FilePath = path
+		if fi, err := os.Stat(path); err == nil {
+			usage.inputBytes = fi.Size()
+		}
+	}
 
-	// 2. 並發轉錄（Semaphore = 2，降低本地 GPU 壓力）
-	transcripts := make([]string, len(chunks))
+	// 1. 先檢查格式與大小 / 長度上限（超限的檔案不必讀完整檔計算 SHA-256），
+	//    再驗證音檔與上傳時的 SHA-256 一致，最後切片（VAD 優先）
+	if err := w.validateUpload(audio.WithUsage(ctx, &usage.ffmpeg), payload.TaskID, payload.FilePath); err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+	checksum, err := verifyUpload(payload)
+	if err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+	glossary := w.taskGlossary(payload)
+	// 相同音訊已轉錄過時沿用既有結果，不再呼叫 STT provider
+	if w.reuseDuplicate(audio.WithUsage(ctx, &usage.ffmpeg), payload, d, checksum, glossary, deadlines.Chunking) {
+		return
+	}
+	defer w.removeScratchDir(payload)
+	if err := w.checkScratchSpace(ctx, payload); err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassStorage, err))
+		return
+	}
+	// 整段靜音 / 音樂的錄音不送 STT，直接失敗；頭尾靜音於切片前裁掉
+	speech, err := w.detectSpeech(audio.WithUsage(ctx, &usage.ffmpeg), payload)
+	if err != nil {
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+	// 2. 串流切片並發轉錄：分片一產生即開始轉錄，不必等整個檔案解碼、切完。
+	//    有 ConcurrencyLimiter 時由其控制整個 instance 的 chunk 並發，否則每任務固定 2（降低本地 GPU 壓力）
+	hint := languageHint(payload)
+	basePrompt := taskPrompt(payload, glossary)
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 2)
 
-	sttCtx, sttCancel := context.WithCancel(ctx)
+	sttSvc := w.sttFor(payload.Canary)
+	// STT 階段總 deadline：與切片重疊進行，自切片開始計時，因此加上切片的 deadline；
+	// firstErr 發生時同樣透過 sttCancel 中止切片與其餘 chunk
+	sttLimit := deadlines.Chunking + deadlines.STT
+	sttCtx, sttCancel := context.WithTimeout(ctx, sttLimit)
 	defer sttCancel()
 
 	var firstErr atomic.Value
-	var completedChunks int32
 
+	// streamingMu 保護切片途中持續增加的 chunks / transcripts / languages / timings 與累進推送狀態
 	var streamingMu sync.Mutex
+	var chunks []audio.Chunk
+	var transcripts, languages []string
+	var timings []models.TranscriptTimings
+	splitDone := false
+	completedChunks := 0
+	lastPercent := 30
 	nextToStream := 0
 	currentFullTranscript := ""
+	// 第一個 chunk 偵測到的語言決定合併策略：中文、日文等不以空白分詞的語言改以字元比對重疊
+	detectedLang := ""
+	merge := w.merge
+	defer func() { audio.CleanupChunks(chunks) }()
+
+	// 前文提示：chunk 先等待前一個 chunk 完成（不佔用並發名額），再以其結尾作為 prompt
+	seq := w.newChunkSequence(sttSvc)
+	var skipped skippedAudio
+	var waveform audio.Waveform
+
+	chunkingCtx, chunkingCancel := context.WithTimeout(sttCtx, deadlines.Chunking)
+	splitCtx := audio.WithUsage(chunkingCtx, &usage.ffmpeg)
+	// 聲道分離模式先切出所有講者 turn，再依序送出轉錄
+	turns, err := w.splitTurns(splitCtx, payload)
+
+	// record 寫入 chunk idx 的結果、更新進度並依序推送累進轉錄稿
+	record := func(idx int, text, lang string) {
+		streamingMu.Lock()
+		defer streamingMu.Unlock()
+		transcripts[idx] = text
+		languages[idx] = lang
+
+		// 切片尚未完成時總數未知，至少還有一段；進度只增不減
+		completedChunks++
+		total := len(chunks)
+		if !splitDone {
+			total++
+		}
+		if percent := 30 + completedChunks*40/total; percent > lastPercent {
+			lastPercent = percent
+			w.notifyProgress(payload.TaskID, percent, "語音轉譯中...")
+		}
 
-	for i, chunk := range chunks {
-		wg.Add(1)
-		go func(idx int, c audio.Chunk) {
-			defer wg.Done()
+		// 累進式順序推送轉錄文字至前端
+		if idx == nextToStream {
+			for nextToStream < len(chunks) && transcripts[nextToStream] != "" {
+				currentFullTranscript = w.appendTranscript(merge, currentFullTranscript, turns, nextToStream, transcripts[nextToStream])
+				nextToStream++
+			}
+			if !w.moderating(payload.Canary) {
+				w.notifyTranscriptUpdate(payload.TaskID, currentFullTranscript)
+				w.Redis.Set(ctx, keys.TranscriptBuffer(payload.TaskID), currentFullTranscript, 10*time.Minute)
+			}
+		}
+	}
 
+	transcribe := func(idx int, c audio.Chunk) {
+		defer wg.Done()
+
+		var chunkTranscript, chunkLang string
+		defer func() { seq.finish(idx, chunkTranscript) }()
+		prompt := basePrompt
+		prompt.tail = seq.prompt(sttCtx, idx, w.priming)
+
+		if w.concurrency == nil {
 			select {
 			case sem <- struct{}{}:
 				defer func() { <-sem }()
 			case <-sttCtx.Done():
 				return
 			}
+		}
 
-			chunkCtx, chunkCancel := context.WithTimeout(sttCtx, 5*time.Minute)
-			defer chunkCancel()
-
-			var chunkTranscript string
-			var sttErr error
-			for attempt := 0; attempt < 3; attempt++ {
-				chunkTranscript, sttErr = w.STT.STT(chunkCtx, c.FilePath)
-				if sttErr == nil {
-					break
+		var res ai.STTResult
+		var sttErr error
+		for attempt := 0; attempt < 3; attempt++ {
+			// 自適應模式下每次嘗試各自取得名額，重試等待期間不佔用
+			if w.concurrency != nil {
+				if err := w.concurrency.Acquire(sttCtx); err != nil {
+					return
 				}
-				log.Printf("STT attempt %d failed for chunk %d of task %s: %v, retrying in 2s...", attempt+1, idx, payload.TaskID, sttErr)
-				time.Sleep(2 * time.Second)
 			}
-
-			if sttErr != nil {
-				if firstErr.CompareAndSwap(nil, sttErr) {
-					sttCancel()
-				}
-				return
+			// 每次嘗試各自計算單一 chunk 的 timeout，避免 retry 共用已耗盡的 deadline
+			chunkCtx, chunkCancel := context.WithTimeout(sttCtx, deadlines.STTChunk)
+			started := time.Now()
+			res, sttErr = w.transcribeChunk(chunkCtx, sttSvc, payload.TaskID, c.FilePath, hint, prompt)
+			chunkCancel()
+			if w.concurrency != nil {
+				w.concurrency.Release(time.Since(started), c.Duration, sttErr)
 			}
-			transcripts[idx] = chunkTranscript
-
-			completed := atomic.AddInt32(&completedChunks, 1)
-			w.notifyProgress(payload.TaskID, 30+int(completed)*40/len(chunks), "語音轉譯中...")
+			if sttErr == nil || sttCtx.Err() != nil {
+				break
+			}
+			w.logf(payload.TaskID, "STT attempt %d failed for chunk %d of task %s: %v, retrying in 2s...", attempt+1, idx, payload.TaskID, sttErr)
+			time.Sleep(2 * time.Second)
+		}
 
-			// 累進式順序推送轉錄文字至前端
-			streamingMu.Lock()
-			if idx == nextToStream {
-				for nextToStream < len(chunks) && transcripts[nextToStream] != "" {
-					currentFullTranscript = mergeTranscripts(currentFullTranscript, transcripts[nextToStream])
-					nextToStream++
-				}
-				w.notifyTranscriptUpdate(payload.TaskID, currentFullTranscript)
-				w.Redis.Set(ctx, fmt.Sprintf("transcript:buffer:%s", payload.TaskID), currentFullTranscript, 10*time.Minute)
+		if sttErr != nil {
+			if firstErr.CompareAndSwap(nil, withClass(errClassSTTProvider, sttErr)) {
+				sttCancel()
+			}
+			return
+		}
+		chunkTranscript, chunkLang = res.Text, res.Language
+		text := w.normalizeTranscript(chunkTranscript, chunkLang)
+		if idx == 0 {
+			if lang, source := detectLanguage(hint, payload.Config.LanguageExplicit, chunkLang, text); lang != "" {
+				streamingMu.Lock()
+				detectedLang, merge = lang, w.merge.forLanguage(lang)
+				streamingMu.Unlock()
+				w.reportLanguage(payload.TaskID, lang, source)
 			}
+		}
+		if w.timestamps {
+			streamingMu.Lock()
+			timings[idx] = chunkTimings(c, res, text)
 			streamingMu.Unlock()
-		}(i, chunk)
+		}
+		record(idx, text, chunkLang)
 	}
 
+	emit := func(c audio.Chunk) error {
+		streamingMu.Lock()
+		chunks = append(chunks, c)
+		transcripts = append(transcripts, "")
+		languages = append(languages, "")
+		timings = append(timings, models.TranscriptTimings{})
+		n := len(chunks)
+		streamingMu.Unlock()
+		if err := w.inputPolicy.CheckChunks(n); err != nil {
+			return err
+		}
+		if n == 1 {
+			usage.sttStarted()
+			w.notifyProgress(payload.TaskID, 30, "語音轉譯中...")
+		}
+		seq.add()
+		if c.Gap > 0 {
+			// 無法解碼的區段不轉錄，以缺漏標記代替
+			skipped.add(c.Gap)
+			seq.finish(n-1, "")
+			record(n-1, partialGap, "")
+			return nil
+		}
+		wg.Add(1)
+		go transcribe(n-1, c)
+		return nil
+	}
+	switch {
+	case err != nil:
+	case turns != nil:
+		for _, t := range turns {
+			if err = emit(t.Chunk); err != nil {
+				break
+			}
+		}
+	default:
+		err = w.splitStream(splitCtx, payload, speech, &waveform, emit)
+	}
+	chunkingCancel()
+	streamingMu.Lock()
+	splitDone = true
+	streamingMu.Unlock()
+	if err != nil {
+		sttCancel()
+		wg.Wait()
+		if storedErr := firstErr.Load(); storedErr != nil {
+			// 切片因轉錄失敗而中止
+			w.failSTTWithPartial(ctx, payload, d, transcripts, storedErr.(error))
+			return
+		}
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err() // 使用者取消：ffmpeg 被中止的錯誤訊息不具意義
+		case errors.Is(sttCtx.Err(), context.DeadlineExceeded):
+			w.failSTTWithPartial(ctx, payload, d, transcripts, fmt.Errorf("stt exceeded deadline %s: %w", sttLimit, context.DeadlineExceeded))
+			return
+		case errors.Is(chunkingCtx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("chunking exceeded deadline %s: %w", deadlines.Chunking, context.DeadlineExceeded)
+		}
+		w.handleSTTError(ctx, payload, d, withClass(errClassAudio, err))
+		return
+	}
+	usage.chunked(chunks)
+	w.recordSkippedAudio(payload.TaskID, skipped)
+
+	w.logf(payload.TaskID, "STT task %s: split into %d chunks", payload.TaskID, len(chunks))
+	streamingMu.Lock()
+	w.notifyProgress(payload.TaskID, lastPercent, fmt.Sprintf("語音轉譯中（%d 段）...", len(chunks)))
+	streamingMu.Unlock()
+
 	wg.Wait()
+	usage.transcribed()
 
 	if storedErr := firstErr.Load(); storedErr != nil {
-		w.handleSTTError(ctx, payload, rawPayload, storedErr.(error))
+		w.failSTTWithPartial(ctx, payload, d, transcripts, storedErr.(error))
+		return
+	}
+
+	// STT 階段逾時：部分 goroutine 可能因 sttCtx 結束而未寫入 firstErr
+	if ctx.Err() == nil && errors.Is(sttCtx.Err(), context.DeadlineExceeded) {
+		w.failSTTWithPartial(ctx, payload, d, transcripts, fmt.Errorf("stt exceeded deadline %s: %w", sttLimit, context.DeadlineExceeded))
 		return
 	}
 
 	if ctx.Err() != nil {
-		w.handleSTTError(ctx, payload, rawPayload, ctx.Err())
+		w.handleSTTError(ctx, payload, d, ctx.Err())
 		return
 	}
 
-	// 3. 智能合併轉錄結果
-	fullTranscript := ""
-	if len(transcripts) > 0 {
-		fullTranscript = transcripts[0]
-		for i := 1; i < len(transcripts); i++ {
-			fullTranscript = mergeTranscripts(fullTranscript, transcripts[i])
+	// 第一個 chunk 無法判斷語言（例如靜音或無法解碼）時改以完整轉錄稿偵測
+	if detectedLang == "" {
+		if lang, source := detectLanguage(hint, payload.Config.LanguageExplicit, "", strings.Join(transcripts, "\n")); lang != "" {
+			detectedLang, merge = lang, w.merge.forLanguage(lang)
+			w.reportLanguage(payload.TaskID, lang, source)
 		}
 	}
 
+	// 3. 智能合併轉錄結果；聲道分離模式依時間交錯並標記講者
+	var fullTranscript string
+	if turns != nil {
+		fullTranscript = w.stereo.assembleTurns(turns, transcripts)
+	} else {
+		var mergeReport MergeReport
+		fullTranscript, mergeReport = merge.mergeAll(transcripts)
+		w.recordMergeReport(payload.TaskID, mergeReport)
+	}
+
+	w.mergeTrace(payload.TaskID, models.ExecutionTrace{
+		ChunkingMs:   usage.chunking.Milliseconds(),
+		Chunks:       len(chunks),
+		TranscribeMs: usage.stt.Milliseconds(),
+		STTModel:     ai.STTModelName(sttSvc),
+		STTRetries:   payload.RetryCount,
+	})
+
+	// 內容審核：未通過時仍保存轉錄稿供人工審核，但任務改為 flagged
+	flagged, err := w.moderate(ctx, payload.TaskID, "transcript", fullTranscript, payload.Canary)
+	if err != nil {
+		w.handleSTTError(ctx, payload, d, err)
+		return
+	}
+
 	// 4. 持久化：transcript 寫入 DB，tasks.status=stt_completed
 	if err := db.SaveTranscript(w.DB, payload.TaskID, fullTranscript); err != nil {
-		w.handleSTTError(ctx, payload, rawPayload, fmt.Errorf("SaveTranscript: %w", err))
+		if w.discardStale(d, payload.TaskID, err) {
+			w.cleanup(payload.FilePath)
+			return
+		}
+		w.handleSTTError(ctx, payload, d, withClass(errClassStorage, fmt.Errorf("SaveTranscript: %w", err)))
+		return
+	}
+	w.saveSourceChecksum(payload.TaskID, checksum)
+	segments := buildSegments(transcripts, languages)
+	if turns != nil {
+		segments = w.stereo.turnSegments(turns, transcripts, languages)
+	}
+	if segments != nil {
+		if err := db.SaveSegments(w.DB, payload.TaskID, segments); err != nil {
+			w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, err)
+		}
+	}
+	if t := joinTimings(timings, turns != nil); t != nil {
+		if err := db.SaveTimings(w.DB, payload.TaskID, t); err != nil {
+			w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, err)
+		}
+	}
+	if len(waveform.Peaks) > 0 {
+		if err := db.SaveWaveform(w.DB, payload.TaskID, waveform); err != nil {
+			w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, err)
+		}
+	}
+
+	if flagged != nil {
+		if err := db.FlagTask(w.DB, payload.TaskID, flagged); err != nil {
+			w.logf(payload.TaskID, "STT task %s: %v", payload.TaskID, err)
+		}
+		w.ack(d)
+		w.notifyFlagged(ctx, payload.TaskID, flagged)
+		w.recordOutcome("stt", payload.Canary, nil)
+		if !w.keepSourceAudio {
+			w.cleanup(payload.FilePath)
+		}
 		return
 	}
+	if w.moderating(payload.Canary) {
+		// 審核通過：一次送出先前未即時串流的轉錄稿
+		w.notifyTranscriptUpdate(payload.TaskID, fullTranscript)
+	}
 
 	// 5. Redis 狀態更新：HSET stt_completed → ZREM → PUBLISH
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", models.StatusSttCompleted)
-	w.Redis.ZRem(ctx, processingSTT, rawPayload)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSttCompleted)
+	w.ack(d)
 	w.notifySTTCompleted(payload.TaskID)
 	w.notifyProgress(payload.TaskID, 75, "轉錄完成，等待觸發摘要...")
-	w.cleanup(payload.FilePath)
+	w.recordOutcome("stt", payload.Canary, nil)
+	go w.generateTitle(payload.TaskID, fullTranscript, payload.Canary)
+	go w.embedTranscript(payload.TaskID, fullTranscript, payload.Canary)
+	if !w.keepSourceAudio {
+		w.cleanup(payload.FilePath)
+	}
 }
 
 // handleSummary 執行 LLM 摘要階段：串流生成摘要 → 每個 chunk 即時推送 SSE → 儲存 summary。
-func (w *Worker) handleSummary(ctx context.Context, payload models.SummaryPayload, rawPayload string) {
-	log.Printf("Processing Summary task: %s", payload.TaskID)
+func (w *Worker) handleSummary(ctx context.Context, payload models.SummaryPayload, d *queue.Delivery) {
+	w.logf(payload.TaskID, "Processing Summary task: %s", payload.TaskID)
+	started := time.Now()
+	defer w.saveSummaryUsage(payload.TaskID, started, selfPeakRSS())
 
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", models.StatusSummaryProcessing)
+	w.claimTask(payload.TaskID)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusSummaryProcessing)
 	w.notifyProgress(payload.TaskID, 80, "摘要生成中...")
+	if payload.Mode == models.ModeSummaryRetry {
+		// 重新摘要：清除上一次的摘要 buffer，避免 SSE 重連時補送舊內容
+		w.Redis.Del(ctx, keys.SummaryBuffer(payload.TaskID))
+	}
+	if !payload.Canary {
+		// 保存摘要設定，供之後的「重新摘要」沿用
+		if err := db.SaveSummaryConfig(w.DB, payload.TaskID, payload.Config); err != nil {
+			w.logf(payload.TaskID, "Summary task %s: %v", payload.TaskID, err)
+		}
+	}
+	if w.reuseSummary(ctx, payload, d) {
+		return
+	}
 
 	var summaryBuffer strings.Builder
 
-	err := w.LLM.SummarizeStream(ctx, payload.Transcript, payload.Config.SummaryPrompt, func(chunk string) {
-		summaryBuffer.WriteString(chunk)
-		w.notifySummaryChunk(payload.TaskID, chunk)
-		w.Redis.Set(ctx, fmt.Sprintf("summary:buffer:%s", payload.TaskID), summaryBuffer.String(), 10*time.Minute)
+	summaryTimeout := w.summaryDeadline(payload)
+	prompts := w.summaryTemplates(payload)
+	summaryCtx, summaryCancel := context.WithTimeout(ai.WithSystemPrompt(w.withTokenUsage(ctx, payload.TaskID), w.summarySystemPromptWithGlossary(payload, prompts.System)), summaryTimeout)
+	defer summaryCancel()
+	// 取樣參數、user 模板與輸出語言只套用於摘要本身，不影響驗證用的自我檢查；
+	// 未指定摘要指示時依轉錄稿語言選用預設指示
+	genCtx := ai.WithUserTemplate(ai.WithGenerationParams(summaryCtx, w.summaryGeneration(payload)), prompts.User)
+	genCtx = ai.WithSummaryLanguage(genCtx, w.summaryLanguage(payload))
+	genCtx = ai.WithTranscriptLanguage(genCtx, w.transcriptLanguage(payload.TaskID))
+
+	// 尚未輸出任何片段前的失敗可安全重試；已串流部分內容時重試會造成前端重複顯示，直接失敗
+	var err error
+	for attempt := 1; attempt <= w.summaryPolicy.MaxAttempts; attempt++ {
+		err = w.llmFor(payload.Canary).SummarizeStream(genCtx, summaryInput(payload), payload.Config.SummaryPrompt, func(chunk string) {
+			summaryBuffer.WriteString(chunk)
+			if !w.moderating(payload.Canary) {
+				w.notifySummaryChunk(payload.TaskID, chunk)
+				w.Redis.Set(ctx, keys.SummaryBuffer(payload.TaskID), summaryBuffer.String(), 10*time.Minute)
+			}
+		})
+		if err == nil || summaryCtx.Err() != nil || summaryBuffer.Len() > 0 || attempt == w.summaryPolicy.MaxAttempts {
+			break
+		}
+		w.logf(payload.TaskID, "Summary attempt %d failed for task %s: %v, retrying...", attempt, payload.TaskID, err)
+		select {
+		case <-summaryCtx.Done():
+		case <-time.After(w.summaryPolicy.Backoff * time.Duration(attempt)):
+		}
+	}
+
+	// 串流被 context 中斷時 provider 可能只回傳截斷的內容而無錯誤
+	if err == nil && summaryCtx.Err() != nil {
+		err = summaryCtx.Err()
+	}
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(summaryCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("summary exceeded deadline %s: %w", summaryTimeout, context.DeadlineExceeded)
+		}
+		w.handleSummaryError(ctx, payload, d, withClass(errClassLLMProvider, err))
+		return
+	}
+
+	// 驗證：拒答或含 transcript 未提及的內容時重新生成，仍不通過則交付並標記待審核
+	reasons := w.checkSummary(summaryCtx, payload, summaryBuffer.String())
+	if len(reasons) > 0 && w.regenerateSummary(ctx, payload, d, reasons) {
+		return
+	}
+
+	w.saveStructuredSummary(genCtx, payload)
+
+	w.mergeTrace(payload.TaskID, models.ExecutionTrace{
+		SummaryMs:      time.Since(started).Milliseconds(),
+		LLMModel:       ai.LLMModelName(w.llmFor(payload.Canary)),
+		SummaryRetries: payload.RetryCount + payload.GuardrailRetries,
 	})
 
+	flagged, err := w.moderate(summaryCtx, payload.TaskID, "summary", summaryBuffer.String(), payload.Canary)
 	if err != nil {
-		w.handleSummaryError(ctx, payload, rawPayload, err)
+		w.handleSummaryError(ctx, payload, d, err)
 		return
 	}
+	if flagged != nil {
+		if err := db.SaveFlaggedSummary(w.DB, payload.TaskID, summaryBuffer.String(), flagged); err != nil {
+			if w.discardStale(d, payload.TaskID, err) {
+				return
+			}
+			w.handleSummaryError(ctx, payload, d, withClass(errClassStorage, fmt.Errorf("SaveFlaggedSummary: %w", err)))
+			return
+		}
+		w.ack(d)
+		w.notifyFlagged(ctx, payload.TaskID, flagged)
+		w.recordOutcome("summary", payload.Canary, nil)
+		return
+	}
+	if w.moderating(payload.Canary) {
+		// 審核通過：一次送出先前未即時串流的摘要
+		w.notifySummaryChunk(payload.TaskID, summaryBuffer.String())
+		w.Redis.Set(ctx, keys.SummaryBuffer(payload.TaskID), summaryBuffer.String(), 10*time.Minute)
+	}
 
 	// 持久化：summary 寫入 DB，tasks.status=completed
 	if err := db.SaveSummary(w.DB, payload.TaskID, summaryBuffer.String()); err != nil {
-		w.handleSummaryError(ctx, payload, rawPayload, fmt.Errorf("SaveSummary: %w", err))
+		if w.discardStale(d, payload.TaskID, err) {
+			return
+		}
+		w.handleSummaryError(ctx, payload, d, withClass(errClassStorage, fmt.Errorf("SaveSummary: %w", err)))
 		return
 	}
+	if len(reasons) > 0 {
+		w.logf(payload.TaskID, "Summary of task %s flagged for review: %s", payload.TaskID, strings.Join(reasons, "; "))
+		if err := db.FlagSummaryForReview(w.DB, payload.TaskID, reasons); err != nil {
+			w.logf(payload.TaskID, "Summary task %s: %v", payload.TaskID, err)
+		}
+		w.notifyEvent(payload.TaskID, "summary_flagged", strings.Join(reasons, "; "))
+	}
 
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", models.StatusCompleted)
-	w.Redis.ZRem(ctx, processingSummary, rawPayload)
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", models.StatusCompleted)
+	w.ack(d)
 	w.notifyCompleted(payload.TaskID)
+	w.notifyWebhook(payload.TaskID, models.StatusCompleted, "")
+	w.recordOutcome("summary", payload.Canary, nil)
 }
 
-// handleSTTError 統一 STT 錯誤處理：區分 Canceled（用戶取消）與其他錯誤，清理音檔。
-func (w *Worker) handleSTTError(ctx context.Context, payload models.STTPayload, rawPayload string, err error) {
+// handleSTTError 統一 STT 錯誤處理：暫時性錯誤延遲重試；否則區分 Canceled（用戶取消）與其他錯誤，清理音檔。
+func (w *Worker) handleSTTError(ctx context.Context, payload models.STTPayload, d *queue.Delivery, err error) {
+	if w.retryable(payload.Canary, payload.RetryCount, err) {
+		next := payload
+		next.RetryCount++
+		if w.scheduleRetry(d, payload.TaskID, models.StatusSttQueued, next.RetryCount, next, err) {
+			return
+		}
+	}
+
 	eventType := models.StatusFailed
 	if errors.Is(err, context.Canceled) {
 		eventType = models.StatusCancelled
-		log.Printf("STT task %s cancelled", payload.TaskID)
+		w.logf(payload.TaskID, "STT task %s cancelled", payload.TaskID)
 	} else {
-		log.Printf("STT task %s failed: %v", payload.TaskID, err)
+		w.logf(payload.TaskID, "STT task %s failed: %v", payload.TaskID, err)
+		w.recordOutcome("stt", payload.Canary, err)
 	}
 	if dbErr := db.SetTaskStatus(w.DB, payload.TaskID, eventType, err.Error()); dbErr != nil {
-		log.Printf("STT task %s: failed to persist terminal status: %v", payload.TaskID, dbErr)
+		// 任務已被取消或刪除：保留既有終態，不再覆寫 Redis 與推送事件
+		if w.discardStale(d, payload.TaskID, dbErr) {
+			w.cleanup(payload.FilePath)
+			return
+		}
+		w.logf(payload.TaskID, "STT task %s: failed to persist terminal status: %v", payload.TaskID, dbErr)
 	}
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", eventType)
-	w.Redis.ZRem(ctx, processingSTT, rawPayload)
-	w.notifyEvent(payload.TaskID, eventType, err.Error())
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", eventType)
+	w.ack(d)
+	w.notifyFailure(payload.TaskID, eventType, err)
 	w.cleanup(payload.FilePath)
+	if payload.SourceURL != "" {
+		w.cleanup(w.sourcePath(payload) + partSuffix)
+	}
 }
 
-// handleSummaryError 統一 Summary 錯誤處理。
-func (w *Worker) handleSummaryError(ctx context.Context, payload models.SummaryPayload, rawPayload string, err error) {
+// handleSummaryError 統一 Summary 錯誤處理：依序嘗試延遲重試、transcript-only 降級，最後標記終態。
+func (w *Worker) handleSummaryError(ctx context.Context, payload models.SummaryPayload, d *queue.Delivery, err error) {
+	if w.retryable(payload.Canary, payload.RetryCount, err) {
+		next := payload
+		next.RetryCount++
+		if w.scheduleRetry(d, payload.TaskID, models.StatusSummaryQueued, next.RetryCount, next, err) {
+			return
+		}
+	}
+	if w.shouldDegrade(ctx, err) {
+		w.completeWithoutSummary(ctx, payload, d, err)
+		return
+	}
+
 	eventType := models.StatusFailed
 	if errors.Is(err, context.Canceled) {
 		eventType = models.StatusCancelled
-		log.Printf("Summary task %s cancelled", payload.TaskID)
+		w.logf(payload.TaskID, "Summary task %s cancelled", payload.TaskID)
 	} else {
-		log.Printf("Summary task %s failed: %v", payload.TaskID, err)
+		w.logf(payload.TaskID, "Summary task %s failed: %v", payload.TaskID, err)
+		w.recordOutcome("summary", payload.Canary, err)
 	}
 	if dbErr := db.SetTaskStatus(w.DB, payload.TaskID, eventType, err.Error()); dbErr != nil {
-		log.Printf("Summary task %s: failed to persist terminal status: %v", payload.TaskID, dbErr)
+		// 任務已被取消或刪除：保留既有終態，不再覆寫 Redis 與推送事件
+		if w.discardStale(d, payload.TaskID, dbErr) {
+			return
+		}
+		w.logf(payload.TaskID, "Summary task %s: failed to persist terminal status: %v", payload.TaskID, dbErr)
+	}
+	w.Redis.HSet(ctx, keys.Task(payload.TaskID), "status", eventType)
+	w.ack(d)
+	if eventType != models.StatusCancelled {
+		w.notifyEvent(payload.TaskID, eventType, err.Error())
+	}
+	w.notifyWebhook(payload.TaskID, eventType, err.Error())
+}
+
+// discardStale 任務在處理期間已被取消或刪除（ErrStateConflict / ErrTaskNotFound）時丟棄結果並 Ack，
+// 不視為失敗；返回 false 表示為其他錯誤，由呼叫端照常處理。
+func (w *Worker) discardStale(d *queue.Delivery, taskID string, err error) bool {
+	if !errors.Is(err, db.ErrStateConflict) && !errors.Is(err, db.ErrTaskNotFound) {
+		return false
 	}
-	w.Redis.HSet(ctx, "task:"+payload.TaskID, "status", eventType)
-	w.Redis.ZRem(ctx, processingSummary, rawPayload)
-	w.notifyEvent(payload.TaskID, eventType, err.Error())
+	w.logf(taskID, "Task %s: discarding result: %v", taskID, err)
+	w.ack(d)
+	return true
+}
+
+// recordOutcome 記錄任務結果至失敗率指標。Canary 任務另有專屬指標，不計入以免稀釋真實失敗率。
+func (w *Worker) recordOutcome(stage string, canary bool, err error) {
+	if canary {
+		return
+	}
+	class := ""
+	if err != nil {
+		class = errorClass(err)
+	}
+	metrics.Failures.RecordOutcome(stage, class)
 }
 
 // --- SSE 事件輔助函式 ---
@@ -378,6 +909,11 @@ func (w *Worker) notifyCompleted(taskID string) {
 		TaskID: taskID,
 		Type:   "completed",
 	}
+	if trace, err := db.GetTaskTrace(w.DB, taskID); err != nil {
+		w.logf(taskID, "Task %s: %v", taskID, err)
+	} else {
+		event.Trace = trace
+	}
 	rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, event)
 }
 
@@ -400,47 +936,16 @@ func (w *Worker) notifyEvent(taskID, eventType, msg string) {
 	rdb_lib.PublishProgress(w.Redis, context.Background(), taskID, event)
 }
 
-// mergeTranscripts 智能合併兩段具有重疊可能的文字（最多 10 個 token 窗口）。
-func mergeTranscripts(t1, t2 string) string {
-	t1 = strings.TrimSpace(t1)
-	t2 = strings.TrimSpace(t2)
-	if t1 == "" {
-		return t2
-	}
-	if t2 == "" {
-		return t1
-	}
-
-	w1 := strings.Fields(t1)
-	w2 := strings.Fields(t2)
-
-	maxMatch := 10
-	if len(w1) < maxMatch {
-		maxMatch = len(w1)
-	}
-	if len(w2) < maxMatch {
-		maxMatch = len(w2)
-	}
-
-	bestMatchLen := 0
-	for i := 1; i <= maxMatch; i++ {
-		match := true
-		for j := 0; j < i; j++ {
-			if w1[len(w1)-i+j] != w2[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			bestMatchLen = i
-		}
-	}
-
-	remainingW2 := w2[bestMatchLen:]
-	if len(remainingW2) == 0 {
-		return t1
+// notifyWebhook 投遞任務終態 webhook；重試在背景執行，不受任務 context 結束影響。
+func (w *Worker) notifyWebhook(taskID, event, errMsg string) {
+	if w.webhooks == nil {
+		return
 	}
-	return t1 + " " + strings.Join(remainingW2, " ")
+	w.webhooks.Notify(context.Background(), webhook.Event{
+		TaskID:       taskID,
+		Event:        event,
+		ErrorMessage: errMsg,
+	})
 }
 
 // cleanup 刪除已處理完成的音檔，釋放磁碟空間。
@@ -0,0 +1,5 @@
+-- 000003_webhooks.down.sql
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_task_id;
+DROP TABLE IF EXISTS webhook_deliveries;
+ALTER TABLE tasks DROP COLUMN IF EXISTS webhook_url;
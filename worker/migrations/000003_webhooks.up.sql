@@ -0,0 +1,17 @@
+-- 000003_webhooks.up.sql
+-- Webhook notifications: per-task callback URL and delivery attempt log.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS webhook_url TEXT;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT,
+    delivered BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_task_id ON webhook_deliveries(task_id);
@@ -0,0 +1,3 @@
+-- 000004_task_usage.down.sql
+
+DROP TABLE IF EXISTS task_usage;
@@ -0,0 +1,15 @@
+-- 000004_task_usage.up.sql
+-- Per-task resource usage for capacity planning.
+
+CREATE TABLE IF NOT EXISTS task_usage (
+    task_id UUID PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+    chunking_ms BIGINT,
+    stt_ms BIGINT,
+    summary_ms BIGINT,
+    ffmpeg_cpu_ms BIGINT,
+    ffmpeg_peak_rss_bytes BIGINT,
+    peak_rss_delta_bytes BIGINT,
+    input_bytes BIGINT,
+    chunk_bytes BIGINT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
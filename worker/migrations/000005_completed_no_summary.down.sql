@@ -0,0 +1,6 @@
+-- 000005_completed_no_summary.down.sql
+
+UPDATE tasks SET status = 'stt_completed' WHERE status = 'completed_no_summary';
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS status_check;
+ALTER TABLE tasks ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'stt_completed', 'completed', 'failed', 'cancelled'));
@@ -0,0 +1,6 @@
+-- 000005_completed_no_summary.up.sql
+-- Transcript-only completion when the LLM provider is unavailable.
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS status_check;
+ALTER TABLE tasks ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'stt_completed', 'completed', 'completed_no_summary', 'failed', 'cancelled'));
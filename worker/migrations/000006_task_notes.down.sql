@@ -0,0 +1,3 @@
+-- 000006_task_notes.down.sql
+
+DROP TABLE IF EXISTS task_notes;
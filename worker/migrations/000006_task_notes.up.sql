@@ -0,0 +1,17 @@
+-- 000006_task_notes.up.sql
+-- User notes and highlights anchored to a position in the transcript.
+
+CREATE TABLE IF NOT EXISTS task_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'note',
+    start_sec DOUBLE PRECISION NOT NULL,
+    end_sec DOUBLE PRECISION,
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT task_notes_kind_check CHECK (kind IN ('note', 'highlight')),
+    CONSTRAINT task_notes_range_check CHECK (start_sec >= 0 AND (end_sec IS NULL OR end_sec >= start_sec))
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_notes_task_id ON task_notes(task_id, start_sec);
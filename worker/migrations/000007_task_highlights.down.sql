@@ -0,0 +1,3 @@
+-- 000007_task_highlights.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS highlights_path;
@@ -0,0 +1,4 @@
+-- 000007_task_highlights.up.sql
+-- Path of the generated highlight reel (concatenated highlight clips).
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS highlights_path TEXT;
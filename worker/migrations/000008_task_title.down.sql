@@ -0,0 +1,3 @@
+-- 000008_task_title.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS title;
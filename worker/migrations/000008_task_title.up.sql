@@ -0,0 +1,4 @@
+-- 000008_task_title.up.sql
+-- Short descriptive title generated from the transcript.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS title VARCHAR(200);
@@ -0,0 +1,3 @@
+-- 000009_task_worker.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS worker_id;
@@ -0,0 +1,4 @@
+-- 000009_task_worker.up.sql
+-- Worker instance that last processed the task.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS worker_id VARCHAR(255);
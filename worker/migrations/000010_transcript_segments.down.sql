@@ -0,0 +1,3 @@
+-- 000010_transcript_segments.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS segments;
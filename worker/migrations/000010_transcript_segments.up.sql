@@ -0,0 +1,4 @@
+-- 000010_transcript_segments.up.sql
+-- Per-chunk transcript segments tagged with the detected language.
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS segments JSONB;
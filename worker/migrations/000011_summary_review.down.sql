@@ -0,0 +1,3 @@
+-- 000011_summary_review.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS review_reasons;
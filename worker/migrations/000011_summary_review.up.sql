@@ -0,0 +1,4 @@
+-- 000011_summary_review.up.sql
+-- Reasons a summary failed post-summary verification (NULL = passed); flagged summaries await review.
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS review_reasons TEXT;
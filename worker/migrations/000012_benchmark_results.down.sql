@@ -0,0 +1,3 @@
+-- 000012_benchmark_results.down.sql
+
+DROP TABLE IF EXISTS benchmark_results;
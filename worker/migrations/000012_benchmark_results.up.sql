@@ -0,0 +1,14 @@
+-- 000012_benchmark_results.up.sql
+-- Per-provider transcripts of benchmark tasks, with WER against an optional reference transcript.
+
+CREATE TABLE IF NOT EXISTS benchmark_results (
+    task_id       UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    provider      VARCHAR(100) NOT NULL,
+    model         VARCHAR(200),
+    transcript    TEXT,
+    wer           DOUBLE PRECISION,
+    duration_ms   BIGINT NOT NULL,
+    error_message TEXT,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, provider)
+);
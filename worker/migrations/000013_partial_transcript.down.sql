@@ -0,0 +1,3 @@
+-- 000013_partial_transcript.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS partial;
@@ -0,0 +1,4 @@
+-- 000013_partial_transcript.up.sql
+-- Marks transcripts salvaged from failed tasks (only some chunks succeeded).
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS partial BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- 000014_task_trace.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS trace;
@@ -0,0 +1,4 @@
+-- 000014_task_trace.up.sql
+-- User-facing processing breakdown (queue wait, chunking, transcription, summary, models).
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS trace JSONB;
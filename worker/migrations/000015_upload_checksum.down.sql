@@ -0,0 +1,4 @@
+-- 000015_upload_checksum.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS source_sha256;
+ALTER TABLE tasks DROP COLUMN IF EXISTS upload_sha256;
@@ -0,0 +1,5 @@
+-- 000015_upload_checksum.up.sql
+-- SHA-256 of the upload recorded at intake, and the checksum the worker verified before processing.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS upload_sha256 TEXT;
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS source_sha256 TEXT;
@@ -0,0 +1,3 @@
+-- 000016_audio_metadata.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_metadata;
@@ -0,0 +1,4 @@
+-- 000016_audio_metadata.up.sql
+-- Audio facts measured during preprocessing (e.g. loudness before/after EBU R128 normalization).
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_metadata JSONB;
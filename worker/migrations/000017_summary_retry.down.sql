@@ -0,0 +1,4 @@
+-- 000017_summary_retry.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS summary_retries;
+ALTER TABLE tasks DROP COLUMN IF EXISTS summary_config;
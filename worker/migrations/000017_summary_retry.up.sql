@@ -0,0 +1,5 @@
+-- 000017_summary_retry.up.sql
+-- Config of the last summary run (reused by "retry summary") and the number of user-requested retries.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS summary_config JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS summary_retries INT NOT NULL DEFAULT 0;
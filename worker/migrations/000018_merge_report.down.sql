@@ -0,0 +1,3 @@
+-- 000018_merge_report.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS merge_report;
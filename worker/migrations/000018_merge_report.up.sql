@@ -0,0 +1,4 @@
+-- 000018_merge_report.up.sql
+-- Per-task overlap merge diagnostics (match length per chunk boundary, zero-overlap and suspected duplicate counts).
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS merge_report JSONB;
@@ -0,0 +1,3 @@
+-- 000019_error_code.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS error_code;
@@ -0,0 +1,4 @@
+-- 000019_error_code.up.sql
+-- Machine-readable failure code for uploads rejected by validation (e.g. ERR_UNSUPPORTED_FORMAT).
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS error_code VARCHAR(64);
@@ -0,0 +1,4 @@
+-- 000020_audio_hash.down.sql
+
+DROP INDEX IF EXISTS idx_tasks_audio_hash;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_hash;
@@ -0,0 +1,5 @@
+-- 000020_audio_hash.up.sql
+-- Hash of the decoded audio content, used to reuse results of identical recordings instead of transcribing again.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_tasks_audio_hash ON tasks (audio_hash) WHERE audio_hash IS NOT NULL;
@@ -0,0 +1,3 @@
+-- 000021_task_waveform.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS waveform;
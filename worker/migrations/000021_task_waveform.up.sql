@@ -0,0 +1,4 @@
+-- 000021_task_waveform.up.sql
+-- Downsampled amplitude envelope computed during chunking, so the UI can draw a waveform without downloading the audio.
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS waveform JSONB;
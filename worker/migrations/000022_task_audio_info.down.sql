@@ -0,0 +1,8 @@
+-- 000022_task_audio_info.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_size_bytes;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_channels;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_sample_rate;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_duration_sec;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_codec;
+ALTER TABLE tasks DROP COLUMN IF EXISTS audio_format;
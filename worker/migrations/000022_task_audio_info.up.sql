@@ -0,0 +1,9 @@
+-- 000022_task_audio_info.up.sql
+-- Recording details read by ffprobe during upload validation, for display and billing.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_format VARCHAR(64);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(64);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_duration_sec DOUBLE PRECISION;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_sample_rate INTEGER;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_channels SMALLINT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS audio_size_bytes BIGINT;
@@ -0,0 +1,3 @@
+-- 000023_task_source_url.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS source_url;
@@ -0,0 +1,4 @@
+-- 000023_task_source_url.up.sql
+-- Remote URL the worker downloads the recording from, for tasks created via POST /api/tasks/:id/source.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS source_url TEXT;
@@ -0,0 +1,3 @@
+-- 000024_task_result_timings.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS timings;
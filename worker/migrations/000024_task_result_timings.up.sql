@@ -0,0 +1,4 @@
+-- 000024_task_result_timings.up.sql
+-- Segment and word timestamps reported by the STT provider, mapped back to the original recording (subtitles, chapters, click-to-seek).
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS timings JSONB;
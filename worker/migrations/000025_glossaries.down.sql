@@ -0,0 +1,4 @@
+-- 000025_glossaries.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS glossary;
+DROP TABLE IF EXISTS user_glossaries;
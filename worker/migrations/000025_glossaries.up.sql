@@ -0,0 +1,11 @@
+-- 000025_glossaries.up.sql
+-- Per-user default glossary (product names, jargon, people) and the glossary applied to each task,
+-- injected into the STT prompt and the summary system prompt.
+
+CREATE TABLE IF NOT EXISTS user_glossaries (
+    user_id VARCHAR(255) PRIMARY KEY,
+    terms JSONB NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS glossary JSONB;
@@ -0,0 +1,3 @@
+-- 000026_structured_summary.down.sql
+
+ALTER TABLE task_results DROP COLUMN IF EXISTS structured_summary;
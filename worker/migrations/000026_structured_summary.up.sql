@@ -0,0 +1,4 @@
+-- 000026_structured_summary.up.sql
+-- Schema-validated summary ({title, tl_dr, key_points, action_items, participants}) generated alongside the prose summary.
+
+ALTER TABLE task_results ADD COLUMN IF NOT EXISTS structured_summary JSONB;
@@ -0,0 +1,4 @@
+-- 000027_task_usage_tokens.down.sql
+
+ALTER TABLE task_usage DROP COLUMN IF EXISTS llm_completion_tokens;
+ALTER TABLE task_usage DROP COLUMN IF EXISTS llm_prompt_tokens;
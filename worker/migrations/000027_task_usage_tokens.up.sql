@@ -0,0 +1,5 @@
+-- 000027_task_usage_tokens.up.sql
+-- LLM prompt / completion tokens reported by the provider, summed over all requests of a task (summary, retries, guardrail checks, title) for cost accounting.
+
+ALTER TABLE task_usage ADD COLUMN IF NOT EXISTS llm_prompt_tokens BIGINT;
+ALTER TABLE task_usage ADD COLUMN IF NOT EXISTS llm_completion_tokens BIGINT;
@@ -0,0 +1,8 @@
+-- 000028_moderation.down.sql
+
+UPDATE tasks SET status = 'failed', error_message = 'content flagged by moderation' WHERE status = 'flagged';
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS status_check;
+ALTER TABLE tasks ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'stt_completed', 'completed', 'completed_no_summary', 'failed', 'cancelled'));
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS moderation;
@@ -0,0 +1,8 @@
+-- 000028_moderation.up.sql
+-- Content moderation: tasks whose transcript or summary is flagged end in status 'flagged' with the flagged categories.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS moderation JSONB;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS status_check;
+ALTER TABLE tasks ADD CONSTRAINT status_check
+    CHECK (status IN ('pending', 'stt_completed', 'completed', 'completed_no_summary', 'failed', 'cancelled', 'flagged'));
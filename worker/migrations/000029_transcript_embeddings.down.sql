@@ -0,0 +1,3 @@
+-- 000029_transcript_embeddings.down.sql
+
+DROP TABLE IF EXISTS transcript_embeddings;
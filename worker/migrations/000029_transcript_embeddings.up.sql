@@ -0,0 +1,25 @@
+-- 000029_transcript_embeddings.up.sql
+-- Transcript chunks and their embeddings (pgvector) for semantic search / Q&A. Skipped with a notice when the
+-- vector extension is not installed on the server. golang-migrate never re-applies a recorded version, so after
+-- installing pgvector run this file by hand (psql -f; it is idempotent) to create the table.
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector') THEN
+        RAISE NOTICE 'pgvector not available, transcript_embeddings not created';
+        RETURN;
+    END IF;
+    CREATE EXTENSION IF NOT EXISTS vector;
+    EXECUTE $sql$
+        CREATE TABLE IF NOT EXISTS transcript_embeddings (
+            task_id     UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+            chunk_index INT NOT NULL,
+            content     TEXT NOT NULL,
+            embedding   vector NOT NULL,
+            model       TEXT NOT NULL DEFAULT '',
+            created_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (task_id, chunk_index)
+        )
+    $sql$;
+END
+$$;
@@ -0,0 +1,3 @@
+-- 000030_prompt_templates.down.sql
+
+DROP TABLE IF EXISTS prompt_templates;
@@ -0,0 +1,9 @@
+-- 000030_prompt_templates.up.sql
+-- Operator-editable summary prompt templates; a non-empty column overrides SUMMARY_SYSTEM_PROMPT / SUMMARY_USER_TEMPLATE without a redeploy.
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    name VARCHAR(64) PRIMARY KEY,
+    system_template TEXT,
+    user_template TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- 000031_detected_language.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS detected_language;
@@ -0,0 +1,4 @@
+-- 000031_detected_language.up.sql
+-- Spoken language detected from the first chunk (provider-reported or guessed from the transcript), ISO-639-1.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS detected_language VARCHAR(16);
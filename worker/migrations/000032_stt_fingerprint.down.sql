@@ -0,0 +1,3 @@
+-- 000032_stt_fingerprint.down.sql
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS stt_fingerprint;
@@ -0,0 +1,4 @@
+-- 000032_stt_fingerprint.up.sql
+-- Hash of the STT-relevant task config (language hint, model, prompt, glossary, stereo split, ...); duplicates must match it as well as audio_hash.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS stt_fingerprint TEXT;
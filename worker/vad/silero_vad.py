@@ -0,0 +1,81 @@
+#!/usr/bin/env python3
+"""Silero VAD (ONNX runtime) helper for the worker's VAD_BACKEND=silero.
+
+Usage: silero_vad.py [--model silero_vad.onnx] [--threshold 0.5] [--min-speech 0.25] <16kHz mono WAV>
+Prints speech segments as JSON: [{"start": 0.52, "end": 3.10}, ...]
+
+Requires: onnxruntime, numpy and the Silero VAD v5 ONNX model
+(https://github.com/snakers4/silero-vad). The model path defaults to $SILERO_VAD_MODEL.
+"""
+import argparse
+import json
+import os
+import sys
+import wave
+
+import numpy as np
+import onnxruntime
+
+SAMPLE_RATE = 16000
+WINDOW = 512  # samples per inference step at 16kHz (32ms)
+CONTEXT = 64  # samples of the previous window the v5 model expects
+
+
+def read_wav(path):
+    with wave.open(path, "rb") as f:
+        if f.getframerate() != SAMPLE_RATE or f.getnchannels() != 1 or f.getsampwidth() != 2:
+            sys.exit(f"{path}: expected 16kHz mono 16-bit PCM")
+        pcm = np.frombuffer(f.readframes(f.getnframes()), dtype=np.int16)
+    return pcm.astype(np.float32) / 32768.0
+
+
+def speech_probabilities(session, audio):
+    state = np.zeros((2, 1, 128), dtype=np.float32)
+    context = np.zeros((1, CONTEXT), dtype=np.float32)
+    sr = np.array(SAMPLE_RATE, dtype=np.int64)
+    probs = []
+    for offset in range(0, len(audio), WINDOW):
+        chunk = audio[offset:offset + WINDOW]
+        if len(chunk) < WINDOW:
+            chunk = np.pad(chunk, (0, WINDOW - len(chunk)))
+        x = np.concatenate([context, chunk[np.newaxis, :]], axis=1)
+        prob, state = session.run(None, {"input": x, "state": state, "sr": sr})
+        context = x[:, -CONTEXT:]
+        probs.append(float(prob[0][0]))
+    return probs
+
+
+def segments(probs, threshold, min_speech):
+    step = WINDOW / SAMPLE_RATE
+    result, start = [], None
+    # hysteresis: speech starts above threshold and ends below threshold - 0.15
+    for i, p in enumerate(probs):
+        if start is None and p >= threshold:
+            start = i * step
+        elif start is not None and p < threshold - 0.15:
+            if i * step - start >= min_speech:
+                result.append({"start": round(start, 3), "end": round(i * step, 3)})
+            start = None
+    if start is not None and len(probs) * step - start >= min_speech:
+        result.append({"start": round(start, 3), "end": round(len(probs) * step, 3)})
+    return result
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--model", default=os.environ.get("SILERO_VAD_MODEL", "silero_vad.onnx"))
+    parser.add_argument("--threshold", type=float, default=0.5)
+    parser.add_argument("--min-speech", type=float, default=0.25)
+    parser.add_argument("wav")
+    args = parser.parse_args()
+
+    opts = onnxruntime.SessionOptions()
+    opts.intra_op_num_threads = 1
+    opts.inter_op_num_threads = 1
+    session = onnxruntime.InferenceSession(args.model, sess_options=opts, providers=["CPUExecutionProvider"])
+    probs = speech_probabilities(session, read_wav(args.wav))
+    json.dump(segments(probs, args.threshold, args.min_speech), sys.stdout)
+
+
+if __name__ == "__main__":
+    main()